@@ -1,23 +1,59 @@
 package main
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"log"
+	"maps"
+	"math"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"text/template"
+	"time"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/accumulator"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/bulk"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/composite"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/connectordef"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/explain"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/idgen"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/jsonrpc"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/lockfile"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/manifest"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/selector"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/specdiff"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/trigger"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/upload"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/workflowdiff"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/workflowlint"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/pkg/connector"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/pkg/generator"
 
 	"github.com/Masterminds/sprig/v3"
-	"github.com/segmentio/ksuid"
 	"sigs.k8s.io/yaml"
 )
 
@@ -35,8 +71,21 @@ type WorkflowData struct {
 	Categories         []string
 	CategoriesMap      map[string]CategoryData
 	SupportIdempotency bool `json:"-"`
+	// FixedOutputs are the standard outputs (status code, error message, ...)
+	// declared after the operation's own variables.
+	FixedOutputs []VariableData `json:"-"`
+	// TableTypes are the table variable types declared by table outputs, keyed
+	// by unique name.
+	TableTypes map[string]TableTypeData
 }
 
+const (
+	// arrayVariableSchemaID is the AO schema used for array-typed workflow variables.
+	arrayVariableSchemaID = "variable_type_array_01JhSTW61I3ZU2IfL7dwQox83eFDzE1qUiA"
+	// tableVariableTypeID is the unique name of a workflow's table output type.
+	tableVariableTypeID = "variable_type_table_$TableTypeKSUID"
+)
+
 type VariableData struct {
 	SchemaID   string
 	Properties VariableProperties
@@ -90,6 +139,25 @@ type ActionData struct {
 	Actions    []ActionData
 }
 
+// TableTypeData is a table variable type exported with the workflow; table
+// variables reference it by unique name as their schema_id.
+type TableTypeData struct {
+	UniqueName string            `json:"unique_name"`
+	Name       string            `json:"name"`
+	Title      string            `json:"title"`
+	Type       string            `json:"type"`
+	BaseType   string            `json:"base_type"`
+	Columns    []TableColumnData `json:"columns"`
+	ObjectType string            `json:"object_type"`
+}
+
+type TableColumnData struct {
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+	IsRequired bool   `json:"is_required"`
+}
+
 type BlockData struct {
 	UniqueName string
 	Name       string
@@ -101,29 +169,24 @@ type BlockData struct {
 	Actions    []ActionData
 }
 
-type APIRequestProperties struct {
-	ActionTimeout     int             `json:"action_timeout"`
-	ApiBody           string          `json:"api_body"`
-	ApiMethod         string          `json:"api_method"`
-	ApiURL            string          `json:"api_url"`
-	ContinueOnFailure bool            `json:"continue_on_failure"`
-	Description       string          `json:"description"`
-	DisplayName       string          `json:"display_name"`
-	RuntimeUser       RuntimeUserData `json:"runtime_user"`
-	SkipExecution     bool            `json:"skip_execution"`
-	Target            map[string]bool `json:"target"`
-}
-
-type NetboxAPIRequestProperties struct {
-	ActionTimeout     int             `json:"action_timeout"`
-	ContinueOnFailure bool            `json:"continue_on_failure"`
-	DisplayName       string          `json:"display_name"`
-	Method            string          `json:"_method"`
-	Endpoint          string          `json:"_endpoint"`
-	RuntimeUser       RuntimeUserData `json:"runtime_user"`
-	SkipExecution     bool            `json:"skip_execution"`
-	Target            map[string]bool `json:"target"`
-	Body              string          `json:"_body,omitempty"`
+// pluginRequestProperties converts request properties of a type the generator
+// does not know, built by a plugin connector, into
+// connector.DefinedRequestProperties so -scaffold and -prefixTargets can edit
+// them; description is taken as the description property when there is one.
+func pluginRequestProperties(props interface{}) (connector.DefinedRequestProperties, bool) {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return connector.DefinedRequestProperties{}, false
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil || values == nil {
+		return connector.DefinedRequestProperties{}, false
+	}
+	p := connector.DefinedRequestProperties{Values: values}
+	if _, ok := values["description"]; ok {
+		p.DescriptionKey = "description"
+	}
+	return p, true
 }
 
 type LogicIfElseProperties struct {
@@ -148,7 +211,7 @@ type BlockProperties struct {
 	ContinueOnFailure bool      `json:"continue_on_failure"`
 	DisplayName       string    `json:"display_name"`
 	SkipExecution     bool      `json:"skip_execution"`
-	Operator          string    `json:"operator"`
+	Operator          string    `json:"operator,omitempty"`
 }
 
 type Condition struct {
@@ -173,6 +236,8 @@ type BodyParam struct {
 	Name     string
 	Required bool
 	Type     string
+	Map      bool        // map-like object (additionalProperties only), left out while it is {}
+	Fields   []BodyParam // fields of an object flattened by -flattenObjects, reassembled by the prep step
 }
 
 type CategoryData struct {
@@ -188,10 +253,45 @@ type CategoryData struct {
 type OpenAPISpec struct {
 	Paths      map[string]PathItem `json:"paths"`
 	Components Components          `json:"components"`
+
+	// resolvedSchemas memoizes component schemas whose refs are fully
+	// resolved, so schemas like Device or NestedSite are resolved once per run
+	// instead of once per operation. loadOpenAPISpec creates it; a nil memo
+	// disables the cache.
+	resolvedSchemas *schemaMemo
+}
+
+// schemaMemo holds resolved component schemas by name. Copies of a spec share
+// it and -serve renders them concurrently, so it is guarded by mu; the schemas
+// in it are never changed once stored.
+type schemaMemo struct {
+	mu      sync.Mutex
+	schemas map[string]Schema
+}
+
+func newSchemaMemo() *schemaMemo {
+	return &schemaMemo{schemas: make(map[string]Schema)}
+}
+
+func (m *schemaMemo) get(name string) (Schema, bool) {
+	if m == nil {
+		return Schema{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schema, ok := m.schemas[name]
+	return schema, ok
+}
+
+func (m *schemaMemo) put(name string, schema Schema) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schemas[name] = schema
 }
 
 type Components struct {
-	Schemas map[string]Schema `json:"schemas"`
+	Schemas    map[string]Schema    `json:"schemas"`
+	Parameters map[string]Parameter `json:"parameters,omitempty"`
 }
 
 type PathItem struct {
@@ -200,22 +300,167 @@ type PathItem struct {
 	Put    *Operation `json:"put,omitempty"`
 	Patch  *Operation `json:"patch,omitempty"`
 	Delete *Operation `json:"delete,omitempty"`
+	// Parameters are shared by every operation of the path; loadOpenAPISpec
+	// merges them into the operations.
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+// parameterRefPrefix starts the refs of parameters declared once under
+// components, e.g. #/components/parameters/limit.
+const parameterRefPrefix = "#/components/parameters/"
+
+// resolveParameterRefs replaces $ref parameters of paths and operations with the
+// component parameters they name, following refs to refs. A ref that cannot be
+// resolved is kept, so -strict can report it and rendering can skip it.
+func (s *OpenAPISpec) resolveParameterRefs() {
+	for _, item := range s.Paths {
+		s.resolveParameterList(item.Parameters)
+		for _, operation := range availableOperations(item) {
+			s.resolveParameterList(operation.Parameters)
+		}
+	}
+}
+
+func (s *OpenAPISpec) resolveParameterList(params []Parameter) {
+	for i, param := range params {
+		seen := make(map[string]bool)
+		for param.Ref != "" && !seen[param.Ref] {
+			seen[param.Ref] = true
+			target, ok := s.Components.Parameters[strings.TrimPrefix(param.Ref, parameterRefPrefix)]
+			if !ok || !strings.HasPrefix(param.Ref, parameterRefPrefix) {
+				break
+			}
+			param = target
+		}
+		if param.Ref == "" {
+			params[i] = param
+		}
+	}
+}
+
+// truncatedSchemaHint ends the description of inputs whose schema was cut.
+const truncatedSchemaHint = "Nested structure not expanded (schema depth limit or recursive schema); enter it as JSON."
+
+// limitOperationSchemaDepth truncates the request body and response schemas of
+// a resolved operation. Only the truncated structures that become inputs or
+// outputs are reported (truncatedVariables); deeper cuts stay inside a JSON
+// value either way.
+func (s *renderSettings) limitOperationSchemaDepth(openAPISpec OpenAPISpec, operation *Operation) {
+	operation.RequestBody.Content.ApplicationJSON.Schema = s.limitSchemaDepth(openAPISpec, operation.RequestBody.Content.ApplicationJSON.Schema, "request body", 0)
+	codes := make([]string, 0, len(operation.Responses))
+	for code := range operation.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		response := operation.Responses[code]
+		response.Content.ApplicationJSON.Schema = s.limitSchemaDepth(openAPISpec, response.Content.ApplicationJSON.Schema, "response "+code, 0)
+		operation.Responses[code] = response
+	}
+}
+
+// limitSchemaDepth replaces the structures of schema nested deeper than
+// maxSchemaDepth, and the recursive refs resolution left in place to cut a
+// cycle, with objects or arrays without fields marked Truncated at location.
+// Refs to missing schemas are left for -strict to report.
+func (s *renderSettings) limitSchemaDepth(openAPISpec OpenAPISpec, schema Schema, location string, depth int) Schema {
+	if schema.Ref != "" {
+		component, ok := openAPISpec.Components.Schemas[extractSchemaRefName(schema.Ref)]
+		if !ok {
+			return schema
+		}
+		schema.Truncated = fmt.Sprintf("%s (recursive %s)", location, schema.Ref)
+		if schema.Type == "" {
+			schema.Type = component.Type
+		}
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+		return schema
+	}
+	if schema.Items == nil && len(schema.Properties) == 0 {
+		return schema
+	}
+	if s.maxSchemaDepth > 0 && depth >= s.maxSchemaDepth {
+		schema.Properties = nil
+		schema.Items = nil
+		schema.Required = nil
+		schema.Truncated = location
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+		return schema
+	}
+	if schema.Items != nil {
+		items := s.limitSchemaDepth(openAPISpec, *schema.Items, location+"[]", depth+1)
+		schema.Items = &items
+	}
+	if len(schema.Properties) > 0 {
+		properties := make(map[string]Schema, len(schema.Properties))
+		for _, key := range sortedSchemaKeys(schema.Properties) {
+			properties[key] = s.limitSchemaDepth(openAPISpec, schema.Properties[key], location+"."+key, depth+1)
+		}
+		schema.Properties = properties
+	}
+	return schema
+}
+
+// skipUnresolvedParameters drops the parameters whose $ref could not be
+// resolved, with a warning, instead of generating nameless inputs.
+func skipUnresolvedParameters(operationId string, params []Parameter) []Parameter {
+	var kept []Parameter
+	for _, param := range params {
+		if param.Ref != "" {
+			log.Printf("Warning: %s: skipping parameter with unresolvable $ref %s", operationId, param.Ref)
+			continue
+		}
+		kept = append(kept, param)
+	}
+	return kept
+}
+
+// mergePathParameters gives every operation the parameters declared on its
+// path. They come first, in spec order; a parameter the operation declares
+// itself (same name and location) overrides the path's.
+func (s *OpenAPISpec) mergePathParameters() {
+	for _, item := range s.Paths {
+		if len(item.Parameters) == 0 {
+			continue
+		}
+		for _, operation := range availableOperations(item) {
+			own := make(map[string]bool, len(operation.Parameters))
+			for _, param := range operation.Parameters {
+				own[param.In+" "+param.Name+" "+param.Ref] = true
+			}
+			var merged []Parameter
+			for _, param := range item.Parameters {
+				if !own[param.In+" "+param.Name+" "+param.Ref] {
+					merged = append(merged, param)
+				}
+			}
+			operation.Parameters = append(merged, operation.Parameters...)
+		}
+	}
 }
 
 type Operation struct {
 	Description string              `json:"description"`
 	OperationId string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
 	Parameters  []Parameter         `json:"parameters"`
 	RequestBody RequestBody         `json:"requestBody"`
 	Responses   map[string]Response `json:"responses"`
 }
 
 type Parameter struct {
+	Ref         string `json:"$ref,omitempty"`
 	Name        string `json:"name"`
 	In          string `json:"in"`
 	Description string `json:"description"`
 	Schema      Schema `json:"schema"`
 	Required    bool   `json:"required"`
+	Style       string `json:"style,omitempty"`
+	Explode     *bool  `json:"explode,omitempty"`
 }
 
 type RequestBody struct {
@@ -224,10 +469,59 @@ type RequestBody struct {
 
 type Content struct {
 	ApplicationJSON ApplicationJSON `json:"application/json"`
+	// MediaTypes lists every media type the spec declares, including the
+	// ones the generator does not support.
+	MediaTypes []string `json:"-"`
+	// JSONMediaType is the media type ApplicationJSON was read from:
+	// application/json, else the first other JSON type such as
+	// application/vnd.api+json or application/json; charset=utf-8.
+	JSONMediaType string `json:"-"`
+}
+
+func (c *Content) UnmarshalJSON(data []byte) error {
+	var media map[string]json.RawMessage
+	if err := json.Unmarshal(data, &media); err != nil {
+		return err
+	}
+	c.MediaTypes = make([]string, 0, len(media))
+	for mediaType := range media {
+		c.MediaTypes = append(c.MediaTypes, mediaType)
+	}
+	sort.Strings(c.MediaTypes)
+	if _, ok := media["application/json"]; ok {
+		c.JSONMediaType = "application/json"
+	} else {
+		for _, mediaType := range c.MediaTypes {
+			if isJSONMediaType(mediaType) {
+				c.JSONMediaType = mediaType
+				break
+			}
+		}
+	}
+	if c.JSONMediaType != "" {
+		return json.Unmarshal(media[c.JSONMediaType], &c.ApplicationJSON)
+	}
+	return nil
+}
+
+// isJSONMediaType reports whether a media type carries JSON: application/json
+// with parameters or a +json structured syntax suffix.
+func isJSONMediaType(mediaType string) bool {
+	base, _, _ := strings.Cut(strings.ToLower(mediaType), ";")
+	base = strings.TrimSpace(base)
+	return base == "application/json" || strings.HasPrefix(base, "application/") && strings.HasSuffix(base, "+json")
 }
 
 type ApplicationJSON struct {
-	Schema Schema `json:"schema"`
+	Schema   Schema                  `json:"schema"`
+	Example  interface{}             `json:"example,omitempty"`
+	Examples map[string]MediaExample `json:"examples,omitempty"`
+}
+
+// MediaExample is one named entry of a media type's examples.
+type MediaExample struct {
+	Summary string      `json:"summary,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
 }
 
 type Response struct {
@@ -241,220 +535,80 @@ type Schema struct {
 	Properties  map[string]Schema `json:"properties,omitempty"`
 	Items       *Schema           `json:"items,omitempty"`
 	Description string            `json:"description,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	WriteOnly   bool              `json:"writeOnly,omitempty"`
+	ReadOnly    bool              `json:"readOnly,omitempty"`
+	Example     interface{}       `json:"example,omitempty"`
+	Minimum     *float64          `json:"minimum,omitempty"`
+	Maximum     *float64          `json:"maximum,omitempty"`
 	Enum        []interface{}     `json:"enum,omitempty"`
 	Required    []string          `json:"required,omitempty"`
+	AllOf       []Schema          `json:"allOf,omitempty"`
+	OneOf       []Schema          `json:"oneOf,omitempty"`
+	AnyOf       []Schema          `json:"anyOf,omitempty"`
+	// AdditionalProperties is the raw additionalProperties keyword: true or a
+	// value schema for map-like objects, false for closed ones.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	// Truncated marks a structure cut at maxSchemaDepth or at a recursive ref
+	// with where it was cut, e.g. "response 200.parent"; it takes a JSON value
+	// instead of its nested fields.
+	Truncated string `json:"-"`
 }
 
+// connectorConfig is the connector the generator renders for, looked up in the
+// connector registry.
 type connectorConfig struct {
-	AtomicGroup         string
-	TargetType          string
-	ActionType          string
-	ResponseBodyField   string
-	StatusMessageField  string
-	APIBasePath         string
-	ContinueOnFailure   bool
-	PlatformDisplayName string
-	BuildActionProps    func(method, endpoint, body string, hasBody bool, operation *Operation, displayName string) interface{}
-}
-
-// Template for the workflow definition
-const workflowTemplate = `
-{
-  "workflow": {
-    "unique_name": "{{ .UniqueName }}",
-    "name": "{{ .Name }}",
-    "title": "{{ .Title }}",
-    "type": "{{ .Type }}",
-    "base_type": "{{ .BaseType }}",
-    "variables": [
-      {{- range $index, $variable := .Variables }}
-      {
-        "schema_id": "{{ $variable.SchemaID }}",
-        "properties": {
-          "value": {{  if eq $variable.Properties.Type "datatype.array" }}{{ $variable.Properties.Value | toJson }}{{- else if eq $variable.Properties.VariableStringFormat "json" }}"{{ $variable.Properties.Value | toJson }}"{{- else if eq $variable.Properties.Type "datatype.boolean" }}{{ $variable.Properties.Value }}{{- else if eq $variable.Properties.Type "datatype.integer" }}{{ $variable.Properties.Value }}{{ else }}"{{ $variable.Properties.Value }}"{{ end }},
-          "scope": "{{ $variable.Properties.Scope }}",
-          "name": "{{ $variable.Properties.Name | jsonEscape | title }}",
-          "type": "{{ $variable.Properties.Type }}",
-          "description": "{{ $variable.Properties.Description | jsonEscape }}",
-          "is_required": {{ $variable.Properties.IsRequired }},
-          "variable_string_format": "{{ $variable.Properties.VariableStringFormat }}",
-          "display_on_wizard": {{ $variable.Properties.DisplayOnWizard }},
-          "is_invisible": {{ $variable.Properties.IsInvisible }}
-        },
-        "unique_name": "{{ $variable.UniqueName }}",
-        "object_type": "{{ $variable.ObjectType }}"
-      }{{ if ne (add1 $index) (len $.Variables) }},{{ end }}
-      {{- end }}
-      {{- if gt (len $.Variables) 0 }},
-      {{- end }}
-      {
-            "schema_id": "datatype.string",
-            "properties": {
-                "value": "",
-                "scope": "output",
-                "name": "Output - Status Message",
-                "type": "datatype.string",
-                "description": "The HTTP status message of the API response.",
-                "is_required": false,
-                "display_on_wizard": false,
-                "is_invisible": false,
-                "variable_string_format": "text"
-            },
-            "unique_name": "variable_workflow_$StatusMessageKSUID",
-            "object_type": "variable_workflow"
-        },
-        {
-            "schema_id": "datatype.integer",
-            "properties": {
-                "value": 0,
-                "scope": "output",
-                "name": "Output - Status Code",
-                "type": "datatype.integer",
-                "description": "The HTTP status code of the API response.",
-                "is_required": false,
-                "display_on_wizard": false,
-                "is_invisible": false,
-                "variable_string_format": ""
-            },
-            "unique_name": "variable_workflow_$StatusCodeKSUID",
-            "object_type": "variable_workflow"
-        },
-        {
-            "schema_id": "datatype.string",
-            "properties": {
-                "value": "",
-                "scope": "output",
-                "name": "Output - Error Message",
-                "type": "datatype.string",
-                "description": "The HTTP error message of the API response.",
-                "is_required": false,
-                "display_on_wizard": false,
-                "is_invisible": false,
-                "variable_string_format": "text"
-            },
-            "unique_name": "variable_workflow_$ErrorMessageKSUID",
-            "object_type": "variable_workflow"
-        }
-    ],
-    "properties": {
-      "atomic": {
-        "atomic_group": "{{ .Properties.Atomic.AtomicGroup }}",
-        "is_atomic": {{ .Properties.Atomic.IsAtomic }}
-      },
-      "description": "{{ .Properties.Description }}",
-      "display_name": "{{ .Properties.DisplayName }}",
-      "runtime_user": {
-        "target_default": {{ .Properties.RuntimeUser.TargetDefault }}
-      },
-      "target": {
-        "target_type": "{{ .Properties.Target.TargetType }}",
-        "specify_on_workflow_start": {{ .Properties.Target.SpecifyOnWorkflowStart }}
-      }
-    },
-    "object_type": "{{ .ObjectType }}",
-    "actions": [
-      {{- range $index, $action := .Actions }}
-      {
-        "unique_name": "{{ $action.UniqueName }}",
-        "name": "{{ $action.Name }}",
-        "title": "{{ $action.Title }}",
-        "type": "{{ $action.Type }}",
-        "base_type": "{{ $action.BaseType }}",
-        "properties": {{ toJson $action.Properties }},
-        "object_type": "{{ $action.ObjectType }}",
-        "blocks": [
-          {{- range $bindex, $block := $action.Blocks }}
-          {
-            "unique_name": "{{ $block.UniqueName }}",
-            "name": "{{ $block.Name }}",
-            "title": "{{ $block.Title }}",
-            "type": "{{ $block.Type }}",
-            "base_type": "{{ $block.BaseType }}",
-            "properties": {
-              "condition": {
-                "left_operand": {{ formatObject $block.Properties.Condition.LeftOperand }},
-                "operator": "{{  $block.Properties.Condition.Operator }}",
-                "right_operand": {{ formatObject $block.Properties.Condition.RightOperand }}
-              },
-              "continue_on_failure": {{ $block.Properties.ContinueOnFailure }},
-              "display_name": "{{ $block.Properties.DisplayName }}",
-              "skip_execution": {{ $block.Properties.SkipExecution }}
-            },
-            "object_type": "{{ $block.ObjectType }}",
-            "actions": [
-              {{- range $aindex, $baction := $block.Actions }}
-              {
-                "unique_name": "{{ $baction.UniqueName }}",
-                "name": "{{ $baction.Name }}",
-                "title": "{{ $baction.Title }}",
-                "type": "{{ $baction.Type }}",
-                "base_type": "{{ $baction.BaseType }}",
-                "properties": {{ toJson $baction.Properties }},
-                "object_type": "{{ $baction.ObjectType }}",
-				"blocks": [
-					  {{- range $abindex, $ablock := $baction.Blocks }}
-					  {
-						"unique_name": "{{ $ablock.UniqueName }}",
-						"name": "{{ $ablock.Name }}",
-						"title": "{{ $ablock.Title }}",
-						"type": "{{ $ablock.Type }}",
-						"base_type": "{{ $ablock.BaseType }}",
-						"properties": {
-						  "condition": {
-							"left_operand": {{ formatObject $ablock.Properties.Condition.LeftOperand }},
-							"operator": "{{  $ablock.Properties.Condition.Operator }}",
-							"right_operand": {{ formatObject $ablock.Properties.Condition.RightOperand }}
-						  },
-						  "continue_on_failure": {{ $ablock.Properties.ContinueOnFailure }},
-						  "display_name": "{{ $ablock.Properties.DisplayName }}",
-						  "skip_execution": {{ $ablock.Properties.SkipExecution }}
-						},
-						"object_type": "{{ $ablock.ObjectType }}",
-						"actions": [
-						  {{- range $abaindex, $abaction := $ablock.Actions }}
-						  {
-							"unique_name": "{{ $abaction.UniqueName }}",
-							"name": "{{ $abaction.Name }}",
-							"title": "{{ $abaction.Title }}",
-							"type": "{{ $abaction.Type }}",
-							"base_type": "{{ $abaction.BaseType }}",
-							"properties": {{ toJson $abaction.Properties }},
-							"object_type": "{{ $abaction.ObjectType }}"
-						  }{{ if ne (add1 $abaindex) (len $ablock.Actions) }},{{ end }}
-						  {{- end }}
-						]
-					  }{{ if ne (add1 $abindex) (len $action.Blocks) }},{{ end }}
-					  {{- end }}
-					]
-              }{{ if ne (add1 $aindex) (len $block.Actions) }},{{ end }}
-              {{- end }}
-            ]
-          }{{ if ne (add1 $bindex) (len $action.Blocks) }},{{ end }}
-          {{- end }}
-        ]
-      }{{ if ne (add1 $index) (len $.Actions) }},{{ end }}
-      {{- end }}
-    ],
-	"categories": {{ $.Categories | toJson }}
-  },
-  "categories": {
-    {{- $lastIndex := sub (len $.CategoriesMap) 1 }}
-    {{- $currentIndex := 0 }}
-    {{- range $key, $category := .CategoriesMap }}
-    "{{ $key }}": {
-      "unique_name": "{{ $category.UniqueName }}",
-      "name": "{{ $category.Name }}",
-      "title": "{{ $category.Title }}",
-      "type": "{{ $category.Type }}",
-      "base_type": "{{ $category.BaseType }}",
-      "category_type": "{{ $category.CategoryType }}",
-      "object_type": "{{ $category.ObjectType }}"
-    }{{ if lt $currentIndex $lastIndex }},{{ end }}
-    {{- $currentIndex = add1 $currentIndex }}
-    {{- end }}
-  }
+	connector.Config
+}
+
+// workflowTemplate is the built-in workflow definition template.
+//
+//go:embed resources/workflow.tmpl
+var workflowTemplate string
+
+// loadWorkflowTemplate reads a user-supplied workflow template and checks that it
+// parses with the generator's helper functions before any workflow is rendered.
+// Parsing only looks up the helpers' names, so zero settings do.
+func loadWorkflowTemplate(path string) (string, error) {
+	data, err := fsutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("template file %s is empty", path)
+	}
+	if _, err := template.New("workflow").Funcs(sprig.TxtFuncMap()).Funcs(new(renderSettings).templateFuncMap()).Parse(text); err != nil {
+		return "", generator.NewError(generator.ErrTemplateRender, "", fmt.Errorf("template file %s: %w", path, err))
+	}
+	return text, nil
+}
+
+// templateFuncMap returns the helper functions available to workflow templates in
+// addition to the sprig library.
+func (s *renderSettings) templateFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"add1": add1,
+		"sub":  sub,
+		"toJson": func(v interface{}) string {
+			a, _ := json.Marshal(v)
+			return string(a)
+		},
+		"jsonEscape":   jsonEscape,
+		"formatObject": formatObject,
+		"humanName":    HumanReadableName,
+		"singularize":  singularize,
+		"platform": func() string {
+			return s.platformName
+		},
+		"connector": func() string {
+			return s.currentConnector.ActionType
+		},
+		"targetType": func() string {
+			return s.currentConnector.TargetType
+		},
+	}
 }
-`
 
 // Add this function to the template to use in the logic
 func add1(i int) int {
@@ -476,9 +630,15 @@ func formatObject(operand interface{}) string {
 	}
 }
 
-// KSUIDGenerator generates a KSUID.
+// idGenerator makes the IDs of generated objects; -idFormat and -idPrefix
+// choose it. It is set once at startup, before any workflow renders, and
+// only read afterwards, so concurrent -serve requests share it safely.
+var idGenerator = idgen.Default
+
+// KSUIDGenerator returns a fresh ID from idGenerator, a KSUID unless -idFormat
+// says otherwise; it is named after the placeholders it replaces.
 func KSUIDGenerator() string {
-	return ksuid.New().String()
+	return idGenerator.New()
 }
 
 // ReplaceKSUIDs replaces KSUID placeholders in the workflow definition.
@@ -495,6 +655,139 @@ func ReplaceKSUIDs(content string) string {
 	})
 }
 
+const (
+	placeholderKindParam  = "param"
+	placeholderKindBody   = "body"
+	placeholderKindOutput = "output"
+	placeholderKindLocal  = "local"
+)
+
+// reservedPlaceholderTokens are the fixed placeholders used by the template and
+// the generated actions; generated variables must never reuse them.
+var reservedPlaceholderTokens = []string{"Workflow", "ApiRequest", "StatusMessage", "StatusCode", "ErrorMessage", "ResponseBody", "RequestURL", "Duration", "TableType", "ignoreIfExist"}
+
+// placeholderRegistry hands out the $<token>KSUID placeholders for one workflow,
+// disambiguating tokens that would otherwise collide (e.g. an input named
+// "nameoutput" and the output generated for "name"). Tokens are keyed by kind
+// and name, so a path parameter and a body field of the same name are two
+// inputs by design: an API may take a different id in the body than the one
+// addressing the object. The name registered first keeps the plain token (id)
+// and the later one is numbered (id_2); a workflow registers its path and query
+// parameters before its body fields and outputs, and each render starts a fresh
+// registry, so the numbering does not depend on other operations.
+type placeholderRegistry struct {
+	tokens map[string]string
+	owners map[string]string
+}
+
+func newPlaceholderRegistry() *placeholderRegistry {
+	registry := &placeholderRegistry{
+		tokens: make(map[string]string),
+		owners: make(map[string]string),
+	}
+	for _, token := range reservedPlaceholderTokens {
+		registry.owners[token] = "reserved"
+	}
+	return registry
+}
+
+func (r *placeholderRegistry) token(kind, name string) string {
+	key := kind + "/" + name
+	if token, ok := r.tokens[key]; ok {
+		return token
+	}
+	base := placeholderSafeName(name)
+	if kind == placeholderKindOutput || kind == placeholderKindLocal {
+		base += kind
+	}
+	token := base
+	for i := 2; r.owners[token] != ""; i++ {
+		token = fmt.Sprintf("%s_%d", base, i)
+	}
+	r.tokens[key] = token
+	r.owners[token] = key
+	return token
+}
+
+// placeholderSafeName escapes the characters of a parameter or field name that
+// cannot appear in a $<token>KSUID placeholder (cf_site[owner], site-id) as
+// _<hex>_, e.g. site_2d_id. The registry resolves the rare collision with a
+// name that is spelled that way and records which name owns each token.
+func placeholderSafeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, "_%x_", r)
+	}
+	return b.String()
+}
+
+// variableUniqueName returns the placeholder unique_name of a generated variable.
+func (s *renderSettings) variableUniqueName(kind, name string) string {
+	return "variable_workflow_$" + s.placeholders.token(kind, name) + "KSUID"
+}
+
+// inputVariableRef returns the workflow input reference for a generated variable.
+func (s *renderSettings) inputVariableRef(kind, name string) string {
+	return fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.input.%s$", s.variableUniqueName(kind, name))
+}
+
+// validateVariableUniqueNames fails when two generated variables share a
+// placeholder, which would collapse them into one variable after KSUID replacement.
+func validateVariableUniqueNames(variables []VariableData) error {
+	seen := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		if other, ok := seen[variable.UniqueName]; ok {
+			return fmt.Errorf("variables %q and %q share placeholder %s", other, variable.Properties.Name, variable.UniqueName)
+		}
+		seen[variable.UniqueName] = variable.Properties.Name
+	}
+	for _, token := range reservedPlaceholderTokens {
+		reserved := "variable_workflow_$" + token + "KSUID"
+		if name, ok := seen[reserved]; ok && token != "ignoreIfExist" {
+			return fmt.Errorf("variable %q uses reserved placeholder %s", name, reserved)
+		}
+	}
+	return nil
+}
+
+// validatePlaceholders fails when $...KSUID placeholders survived ID
+// replacement, listing each with its location.
+func validatePlaceholders(content []byte) error {
+	issues, err := workflowlint.CheckPlaceholders(content)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.Location + ": " + issue.Message
+	}
+	return fmt.Errorf("unreplaced placeholders in rendered workflow:\n  %s", strings.Join(messages, "\n  "))
+}
+
+// validateReferences fails when the rendered workflow references activities or
+// variables that do not exist in it, e.g. a prep step renamed during a refactor.
+func validateReferences(content []byte) error {
+	issues, err := workflowlint.CheckReferences(content)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.Location + ": " + issue.Message
+	}
+	return fmt.Errorf("broken references in rendered workflow:\n  %s", strings.Join(messages, "\n  "))
+}
+
 // ExtractOperation extracts the operation details from the OpenAPI spec.
 func ExtractOperation(openAPISpec OpenAPISpec, operationId string) (*Operation, string, string, error) {
 	for path, pathItem := range openAPISpec.Paths {
@@ -514,55 +807,288 @@ func ExtractOperation(openAPISpec OpenAPISpec, operationId string) (*Operation,
 			return pathItem.Delete, path, "DELETE", nil
 		}
 	}
-	return nil, "", "", fmt.Errorf("operationId %s not found", operationId)
+	return nil, "", "", generator.NewError(generator.ErrOperationNotFound, operationId, nil)
 }
 
-func resolveOperationSchemas(openAPISpec OpenAPISpec, operation *Operation) {
+// resolveOperationSchemas returns a copy of operation with its parameter,
+// request body and response schemas resolved. The copy shares nothing with the
+// spec, so rendering may narrow or extend its schemas without the next
+// operation seeing it.
+func resolveOperationSchemas(openAPISpec OpenAPISpec, operation *Operation) *Operation {
 	if operation == nil {
-		return
+		return nil
 	}
+	resolved := *operation
+	resolved.Tags = append([]string(nil), operation.Tags...)
+	resolved.Parameters = make([]Parameter, len(operation.Parameters))
 	for idx, param := range operation.Parameters {
-		operation.Parameters[idx].Schema = resolveSchemaRefs(openAPISpec, param.Schema)
+		param.Schema = resolveSchemaRefs(openAPISpec, param.Schema).clone()
+		resolved.Parameters[idx] = param
 	}
-	operation.RequestBody.Content.ApplicationJSON.Schema = resolveSchemaRefs(openAPISpec, operation.RequestBody.Content.ApplicationJSON.Schema)
+	resolved.RequestBody.Content = operation.RequestBody.Content.resolve(openAPISpec)
+	resolved.Responses = make(map[string]Response, len(operation.Responses))
 	for code, response := range operation.Responses {
-		responseSchema := resolveSchemaRefs(openAPISpec, response.Content.ApplicationJSON.Schema)
-		response.Content.ApplicationJSON.Schema = responseSchema
+		response.Content = response.Content.resolve(openAPISpec)
+		resolved.Responses[code] = response
+	}
+	return &resolved
+}
+
+// resolve returns a copy of c with its JSON schema resolved.
+func (c Content) resolve(openAPISpec OpenAPISpec) Content {
+	c.MediaTypes = append([]string(nil), c.MediaTypes...)
+	c.ApplicationJSON.Schema = resolveSchemaRefs(openAPISpec, c.ApplicationJSON.Schema).clone()
+	return c
+}
+
+// forceOperationSchemas replaces the resolved request body schema and the
+// success response schema with the named component schemas (-requestSchema,
+// -responseSchema) for specs whose operations reference the wrong one. Empty
+// names keep the spec's schema.
+func forceOperationSchemas(openAPISpec OpenAPISpec, operation *Operation, requestSchema, responseSchema string) error {
+	component := func(flag, name string) (Schema, error) {
+		if _, ok := openAPISpec.Components.Schemas[name]; !ok {
+			return Schema{}, fmt.Errorf("%s: no component schema %q", flag, name)
+		}
+		return resolveSchemaRefs(openAPISpec, Schema{Ref: "#/components/schemas/" + name}).clone(), nil
+	}
+	if name := strings.TrimSpace(requestSchema); name != "" {
+		schema, err := component("request schema", name)
+		if err != nil {
+			return err
+		}
+		operation.RequestBody.Content.ApplicationJSON.Schema = schema
+		if operation.RequestBody.Content.JSONMediaType == "" {
+			operation.RequestBody.Content.JSONMediaType = "application/json"
+			operation.RequestBody.Content.MediaTypes = append(operation.RequestBody.Content.MediaTypes, "application/json")
+		}
+	}
+	if name := strings.TrimSpace(responseSchema); name != "" {
+		schema, err := component("response schema", name)
+		if err != nil {
+			return err
+		}
+		code, response, ok := successResponse(operation.Responses)
+		if !ok {
+			return fmt.Errorf("response schema %s: the operation declares no response", name)
+		}
+		response.Content.ApplicationJSON.Schema = schema
+		if response.Content.JSONMediaType == "" {
+			response.Content.JSONMediaType = "application/json"
+			response.Content.MediaTypes = append(response.Content.MediaTypes, "application/json")
+		}
+		operation.Responses[code] = response
+	}
+	return nil
+}
+
+// applySchemaViews narrows the resolved schemas of an operation to what each
+// direction carries: readOnly properties never take an input, and writeOnly
+// ones are never read from a response. Specs that share one schema between
+// requests and responses thus get inputs from its request view and outputs
+// from its response view.
+func applySchemaViews(operation *Operation) {
+	schema := &operation.RequestBody.Content.ApplicationJSON.Schema
+	*schema = schemaView(*schema, func(property Schema) bool { return property.ReadOnly })
+	for code, response := range operation.Responses {
+		response.Content.ApplicationJSON.Schema = schemaView(response.Content.ApplicationJSON.Schema, func(property Schema) bool { return property.WriteOnly })
 		operation.Responses[code] = response
 	}
 }
 
+// schemaView returns schema without the properties, at any depth, that drop
+// reports; they are also taken out of required.
+func schemaView(schema Schema, drop func(Schema) bool) Schema {
+	if schema.Items != nil {
+		items := schemaView(*schema.Items, drop)
+		schema.Items = &items
+	}
+	if len(schema.Properties) == 0 {
+		return schema
+	}
+	properties := make(map[string]Schema, len(schema.Properties))
+	var required []string
+	for name, property := range schema.Properties {
+		if drop(property) {
+			continue
+		}
+		properties[name] = schemaView(property, drop)
+	}
+	for _, name := range schema.Required {
+		if _, ok := properties[name]; ok {
+			required = append(required, name)
+		}
+	}
+	schema.Properties = properties
+	schema.Required = required
+	return schema
+}
+
+// clone returns a deep copy of s that shares no map, slice or pointer with it.
+// Enum and example values are only ever read and stay shared.
+func (s Schema) clone() Schema {
+	if s.Properties != nil {
+		properties := make(map[string]Schema, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = property.clone()
+		}
+		s.Properties = properties
+	}
+	if s.Items != nil {
+		items := s.Items.clone()
+		s.Items = &items
+	}
+	s.Enum = append([]interface{}(nil), s.Enum...)
+	s.Required = append([]string(nil), s.Required...)
+	s.AllOf = cloneSchemas(s.AllOf)
+	s.OneOf = cloneSchemas(s.OneOf)
+	s.AnyOf = cloneSchemas(s.AnyOf)
+	return s
+}
+
+func cloneSchemas(schemas []Schema) []Schema {
+	if schemas == nil {
+		return nil
+	}
+	cloned := make([]Schema, len(schemas))
+	for i, schema := range schemas {
+		cloned[i] = schema.clone()
+	}
+	return cloned
+}
+
 func resolveSchemaRefs(openAPISpec OpenAPISpec, schema Schema) Schema {
-	return resolveSchemaRefsWithHistory(openAPISpec, schema, map[string]bool{})
+	resolved, _ := resolveSchemaRefsWithHistory(openAPISpec, schema, map[string]bool{})
+	return resolved
 }
 
-func resolveSchemaRefsWithHistory(openAPISpec OpenAPISpec, schema Schema, history map[string]bool) Schema {
+// resolveSchemaRefsWithHistory resolves the refs of schema into new maps,
+// leaving schema and the spec's components as they are; history holds the
+// refs being resolved above it, and a ref back into history is left in place to
+// cut the cycle. cut reports whether that happened: such a result depends on
+// history, so only uncut component schemas are memoized. The memo hands out
+// clones, so callers may narrow what they get without changing later lookups.
+func resolveSchemaRefsWithHistory(openAPISpec OpenAPISpec, schema Schema, history map[string]bool) (resolved Schema, cut bool) {
 	if schema.Ref != "" {
 		refName := extractSchemaRefName(schema.Ref)
 		if refName != "" {
+			if cached, ok := openAPISpec.resolvedSchemas.get(refName); ok {
+				return cached.clone(), false
+			}
 			if history[refName] {
-				return schema
+				return schema, true
 			}
 			history[refName] = true
-			if resolved, ok := openAPISpec.Components.Schemas[refName]; ok {
-				resolved = resolveSchemaRefsWithHistory(openAPISpec, resolved, history)
+			if component, ok := openAPISpec.Components.Schemas[refName]; ok {
+				component, cut = resolveSchemaRefsWithHistory(openAPISpec, component, history)
 				delete(history, refName)
-				return resolved
+				if !cut && openAPISpec.resolvedSchemas != nil {
+					openAPISpec.resolvedSchemas.put(refName, component)
+					return component.clone(), false
+				}
+				return component, cut
 			}
 			delete(history, refName)
 		}
 	}
 	if schema.Items != nil {
-		resolvedItems := resolveSchemaRefsWithHistory(openAPISpec, *schema.Items, history)
+		resolvedItems, itemsCut := resolveSchemaRefsWithHistory(openAPISpec, *schema.Items, history)
 		schema.Items = &resolvedItems
+		cut = cut || itemsCut
 	}
 	if len(schema.Properties) > 0 {
+		properties := make(map[string]Schema, len(schema.Properties))
 		for key, propSchema := range schema.Properties {
-			resolvedProp := resolveSchemaRefsWithHistory(openAPISpec, propSchema, history)
-			schema.Properties[key] = resolvedProp
+			resolvedProp, propCut := resolveSchemaRefsWithHistory(openAPISpec, propSchema, history)
+			properties[key] = resolvedProp
+			cut = cut || propCut
 		}
+		schema.Properties = properties
 	}
-	return schema
+	if schema.Type == "" && isMapSchema(schema) {
+		schema.Type = "object"
+	}
+	return schema, cut
+}
+
+// unsupportedConstructs lists what the generator would silently degrade for an
+// operation with resolved schemas: unresolvable refs, non-JSON content types,
+// unsupported parameter locations or styles and composition keywords.
+func unsupportedConstructs(openAPISpec OpenAPISpec, operation *Operation) []string {
+	var issues []string
+	for _, param := range operation.Parameters {
+		if param.Ref != "" {
+			issues = append(issues, fmt.Sprintf("parameter has unresolvable $ref %s", param.Ref))
+			continue
+		}
+		switch param.In {
+		case "path":
+			if param.Style != "" && param.Style != "simple" {
+				issues = append(issues, fmt.Sprintf("path parameter %s uses unsupported style %q", param.Name, param.Style))
+			}
+		case "query":
+			switch param.Style {
+			case "", "form", "spaceDelimited", "pipeDelimited", "deepObject":
+			default:
+				issues = append(issues, fmt.Sprintf("query parameter %s uses unsupported style %q", param.Name, param.Style))
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("%s parameter %s is not supported", param.In, param.Name))
+		}
+		issues = append(issues, schemaIssues(openAPISpec, param.Schema, "parameter "+param.Name)...)
+	}
+	issues = append(issues, contentIssues(openAPISpec, operation.RequestBody.Content, "request body")...)
+	codes := make([]string, 0, len(operation.Responses))
+	for code := range operation.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			issues = append(issues, contentIssues(openAPISpec, operation.Responses[code].Content, "response "+code)...)
+		}
+	}
+	return issues
+}
+
+// contentIssues reports a body without a JSON media type and the schema issues
+// of the JSON one.
+func contentIssues(openAPISpec OpenAPISpec, content Content, location string) []string {
+	if len(content.MediaTypes) > 0 && content.JSONMediaType == "" {
+		return []string{fmt.Sprintf("%s has unsupported content type(s) %s", location, strings.Join(content.MediaTypes, ", "))}
+	}
+	return schemaIssues(openAPISpec, content.ApplicationJSON.Schema, location)
+}
+
+// schemaIssues walks a resolved schema for refs that could not be resolved and
+// composition keywords the generator ignores.
+func schemaIssues(openAPISpec OpenAPISpec, schema Schema, location string) []string {
+	var issues []string
+	if schema.Ref != "" {
+		// A ref left in place is either recursive (kept deliberately) or missing.
+		if _, ok := openAPISpec.Components.Schemas[extractSchemaRefName(schema.Ref)]; !ok || !strings.HasPrefix(schema.Ref, "#/components/schemas/") {
+			issues = append(issues, fmt.Sprintf("%s has unresolvable $ref %s", location, schema.Ref))
+		}
+		return issues
+	}
+	for keyword, schemas := range map[string][]Schema{"allOf": schema.AllOf, "oneOf": schema.OneOf, "anyOf": schema.AnyOf} {
+		if len(schemas) > 0 {
+			issues = append(issues, fmt.Sprintf("%s uses unsupported %s", location, keyword))
+		}
+	}
+	sort.Strings(issues)
+	if schema.Items != nil {
+		issues = append(issues, schemaIssues(openAPISpec, *schema.Items, location+"[]")...)
+	}
+	keys := make([]string, 0, len(schema.Properties))
+	for key := range schema.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		issues = append(issues, schemaIssues(openAPISpec, schema.Properties[key], location+"."+key)...)
+	}
+	return issues
 }
 
 func extractSchemaRefName(ref string) string {
@@ -625,10 +1151,17 @@ func HumanReadableName(name string) string {
 	name = strings.ReplaceAll(name, "_", " ")
 	name = strings.ReplaceAll(name, "-", " ")
 	name = whitespaceRegex.ReplaceAllString(strings.TrimSpace(name), " ")
+	// A capital starts a word after a lowercase letter or digit, or ends a run of
+	// capitals when a lowercase letter follows: domainUUID, HTTPServer.
+	runes := []rune(name)
 	var result []rune
-	for i, char := range name {
-		if i > 0 && char >= 'A' && char <= 'Z' && name[i-1] != ' ' {
-			result = append(result, ' ')
+	for i, char := range runes {
+		if i > 0 && char >= 'A' && char <= 'Z' && runes[i-1] != ' ' {
+			previousUpper := runes[i-1] >= 'A' && runes[i-1] <= 'Z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if !previousUpper || nextLower {
+				result = append(result, ' ')
+			}
 		}
 		result = append(result, char)
 	}
@@ -688,13 +1221,22 @@ func extractResourceFromPath(path string) (string, bool) {
 }
 
 func buildOperationDisplayName(operationId, path, method string) string {
+	action, resourceName, suffix, ok := operationNameParts(path, method)
+	if !ok {
+		return HumanReadableName(operationId)
+	}
+	return fmt.Sprintf("%s %s%s", action, resourceName, suffix)
+}
+
+// operationNameParts splits the display name of an operation into its action
+// ("List", "Create"), resource ("Sites", "Site") and suffix (" by ID"); ok is
+// false when the path or method does not give one.
+func operationNameParts(path, method string) (action, resourceName, suffix string, ok bool) {
 	resourceSegment, hasParam := extractResourceFromPath(path)
 	if resourceSegment == "" {
-		return HumanReadableName(operationId)
+		return "", "", "", false
 	}
-	resourceName := HumanReadableName(resourceSegment)
-	action := ""
-	suffix := ""
+	resourceName = HumanReadableName(resourceSegment)
 	switch strings.ToUpper(method) {
 	case "GET":
 		if hasParam {
@@ -732,28 +1274,195 @@ func buildOperationDisplayName(operationId, path, method string) string {
 			// Keep plural
 		}
 	default:
-		return HumanReadableName(operationId)
+		return "", "", "", false
 	}
-	return fmt.Sprintf("%s %s%s", action, resourceName, suffix)
+	return action, resourceName, suffix, true
 }
 
-// GenerateAPIEndpoint constructs the API endpoint with placeholders for parameters.
-func GenerateAPIEndpoint(path string, params []Parameter, includeQuery bool) string {
-	// Replace path parameters and collect query parameters
-	var queryParts []string
-	for _, param := range params {
-		switch param.In {
-		case "path":
+// NameTemplateData is the data of -nameTemplate: Name is the default display
+// name ("Get Site by ID"), Action and Resource its parts ("Get", "Site").
+type NameTemplateData struct {
+	Platform    string
+	Action      string
+	Resource    string
+	Name        string
+	OperationID string
+	Method      string
+	Path        string
+}
+
+// parseNameTemplate parses a -nameTemplate or options.name_template value.
+func parseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("name template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyNameTemplate names the workflow after nameTemplate. The platform prefix
+// then leaves the workflow name alone; the template places .Platform itself.
+func (s *renderSettings) applyNameTemplate(workflowData *WorkflowData, operationId, path, method string) error {
+	if strings.TrimSpace(s.nameTemplate) == "" {
+		return nil
+	}
+	tmpl, err := parseNameTemplate(s.nameTemplate)
+	if err != nil {
+		return err
+	}
+	platform := strings.TrimSpace(s.platformName)
+	if platform == "" {
+		platform = s.currentConnector.PlatformDisplayName
+	}
+	data := NameTemplateData{
+		Platform:    platform,
+		Name:        workflowData.Name,
+		OperationID: operationId,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+	}
+	if action, resource, _, ok := operationNameParts(path, method); ok {
+		data.Action, data.Resource = action, resource
+	} else {
+		data.Resource = HumanReadableName(operationId)
+	}
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return fmt.Errorf("name template: %w", err)
+	}
+	title := strings.Join(strings.Fields(name.String()), " ")
+	if title == "" {
+		return fmt.Errorf("name template: %q renders an empty name for %s", s.nameTemplate, operationId)
+	}
+	workflowData.Name = title
+	workflowData.Title = title
+	workflowData.Properties.DisplayName = title
+	return nil
+}
+
+// categoryPathSeparator joins the levels of a category path into the category
+// name ("NetBox / IPAM").
+const categoryPathSeparator = " / "
+
+// CategoryPathData is the data of each -categoryPath level: Group is the first
+// path segment of the operation ("IPAM" for /api/ipam/prefixes/), Tag its first
+// OpenAPI tag and Resource the resource it acts on ("Prefixes").
+type CategoryPathData struct {
+	Platform string
+	Group    string
+	Tag      string
+	Resource string
+}
+
+// parseCategoryPath parses the levels of a -categoryPath or options.category_path value.
+func parseCategoryPath(levels []string) ([]*template.Template, error) {
+	templates := make([]*template.Template, 0, len(levels))
+	for _, level := range levels {
+		if strings.TrimSpace(level) == "" {
+			return nil, errors.New("category path: levels must not be empty")
+		}
+		tmpl, err := template.New("category").Option("missingkey=error").Parse(level)
+		if err != nil {
+			return nil, fmt.Errorf("category path: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// pathGroup returns the first segment of path after the /api prefix and any
+// version segment ("ipam" for /api/ipam/prefixes/{id}/).
+func pathGroup(path string) string {
+	for i, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" || part == "api" || strings.HasPrefix(part, "{") {
+			continue
+		}
+		if i <= 1 && versionSegmentRegex.MatchString(part) {
+			continue
+		}
+		return part
+	}
+	return ""
+}
+
+var versionSegmentRegex = regexp.MustCompile(`^v\d+(\.\d+)*$`)
+
+// categoryPathID derives the category unique name from its full name, so every
+// workflow of a level lands in the same category across runs.
+func categoryPathID(name string) string {
+	return "category_" + idGenerator.Derive(sha256.Sum256([]byte(strings.ToLower(name))))
+}
+
+// applyCategoryPath files the workflow under the category named by categoryPath.
+// AO categories are flat, so the hierarchy is expressed in the leaf's name
+// ("NetBox / IPAM") and only the leaf is created; levels rendering empty are
+// dropped.
+func (s *renderSettings) applyCategoryPath(workflowData *WorkflowData, operation *Operation, path, method string) error {
+	if len(s.categoryPath) == 0 {
+		return nil
+	}
+	templates, err := parseCategoryPath(s.categoryPath)
+	if err != nil {
+		return err
+	}
+	platform := strings.TrimSpace(s.platformName)
+	if platform == "" {
+		platform = s.currentConnector.PlatformDisplayName
+	}
+	acronyms := acronymReplacer()
+	data := CategoryPathData{Platform: platform, Group: acronyms(HumanReadableName(pathGroup(path)))}
+	if operation != nil && len(operation.Tags) > 0 {
+		data.Tag = acronyms(HumanReadableName(operation.Tags[0]))
+	}
+	if _, resource, _, ok := operationNameParts(path, method); ok {
+		data.Resource = acronyms(resource)
+	}
+	levels := make([]string, 0, len(templates))
+	for _, tmpl := range templates {
+		var level strings.Builder
+		if err := tmpl.Execute(&level, data); err != nil {
+			return fmt.Errorf("category path: %w", err)
+		}
+		if text := strings.Join(strings.Fields(level.String()), " "); text != "" {
+			levels = append(levels, text)
+		}
+	}
+	if len(levels) == 0 {
+		return fmt.Errorf("category path: %q renders no category for %s %s", strings.Join(s.categoryPath, ", "), strings.ToUpper(method), path)
+	}
+	name := strings.Join(levels, categoryPathSeparator)
+	id := categoryPathID(name)
+	workflowData.Categories = []string{id}
+	workflowData.CategoriesMap = map[string]CategoryData{id: {
+		UniqueName:   id,
+		Name:         name,
+		Title:        name,
+		Type:         "basic.category",
+		BaseType:     "category",
+		CategoryType: "custom",
+		ObjectType:   "category",
+	}}
+	return nil
+}
+
+// GenerateAPIEndpoint constructs the API endpoint with placeholders for parameters.
+func (s *renderSettings) GenerateAPIEndpoint(path string, params []Parameter, includeQuery bool) string {
+	// Replace path parameters and collect query parameters
+	var queryParts []string
+	for _, param := range params {
+		switch param.In {
+		case "path":
 			placeholder := fmt.Sprintf("{%s}", param.Name)
-			path = strings.ReplaceAll(path, placeholder, fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$%sKSUID$", param.Name))
+			path = strings.ReplaceAll(path, placeholder, s.inputVariableRef(placeholderKindParam, param.Name))
 		case "query":
 			// Build query string placeholder from input variable
 			if includeQuery {
-				qp := fmt.Sprintf("%s=$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$%sKSUID$", param.Name, param.Name)
+				qp := fmt.Sprintf("%s=%s", param.Name, s.inputVariableRef(placeholderKindParam, param.Name))
 				queryParts = append(queryParts, qp)
 			}
 		}
 	}
+	path = s.currentConnector.withPathSuffix(path)
 	if includeQuery && len(queryParts) > 0 {
 		separator := "?"
 		if strings.Contains(path, "?") {
@@ -761,12 +1470,63 @@ func GenerateAPIEndpoint(path string, params []Parameter, includeQuery bool) str
 		}
 		path = path + separator + strings.Join(queryParts, "&")
 	}
-	if currentConnector.APIBasePath != "" && !strings.HasPrefix(path, currentConnector.APIBasePath) {
-		return currentConnector.APIBasePath + path
+	if s.currentConnector.APIBasePath != "" && !strings.HasPrefix(path, s.currentConnector.apiRoot()) {
+		return s.currentConnector.APIBasePath + path
 	}
 	return path
 }
 
+// apiRoot returns the prefix of spec paths that already include the base path.
+func (c connectorConfig) apiRoot() string {
+	if c.APIRoot != "" {
+		return c.APIRoot
+	}
+	return c.APIBasePath
+}
+
+// applyListQueryParams turns the connector's ListQueryParams into non-exploded
+// string arrays, so they take a comma-separated list like the NetBox filters
+// but are sent as one name=a,b,c value.
+func (c connectorConfig) applyListQueryParams(operation *Operation) {
+	for i, param := range operation.Parameters {
+		if param.In != "query" || param.Schema.Type == "array" || !contains(c.ListQueryParams, param.Name) {
+			continue
+		}
+		explode := false
+		operation.Parameters[i].Schema = Schema{Type: "array", Description: param.Schema.Description, Items: &Schema{Type: "string"}}
+		operation.Parameters[i].Explode = &explode
+	}
+}
+
+// withPathSuffix adds the connector's PathSuffix to path unless it already ends
+// in a format extension, keeping any query string behind it.
+func (c connectorConfig) withPathSuffix(path string) string {
+	if c.PathSuffix == "" {
+		return path
+	}
+	base, query, hasQuery := strings.Cut(path, "?")
+	if strings.HasSuffix(base, ".json") || strings.HasSuffix(base, ".xml") {
+		return path
+	}
+	base = strings.TrimSuffix(base, "/") + c.PathSuffix
+	if hasQuery {
+		return base + "?" + query
+	}
+	return base
+}
+
+// bodyPrep reports whether the connector's POST/PUT/PATCH bodies are built by a
+// Prepare Request Body step, as NetBox's invoke_api takes them as JSON text.
+func (c connectorConfig) bodyPrep() bool {
+	return c.ActionType == "netbox.invoke_api"
+}
+
+// paginatedLists reports whether the connector's list responses are NetBox
+// Paginated*List envelopes, whose results become the list outputs.
+func (c connectorConfig) paginatedLists() bool {
+	return c.ActionType == "netbox.invoke_api"
+}
+
 // Function to check if a slice contains a given string
 func contains(slice []string, value string) bool {
 	for _, v := range slice {
@@ -778,22 +1538,25 @@ func contains(slice []string, value string) bool {
 }
 
 // GenerateAPIRequestBody constructs the API request body as a JSON object with placeholders.
-func GenerateAPIRequestBody(schema Schema) string {
+func (s *renderSettings) GenerateAPIRequestBody(schema Schema) string {
 	switch schema.Type {
 	case "object":
-		return buildObjectRequestBody(schema)
+		return s.buildObjectRequestBody(schema)
 	case "array":
 		if schema.Items == nil {
 			return "[]"
 		}
-		itemBody := GenerateAPIRequestBody(*schema.Items)
+		itemBody := s.GenerateAPIRequestBody(*schema.Items)
 		return "[\n" + indentMultilineString(itemBody, "\t") + "\n]"
 	default:
 		return "{\n\t\n}"
 	}
 }
 
-func buildObjectRequestBody(schema Schema) string {
+func (s *renderSettings) buildObjectRequestBody(schema Schema) string {
+	if isMapSchema(schema) {
+		return s.inputVariableRef(placeholderKindBody, mapBodyVariableName)
+	}
 	if len(schema.Properties) == 0 {
 		return "{\n\t\n}"
 	}
@@ -801,13 +1564,13 @@ func buildObjectRequestBody(schema Schema) string {
 	parts := make([]string, len(keys))
 	for i, key := range keys {
 		propSchema := schema.Properties[key]
-		placeholder := fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$%sKSUID$", key)
+		placeholder := s.inputVariableRef(placeholderKindBody, key)
 		var value string
 		switch propSchema.Type {
 		case "array", "object":
 			value = placeholder
 		case "integer", "number", "boolean":
-			if stringifyBodyInputs {
+			if s.stringifyBodyInputs {
 				value = fmt.Sprintf("\"%s\"", placeholder)
 			} else {
 				value = placeholder
@@ -864,7 +1627,7 @@ func normalizeOpenAPIContent(data []byte) ([]byte, error) {
 	return jsonData, nil
 }
 
-func buildAPIRequestAction(operation *Operation, endpoint string, method string, hasBody bool, displayName string, bodyRef string) ActionData {
+func (s *renderSettings) buildAPIRequestAction(operation *Operation, endpoint string, method string, hasBody bool, displayName string, bodyRef string) ActionData {
 	var body string
 	if hasBody {
 		if bodyRef != "" {
@@ -872,1657 +1635,9049 @@ func buildAPIRequestAction(operation *Operation, endpoint string, method string,
 			body = bodyRef
 		} else {
 			// Use the static template (fallback for non-NetBox)
-			body = GenerateAPIRequestBody(operation.RequestBody.Content.ApplicationJSON.Schema)
+			body = s.GenerateAPIRequestBody(operation.RequestBody.Content.ApplicationJSON.Schema)
 		}
 	}
-	props := currentConnector.BuildActionProps(method, endpoint, body, hasBody, operation, displayName)
+	props := s.currentConnector.BuildActionProps(connector.Request{
+		Method:      method,
+		Endpoint:    endpoint,
+		Body:        body,
+		HasBody:     hasBody,
+		Description: operation.Description,
+		DisplayName: displayName,
+		Timeout:     s.apiRequestTimeout,
+		Headers:     s.httpHeaders,
+	})
+	if s.generateScaffold {
+		props = scaffoldActionProperties(props)
+	}
 	return ActionData{
 		UniqueName: "definition_activity_$ApiRequestKSUID",
 		Name:       "API Request for " + displayName,
 		Title:      displayName,
-		Type:       currentConnector.ActionType,
+		Type:       s.currentConnector.ActionType,
 		BaseType:   "activity",
 		Properties: props,
 		ObjectType: "definition_activity",
 	}
 }
 
-func merakiActionProperties(method, endpoint, body string, _ bool, operation *Operation, displayName string) interface{} {
-	return APIRequestProperties{
-		ActionTimeout:     180,
-		ApiMethod:         method,
-		ApiURL:            endpoint,
-		ApiBody:           body,
-		ContinueOnFailure: false,
-		Description:       operation.Description,
-		DisplayName:       displayName,
-		RuntimeUser:       RuntimeUserData{TargetDefault: true},
-		SkipExecution:     false,
-		Target:            map[string]bool{"use_workflow_target": true},
+// scaffoldBanner prefixes the description of workflows generated with -scaffold.
+const scaffoldBanner = "Generated scaffold — review before enabling."
+
+// scaffoldActionProperties marks the API request as skipped so a scaffold can be
+// imported and reviewed without ever calling the target.
+func scaffoldActionProperties(props interface{}) interface{} {
+	switch p := props.(type) {
+	case connector.APIRequestProperties:
+		p.SkipExecution = true
+		p.Description = scaffoldDescription(p.Description)
+		return p
+	case connector.NetboxAPIRequestProperties:
+		p.SkipExecution = true
+		return p
+	case connector.HTTPRequestProperties:
+		p.SkipExecution = true
+		p.Description = scaffoldDescription(p.Description)
+		return p
+	case connector.DefinedRequestProperties:
+		p = p.With("skip_execution", true)
+		if p.DescriptionKey == "" {
+			return p
+		}
+		description, _ := p.Values[p.DescriptionKey].(string)
+		return p.With(p.DescriptionKey, scaffoldDescription(description))
+	}
+	if p, ok := pluginRequestProperties(props); ok {
+		return scaffoldActionProperties(p)
 	}
+	return props
 }
 
-func netboxActionProperties(method, endpoint, body string, hasBody bool, operation *Operation, displayName string) interface{} {
-	props := NetboxAPIRequestProperties{
-		ActionTimeout:     180,
-		ContinueOnFailure: true,
-		DisplayName:       displayName,
-		Method:            method,
-		Endpoint:          endpoint,
-		RuntimeUser:       RuntimeUserData{TargetDefault: true},
-		SkipExecution:     false,
-		Target:            map[string]bool{"use_workflow_target": true},
+// scaffoldDescription prepends the scaffold banner to a description.
+func scaffoldDescription(description string) string {
+	if strings.TrimSpace(description) == "" {
+		return scaffoldBanner
 	}
-	if hasBody && strings.TrimSpace(body) != "" {
-		props.Body = body
+	return scaffoldBanner + " " + description
+}
+
+// authFailureMessage is the error reported when the target rejects the request with 401/403.
+func (s *renderSettings) authFailureMessage() string {
+	platform := strings.TrimSpace(s.platformName)
+	if platform == "" {
+		platform = s.currentConnector.PlatformDisplayName
 	}
-	return props
+	return fmt.Sprintf("Authentication/authorization to %s failed; check the target's API token", platform)
 }
 
-func getConnectorConfig(name string) (connectorConfig, error) {
-	switch strings.ToLower(name) {
-	case "", "meraki":
-		return connectorConfig{
-			AtomicGroup:         "Cisco Meraki",
-			TargetType:          "meraki.endpoint",
-			ActionType:          "meraki.api_request",
-			ResponseBodyField:   "response_body",
-			StatusMessageField:  "status_text",
-			APIBasePath:         "/api/v1",
-			ContinueOnFailure:   false,
-			PlatformDisplayName: "Cisco Meraki",
-			BuildActionProps:    merakiActionProperties,
-		}, nil
-	case "netbox":
-		return connectorConfig{
-			AtomicGroup:         "NetBox",
-			TargetType:          "netbox.endpoint",
-			ActionType:          "netbox.invoke_api",
-			ResponseBodyField:   "raw_body",
-			StatusMessageField:  "",
-			APIBasePath:         "",
-			ContinueOnFailure:   true,
-			PlatformDisplayName: "Netbox",
-			BuildActionProps:    netboxActionProperties,
-		}, nil
-	default:
-		return connectorConfig{}, fmt.Errorf("unsupported connector type %s", name)
+// buildAuthFailureCheck returns the failed-branch step that ends the run with an
+// actionable message when the status code is 401 or 403 instead of the raw body.
+func (s *renderSettings) buildAuthFailureCheck(statusOperand string) ActionData {
+	return ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Condition Block",
+		Title:      "Authentication Failed?",
+		Type:       "logic.if_else",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"conditions":          []interface{}{},
+			"continue_on_failure": false,
+			"display_name":        "Authentication Failed?",
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Blocks: []BlockData{
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      "401/403 Unauthorized",
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: BlockProperties{
+					Condition: Condition{
+						LeftOperand: Condition{
+							LeftOperand:  statusOperand,
+							Operator:     "eq",
+							RightOperand: 401,
+						},
+						Operator: "or",
+						RightOperand: Condition{
+							LeftOperand:  statusOperand,
+							Operator:     "eq",
+							RightOperand: 403,
+						},
+					},
+					DisplayName:       "401/403 Unauthorized",
+					ContinueOnFailure: false,
+					SkipExecution:     false,
+				},
+				ObjectType: "definition_activity",
+				Actions: []ActionData{
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Set Variables",
+						Title:      "Set Error Message",
+						Type:       "core.set_multiple_variables",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"continue_on_failure": false,
+							"display_name":        "Set Error Message",
+							"skip_execution":      false,
+							"variables_to_update": []VariableUpdate{
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+									VariableValueNew: s.authFailureMessage(),
+								},
+							},
+						},
+						ObjectType: "definition_activity",
+					},
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Completed",
+						Title:      "Completed - Failed",
+						Type:       "logic.completed",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"completion_type":     "failed-completed",
+							"continue_on_failure": false,
+							"display_name":        "Completed - Failed",
+							"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+							"skip_execution":      false,
+						},
+						ObjectType: "definition_activity",
+					},
+				},
+			},
+		},
 	}
 }
 
-type WorkflowConfig struct {
-	Endpoint    string           `json:"endpoint" yaml:"endpoint"`
-	Methods     []string         `json:"methods,omitempty" yaml:"methods,omitempty"`
-	QueryParams []string         `json:"query_params,omitempty" yaml:"query_params,omitempty"`
-	BodyParams  []string         `json:"body_params,omitempty" yaml:"body_params,omitempty"`
-	Options     *WorkflowOptions `json:"options,omitempty" yaml:"options,omitempty"`
+// buildConnectionFailureBranch returns the condition branch taken when the adapter
+// produced no status code (timeout, DNS or TLS failure), so connectivity problems
+// are reported separately from HTTP errors.
+func (s *renderSettings) buildConnectionFailureBranch(statusOperand string, diagnosticUpdates []VariableUpdate) BlockData {
+	platform := strings.TrimSpace(s.platformName)
+	if platform == "" {
+		platform = s.currentConnector.PlatformDisplayName
+	}
+	message := fmt.Sprintf("Could not connect to the %s target (no HTTP status received); check the target's host, port, DNS and TLS settings", platform)
+	if errorMessage := s.errorMessageExpr(""); errorMessage != "" {
+		message += ": " + errorMessage
+	}
+	return BlockData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Condition Branch",
+		Title:      "Connection Failed",
+		Type:       "logic.condition_block",
+		BaseType:   "activity",
+		Properties: BlockProperties{
+			Condition: Condition{
+				LeftOperand: Condition{
+					LeftOperand:  statusOperand,
+					Operator:     "eq",
+					RightOperand: "",
+				},
+				Operator: "or",
+				RightOperand: Condition{
+					LeftOperand:  statusOperand,
+					Operator:     "eq",
+					RightOperand: 0,
+				},
+			},
+			DisplayName:       "Connection Failed",
+			ContinueOnFailure: false,
+			SkipExecution:     false,
+		},
+		ObjectType: "definition_activity",
+		Actions: []ActionData{
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Set Variables",
+				Title:      "Set Output Variables",
+				Type:       "core.set_multiple_variables",
+				BaseType:   "activity",
+				Properties: map[string]interface{}{
+					"continue_on_failure": false,
+					"display_name":        "Set Output Variables",
+					"skip_execution":      false,
+					"variables_to_update": append([]VariableUpdate{
+						{
+							VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+							VariableValueNew: message,
+						},
+						{
+							VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+							VariableValueNew: "workflow-errored",
+						},
+					}, diagnosticUpdates...),
+				},
+				ObjectType: "definition_activity",
+			},
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Completed",
+				Title:      "Completed - Failed",
+				Type:       "logic.completed",
+				BaseType:   "activity",
+				Properties: map[string]interface{}{
+					"completion_type":     "failed-completed",
+					"continue_on_failure": false,
+					"display_name":        "Completed - Failed",
+					"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+					"skip_execution":      false,
+				},
+				ObjectType: "definition_activity",
+			},
+		},
+	}
 }
 
-type WorkflowOptions struct {
-	SupportIdempotency   *bool  `json:"support_idempotency,omitempty" yaml:"support_idempotency,omitempty"`
-	IdempotencyCondition string `json:"idempotency_condition,omitempty" yaml:"idempotency_condition,omitempty"`
-	CategoryId           string `json:"category_id,omitempty" yaml:"category_id,omitempty"`
-	CategoryName         string `json:"category_name,omitempty" yaml:"category_name,omitempty"`
-	Platform             string `json:"platform,omitempty" yaml:"platform,omitempty"`
+// httpBasePath (-httpBasePath) prefixes the generic connector's relative URLs,
+// e.g. /api/v2 when the spec's paths start below the server URL's path. Like
+// idGenerator it is only written while the flags are parsed.
+var httpBasePath = ""
+
+// parseHTTPHeader parses a -httpHeader value, "Name: value".
+func parseHTTPHeader(value string) (connector.HTTPHeader, error) {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return connector.HTTPHeader{}, fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	return connector.HTTPHeader{Name: name, Value: strings.TrimSpace(headerValue)}, nil
 }
 
-type WorkflowDefaults struct {
-	QueryParams []string `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+// getConnectorConfig looks up a connector in the registry: the built-in ones of
+// pkg/connector, those of blank-imported plugin packages and those loaded with
+// -connectorDef. An empty name means Meraki.
+func getConnectorConfig(name string) (connectorConfig, error) {
+	if strings.TrimSpace(name) == "" {
+		name = "meraki"
+	}
+	cfg, ok := connector.Lookup(name)
+	if !ok {
+		return connectorConfig{}, fmt.Errorf("unsupported connector type %s", name)
+	}
+	if cfg.ActionType == connector.HTTPRequestActionType && httpBasePath != "" {
+		cfg.APIBasePath = httpBasePath
+	}
+	return connectorConfig{Config: cfg}, nil
 }
 
-type workflowConfigFile struct {
-	Defaults  WorkflowDefaults `json:"defaults" yaml:"defaults"`
-	Workflows []WorkflowConfig `json:"workflows" yaml:"workflows"`
+// connectorInfo is one connector of -listConnectors: what it renders and the
+// generator features it supports.
+type connectorInfo struct {
+	Name        string `json:"name"`
+	ActionType  string `json:"action_type"`
+	TargetType  string `json:"target_type"`
+	BasePath    string `json:"base_path"`
+	QueryPrep   bool   `json:"query_prep"`
+	BodyPrep    bool   `json:"body_prep"`
+	Idempotency bool   `json:"idempotency"`
+	Pagination  bool   `json:"pagination"`
 }
 
-var nonIdentifierRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
-var bodyParamFilter = make(map[string]map[string]struct{})
-var stringifyBodyInputs bool
+// listConnectors describes every registered connector, aliases and
+// -connectorDef ones included, sorted by name. Idempotency is rendered by the
+// generator on every connector.
+func listConnectors() ([]connectorInfo, error) {
+	var infos []connectorInfo
+	for _, name := range connector.Names() {
+		cfg, err := getConnectorConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, connectorInfo{
+			Name:        name,
+			ActionType:  cfg.ActionType,
+			TargetType:  cfg.TargetType,
+			BasePath:    cfg.APIBasePath,
+			QueryPrep:   cfg.QueryPrep,
+			BodyPrep:    cfg.bodyPrep(),
+			Idempotency: true,
+			Pagination:  cfg.paginatedLists(),
+		})
+	}
+	return infos, nil
+}
 
-func pythonIdentifier(name string, fallback string, idx int) string {
-	if name == "" {
-		name = fallback
+// printConnectors writes the -listConnectors report as a table or, with
+// asJSON, as a JSON array.
+func printConnectors(w io.Writer, asJSON bool) error {
+	infos, err := listConnectors()
+	if err != nil {
+		return err
 	}
-	clean := nonIdentifierRegex.ReplaceAllString(name, "_")
-	if clean == "" {
-		clean = fmt.Sprintf("%s_%d", fallback, idx)
+	if asJSON {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
 	}
-	if clean[0] >= '0' && clean[0] <= '9' {
-		clean = "_" + clean
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	out := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "NAME\tACTION TYPE\tBASE PATH\tQUERY PREP\tBODY PREP\tIDEMPOTENCY\tPAGINATION")
+	for _, info := range infos {
+		basePath := info.BasePath
+		if basePath == "" {
+			basePath = "-"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", info.Name, info.ActionType, basePath, yesNo(info.QueryPrep), yesNo(info.BodyPrep), yesNo(info.Idempotency), yesNo(info.Pagination))
 	}
-	return clean
+	return out.Flush()
 }
 
-func buildQueryPrepAction(queryParams []Parameter) (ActionData, string) {
-	if len(queryParams) == 0 {
-		return ActionData{}, ""
+const (
+	fixedOutputStatusMessage = connector.OutputStatusMessage
+	fixedOutputStatusCode    = connector.OutputStatusCode
+	fixedOutputErrorMessage  = connector.OutputErrorMessage
+	fixedOutputResponseBody  = connector.OutputResponseBody
+	fixedOutputRequestURL    = connector.OutputRequestURL
+	fixedOutputDuration      = connector.OutputDuration
+)
+
+// fixedOutputDefinition describes one of the standard outputs every atomic can
+// declare besides the response properties.
+type fixedOutputDefinition struct {
+	Token       string
+	Name        string
+	Type        string
+	Description string
+}
+
+var fixedOutputDefinitions = map[string]fixedOutputDefinition{
+	fixedOutputStatusMessage: {Token: "StatusMessage", Name: "Output - Status Message", Type: "datatype.string", Description: "The HTTP status message of the API response."},
+	fixedOutputStatusCode:    {Token: "StatusCode", Name: "Output - Status Code", Type: "datatype.integer", Description: "The HTTP status code of the API response."},
+	fixedOutputErrorMessage:  {Token: "ErrorMessage", Name: "Output - Error Message", Type: "datatype.string", Description: "The HTTP error message of the API response."},
+	fixedOutputResponseBody:  {Token: "ResponseBody", Name: "Output - Response Body", Type: "datatype.string", Description: "The raw response body (JSON), whichever output field the connector returns it in."},
+	fixedOutputRequestURL:    {Token: "RequestURL", Name: "Output - Request URL", Type: "datatype.string", Description: "The endpoint the API request was sent to, with path and query parameters filled in."},
+	fixedOutputDuration:      {Token: "Duration", Name: "Output - Duration", Type: "datatype.integer", Description: "How long the API request took, in milliseconds."},
+}
+
+// requiredFixedOutputs are always declared: the failure branches report through them.
+var requiredFixedOutputs = []string{fixedOutputStatusCode, fixedOutputErrorMessage}
+
+// normalizedFixedOutputs are declared on every atomic with -normalizeOutputs, so
+// composites can read the same output names whatever the connector.
+var normalizedFixedOutputs = []string{fixedOutputStatusMessage, fixedOutputStatusCode, fixedOutputErrorMessage, fixedOutputResponseBody}
+
+// statusMessageField is the API request output the status message output is
+// set from; connectors without a status text report the status code instead.
+func (s *renderSettings) statusMessageField() string {
+	if s.currentConnector.StatusMessageField == "" {
+		return "status_code"
 	}
-	pyVars := make([]string, len(queryParams))
-	seen := make(map[string]int)
-	for i, param := range queryParams {
-		base := pythonIdentifier(param.Name, "param", i)
-		if count, ok := seen[base]; ok {
-			seen[base] = count + 1
-			base = fmt.Sprintf("%s_%d", base, count+1)
-		} else {
-			seen[base] = 0
+	return s.currentConnector.StatusMessageField
+}
+
+// errorMessageExpr references the request action's error text, or fallback on
+// connectors that declare no ErrorMessageField.
+func (s *renderSettings) errorMessageExpr(fallback string) string {
+	if s.currentConnector.ErrorMessageField == "" {
+		return fallback
+	}
+	return fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", s.currentConnector.ErrorMessageField)
+}
+
+// activeFixedOutputs returns the standard outputs of the current workflow: the
+// configured set (or the connector's default) plus the required ones, without
+// the status message on connectors that do not return one unless outputs are
+// normalized. The default set also echoes the request URL when a prep step
+// builds the query string, since filters dropped there are otherwise invisible
+// in the run.
+func (s *renderSettings) activeFixedOutputs(preparedQuery bool) []string {
+	selected := make(map[string]bool)
+	configured := s.fixedOutputs
+	if configured == nil {
+		configured = s.currentConnector.FixedOutputs
+		if preparedQuery {
+			configured = append(append([]string{}, configured...), fixedOutputRequestURL)
 		}
-		pyVars[i] = base
 	}
-	var builder strings.Builder
-	builder.WriteString("import sys\nimport urllib.parse\n\n")
-	if len(pyVars) == 1 {
-		builder.WriteString(fmt.Sprintf("(%s,) = sys.argv[1:2]\n\n", pyVars[0]))
-	} else {
-		builder.WriteString(fmt.Sprintf("(%s) = sys.argv[1:%d]\n\n", strings.Join(pyVars, ", "), len(pyVars)+1))
+	for _, name := range append(append([]string{}, configured...), requiredFixedOutputs...) {
+		selected[name] = true
 	}
-	builder.WriteString("queryStr = \"\"\nfirst = True\n\n")
-	for i, param := range queryParams {
-		pyVar := pyVars[i]
-		builder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
-		builder.WriteString("    if not first:\n        queryStr += '&'\n")
-		builder.WriteString(fmt.Sprintf("    queryStr += \"%s=\" + urllib.parse.quote_plus(str(%s))\n", param.Name, pyVar))
-		builder.WriteString("    first = False\n\n")
+	if s.normalizeOutputs {
+		for _, name := range normalizedFixedOutputs {
+			selected[name] = true
+		}
+	} else if s.currentConnector.StatusMessageField == "" {
+		delete(selected, fixedOutputStatusMessage)
 	}
-	builder.WriteString("print(queryStr)\n")
-
-	scriptArguments := make([]string, len(queryParams))
-	for i, param := range queryParams {
-		scriptArguments[i] = fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$%sKSUID$", param.Name)
+	var active []string
+	for _, name := range connector.Outputs {
+		if selected[name] {
+			active = append(active, name)
+		}
 	}
+	return active
+}
 
-	scriptAction := ActionData{
-		UniqueName: "definition_activity_" + KSUIDGenerator(),
-		Name:       "Execute Python Script",
-		Title:      "Prepare Query Params",
+// fixedOutputRef returns the workflow output reference of a standard output.
+func fixedOutputRef(name string) string {
+	return fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$%sKSUID$", fixedOutputDefinitions[name].Token)
+}
+
+// fixedOutputVariables builds the variable declarations of the given standard outputs.
+func fixedOutputVariables(names []string) []VariableData {
+	variables := make([]VariableData, 0, len(names))
+	for _, name := range names {
+		definition := fixedOutputDefinitions[name]
+		variable := VariableData{
+			SchemaID: definition.Type,
+			Properties: VariableProperties{
+				Value:                "",
+				Scope:                "output",
+				Name:                 definition.Name,
+				Type:                 definition.Type,
+				Description:          definition.Description,
+				VariableStringFormat: "text",
+			},
+			UniqueName: "variable_workflow_$" + definition.Token + "KSUID",
+			ObjectType: "variable_workflow",
+		}
+		if definition.Type == "datatype.integer" {
+			variable.Properties.Value = 0
+			variable.Properties.VariableStringFormat = ""
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// diagnosticOutputUpdates sets the response body, request URL and duration
+// outputs, when declared, in every branch that follows the API request.
+func diagnosticOutputUpdates(outputs []string, endpoint, responseBody, durationRef string) []VariableUpdate {
+	var updates []VariableUpdate
+	if contains(outputs, fixedOutputResponseBody) {
+		updates = append(updates, VariableUpdate{
+			VariableToUpdate: fixedOutputRef(fixedOutputResponseBody),
+			VariableValueNew: responseBody,
+		})
+	}
+	if contains(outputs, fixedOutputRequestURL) {
+		updates = append(updates, VariableUpdate{
+			VariableToUpdate: fixedOutputRef(fixedOutputRequestURL),
+			VariableValueNew: endpoint,
+		})
+	}
+	if contains(outputs, fixedOutputDuration) && durationRef != "" {
+		updates = append(updates, VariableUpdate{
+			VariableToUpdate: fixedOutputRef(fixedOutputDuration),
+			VariableValueNew: durationRef,
+		})
+	}
+	return updates
+}
+
+// startTimerTitle names the step recording when the API request started.
+const startTimerTitle = "Start Timer"
+
+// buildDurationActions returns the python steps timing the API request and the
+// reference to the measured duration in milliseconds.
+func buildDurationActions() (ActionData, ActionData, string) {
+	start := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      startTimerTitle,
 		Type:       "python3.script",
 		BaseType:   "activity",
 		Properties: map[string]interface{}{
 			"action_timeout":      180,
 			"continue_on_failure": false,
-			"display_name":        "Prepare Query Params",
-			"script":              builder.String(),
-			"script_arguments":    scriptArguments,
+			"display_name":        startTimerTitle,
+			"script":              "import time\n\nstarted = int(time.time() * 1000)\nprint(started)\n",
+			"script_arguments":    []string{},
 			"script_queries": []map[string]string{
 				{
-					"script_query":      "queryStr",
-					"script_query_name": "queryStr",
-					"script_query_type": "string",
+					"script_query":      "started",
+					"script_query_name": "started",
+					"script_query_type": "integer",
 				},
 			},
 			"skip_execution": false,
 		},
 		ObjectType: "definition_activity",
 	}
-
-	queryReference := fmt.Sprintf("$activity.%s.output.script_queries.queryStr$", scriptAction.UniqueName)
-	return scriptAction, queryReference
-}
-
-func buildRequestBodyPrepAction(bodySchema Schema, operationId string) (ActionData, string) {
-	// Extract properties from schema
-	var bodyParams []BodyParam
-	switch bodySchema.Type {
-	case "object":
-		for propName, propSchema := range bodySchema.Properties {
-			isRequired := contains(bodySchema.Required, propName)
-			bodyParams = append(bodyParams, BodyParam{
-				Name:     propName,
-				Required: isRequired,
-				Type:     propSchema.Type,
-			})
-		}
-	case "array":
-		if bodySchema.Items != nil && bodySchema.Items.Type == "object" {
-			for propName, propSchema := range bodySchema.Items.Properties {
-				isRequired := contains(bodySchema.Items.Required, propName)
-				bodyParams = append(bodyParams, BodyParam{
-					Name:     propName,
-					Required: isRequired,
-					Type:     propSchema.Type,
-				})
-			}
-		}
-	}
-
-	// Sort bodyParams for consistent output
-	sort.Slice(bodyParams, func(i, j int) bool {
-		return bodyParams[i].Name < bodyParams[j].Name
-	})
-
-	// Generate Python script
-	var scriptBuilder strings.Builder
-	scriptBuilder.WriteString("import json\n\n")
-
-	// Import input variables
-	for _, param := range bodyParams {
-		variableRef := fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$%sKSUID$", param.Name)
-		pyVar := pythonIdentifier(param.Name, "param", 0)
-		scriptBuilder.WriteString(fmt.Sprintf("%s = '%s'\n", pyVar, variableRef))
-	}
-
-	scriptBuilder.WriteString("\nrequest_body_object = {}\n")
-
-	// Build conditional field additions
-	for _, param := range bodyParams {
-		pyVar := pythonIdentifier(param.Name, "param", 0)
-		
-		// Determine how to add the value based on type
-		var valueExpr string
-		switch param.Type {
-		case "array", "object":
-			// Parse JSON strings for arrays and objects
-			valueExpr = fmt.Sprintf("json.loads(%s) if %s != '' else None", pyVar, pyVar)
-		case "integer", "number":
-			// Convert to int/float
-			if param.Type == "integer" {
-				valueExpr = fmt.Sprintf("int(%s) if %s != '' else None", pyVar, pyVar)
-			} else {
-				valueExpr = fmt.Sprintf("float(%s) if %s != '' else None", pyVar, pyVar)
-			}
-		case "boolean":
-			// Convert to boolean
-			valueExpr = fmt.Sprintf("%s.lower() == 'true' if %s != '' else None", pyVar, pyVar)
-		default:
-			// Keep as string
-			valueExpr = pyVar
-		}
-		
-		if param.Required {
-			// Required fields: add with appropriate type conversion
-			if param.Type == "array" || param.Type == "object" {
-				scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
-				scriptBuilder.WriteString(fmt.Sprintf("    request_body_object[\"%s\"] = %s\n", param.Name, valueExpr))
-			} else if param.Type == "integer" || param.Type == "number" || param.Type == "boolean" {
-				scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
-				scriptBuilder.WriteString(fmt.Sprintf("    request_body_object[\"%s\"] = %s\n", param.Name, valueExpr))
-			} else {
-				scriptBuilder.WriteString(fmt.Sprintf("request_body_object[\"%s\"] = %s\n", param.Name, pyVar))
-			}
-		} else {
-			// Optional fields: only add if non-empty
-			scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
-			if param.Type == "array" || param.Type == "object" || param.Type == "integer" || param.Type == "number" || param.Type == "boolean" {
-				scriptBuilder.WriteString(fmt.Sprintf("    value = %s\n", valueExpr))
-				scriptBuilder.WriteString(fmt.Sprintf("    if value is not None:\n"))
-				scriptBuilder.WriteString(fmt.Sprintf("        request_body_object[\"%s\"] = value\n", param.Name))
-			} else {
-				scriptBuilder.WriteString(fmt.Sprintf("    request_body_object[\"%s\"] = %s\n", param.Name, pyVar))
-			}
-		}
-	}
-
-	// Determine if we need array wrapping
-	needsArrayWrap := bodySchema.Type == "array" || strings.Contains(operationId, "_available_")
-	if needsArrayWrap {
-		scriptBuilder.WriteString("request_body_string = json.dumps([request_body_object])\n")
-	} else {
-		scriptBuilder.WriteString("request_body_string = json.dumps(request_body_object)\n")
-	}
-
-	scriptAction := ActionData{
+	measure := ActionData{
 		UniqueName: "definition_activity_" + KSUIDGenerator(),
 		Name:       "Execute Python Script",
-		Title:      "Prepare Request Body",
+		Title:      "Measure Duration",
 		Type:       "python3.script",
 		BaseType:   "activity",
 		Properties: map[string]interface{}{
 			"action_timeout":      180,
 			"continue_on_failure": false,
-			"display_name":        "Prepare Request Body",
-			"script":              scriptBuilder.String(),
+			"display_name":        "Measure Duration",
+			"script":              "import sys\nimport time\n\n(started,) = sys.argv[1:2]\n\nduration = int(time.time() * 1000) - int(started or 0)\nprint(duration)\n",
+			"script_arguments":    []string{fmt.Sprintf("$activity.%s.output.script_queries.started$", start.UniqueName)},
 			"script_queries": []map[string]string{
 				{
-					"script_query":      "request_body_string",
-					"script_query_name": "request_body",
-					"script_query_type": "string",
+					"script_query":      "duration",
+					"script_query_name": "duration",
+					"script_query_type": "integer",
 				},
 			},
 			"skip_execution": false,
 		},
 		ObjectType: "definition_activity",
 	}
+	return start, measure, fmt.Sprintf("$activity.%s.output.script_queries.duration$", measure.UniqueName)
+}
 
-	bodyReference := fmt.Sprintf("$activity.%s.output.script_queries.request_body$", scriptAction.UniqueName)
-	return scriptAction, bodyReference
+type WorkflowConfig struct {
+	Endpoint       string           `json:"endpoint" yaml:"endpoint"`
+	Connector      string           `json:"connector,omitempty" yaml:"connector,omitempty"`
+	Methods        []string         `json:"methods,omitempty" yaml:"methods,omitempty"`
+	QueryParams    []string         `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	QueryMode      string           `json:"query_mode,omitempty" yaml:"query_mode,omitempty"`
+	BodyParams     []string         `json:"body_params,omitempty" yaml:"body_params,omitempty"`
+	RequestSchema  string           `json:"request_schema,omitempty" yaml:"request_schema,omitempty"`
+	ResponseSchema string           `json:"response_schema,omitempty" yaml:"response_schema,omitempty"`
+	Assert         assertionList    `json:"assert,omitempty" yaml:"assert,omitempty"`
+	WaitFor        *WaitForConfig   `json:"wait_for,omitempty" yaml:"wait_for,omitempty"`
+	Table          *TableConfig     `json:"table,omitempty" yaml:"table,omitempty"`
+	Options        *WorkflowOptions `json:"options,omitempty" yaml:"options,omitempty"`
 }
 
-func loadQueryParamConfig(path string) (map[string][]string, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	data = bytes.TrimSpace(data)
-	if len(data) == 0 {
-		return map[string][]string{}, nil
+// TableConfig adds a table output to a list endpoint: one row per returned item
+// and one column per field, given as a dotted path ("site.name") or as
+// "Title=path" to name the column.
+type TableConfig struct {
+	Name   string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Fields []string `json:"fields" yaml:"fields"`
+}
+
+// TableColumn is one column of a table output: the column key, its title in
+// run results and the path read from each item.
+type TableColumn struct {
+	Name  string
+	Title string
+	Path  string
+}
+
+// TableOutput is a validated table entry.
+type TableOutput struct {
+	Name    string
+	Columns []TableColumn
+}
+
+var tableColumnNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeTable validates a table entry and derives the column keys and titles.
+func normalizeTable(table TableConfig) (TableOutput, error) {
+	if len(table.Fields) == 0 {
+		return TableOutput{}, fmt.Errorf("table: fields are required")
 	}
-	if data[0] != '{' && data[0] != '[' {
-		data, err = yaml.YAMLToJSON(data)
-		if err != nil {
-			return nil, err
+	output := TableOutput{Name: strings.TrimSpace(table.Name)}
+	seen := make(map[string]bool)
+	for _, field := range table.Fields {
+		title, path, named := strings.Cut(field, "=")
+		if !named {
+			path = title
 		}
-	}
-	var parsed map[string][]string
-	if err := json.Unmarshal(data, &parsed); err != nil {
-		return nil, err
-	}
-	for key, values := range parsed {
-		set := make(map[string]struct{})
-		for _, v := range values {
-			value := strings.TrimSpace(v)
-			if value == "" {
-				continue
-			}
-			set[value] = struct{}{}
+		path = strings.Trim(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+		if path == "" {
+			return TableOutput{}, fmt.Errorf("table: field %q has no path", field)
 		}
-		if len(set) == 0 {
-			delete(parsed, key)
-			continue
+		title = strings.TrimSpace(title)
+		if !named {
+			title = HumanReadableName(strings.ReplaceAll(path, ".", "_"))
 		}
-		filtered := make([]string, 0, len(set))
-		for val := range set {
-			filtered = append(filtered, val)
+		name := strings.Trim(tableColumnNameRegex.ReplaceAllString(strings.ToLower(title), "_"), "_")
+		if name == "" || seen[name] {
+			return TableOutput{}, fmt.Errorf("table: field %q needs a distinct column title", field)
 		}
-		sort.Strings(filtered)
-		parsed[key] = filtered
+		seen[name] = true
+		output.Columns = append(output.Columns, TableColumn{Name: name, Title: title, Path: path})
 	}
-	return parsed, nil
+	return output, nil
 }
 
-func loadWorkflowConfig(path string) (*workflowConfigFile, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	data = bytes.TrimSpace(data)
-	if len(data) == 0 {
-		return nil, fmt.Errorf("config file %s is empty", path)
-	}
-	if data[0] != '{' && data[0] != '[' {
-		data, err = yaml.YAMLToJSON(data)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var cfg workflowConfigFile
-	if err := json.Unmarshal(data, &cfg); err == nil && len(cfg.Workflows) > 0 {
-		return &cfg, nil
-	}
-
-	var arr []WorkflowConfig
-	if err := json.Unmarshal(data, &arr); err != nil {
-		return nil, err
-	}
-	return &workflowConfigFile{Workflows: arr}, nil
+// WaitForConfig turns a GET entry into a "Wait for <Resource> <Field> = <Value>"
+// atomic polling the object until the field reaches the value or attempts run out.
+type WaitForConfig struct {
+	Field    string      `json:"field" yaml:"field"`
+	Value    interface{} `json:"value" yaml:"value"`
+	Interval int         `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Attempts int         `json:"attempts,omitempty" yaml:"attempts,omitempty"`
 }
 
-func getQueryParamAllowSet(operationId string) map[string]struct{} {
-	var allowed []string
-	if queryParamFilter != nil {
-		if vals, ok := queryParamFilter[operationId]; ok {
-			allowed = vals
-		}
+const (
+	defaultWaitInterval = 10
+	defaultWaitAttempts = 30
+)
+
+// normalizeWaitFor validates a wait_for entry and fills in the defaults.
+func normalizeWaitFor(wait WaitForConfig) (WaitForConfig, error) {
+	wait.Field = strings.TrimSpace(wait.Field)
+	if wait.Field == "" {
+		return WaitForConfig{}, fmt.Errorf("wait_for: field is required")
 	}
-	if len(allowed) == 0 && currentConnector.ActionType == "netbox.invoke_api" {
-		if vals, ok := defaultNetboxQueryFilters[operationId]; ok {
-			allowed = vals
-		}
+	if !strings.HasPrefix(wait.Field, "$") {
+		wait.Field = "$." + strings.TrimPrefix(wait.Field, ".")
 	}
-	if len(allowed) == 0 {
-		return nil
+	if wait.Value == nil {
+		return WaitForConfig{}, fmt.Errorf("wait_for: value is required")
 	}
-	set := make(map[string]struct{}, len(allowed))
-	for _, val := range allowed {
-		val = strings.TrimSpace(val)
-		if val == "" {
-			continue
-		}
-		set[val] = struct{}{}
+	if wait.Interval <= 0 {
+		wait.Interval = defaultWaitInterval
 	}
-	if _, exists := set["q"]; !exists {
-		set["q"] = struct{}{}
+	if wait.Attempts <= 0 {
+		wait.Attempts = defaultWaitAttempts
 	}
-	return set
+	return wait, nil
 }
 
-func setBodyParamFilter(operationId string, params []string) {
-	operationId = strings.TrimSpace(operationId)
-	if operationId == "" {
-		return
-	}
-	cleaned := make(map[string]struct{})
-	for _, param := range params {
-		param = strings.TrimSpace(param)
-		if param == "" {
-			continue
-		}
-		cleaned[param] = struct{}{}
-	}
-	if len(cleaned) == 0 {
-		delete(bodyParamFilter, operationId)
-		return
-	}
-	bodyParamFilter[operationId] = cleaned
-}
+// assertionList accepts either a single assertion string or a list of them.
+type assertionList []string
 
-func getBodyParamAllowSet(operationId string) map[string]struct{} {
-	if len(bodyParamFilter) == 0 {
+func (a *assertionList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = assertionList{single}
 		return nil
 	}
-	return bodyParamFilter[operationId]
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("assert must be a string or a list of strings: %w", err)
+	}
+	*a = list
+	return nil
 }
 
-func applyBodyParamFilter(operationId string, schema *Schema) {
-	if schema == nil {
-		return
+// variantList accepts either a single variant or a list of them.
+type variantList []string
+
+func (v *variantList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*v = variantList{single}
+		return nil
 	}
-	allowed := getBodyParamAllowSet(operationId)
-	if allowed == nil || len(allowed) == 0 {
-		return
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("variant must be a string or a list of strings: %w", err)
 	}
-	filterSchemaProperties(schema, allowed)
+	*v = list
+	return nil
 }
 
-func filterSchemaProperties(schema *Schema, allowed map[string]struct{}) {
-	if schema == nil {
-		return
-	}
-	if schema.Type == "object" {
-		if len(schema.Properties) > 0 {
-			for key, prop := range schema.Properties {
-				if _, ok := allowed[key]; !ok {
-					delete(schema.Properties, key)
-					continue
-				}
-				filterSchemaProperties(&prop, allowed)
-				schema.Properties[key] = prop
-			}
-		}
-		if len(schema.Required) > 0 {
-			var filtered []string
-			for _, req := range schema.Required {
-				if _, ok := allowed[req]; ok {
-					filtered = append(filtered, req)
-				}
-			}
-			schema.Required = filtered
-		}
-	}
-	if schema.Type == "array" && schema.Items != nil {
-		filterSchemaProperties(schema.Items, allowed)
-	}
+// ResponseAssertion is a parsed `<jsonpath> <op> <value>` check run against a
+// successful response.
+type ResponseAssertion struct {
+	Expression string
+	Path       string
+	Operator   string
+	Value      interface{}
 }
 
-func renderWorkflow(openAPISpec OpenAPISpec, operationId string) (string, error) {
-	operation, path, method, err := ExtractOperation(openAPISpec, operationId)
-	if err != nil {
-		return "", err
-	}
-	resolveOperationSchemas(openAPISpec, operation)
-	applyOperationSchemaOverrides(operationId, operation)
-	schema := &operation.RequestBody.Content.ApplicationJSON.Schema
-	if schema != nil && (schema.Type != "" || len(schema.Properties) > 0 || schema.Items != nil) {
-		applyBodyParamFilter(operationId, schema)
-	}
+var assertionRegex = regexp.MustCompile(`^\s*(\$\S*)\s*(==|!=|=~|>=|<=|>|<)\s*(.+?)\s*$`)
+
+// assertionOperators maps assertion comparison syntax to AO condition operators.
+var assertionOperators = map[string]string{
+	"==": "eq",
+	"!=": "ne",
+	"=~": "mregex",
+	">":  "gt",
+	">=": "gte",
+	"<":  "lt",
+	"<=": "lte",
+}
 
-	workflowData := GenerateWorkflowData(operation, path, method)
-	capitalizeAcronyms(&workflowData)
-	applyPlatformPrefix(&workflowData)
-	workflowData.SupportIdempotency = supportIdempotency
+// parseResponseAssertion parses e.g. `$.status.value == "active"`. The value is
+// read as JSON when possible and as a bare string otherwise.
+func parseResponseAssertion(expression string) (ResponseAssertion, error) {
+	match := assertionRegex.FindStringSubmatch(expression)
+	if match == nil {
+		return ResponseAssertion{}, fmt.Errorf("invalid assertion %q (expected `$.path <op> value` with ==, !=, =~, >, >=, < or <=)", expression)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(match[3]), &value); err != nil {
+		value = match[3]
+	}
+	return ResponseAssertion{
+		Expression: strings.TrimSpace(expression),
+		Path:       match[1],
+		Operator:   assertionOperators[match[2]],
+		Value:      value,
+	}, nil
+}
 
-	tmpl, err := template.New("workflow").Funcs(sprig.TxtFuncMap()).Funcs(template.FuncMap{
-		"add1": add1,
-		"sub":  sub,
-		"toJson": func(v interface{}) string {
-			a, _ := json.Marshal(v)
-			return string(a)
+// buildAssertionActions runs completionActions when every assertion passes and
+// otherwise fails the run with message, even though the API call succeeded.
+func buildAssertionActions(passed Condition, message string, completionActions []ActionData) []ActionData {
+	return []ActionData{
+		{
+			UniqueName: "definition_activity_" + KSUIDGenerator(),
+			Name:       "Condition Block",
+			Title:      "Response In Expected State?",
+			Type:       "logic.if_else",
+			BaseType:   "activity",
+			Properties: map[string]interface{}{
+				"conditions":          []interface{}{},
+				"continue_on_failure": false,
+				"display_name":        "Response In Expected State?",
+				"skip_execution":      false,
+			},
+			ObjectType: "definition_activity",
+			Blocks: []BlockData{
+				{
+					UniqueName: "definition_activity_" + KSUIDGenerator(),
+					Name:       "Condition Branch",
+					Title:      "Assertions Passed",
+					Type:       "logic.condition_block",
+					BaseType:   "activity",
+					Properties: BlockProperties{
+						Condition:         passed,
+						DisplayName:       "Assertions Passed",
+						ContinueOnFailure: false,
+						SkipExecution:     false,
+					},
+					ObjectType: "definition_activity",
+					Actions:    completionActions,
+				},
+			},
+		},
+		{
+			UniqueName: "definition_activity_" + KSUIDGenerator(),
+			Name:       "Set Variables",
+			Title:      "Set Error Message",
+			Type:       "core.set_multiple_variables",
+			BaseType:   "activity",
+			Properties: map[string]interface{}{
+				"continue_on_failure": false,
+				"display_name":        "Set Error Message",
+				"skip_execution":      false,
+				"variables_to_update": []VariableUpdate{
+					{
+						VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+						VariableValueNew: message,
+					},
+					{
+						VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+						VariableValueNew: "workflow-errored",
+					},
+				},
+			},
+			ObjectType: "definition_activity",
+		},
+		{
+			UniqueName: "definition_activity_" + KSUIDGenerator(),
+			Name:       "Completed",
+			Title:      "Completed - Failed",
+			Type:       "logic.completed",
+			BaseType:   "activity",
+			Properties: map[string]interface{}{
+				"completion_type":     "failed-completed",
+				"continue_on_failure": false,
+				"display_name":        "Completed - Failed",
+				"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+				"skip_execution":      false,
+			},
+			ObjectType: "definition_activity",
 		},
-		"jsonEscape":   jsonEscape,
-		"formatObject": formatObject,
-	}).Parse(workflowTemplate)
-	if err != nil {
-		return "", err
 	}
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, workflowData); err != nil {
-		return "", err
+// buildApprovalActions asks for approval of the request described by summary
+// and fails the run unless the decision is "approved"; on approval the
+// workflow carries on with the request.
+func buildApprovalActions(approval ApprovalConfig, summary string) []ActionData {
+	message := approval.Message
+	if message == "" {
+		message = "Approve " + summary + "?"
 	}
-
-	finalContent := ReplaceKSUIDs(buf.String())
-	var formattedContent bytes.Buffer
-	if err := json.Indent(&formattedContent, []byte(finalContent), "", "  "); err != nil {
-		_ = os.WriteFile("debug_workflow_raw.json", []byte(finalContent), 0644)
-		return "", err
+	properties := map[string]interface{}{
+		"continue_on_failure": false,
+		"display_name":        "Request Approval",
+		"message":             message,
+		"skip_execution":      false,
+		"timeout":             approval.Timeout,
 	}
-
-	return formattedContent.String(), nil
-}
-
-func generateFromConfig(openAPISpec OpenAPISpec, configPath, outputDir string) error {
-	cfg, err := loadWorkflowConfig(configPath)
-	if err != nil {
-		return err
+	if len(approval.Approvers) > 0 {
+		properties["approvers"] = approval.Approvers
 	}
-	if len(cfg.Workflows) == 0 {
-		return fmt.Errorf("config %s contains no workflows", configPath)
+	if approval.Room != "" {
+		properties["room_id"] = approval.Room
 	}
-	defaultQueryParams := ensureQueryParamList(cfg.Defaults.QueryParams)
-	workflows := cfg.Workflows
-	if outputDir == "" {
-		outputDir = "outputs"
+	request := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Request Approval",
+		Title:      "Request Approval",
+		Type:       approvalActionTypes[approval.Channel],
+		BaseType:   "activity",
+		Properties: properties,
+		ObjectType: "definition_activity",
 	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return err
+	decision := fmt.Sprintf("$activity.%s.output.decision$", request.UniqueName)
+	rejected := fmt.Sprintf("%s was not approved (decision: %s)", summary, decision)
+	return []ActionData{
+		request,
+		{
+			UniqueName: "definition_activity_" + KSUIDGenerator(),
+			Name:       "Condition Block",
+			Title:      "Was the Request Approved?",
+			Type:       "logic.if_else",
+			BaseType:   "activity",
+			Properties: map[string]interface{}{
+				"conditions":          []interface{}{},
+				"continue_on_failure": false,
+				"display_name":        "Was the Request Approved?",
+				"skip_execution":      false,
+			},
+			ObjectType: "definition_activity",
+			Blocks: []BlockData{
+				{
+					UniqueName: "definition_activity_" + KSUIDGenerator(),
+					Name:       "Condition Branch",
+					Title:      "Not Approved",
+					Type:       "logic.condition_block",
+					BaseType:   "activity",
+					Properties: BlockProperties{
+						Condition:         Condition{LeftOperand: decision, Operator: "ne", RightOperand: "approved"},
+						DisplayName:       "Not Approved",
+						ContinueOnFailure: false,
+						SkipExecution:     false,
+					},
+					ObjectType: "definition_activity",
+					Actions: []ActionData{
+						{
+							UniqueName: "definition_activity_" + KSUIDGenerator(),
+							Name:       "Set Variables",
+							Title:      "Set Error Message",
+							Type:       "core.set_multiple_variables",
+							BaseType:   "activity",
+							Properties: map[string]interface{}{
+								"continue_on_failure": false,
+								"display_name":        "Set Error Message",
+								"skip_execution":      false,
+								"variables_to_update": []VariableUpdate{
+									{
+										VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+										VariableValueNew: rejected,
+									},
+									{
+										VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+										VariableValueNew: "workflow-errored",
+									},
+								},
+							},
+							ObjectType: "definition_activity",
+						},
+						{
+							UniqueName: "definition_activity_" + KSUIDGenerator(),
+							Name:       "Completed",
+							Title:      "Completed - Not Approved",
+							Type:       "logic.completed",
+							BaseType:   "activity",
+							Properties: map[string]interface{}{
+								"completion_type":     "failed-completed",
+								"continue_on_failure": false,
+								"display_name":        "Completed - Not Approved",
+								"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+								"skip_execution":      false,
+							},
+							ObjectType: "definition_activity",
+						},
+					},
+				},
+			},
+		},
 	}
+}
 
-	for _, wf := range workflows {
-		if strings.TrimSpace(wf.Endpoint) == "" {
-			return fmt.Errorf("workflow entry missing endpoint")
-		}
-		normalizedPath := normalizeEndpointPath(wf.Endpoint)
-		if normalizedPath == "" {
-			return fmt.Errorf("invalid endpoint %q", wf.Endpoint)
-		}
-		pathKey, pathItem, err := findPathItem(openAPISpec, normalizedPath)
-		if err != nil {
-			return err
+// assertionQueryType picks the JSONPath query type matching the expected value.
+func assertionQueryType(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
 		}
+	}
+	return "string"
+}
 
-		ops := availableOperations(pathItem)
-		if len(ops) == 0 {
-			return fmt.Errorf("no operations found for endpoint %s", pathKey)
+// assertionQueryNames returns JSONPath query names for the assertions that do
+// not collide with the queries already extracted.
+func assertionQueryNames(existing []JsonpathQuery, count int) []string {
+	used := map[string]bool{}
+	for _, query := range existing {
+		used[strings.ToLower(query.JsonpathQueryName)] = true
+	}
+	names := make([]string, 0, count)
+	for i := 1; len(names) < count; i++ {
+		name := fmt.Sprintf("Assertion %d", i)
+		if !used[strings.ToLower(name)] {
+			names = append(names, name)
 		}
+	}
+	return names
+}
 
-		methods := make([]string, 0)
-		if len(wf.Methods) == 0 {
-			for method := range ops {
-				methods = append(methods, method)
-			}
-			sort.Strings(methods)
-		} else {
-			for _, method := range wf.Methods {
-				method = strings.ToUpper(strings.TrimSpace(method))
-				if method == "" {
-					continue
-				}
-				methods = append(methods, method)
-			}
+// buildAssertionCheck appends the assertion queries to the extraction step and
+// returns the condition that holds when every assertion passes, together with
+// the failure message listing them.
+func (s *renderSettings) buildAssertionCheck(queries []JsonpathQuery, extractUniqueName string) ([]JsonpathQuery, Condition, string) {
+	names := assertionQueryNames(queries, len(s.responseAssertions))
+	var passed Condition
+	var expressions []string
+	for i, assertion := range s.responseAssertions {
+		queries = append(queries, JsonpathQuery{
+			JsonpathQuery:     assertion.Path,
+			JsonpathQueryName: names[i],
+			JsonpathQueryType: assertionQueryType(assertion.Value),
+			ZdateTypeFormat:   s.dateFormat,
+		})
+		condition := Condition{
+			LeftOperand:  fmt.Sprintf("$activity.%s.output.jsonpath_queries.%s$", extractUniqueName, names[i]),
+			Operator:     assertion.Operator,
+			RightOperand: assertion.Value,
 		}
-		if len(methods) == 0 {
-			return fmt.Errorf("no valid methods specified for endpoint %s", wf.Endpoint)
+		if i == 0 {
+			passed = condition
+		} else {
+			passed = Condition{LeftOperand: passed, Operator: "and", RightOperand: condition}
 		}
+		// Drop the leading "$" so the message cannot be read as a variable reference.
+		expressions = append(expressions, strings.TrimPrefix(strings.TrimPrefix(assertion.Expression, "$"), "."))
+	}
+	message := "The request succeeded but the response did not match the expected state: " + strings.Join(expressions, "; ")
+	return queries, passed, message
+}
 
-		for _, method := range methods {
-			op := ops[method]
-			if op == nil {
-				return fmt.Errorf("method %s not available for endpoint %s", method, pathKey)
-			}
-			operationId := op.OperationId
-			if operationId == "" {
-				return fmt.Errorf("operation id missing for %s %s", method, pathKey)
-			}
-
-			if strings.EqualFold(method, "GET") {
-				combinedParams := make([]string, 0, len(defaultQueryParams)+len(wf.QueryParams))
-				combinedParams = append(combinedParams, defaultQueryParams...)
-				combinedParams = append(combinedParams, wf.QueryParams...)
-				combinedParams = ensureQueryParamList(combinedParams)
-				if len(combinedParams) > 0 {
-					if queryParamFilter == nil {
-						queryParamFilter = make(map[string][]string)
-					}
-					queryParamFilter[operationId] = combinedParams
-				}
-			}
-			var (
-				appliedBodyFilter   bool
-				previousBodyFilters map[string]struct{}
-			)
-			if strings.EqualFold(method, "POST") && len(wf.BodyParams) > 0 {
-				cleanBodyParams := ensureBodyParamList(wf.BodyParams)
-				if len(cleanBodyParams) > 0 {
-					if existing, ok := bodyParamFilter[operationId]; ok {
-						previousBodyFilters = existing
-					}
-					setBodyParamFilter(operationId, cleanBodyParams)
-					appliedBodyFilter = true
-				}
-			}
+type WorkflowOptions struct {
+	SupportIdempotency   *bool            `json:"support_idempotency,omitempty" yaml:"support_idempotency,omitempty"`
+	IdempotencyCondition string           `json:"idempotency_condition,omitempty" yaml:"idempotency_condition,omitempty"`
+	CategoryId           string           `json:"category_id,omitempty" yaml:"category_id,omitempty"`
+	CategoryName         string           `json:"category_name,omitempty" yaml:"category_name,omitempty"`
+	CategoryPath         []string         `json:"category_path,omitempty" yaml:"category_path,omitempty"`
+	Platform             string           `json:"platform,omitempty" yaml:"platform,omitempty"`
+	PrefixTargets        []string         `json:"prefix_targets,omitempty" yaml:"prefix_targets,omitempty"`
+	NameTemplate         string           `json:"name_template,omitempty" yaml:"name_template,omitempty"`
+	PostProcess          []string         `json:"post_process,omitempty" yaml:"post_process,omitempty"`
+	CommaSeparatedParams []string         `json:"comma_separated_params,omitempty" yaml:"comma_separated_params,omitempty"`
+	StatusCondition      *StatusCondition `json:"status_condition,omitempty" yaml:"status_condition,omitempty"`
+	Summary              *bool            `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Scaffold             *bool            `json:"scaffold,omitempty" yaml:"scaffold,omitempty"`
+	MaxBodyInputs        *int             `json:"max_body_inputs,omitempty" yaml:"max_body_inputs,omitempty"`
+	FlattenObjects       *int             `json:"flatten_objects,omitempty" yaml:"flatten_objects,omitempty"`
+	Variant              variantList      `json:"variant,omitempty" yaml:"variant,omitempty"`
+	FixedOutputs         []string         `json:"fixed_outputs,omitempty" yaml:"fixed_outputs,omitempty"`
+	NormalizeOutputs     *bool            `json:"normalize_outputs,omitempty" yaml:"normalize_outputs,omitempty"`
+	Approval             *ApprovalConfig  `json:"approval,omitempty" yaml:"approval,omitempty"`
+	LocalVariables       *bool            `json:"local_variables,omitempty" yaml:"local_variables,omitempty"`
+	IDsAsStrings         *bool            `json:"ids_as_strings,omitempty" yaml:"ids_as_strings,omitempty"`
+	IncludeRequired      *bool            `json:"always_include_required,omitempty" yaml:"always_include_required,omitempty"`
+	InputSections        []InputSection   `json:"input_sections,omitempty" yaml:"input_sections,omitempty"`
+	HideOptionalInputs   *bool            `json:"hide_optional_inputs,omitempty" yaml:"hide_optional_inputs,omitempty"`
+	ObjectURLInput       *bool            `json:"object_url_input,omitempty" yaml:"object_url_input,omitempty"`
+	ResponseExamples     *bool            `json:"response_examples,omitempty" yaml:"response_examples,omitempty"`
+	SensitiveFields      []string         `json:"sensitive_fields,omitempty" yaml:"sensitive_fields,omitempty"`
+	DateFormat           *DateConfig      `json:"date_format,omitempty" yaml:"date_format,omitempty"`
+	Timeout              *int             `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
 
-			savedSupport := supportIdempotency
-			savedCond := idempotencyCondition
-			savedCategoryId := categoryId
-			savedCategoryName := categoryName
-			savedPlatform := platformName
+// StatusCondition controls how the success and failed branches compare the API
+// status code. Empty values keep the defaults (eq/ne against the spec's success code).
+type StatusCondition struct {
+	SuccessOperator string      `json:"success_operator,omitempty" yaml:"success_operator,omitempty"`
+	SuccessValue    interface{} `json:"success_value,omitempty" yaml:"success_value,omitempty"`
+	FailureOperator string      `json:"failure_operator,omitempty" yaml:"failure_operator,omitempty"`
+	FailureValue    interface{} `json:"failure_value,omitempty" yaml:"failure_value,omitempty"`
+	BlockOperator   string      `json:"block_operator,omitempty" yaml:"block_operator,omitempty"`
+}
 
-			if wf.Options != nil {
-				if wf.Options.SupportIdempotency != nil {
-					supportIdempotency = *wf.Options.SupportIdempotency
-				}
-				if strings.TrimSpace(wf.Options.IdempotencyCondition) != "" {
-					idempotencyCondition = wf.Options.IdempotencyCondition
-				}
-				if strings.TrimSpace(wf.Options.CategoryId) != "" {
-					categoryId = wf.Options.CategoryId
-				}
-				if strings.TrimSpace(wf.Options.CategoryName) != "" {
-					categoryName = wf.Options.CategoryName
-				}
-				if strings.TrimSpace(wf.Options.Platform) != "" {
-					platformName = wf.Options.Platform
-				}
-			}
+// InputSection groups wizard inputs under Name; Fields are parameter or body
+// property names (site, asset_tag).
+type InputSection struct {
+	Name   string   `json:"name" yaml:"name"`
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
 
-			content, err := renderWorkflow(openAPISpec, operationId)
-			supportIdempotency = savedSupport
-			idempotencyCondition = savedCond
-			categoryId = savedCategoryId
-			categoryName = savedCategoryName
-			platformName = savedPlatform
+// DateConfig sets the date pattern (zdate_type_format) of the JSONPath queries
+// extracting response values: Default for every query and Fields per response
+// property, e.g. last_updated: yyyy-MM-dd'T'HH:mm:ss.SSSSSSXXX.
+type DateConfig struct {
+	Default string            `json:"default,omitempty" yaml:"default,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
 
-			if err != nil {
-				return err
-			}
-			if appliedBodyFilter {
-				if previousBodyFilters != nil {
-					bodyParamFilter[operationId] = previousBodyFilters
-				} else {
-					delete(bodyParamFilter, operationId)
-				}
-			}
+// ApprovalConfig pauses destructive requests (DELETE and bulk operations with an
+// array body) until a human approves them, through an AO approval task or a
+// Webex card posted to room.
+type ApprovalConfig struct {
+	Channel   string   `json:"channel,omitempty" yaml:"channel,omitempty"`
+	Approvers []string `json:"approvers,omitempty" yaml:"approvers,omitempty"`
+	Room      string   `json:"room,omitempty" yaml:"room,omitempty"`
+	Message   string   `json:"message,omitempty" yaml:"message,omitempty"`
+	Timeout   int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
 
-			filename := fmt.Sprintf("%s.json", operationId)
-			outputPath := filepath.Join(outputDir, filename)
-			if err := os.WriteFile(outputPath, []byte(content+"\n"), 0644); err != nil {
-				return err
-			}
-		}
-	}
+const (
+	approvalChannelTask  = "task"
+	approvalChannelWebex = "webex"
+	// defaultApprovalTimeout is how long, in minutes, a request waits for a decision.
+	defaultApprovalTimeout = 1440
+)
 
-	return nil
+// approvalActionTypes maps approval channels to the activity asking for the decision.
+var approvalActionTypes = map[string]string{
+	approvalChannelTask:  "core.approval_task",
+	approvalChannelWebex: "webex.approval_card",
 }
 
-func normalizeEndpointPath(endpoint string) string {
-	endpoint = strings.TrimSpace(endpoint)
-	if endpoint == "" {
-		return ""
+// normalizeApproval validates an approval option and fills in the defaults.
+func normalizeApproval(approval ApprovalConfig) (ApprovalConfig, error) {
+	approval.Channel = strings.ToLower(strings.TrimSpace(approval.Channel))
+	if approval.Channel == "" {
+		approval.Channel = approvalChannelTask
 	}
-	if !strings.HasPrefix(endpoint, "/") {
-		endpoint = "/" + endpoint
+	if _, ok := approvalActionTypes[approval.Channel]; !ok {
+		return ApprovalConfig{}, fmt.Errorf("approval: unsupported channel %q (expected task or webex)", approval.Channel)
 	}
-	if !strings.HasPrefix(endpoint, "/api/") {
-		if strings.HasPrefix(endpoint, "/api") {
-			endpoint = "/api" + strings.TrimPrefix(endpoint, "/api")
-		} else {
-			endpoint = "/api" + endpoint
-		}
+	if approval.Channel == approvalChannelWebex && strings.TrimSpace(approval.Room) == "" {
+		return ApprovalConfig{}, fmt.Errorf("approval: channel webex requires room")
 	}
-	if !strings.HasSuffix(endpoint, "/") {
-		endpoint = endpoint + "/"
+	if approval.Channel == approvalChannelTask && len(approval.Approvers) == 0 {
+		return ApprovalConfig{}, fmt.Errorf("approval: channel task requires approvers")
 	}
-	return endpoint
+	if approval.Timeout <= 0 {
+		approval.Timeout = defaultApprovalTimeout
+	}
+	return approval, nil
 }
 
-func findPathItem(openAPISpec OpenAPISpec, endpoint string) (string, PathItem, error) {
-	candidates := []string{endpoint}
-	if strings.HasSuffix(endpoint, "/") {
-		candidates = append(candidates, strings.TrimSuffix(endpoint, "/"))
-	} else {
-		candidates = append(candidates, endpoint+"/")
-	}
-	for _, candidate := range candidates {
-		if item, ok := openAPISpec.Paths[candidate]; ok {
-			return candidate, item, nil
-		}
-	}
-	return "", PathItem{}, fmt.Errorf("path %s not found in OpenAPI spec", endpoint)
+// needsApproval reports whether a request is destructive enough for the approval gate.
+func needsApproval(method string, bodySchema Schema) bool {
+	return strings.EqualFold(method, "DELETE") || bodySchema.Type == "array"
 }
 
-func availableOperations(item PathItem) map[string]*Operation {
-	result := make(map[string]*Operation)
-	if item.Get != nil {
-		result["GET"] = item.Get
+// complementOperators maps a success operator to the failed-branch operator used
+// when failure_operator is not configured.
+var complementOperators = map[string]string{
+	"eq":  "ne",
+	"ne":  "eq",
+	"gt":  "lte",
+	"gte": "lt",
+	"lt":  "gte",
+	"lte": "gt",
+}
+
+// mergeStatusCondition overlays override on base and derives the failed-branch
+// comparison when only the success side is configured.
+func mergeStatusCondition(base, override StatusCondition) (StatusCondition, error) {
+	merged := base
+	if strings.TrimSpace(override.SuccessOperator) != "" {
+		merged.SuccessOperator = strings.TrimSpace(override.SuccessOperator)
+		merged.FailureOperator = ""
+		merged.FailureValue = nil
 	}
-	if item.Post != nil {
-		result["POST"] = item.Post
+	if override.SuccessValue != nil {
+		merged.SuccessValue = override.SuccessValue
 	}
-	if item.Put != nil {
-		result["PUT"] = item.Put
+	if strings.TrimSpace(override.FailureOperator) != "" {
+		merged.FailureOperator = strings.TrimSpace(override.FailureOperator)
 	}
-	if item.Patch != nil {
-		result["PATCH"] = item.Patch
+	if override.FailureValue != nil {
+		merged.FailureValue = override.FailureValue
 	}
-	if item.Delete != nil {
-		result["DELETE"] = item.Delete
+	if strings.TrimSpace(override.BlockOperator) != "" {
+		merged.BlockOperator = strings.TrimSpace(override.BlockOperator)
 	}
-	return result
+	if merged.SuccessOperator != "" && merged.FailureOperator == "" {
+		complement, ok := complementOperators[merged.SuccessOperator]
+		if !ok {
+			return StatusCondition{}, fmt.Errorf("status_condition: failure_operator is required with success_operator %q", merged.SuccessOperator)
+		}
+		merged.FailureOperator = complement
+	}
+	return merged, nil
 }
 
-func ensureQueryParamList(list []string) []string {
-	set := make(map[string]struct{})
-	for _, v := range list {
-		v = strings.TrimSpace(v)
-		if v == "" {
-			continue
-		}
-		set[v] = struct{}{}
+// statusComparison returns the success and failed branch conditions for a status code operand.
+func (s *renderSettings) statusComparison(statusOperand string, successCode interface{}) (Condition, Condition) {
+	successOperator, failureOperator := "eq", "ne"
+	if s.statusConditionSettings.SuccessOperator != "" {
+		successOperator = s.statusConditionSettings.SuccessOperator
+		failureOperator = s.statusConditionSettings.FailureOperator
 	}
-	if _, ok := set["q"]; !ok {
-		set["q"] = struct{}{}
+	successValue, failureValue := successCode, successCode
+	if s.statusConditionSettings.SuccessValue != nil {
+		successValue = s.statusConditionSettings.SuccessValue
+		failureValue = s.statusConditionSettings.SuccessValue
 	}
-	result := make([]string, 0, len(set))
-	for v := range set {
-		result = append(result, v)
+	if s.statusConditionSettings.FailureValue != nil {
+		failureValue = s.statusConditionSettings.FailureValue
 	}
-	sort.Strings(result)
-	return result
+	return Condition{LeftOperand: statusOperand, Operator: successOperator, RightOperand: successValue},
+		Condition{LeftOperand: statusOperand, Operator: failureOperator, RightOperand: failureValue}
 }
 
-func ensureBodyParamList(list []string) []string {
-	set := make(map[string]struct{})
-	for _, v := range list {
-		v = strings.TrimSpace(v)
-		if v == "" {
-			continue
-		}
-		set[v] = struct{}{}
+// WorkflowDefaults holds settings applied to every workflow entry that does not
+// set them itself.
+type WorkflowDefaults struct {
+	QueryParams []string         `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	QueryMode   string           `json:"query_mode,omitempty" yaml:"query_mode,omitempty"`
+	BodyParams  []string         `json:"body_params,omitempty" yaml:"body_params,omitempty"`
+	Options     *WorkflowOptions `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// mergeWorkflowDefaults returns base with every setting overlay specifies replaced.
+func mergeWorkflowDefaults(base, overlay WorkflowDefaults) WorkflowDefaults {
+	if overlay.QueryParams != nil {
+		base.QueryParams = overlay.QueryParams
 	}
-	if len(set) == 0 {
-		return nil
+	if strings.TrimSpace(overlay.QueryMode) != "" {
+		base.QueryMode = overlay.QueryMode
 	}
-	result := make([]string, 0, len(set))
-	for v := range set {
-		result = append(result, v)
+	if overlay.BodyParams != nil {
+		base.BodyParams = overlay.BodyParams
 	}
-	sort.Strings(result)
-	return result
+	base.Options = mergeWorkflowOptions(base.Options, overlay.Options)
+	return base
 }
 
-func ensureNetboxPagination(schema *Schema) {
-	if schema.Properties == nil {
-		schema.Properties = make(map[string]Schema)
+// mergeWorkflowOptions returns base with every option overlay sets replaced.
+func mergeWorkflowOptions(base, overlay *WorkflowOptions) *WorkflowOptions {
+	if overlay == nil {
+		return base
 	}
-	if schema.Type == "" {
-		schema.Type = "object"
+	if base == nil {
+		return overlay
 	}
-	for key, def := range netboxPaginationSchema {
-		if _, exists := schema.Properties[key]; !exists {
-			schema.Properties[key] = def
-		}
+	merged := *base
+	if overlay.SupportIdempotency != nil {
+		merged.SupportIdempotency = overlay.SupportIdempotency
+	}
+	if strings.TrimSpace(overlay.IdempotencyCondition) != "" {
+		merged.IdempotencyCondition = overlay.IdempotencyCondition
+	}
+	if strings.TrimSpace(overlay.CategoryId) != "" {
+		merged.CategoryId = overlay.CategoryId
+	}
+	if strings.TrimSpace(overlay.CategoryName) != "" {
+		merged.CategoryName = overlay.CategoryName
 	}
+	if overlay.CategoryPath != nil {
+		merged.CategoryPath = overlay.CategoryPath
+	}
+	if strings.TrimSpace(overlay.Platform) != "" {
+		merged.Platform = overlay.Platform
+	}
+	if overlay.PrefixTargets != nil {
+		merged.PrefixTargets = overlay.PrefixTargets
+	}
+	if strings.TrimSpace(overlay.NameTemplate) != "" {
+		merged.NameTemplate = overlay.NameTemplate
+	}
+	if overlay.PostProcess != nil {
+		merged.PostProcess = overlay.PostProcess
+	}
+	if overlay.CommaSeparatedParams != nil {
+		merged.CommaSeparatedParams = overlay.CommaSeparatedParams
+	}
+	if overlay.StatusCondition != nil {
+		merged.StatusCondition = overlay.StatusCondition
+	}
+	if overlay.Summary != nil {
+		merged.Summary = overlay.Summary
+	}
+	if overlay.Scaffold != nil {
+		merged.Scaffold = overlay.Scaffold
+	}
+	if overlay.MaxBodyInputs != nil {
+		merged.MaxBodyInputs = overlay.MaxBodyInputs
+	}
+	if overlay.FlattenObjects != nil {
+		merged.FlattenObjects = overlay.FlattenObjects
+	}
+	if overlay.Variant != nil {
+		merged.Variant = overlay.Variant
+	}
+	if overlay.FixedOutputs != nil {
+		merged.FixedOutputs = overlay.FixedOutputs
+	}
+	if overlay.NormalizeOutputs != nil {
+		merged.NormalizeOutputs = overlay.NormalizeOutputs
+	}
+	if overlay.Approval != nil {
+		merged.Approval = overlay.Approval
+	}
+	if overlay.LocalVariables != nil {
+		merged.LocalVariables = overlay.LocalVariables
+	}
+	if overlay.IDsAsStrings != nil {
+		merged.IDsAsStrings = overlay.IDsAsStrings
+	}
+	if overlay.IncludeRequired != nil {
+		merged.IncludeRequired = overlay.IncludeRequired
+	}
+	if overlay.InputSections != nil {
+		merged.InputSections = overlay.InputSections
+	}
+	if overlay.HideOptionalInputs != nil {
+		merged.HideOptionalInputs = overlay.HideOptionalInputs
+	}
+	if overlay.ObjectURLInput != nil {
+		merged.ObjectURLInput = overlay.ObjectURLInput
+	}
+	if overlay.ResponseExamples != nil {
+		merged.ResponseExamples = overlay.ResponseExamples
+	}
+	if overlay.SensitiveFields != nil {
+		merged.SensitiveFields = overlay.SensitiveFields
+	}
+	if overlay.DateFormat != nil {
+		merged.DateFormat = overlay.DateFormat
+	}
+	if overlay.Timeout != nil {
+		merged.Timeout = overlay.Timeout
+	}
+	return &merged
 }
 
-func appendRequestBodyObjectVariables(variables []VariableData, schema Schema) []VariableData {
-	if len(schema.Properties) == 0 {
-		return variables
+// applyWorkflowDefaults fills the settings a workflow entry leaves unset from the
+// config defaults; query_params are combined separately for GET entries.
+func applyWorkflowDefaults(defaults WorkflowDefaults, wf WorkflowConfig) WorkflowConfig {
+	if strings.TrimSpace(wf.QueryMode) == "" {
+		wf.QueryMode = defaults.QueryMode
 	}
-	propKeys := sortedSchemaKeys(schema.Properties)
-	for _, propName := range propKeys {
-		propSchema := schema.Properties[propName]
-		isRequired := contains(schema.Required, propName)
-		variable := buildRequestBodyVariable(propName, propSchema, isRequired)
-		variables = append(variables, variable)
+	if wf.BodyParams == nil {
+		wf.BodyParams = defaults.BodyParams
 	}
-	return variables
+	wf.Options = mergeWorkflowOptions(defaults.Options, wf.Options)
+	return wf
 }
 
-func buildRequestBodyVariable(propName string, propSchema Schema, isRequired bool) VariableData {
-	name := "Input - " + HumanReadableName(propName)
-	descriptionPostFix := ""
-	if propSchema.Type == "string" && len(propSchema.Enum) > 0 {
-		enumValues := make([]string, len(propSchema.Enum))
-		for i, val := range propSchema.Enum {
-			enumValues[i] = fmt.Sprint(val)
-		}
-		descriptionPostFix = " Valid options are: " + strings.Join(enumValues, ", ") + "."
-	}
+type workflowConfigFile struct {
+	Include    []string               `json:"include,omitempty" yaml:"include,omitempty"`
+	Variables  map[string]interface{} `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Defaults   WorkflowDefaults       `json:"defaults" yaml:"defaults"`
+	Workflows  []WorkflowConfig       `json:"workflows" yaml:"workflows"`
+	Composites []composite.Recipe     `json:"composites,omitempty" yaml:"composites,omitempty"`
+	Recipes    []string               `json:"recipes,omitempty" yaml:"recipes,omitempty"`
+	Bulk       []bulk.Config          `json:"bulk,omitempty" yaml:"bulk,omitempty"`
+	Triggers   []trigger.Config       `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	Specs      []SpecConfig           `json:"specs,omitempty" yaml:"specs,omitempty"`
+}
 
-	schemaId := "datatype.string"
-	varType := "datatype.string"
-	varValue := interface{}("")
-	variableStringFormat := "text"
+// SpecConfig pairs a connector with the OpenAPI spec its operations render
+// from (the top-level specs of a config). A relative openapi path is resolved
+// against the config file declaring it.
+type SpecConfig = connectordef.Spec
 
-	originalType := propSchema.Type
-	switch propSchema.Type {
-	case "integer", "number":
-		schemaId = "datatype.integer"
-		varType = "datatype.integer"
-		varValue = 0
-	case "boolean":
-		schemaId = "datatype.boolean"
-		varType = "datatype.boolean"
-		varValue = false
-	case "array":
-		schemaId = "datatype.string"
-		varType = "datatype.string"
-		varValue = []interface{}{}
-		variableStringFormat = "json"
-	case "object":
-		schemaId = "datatype.string"
-		varType = "datatype.string"
-		varValue = map[string]interface{}{}
-		variableStringFormat = "json"
+var nonIdentifierRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func pythonIdentifier(name string, fallback string, idx int) string {
+	if name == "" {
+		name = fallback
 	}
-	if stringifyBodyInputs && (originalType == "integer" || originalType == "number" || originalType == "boolean") {
-		schemaId = "datatype.string"
-		varType = "datatype.string"
-		varValue = ""
-		variableStringFormat = "text"
+	clean := nonIdentifierRegex.ReplaceAllString(name, "_")
+	if clean == "" {
+		clean = fmt.Sprintf("%s_%d", fallback, idx)
 	}
-
-	return VariableData{
-		SchemaID: schemaId,
-		Properties: VariableProperties{
-			Scope:                "input",
-			Name:                 name,
-			Type:                 varType,
-			Description:          propSchema.Description + descriptionPostFix,
-			IsRequired:           isRequired,
-			Value:                varValue,
-			VariableStringFormat: variableStringFormat,
-			DisplayOnWizard:      true,
-			IsInvisible:          false,
-		},
-		UniqueName: "variable_workflow_$" + propName + "KSUID",
-		ObjectType: "variable_workflow",
+	if clean[0] >= '0' && clean[0] <= '9' {
+		clean = "_" + clean
 	}
+	return clean
 }
 
-func schemaHasRequestBody(schema Schema) bool {
-	if schema.Type == "object" && len(schema.Properties) > 0 {
-		return true
+// appendSentence appends a sentence to a description, adding the missing full stop.
+func appendSentence(description, sentence string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return sentence
 	}
-	if schema.Type == "array" && schema.Items != nil && schema.Items.Type == "object" && len(schema.Items.Properties) > 0 {
-		return true
+	if !strings.HasSuffix(description, ".") {
+		description += "."
 	}
-	return false
+	return description + " " + sentence
 }
 
-// GenerateWorkflowData generates the workflow data structure from the OpenAPI spec operation.
-func GenerateWorkflowData(operation *Operation, path string, method string) WorkflowData {
-	var variables []VariableData
-	var actions []ActionData
-	var outputVariables []VariableData
-	operationDisplayName := buildOperationDisplayName(operation.OperationId, path, method)
-	if strings.TrimSpace(operationDisplayName) == "" {
-		operationDisplayName = HumanReadableName(operation.OperationId)
-	}
+// Lengths the example snippets are shortened to.
+const (
+	responseExampleMaxLength = 1000
+	outputExampleMaxLength   = 200
+)
 
-	var queryParams []Parameter
-	allowedQuerySet := getQueryParamAllowSet(operation.OperationId)
+// schemaExampleMaxDepth bounds how deep schemaExample assembles nested examples.
+const schemaExampleMaxDepth = 8
 
-	// Add parameters as input variables (path and query)
-	for _, param := range operation.Parameters {
-		// Only handle path and query parameters
-		if param.In != "path" && param.In != "query" {
-			continue
+// responseExample returns the example of a response: the media type's example,
+// its first named example, or one assembled from the schema's examples.
+func responseExample(response Response) (interface{}, bool) {
+	media := response.Content.ApplicationJSON
+	if media.Example != nil {
+		return media.Example, true
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := media.Examples[name].Value; value != nil {
+			return value, true
 		}
+	}
+	if example := schemaExample(media.Schema, 0); example != nil {
+		return example, true
+	}
+	return nil, false
+}
 
-		if param.In == "query" && allowedQuerySet != nil {
-			if _, ok := allowedQuerySet[param.Name]; !ok {
-				continue
-			}
+// schemaExample returns the schema's example, or one assembled from the
+// examples of its items or properties; nil when the schema has none.
+func schemaExample(schema Schema, depth int) interface{} {
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if depth >= schemaExampleMaxDepth {
+		return nil
+	}
+	if schema.Items != nil {
+		if item := schemaExample(*schema.Items, depth+1); item != nil {
+			return []interface{}{item}
 		}
-
-		isRequired := param.Required
-		displayOnWizard := true
-		var displayNamePrefix string
-		if param.In == "path" {
-			// Path parameters are always required
-			isRequired = true
-			// Historically hidden in wizard; keep behavior
-			displayOnWizard = false
-			displayNamePrefix = "Input - "
-		} else {
-			// Query parameters visible and prefixed with "Query - "
-			displayNamePrefix = "Query - "
+		return nil
+	}
+	object := make(map[string]interface{})
+	for name, property := range schema.Properties {
+		if example := schemaExample(property, depth+1); example != nil {
+			object[name] = example
 		}
+	}
+	if len(object) == 0 {
+		return nil
+	}
+	return object
+}
 
-		name := displayNamePrefix + HumanReadableName(param.Name)
+// exampleSnippet renders an example as compact JSON, cut to maxLength runes.
+func exampleSnippet(example interface{}, maxLength int) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(example); err != nil {
+		return ""
+	}
+	snippet := []rune(strings.TrimSpace(buffer.String()))
+	if len(snippet) > maxLength {
+		return string(snippet[:maxLength]) + " ..."
+	}
+	return string(snippet)
+}
 
-		variable := VariableData{
-			SchemaID: "datatype." + param.Schema.Type,
-			Properties: VariableProperties{
-				Value:           "",
-				Scope:           "input",
-				Name:            name,
-				Type:            "datatype." + param.Schema.Type,
-				Description:     param.Description,
-				IsRequired:      isRequired,
-				DisplayOnWizard: displayOnWizard,
-				IsInvisible:     false,
-			},
-			UniqueName: "variable_workflow_$" + param.Name + "KSUID",
-			ObjectType: "variable_workflow",
-		}
+// outputExampleSentence describes the example value of the response property
+// name, or returns "" when the example has none.
+func outputExampleSentence(example interface{}, name string) string {
+	object, ok := example.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, ok := object[name]
+	if !ok || value == nil {
+		return ""
+	}
+	return "Example: " + exampleSnippet(value, outputExampleMaxLength)
+}
 
-		switch param.Schema.Type {
-		case "string":
-			variable.Properties.Value = ""
-			variable.Properties.VariableStringFormat = "text"
-		case "integer", "number":
-			variable.SchemaID = "datatype.integer"
-			variable.Properties.Type = "datatype.integer"
-			variable.Properties.Value = 0
-			variable.Properties.VariableStringFormat = ""
-		case "boolean":
-			variable.SchemaID = "datatype.boolean"
-			variable.Properties.Type = "datatype.boolean"
-			variable.Properties.Value = false
-			variable.Properties.VariableStringFormat = ""
-		case "array":
-			variable.SchemaID = "variable_type_array_01JhSTW61I3ZU2IfL7dwQox83eFDzE1qUiA"
-			variable.Properties.Type = "datatype.array"
-			variable.Properties.Value = []interface{}{}
-			variable.Properties.VariableStringFormat = "json"
-		case "object":
-			variable.Properties.Type = "datatype.string"
-			variable.Properties.Value = map[string]interface{}{}
-			variable.Properties.VariableStringFormat = "json"
-		}
-		// For path/query params, default to string presentation to simplify UI and avoid numeric quoting issues
-		if param.In == "path" || param.In == "query" {
-			variable.SchemaID = "datatype.string"
-			variable.Properties.Type = "datatype.string"
-			variable.Properties.Value = ""
-			variable.Properties.VariableStringFormat = "text"
-		}
+// connectorUsesQueryPrep reports whether query strings for this method are built
+// by a Python prep step (which can serialize arrays) instead of inline placeholders.
+func (s *renderSettings) connectorUsesQueryPrep(method string) bool {
+	return s.currentConnector.QueryPrep && strings.EqualFold(method, "GET")
+}
 
-		variables = append(variables, variable)
+// isDeepObjectParam reports whether a query parameter uses style deepObject
+// (filter[key]=value), which always needs the prep step to flatten it.
+func isDeepObjectParam(param Parameter) bool {
+	return param.In == "query" && param.Style == "deepObject"
+}
 
-		if param.In == "query" {
-			queryParams = append(queryParams, param)
+func hasDeepObjectParam(params []Parameter) bool {
+	for _, param := range params {
+		if isDeepObjectParam(param) {
+			return true
 		}
 	}
+	return false
+}
 
-	IdempotencyInputName := "Input - Ignore If Exists"
-	if method == "DELETE" || method == "GET" || method == "PUT" {
-		IdempotencyInputName = "Input - Ignore If Does Not Exists"
+// isCommaSeparatedParam reports whether an array query filter is exposed as a
+// comma-separated string input instead of an array input.
+func (s *renderSettings) isCommaSeparatedParam(param Parameter) bool {
+	if param.In != "query" || param.Schema.Type != "array" {
+		return false
 	}
+	return contains(s.commaSeparatedQueryParams, param.Name) || contains(s.currentConnector.ListQueryParams, param.Name)
+}
 
-	IdempotancyInput := VariableData{
-		SchemaID: "datatype.boolean",
-		Properties: VariableProperties{
-			Value:           false,
-			Scope:           "input",
-			Name:            IdempotencyInputName,
-			Type:            "datatype.boolean",
-			Description:     "When enabled, the workflow will be marked as successful if the same operation has been completed before.",
-			IsRequired:      false,
-			DisplayOnWizard: false,
-			IsInvisible:     false,
-		},
-		UniqueName: "variable_workflow_$ignoreIfExistKSUID",
-		ObjectType: "variable_workflow",
-	}
-	if supportIdempotency {
-		variables = append(variables, IdempotancyInput)
+// queryParamExplode returns whether an array query parameter is serialized as
+// repeated key=value pairs. OpenAPI defaults explode to true for style form only.
+func queryParamExplode(param Parameter) bool {
+	if param.Explode != nil {
+		return *param.Explode
 	}
+	return param.Style == "" || param.Style == "form"
+}
 
-	bodySchema := operation.RequestBody.Content.ApplicationJSON.Schema
-	switch bodySchema.Type {
-	case "object":
-		variables = appendRequestBodyObjectVariables(variables, bodySchema)
-	case "array":
-		if bodySchema.Items != nil && bodySchema.Items.Type == "object" {
-			variables = appendRequestBodyObjectVariables(variables, *bodySchema.Items)
-		}
+// queryParamDelimiter returns the separator for non-exploded array values.
+func queryParamDelimiter(param Parameter) string {
+	switch param.Style {
+	case "spaceDelimited":
+		return " "
+	case "pipeDelimited":
+		return "|"
+	default:
+		return ","
 	}
+}
 
-	// Determine the success response code from the available responses
-	var successCode interface{}
-	var responseSchema Schema
-	for code, response := range operation.Responses {
-		if successCode == nil {
-			if numeric, err := strconv.Atoi(code); err == nil {
-				successCode = numeric
-			} else {
-				successCode = code
-			}
-			responseSchema = response.Content.ApplicationJSON.Schema
-		}
+// arrayQueryParamHint documents how an array query input is serialized.
+func arrayQueryParamHint(param Parameter) string {
+	if queryParamExplode(param) {
+		return fmt.Sprintf("Accepts a list; each value is sent as a separate %s= query parameter.", param.Name)
 	}
+	return fmt.Sprintf("Accepts a list; values are joined with %q into a single %s= query parameter.", queryParamDelimiter(param), param.Name)
+}
 
-	isNetboxList := currentConnector.ActionType == "netbox.invoke_api" && strings.EqualFold(method, "GET") && (len(queryParams) > 0 || allowedQuerySet != nil)
-	if isNetboxList {
-		ensureNetboxPagination(&responseSchema)
-	}
+// pythonToBool defines to_bool, the prep scripts' coercion of boolean inputs
+// entered as text; values it does not recognize fail the step instead of
+// silently becoming false.
+const pythonToBool = `def to_bool(name, value):
+    value = str(value).strip().lower()
+    if value in ('true', '1', 'yes', 'y', 'on'):
+        return True
+    if value in ('false', '0', 'no', 'n', 'off'):
+        return False
+    raise ValueError(name + ' must be true or false, got ' + repr(value))
 
-	// Add output variables based on the response schema
-	// Skip individual property extraction for POST/PATCH/PUT - just return the full result
-	isCreateOrUpdate := strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT")
-	if responseSchema.Type == "object" && !isCreateOrUpdate {
-		for propName, propSchema := range responseSchema.Properties {
-			name := "Output - " + HumanReadableName(propName)
-			var dataType string
-			if propSchema.Type == "boolean" {
-				dataType = "datatype.boolean"
-			} else {
-				dataType = "datatype.string"
-			}
-			outputVariable := VariableData{
-				SchemaID: dataType,
-				Properties: VariableProperties{
-					Scope:                "output",
-					Name:                 name,
-					Type:                 dataType,
-					Description:          propSchema.Description,
-					IsRequired:           false,
-					VariableStringFormat: "text", // Adjust as needed based on type
-					DisplayOnWizard:      false,
-					IsInvisible:          false,
-				},
-				UniqueName: "variable_workflow_$" + propName + "output" + "KSUID",
-				ObjectType: "variable_workflow",
-			}
-			if propSchema.Type == "boolean" {
-				outputVariable.Properties.Value = false
-			} else {
-				outputVariable.Properties.Value = ""
-			}
-			variables = append(variables, outputVariable)
-			outputVariables = append(outputVariables, outputVariable)
-		}
-	}
+`
 
-	hasRequestBody := schemaHasRequestBody(bodySchema)
+// booleanTextHint documents the values to_bool accepts on text inputs.
+const booleanTextHint = "Accepts true/false, yes/no or 1/0 (any case)."
 
-	needsQueryPrep := currentConnector.ActionType == "netbox.invoke_api" && strings.EqualFold(method, "GET") && len(queryParams) > 0
-	var queryReference string
-	if needsQueryPrep {
-		scriptAction, reference := buildQueryPrepAction(queryParams)
-		actions = append(actions, scriptAction)
-		queryReference = reference
+func (s *renderSettings) buildQueryPrepAction(queryParams []Parameter) (ActionData, string) {
+	if len(queryParams) == 0 {
+		return ActionData{}, ""
 	}
-
-	// Add body preparation for POST/PATCH/PUT in NetBox
-	needsBodyPrep := currentConnector.ActionType == "netbox.invoke_api" && hasRequestBody && (strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT"))
-	var bodyReference string
-	if needsBodyPrep {
-		bodyPrepAction, bodyRef := buildRequestBodyPrepAction(bodySchema, operation.OperationId)
-		actions = append(actions, bodyPrepAction)
-		bodyReference = bodyRef
-	}
-
-	endpoint := GenerateAPIEndpoint(path, operation.Parameters, !needsQueryPrep)
-	if needsQueryPrep && queryReference != "" {
-		if strings.Contains(endpoint, "?") {
-			endpoint = endpoint + "&" + queryReference
+	pyVars := make([]string, len(queryParams))
+	seen := make(map[string]int)
+	for i, param := range queryParams {
+		base := pythonIdentifier(param.Name, "param", i)
+		if count, ok := seen[base]; ok {
+			seen[base] = count + 1
+			base = fmt.Sprintf("%s_%d", base, count+1)
 		} else {
-			endpoint = endpoint + "?" + queryReference
+			seen[base] = 0
 		}
+		pyVars[i] = base
 	}
+	hasArrayParams, hasBooleans := false, false
+	hasDeepObjects := hasDeepObjectParam(queryParams)
+	for _, param := range queryParams {
+		if param.Schema.Type == "array" && !isDeepObjectParam(param) {
+			hasArrayParams = true
+		}
+		if param.Schema.Type == "boolean" {
+			hasBooleans = true
+		}
+	}
+	var builder strings.Builder
+	if hasArrayParams || hasDeepObjects {
+		builder.WriteString("import json\n")
+	}
+	builder.WriteString("import sys\nimport urllib.parse\n\n")
+	if hasDeepObjects {
+		builder.WriteString("def flatten_deep(prefix, value):\n")
+		builder.WriteString("    if isinstance(value, dict):\n")
+		builder.WriteString("        pairs = []\n")
+		builder.WriteString("        for key, item in value.items():\n")
+		builder.WriteString("            pairs += flatten_deep(prefix + '[' + urllib.parse.quote_plus(str(key)) + ']', item)\n")
+		builder.WriteString("        return pairs\n")
+		builder.WriteString("    if isinstance(value, list):\n")
+		builder.WriteString("        return [(prefix, item) for item in value]\n")
+		builder.WriteString("    return [(prefix, value)]\n\n")
+	}
+	if hasArrayParams {
+		builder.WriteString("def parse_list(value, comma_separated=False):\n")
+		builder.WriteString("    value = value.strip()\n")
+		builder.WriteString("    if value.startswith('['):\n")
+		builder.WriteString("        return [v for v in json.loads(value) if str(v) != '']\n")
+		builder.WriteString("    if comma_separated:\n")
+		builder.WriteString("        return [v.strip() for v in value.split(',') if v.strip() != '']\n")
+		builder.WriteString("    return [value]\n\n")
+	}
+	if hasBooleans {
+		builder.WriteString(pythonToBool)
+	}
+	if len(pyVars) == 1 {
+		builder.WriteString(fmt.Sprintf("(%s,) = sys.argv[1:2]\n\n", pyVars[0]))
+	} else {
+		builder.WriteString(fmt.Sprintf("(%s) = sys.argv[1:%d]\n\n", strings.Join(pyVars, ", "), len(pyVars)+1))
+	}
+	builder.WriteString("queryStr = \"\"\nfirst = True\n\n")
+	for i, param := range queryParams {
+		pyVar := pyVars[i]
+		if isDeepObjectParam(param) {
+			writeDeepObjectQueryParam(&builder, param, pyVar)
+			continue
+		}
+		if param.Schema.Type == "array" {
+			s.writeArrayQueryParam(&builder, param, pyVar)
+			continue
+		}
+		value := fmt.Sprintf("urllib.parse.quote_plus(str(%s))", pyVar)
+		if param.Schema.Type == "boolean" {
+			value = fmt.Sprintf("('true' if to_bool('%s', %s) else 'false')", param.Name, pyVar)
+		}
+		builder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
+		builder.WriteString("    if not first:\n        queryStr += '&'\n")
+		builder.WriteString(fmt.Sprintf("    queryStr += \"%s=\" + %s\n", param.Name, value))
+		builder.WriteString("    first = False\n\n")
+	}
+	builder.WriteString("print(queryStr)\n")
 
-	apiRequestAction := buildAPIRequestAction(operation, endpoint, method, hasRequestBody, operationDisplayName, bodyReference)
-
-	actions = append(actions, apiRequestAction)
+	scriptArguments := make([]string, len(queryParams))
+	for i, param := range queryParams {
+		scriptArguments[i] = s.inputVariableRef(placeholderKindParam, param.Name)
+	}
 
-	// Find the first API request action unique name
-	var apiRequestActionUniqueName string
-	for _, action := range actions {
-		if action.Type == currentConnector.ActionType {
-			apiRequestActionUniqueName = "activity." + action.UniqueName
-			break
-		}
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Prepare Query Params",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Prepare Query Params",
+			"script":              builder.String(),
+			"script_arguments":    scriptArguments,
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "queryStr",
+					"script_query_name": "queryStr",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
 	}
 
-	ConditionalSuccessBlockJsonPathQueryUniqueName := "definition_activity_" + KSUIDGenerator()
+	queryReference := fmt.Sprintf("$activity.%s.output.script_queries.queryStr$", scriptAction.UniqueName)
+	return scriptAction, queryReference
+}
 
-	responseBodyExpr := fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", currentConnector.ResponseBodyField)
-	responseBodyPath := fmt.Sprintf("$%s.output.%s$", apiRequestActionUniqueName, currentConnector.ResponseBodyField)
+const (
+	queryModeFields = "fields"
+	queryModeJSON   = "json"
+)
 
-	// Define the Set Variables action for the fixed output.
-	var setOutputVariablesToUpdateForSuccessBlock []VariableUpdate
-	if currentConnector.StatusMessageField != "" {
-		setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, VariableUpdate{
-			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$",
-			VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", currentConnector.StatusMessageField),
-		})
+// queryFiltersVariableName is the placeholder name of the single JSON filters
+// input generated in query_mode json.
+const queryFiltersVariableName = "query_filters"
+
+// buildQueryFiltersVariable returns the "Query - Filters (JSON)" input that replaces
+// individual query inputs in query_mode json.
+func (s *renderSettings) buildQueryFiltersVariable(queryParams []Parameter) VariableData {
+	names := make([]string, len(queryParams))
+	for i, param := range queryParams {
+		names[i] = param.Name
 	}
-	setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock,
-		VariableUpdate{
-			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$",
-			VariableValueNew: "$activity.definition_activity_$ApiRequestKSUID.output.status_code$",
-		},
-		VariableUpdate{
-			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
-			VariableValueNew: responseBodyExpr,
-		},
-		VariableUpdate{
-			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
-			VariableValueNew: "completed-successfully",
+	sort.Strings(names)
+	description := `JSON object of query filters, e.g. {"status": "active", "site_id": [1, 2]}. ` +
+		"List values are sent as repeated parameters. Supported keys: " + strings.Join(names, ", ") + "."
+	return VariableData{
+		SchemaID: "datatype.string",
+		Properties: VariableProperties{
+			Value:                map[string]interface{}{},
+			Scope:                "input",
+			Name:                 "Query - Filters (JSON)",
+			Type:                 "datatype.string",
+			Description:          description,
+			IsRequired:           false,
+			VariableStringFormat: "json",
+			DisplayOnWizard:      true,
+			IsInvisible:          false,
 		},
-	)
-	for _, outputVar := range outputVariables {
-		setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, VariableUpdate{
-			VariableToUpdate: fmt.Sprintf("$workflow.%s.output.%s$", "definition_workflow_$WorkflowKSUID", outputVar.UniqueName),
-			VariableValueNew: fmt.Sprintf("$activity.%s.output.jsonpath_queries.%s$", ConditionalSuccessBlockJsonPathQueryUniqueName, strings.TrimPrefix(outputVar.Properties.Name, "Output - ")),
-		})
+		UniqueName: s.variableUniqueName(placeholderKindParam, queryFiltersVariableName),
+		ObjectType: "variable_workflow",
 	}
-	conditionalBlock := ActionData{
+}
+
+// localValue is a prepared value (query string, request body) that
+// -localVariables copies into a local workflow variable.
+type localValue struct {
+	Name        string
+	Placeholder string
+	Reference   *string
+}
+
+// materializeLocalVariables declares a local variable for each prepared value
+// and returns them with the step copying the prep outputs into them. Each
+// reference is replaced by its local variable, so the request sends what the
+// run view shows. Values that were not prepared are skipped.
+func (s *renderSettings) materializeLocalVariables(values []localValue) ([]VariableData, ActionData) {
+	var variables []VariableData
+	var updates []VariableUpdate
+	for _, value := range values {
+		if *value.Reference == "" {
+			continue
+		}
+		variable := VariableData{
+			SchemaID: "datatype.string",
+			Properties: VariableProperties{
+				Value:                "",
+				Scope:                "local",
+				Name:                 value.Name,
+				Type:                 "datatype.string",
+				VariableStringFormat: "text",
+			},
+			UniqueName: s.variableUniqueName(placeholderKindLocal, value.Placeholder),
+			ObjectType: "variable_workflow",
+		}
+		local := fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.local.%s$", variable.UniqueName)
+		updates = append(updates, VariableUpdate{VariableToUpdate: local, VariableValueNew: *value.Reference})
+		*value.Reference = local
+		variables = append(variables, variable)
+	}
+	return variables, ActionData{
 		UniqueName: "definition_activity_" + KSUIDGenerator(),
-		Name:       "Condition Block",
-		Title:      "Was the Request Successful?",
-		Type:       "logic.if_else",
+		Name:       "Set Variables",
+		Title:      "Set Local Variables",
+		Type:       "core.set_multiple_variables",
 		BaseType:   "activity",
-		Properties: LogicIfElseProperties{
-			Conditions:        []interface{}{},
-			ContinueOnFailure: false,
-			Description:       "Was The Request Successful?",
-			DisplayName:       "Was the Request Successful?",
-			SkipExecution:     false,
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        "Set Local Variables",
+			"skip_execution":      false,
+			"variables_to_update": updates,
 		},
 		ObjectType: "definition_activity",
-		Blocks: []BlockData{
-			{
-				UniqueName: "definition_activity_" + KSUIDGenerator(),
-				Name:       "Condition Branch",
-				Title:      fmt.Sprintf("%v/Success", successCode),
-				Type:       "logic.condition_block",
-				BaseType:   "activity",
-				Properties: BlockProperties{
-					Condition: Condition{
-						LeftOperand:  fmt.Sprintf("$%s.output.status_code$", apiRequestActionUniqueName),
-						Operator:     "eq",
-						RightOperand: successCode,
-					},
-					DisplayName:       fmt.Sprintf("%v/Success", successCode),
-					ContinueOnFailure: false,
-					SkipExecution:     false,
-				},
-				ObjectType: "definition_activity",
-				Actions: []ActionData{
-					{
-						UniqueName: ConditionalSuccessBlockJsonPathQueryUniqueName,
-						Name:       "JSONPath Query",
-						Title:      "Extract API Results",
-						Type:       "corejava.jsonpathquery",
-						BaseType:   "activity",
-						Properties: JsonpathQueryProperties{
-							ActionTimeout:     180,
-							DisplayName:       "Extract API Results",
-							ContinueOnFailure: true,
-							InputJSON:         responseBodyPath,
-							JsonpathQueries:   GenerateJsonpathQueries(responseSchema, method),
-							SkipExecution:     false,
-						},
-						ObjectType: "definition_activity",
-					},
-					{
-						UniqueName: "definition_activity_" + KSUIDGenerator(),
-						Name:       "Set Variables",
-						Title:      "Set Output Variables",
-						Type:       "core.set_multiple_variables",
-						BaseType:   "activity",
-						Properties: map[string]interface{}{
-							"continue_on_failure": false,
-							"display_name":        "Set Output Variables",
-							"skip_execution":      false,
-							"variables_to_update": setOutputVariablesToUpdateForSuccessBlock,
-						},
-						ObjectType: "definition_activity",
-					},
-					{
-						UniqueName: "definition_activity_" + KSUIDGenerator(),
-						Name:       "Completed",
-						Title:      "Completed - Success",
-						Type:       "logic.completed",
-						BaseType:   "activity",
-						Properties: map[string]interface{}{
-							"continue_on_failure": false,
-							"display_name":        "Completed - Success",
-							"skip_execution":      false,
-							"variables_to_update": setOutputVariablesToUpdateForSuccessBlock,
-							"completion_type":     "succeeded",
-							"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
-						},
-						ObjectType: "definition_activity",
-					},
-				},
-			},
-			{
-				UniqueName: "definition_activity_" + KSUIDGenerator(),
-				Name:       "Condition Branch",
-				Title:      "Failed",
-				Type:       "logic.condition_block",
-				BaseType:   "activity",
-				Properties: BlockProperties{
-					Condition: Condition{
-						LeftOperand:  fmt.Sprintf("$%s.output.status_code$", apiRequestActionUniqueName),
-						Operator:     "ne",
-						RightOperand: successCode,
-					},
-					DisplayName:       "Failed",
-					ContinueOnFailure: false,
-					SkipExecution:     false,
-				},
-				ObjectType: "definition_activity",
-				Actions: []ActionData{
-					{
-						UniqueName: "definition_activity_" + KSUIDGenerator(),
-						Name:       "Set Variables",
-						Title:      "Set Output Variables",
-						Type:       "core.set_multiple_variables",
-						BaseType:   "activity",
-						Properties: func() map[string]interface{} {
-							failedUpdates := []VariableUpdate{
-								{
-									VariableToUpdate: fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$"),
-									VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.status_code$"),
-								},
-							}
-							if currentConnector.StatusMessageField != "" {
-								failedUpdates = append(failedUpdates, VariableUpdate{
-									VariableToUpdate: fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$"),
-									VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", currentConnector.StatusMessageField),
-								})
-							}
-							failedUpdates = append(failedUpdates,
-								VariableUpdate{
-									VariableToUpdate: fmt.Sprintf("$workflow.%s.output.%s$", "definition_workflow_$WorkflowKSUID", "variable_workflow_$ErrorMessageKSUID"),
-									VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.error.message$"),
-								},
-								VariableUpdate{
-									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
-									VariableValueNew: responseBodyExpr,
-								},
-								VariableUpdate{
-									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
-									VariableValueNew: "workflow-errored",
-								},
-							)
-							return map[string]interface{}{
-								"continue_on_failure": false,
-								"display_name":        "Set Output Variables",
-								"skip_execution":      false,
-								"variables_to_update": failedUpdates,
-							}
-						}(),
-						ObjectType: "definition_activity",
-					},
-				},
-			},
-		},
 	}
+}
 
-	for i := range conditionalBlock.Blocks {
-		block := &conditionalBlock.Blocks[i]
-		if block.Name == "Condition Branch" && block.Title == "Failed" {
-			// if idempotency is needed, we need to add the behavior to allow skipping if failures.
-			if supportIdempotency {
+// buildJSONQueryPrepAction builds the prep step turning the JSON filters input
+// into a query string.
+func (s *renderSettings) buildJSONQueryPrepAction() (ActionData, string) {
+	var builder strings.Builder
+	builder.WriteString("import json\nimport sys\nimport urllib.parse\n\n")
+	builder.WriteString("(filters,) = sys.argv[1:2]\n\n")
+	builder.WriteString("queryStr = \"\"\nfirst = True\n\n")
+	builder.WriteString("if filters.strip() not in ('', '{}'):\n")
+	builder.WriteString("    for key, value in json.loads(filters).items():\n")
+	builder.WriteString("        values = value if isinstance(value, list) else [value]\n")
+	builder.WriteString("        for item in values:\n")
+	builder.WriteString("            if isinstance(item, bool):\n")
+	builder.WriteString("                item = str(item).lower()\n")
+	builder.WriteString("            if not first:\n                queryStr += '&'\n")
+	builder.WriteString("            queryStr += urllib.parse.quote_plus(str(key)) + \"=\" + urllib.parse.quote_plus(str(item))\n")
+	builder.WriteString("            first = False\n\n")
+	builder.WriteString("print(queryStr)\n")
 
-				idempotencyIndicator := Condition{
-					LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
-					Operator:     "mregex",
-					RightOperand: idempotencyCondition,
-				}
-				blockTitle := "Ignore If Exists"
-				if method == "DELETE" || method == "GET" || method == "PUT" {
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Prepare Query Params",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Prepare Query Params",
+			"script":              builder.String(),
+			"script_arguments":    []string{s.inputVariableRef(placeholderKindParam, queryFiltersVariableName)},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "queryStr",
+					"script_query_name": "queryStr",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	queryReference := fmt.Sprintf("$activity.%s.output.script_queries.queryStr$", scriptAction.UniqueName)
+	return scriptAction, queryReference
+}
+
+// writeDeepObjectQueryParam emits prep code flattening a JSON object input into
+// bracketed name[key]=value query parameters.
+func writeDeepObjectQueryParam(builder *strings.Builder, param Parameter, pyVar string) {
+	builder.WriteString(fmt.Sprintf("if %s != '' and %s != '{}':\n", pyVar, pyVar))
+	builder.WriteString(fmt.Sprintf("    for key, value in flatten_deep(%q, json.loads(%s)):\n", param.Name, pyVar))
+	builder.WriteString("        if not first:\n            queryStr += '&'\n")
+	builder.WriteString("        queryStr += key + \"=\" + urllib.parse.quote_plus(str(value))\n")
+	builder.WriteString("        first = False\n\n")
+}
+
+// writeArrayQueryParam emits prep code serializing an array input either as
+// repeated key=value pairs (explode) or as one delimited value.
+func (s *renderSettings) writeArrayQueryParam(builder *strings.Builder, param Parameter, pyVar string) {
+	parseArgs := pyVar
+	if s.isCommaSeparatedParam(param) {
+		parseArgs = pyVar + ", True"
+	}
+	builder.WriteString(fmt.Sprintf("if %s != '' and %s != '[]':\n", pyVar, pyVar))
+	if queryParamExplode(param) {
+		builder.WriteString(fmt.Sprintf("    for value in parse_list(%s):\n", parseArgs))
+		builder.WriteString("        if not first:\n            queryStr += '&'\n")
+		builder.WriteString(fmt.Sprintf("        queryStr += \"%s=\" + urllib.parse.quote_plus(str(value))\n", param.Name))
+		builder.WriteString("        first = False\n\n")
+		return
+	}
+	builder.WriteString("    if not first:\n        queryStr += '&'\n")
+	builder.WriteString(fmt.Sprintf("    queryStr += \"%s=\" + urllib.parse.quote_plus(%q.join(str(v) for v in parse_list(%s)))\n", param.Name, queryParamDelimiter(param), parseArgs))
+	builder.WriteString("    first = False\n\n")
+}
+
+// summaryVerb returns the past-tense verb describing what an operation did.
+func summaryVerb(method string) string {
+	switch strings.ToUpper(method) {
+	case "POST":
+		return "Created"
+	case "PUT", "PATCH":
+		return "Updated"
+	case "DELETE":
+		return "Deleted"
+	default:
+		return "Retrieved"
+	}
+}
+
+// redactedValue replaces sensitive values in echoed response bodies.
+const redactedValue = "********"
+
+// isSensitive reports whether a parameter or body property holds a secret: the
+// spec marks it writeOnly or format password, or -sensitiveFields names it.
+func (s *renderSettings) isSensitive(name string, schema Schema) bool {
+	if schema.WriteOnly || schema.Format == "password" {
+		return true
+	}
+	for _, field := range s.sensitiveFields {
+		if strings.EqualFold(strings.TrimSpace(field), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitiveKeys returns the lower-cased field names masked in the response
+// body: the operation's sensitive parameters and body properties plus every
+// -sensitiveFields entry.
+func (s *renderSettings) sensitiveKeys(params []Parameter, bodySchema Schema) []string {
+	keys := make(map[string]bool)
+	for _, field := range s.sensitiveFields {
+		if field = strings.TrimSpace(field); field != "" {
+			keys[strings.ToLower(field)] = true
+		}
+	}
+	for _, param := range params {
+		if s.isSensitive(param.Name, param.Schema) {
+			keys[strings.ToLower(param.Name)] = true
+		}
+	}
+	if object := bodyObjectSchema(bodySchema); object != nil {
+		for name, property := range object.Properties {
+			if s.isSensitive(name, property) {
+				keys[strings.ToLower(name)] = true
+			}
+			if fields, ok := s.flattenedObjectFields(property); ok {
+				for _, field := range fields {
+					if s.isSensitive(field, property.Properties[field]) {
+						keys[strings.ToLower(field)] = true
+					}
+				}
+			}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// hasSensitiveURLParam reports whether a sensitive path or query parameter ends
+// up in the request URL.
+func (s *renderSettings) hasSensitiveURLParam(params []Parameter) bool {
+	for _, param := range params {
+		if (param.In == "path" || param.In == "query") && s.isSensitive(param.Name, param.Schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRedactionAction returns a step masking the values of keys, at any depth,
+// in the response body, plus the reference to the masked body. Bodies that are
+// not JSON pass through unchanged.
+func buildRedactionAction(keys []string, responseBodyRef string) (ActionData, string) {
+	keysJSON, _ := json.Marshal(keys)
+	var builder strings.Builder
+	builder.WriteString("import json\nimport sys\n\n")
+	builder.WriteString("(raw,) = sys.argv[1:2]\n\n")
+	builder.WriteString(fmt.Sprintf("keys = set(json.loads(%q))\n\n", keysJSON))
+	builder.WriteString("def redact(value):\n")
+	builder.WriteString("    if isinstance(value, dict):\n")
+	builder.WriteString(fmt.Sprintf("        return {k: (%q if str(k).lower() in keys and v not in (None, '') else redact(v)) for k, v in value.items()}\n", redactedValue))
+	builder.WriteString("    if isinstance(value, list):\n        return [redact(v) for v in value]\n")
+	builder.WriteString("    return value\n\n")
+	builder.WriteString("try:\n    body = json.dumps(redact(json.loads(raw)))\nexcept ValueError:\n    body = raw\n\n")
+	builder.WriteString("print(body)\n")
+
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Redact Response",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Redact Response",
+			"script":              builder.String(),
+			"script_arguments":    []string{responseBodyRef},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "body",
+					"script_query_name": "body",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	return scriptAction, fmt.Sprintf("$activity.%s.output.script_queries.body$", scriptAction.UniqueName)
+}
+
+// buildSummaryAction returns a prep step composing a short human-readable result
+// ("Created device leaf-01 (id 123) in site DC1") from the response body, plus
+// the reference to its output.
+func buildSummaryAction(path, method, responseBodyRef string) (ActionData, string) {
+	resourceSegment, _ := extractResourceFromPath(path)
+	plural := strings.ToLower(HumanReadableName(resourceSegment))
+	if plural == "" {
+		plural = "records"
+	}
+	noun := singularize(plural)
+
+	var builder strings.Builder
+	builder.WriteString("import json\nimport sys\n\n")
+	builder.WriteString("(raw,) = sys.argv[1:2]\n\n")
+	builder.WriteString(fmt.Sprintf("verb = %q\nnoun = %q\nplural = %q\n\n", summaryVerb(method), noun, plural))
+	builder.WriteString("try:\n    data = json.loads(raw) if raw.strip() else None\nexcept ValueError:\n    data = None\n\n")
+	builder.WriteString("def label(obj):\n")
+	builder.WriteString("    if not isinstance(obj, dict):\n        return ''\n")
+	builder.WriteString("    for key in ('name', 'display', 'prefix', 'address', 'slug', 'serial'):\n")
+	builder.WriteString("        if obj.get(key) not in (None, ''):\n            return str(obj[key])\n")
+	builder.WriteString("    return ''\n\n")
+	builder.WriteString("def describe(obj):\n")
+	builder.WriteString("    text = noun\n")
+	builder.WriteString("    if label(obj):\n        text += ' ' + label(obj)\n")
+	builder.WriteString("    if isinstance(obj, dict) and obj.get('id') is not None:\n        text += ' (id %s)' % obj['id']\n")
+	builder.WriteString("    if isinstance(obj, dict) and label(obj.get('site')):\n        text += ' in site ' + label(obj['site'])\n")
+	builder.WriteString("    return text\n\n")
+	builder.WriteString("def counted(count):\n")
+	builder.WriteString("    return '%s %s' % (count, noun if count == 1 else plural)\n\n")
+	builder.WriteString("if isinstance(data, dict) and isinstance(data.get('results'), list):\n")
+	builder.WriteString("    summary = 'Found ' + counted(data.get('count', len(data['results'])))\n")
+	builder.WriteString("elif isinstance(data, list):\n")
+	builder.WriteString("    summary = ('Found' if verb == 'Retrieved' else verb) + ' ' + counted(len(data))\n")
+	builder.WriteString("elif isinstance(data, dict):\n")
+	builder.WriteString("    summary = verb + ' ' + describe(data)\n")
+	builder.WriteString("else:\n")
+	builder.WriteString("    summary = verb + ' ' + noun\n\n")
+	builder.WriteString("print(summary)\n")
+
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Summarize Result",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Summarize Result",
+			"script":              builder.String(),
+			"script_arguments":    []string{responseBodyRef},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "summary",
+					"script_query_name": "summary",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	return scriptAction, fmt.Sprintf("$activity.%s.output.script_queries.summary$", scriptAction.UniqueName)
+}
+
+// buildTableOutput returns the table type and output variable for a list
+// endpoint, the prep step turning the listed items into rows and the update
+// storing them in the output. The items are the response array or, when set,
+// the listProperty array of the response object.
+func (s *renderSettings) buildTableOutput(path string, table TableOutput, responseBodyRef, listProperty string) (TableTypeData, VariableData, ActionData, VariableUpdate) {
+	name := table.Name
+	if name == "" {
+		resourceSegment, _ := extractResourceFromPath(path)
+		name = strings.TrimSpace(HumanReadableName(resourceSegment) + " Table")
+	}
+	tableType := TableTypeData{
+		UniqueName: tableVariableTypeID,
+		Name:       name,
+		Title:      name,
+		Type:       "datatype.table",
+		BaseType:   "datatype",
+		ObjectType: "variable_type_table",
+	}
+	columns := make([][2]string, len(table.Columns))
+	for i, column := range table.Columns {
+		tableType.Columns = append(tableType.Columns, TableColumnData{Name: column.Name, Title: column.Title, Type: "datatype.string"})
+		columns[i] = [2]string{column.Name, column.Path}
+	}
+	columnsJSON, _ := json.Marshal(columns)
+
+	variable := VariableData{
+		SchemaID: tableVariableTypeID,
+		Properties: VariableProperties{
+			Value:                []interface{}{},
+			Scope:                "output",
+			Name:                 "Output - " + name,
+			Type:                 "datatype.table",
+			Description:          "One row per returned item.",
+			VariableStringFormat: "json",
+		},
+		UniqueName: s.variableUniqueName(placeholderKindOutput, "table"),
+		ObjectType: "variable_workflow",
+	}
+
+	var builder strings.Builder
+	builder.WriteString("import json\nimport sys\n\n")
+	builder.WriteString("(raw,) = sys.argv[1:2]\n\n")
+	builder.WriteString(fmt.Sprintf("columns = json.loads(%q)\n\n", columnsJSON))
+	builder.WriteString("try:\n    data = json.loads(raw) if raw.strip() else None\nexcept ValueError:\n    data = None\n\n")
+	if listProperty != "" {
+		builder.WriteString(fmt.Sprintf("if isinstance(data, dict) and isinstance(data.get('%[1]s'), list):\n    items = data['%[1]s']\nelif isinstance(data, list):\n    items = data\n", listProperty))
+	} else {
+		builder.WriteString("if isinstance(data, list):\n    items = data\n")
+	}
+	builder.WriteString("else:\n    items = []\n\n")
+	builder.WriteString("def pick(value, path):\n")
+	builder.WriteString("    for key in path.split('.'):\n")
+	builder.WriteString("        if isinstance(value, dict):\n            value = value.get(key)\n")
+	builder.WriteString("        elif isinstance(value, list) and key.isdigit() and int(key) < len(value):\n            value = value[int(key)]\n")
+	builder.WriteString("        else:\n            return ''\n")
+	builder.WriteString("    if value is None:\n        return ''\n")
+	builder.WriteString("    if isinstance(value, (dict, list, bool)):\n        return json.dumps(value)\n")
+	builder.WriteString("    return str(value)\n\n")
+	builder.WriteString("rows = json.dumps([{name: pick(item, path) for name, path in columns} for item in items])\n")
+	builder.WriteString("print(rows)\n")
+
+	title := "Build " + name
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      title,
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        title,
+			"script":              builder.String(),
+			"script_arguments":    []string{responseBodyRef},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "rows",
+					"script_query_name": "rows",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	update := VariableUpdate{
+		VariableToUpdate: fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.%s$", variable.UniqueName),
+		VariableValueNew: fmt.Sprintf("$activity.%s.output.script_queries.rows$", scriptAction.UniqueName),
+	}
+	return tableType, variable, scriptAction, update
+}
+
+// connectorUsesBodyPrep reports whether the request body is assembled by a
+// "Prepare Request Body" python step instead of a static template.
+func (s *renderSettings) connectorUsesBodyPrep(method string) bool {
+	return s.currentConnector.bodyPrep() && (strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT"))
+}
+
+// broadBodyPropertyThreshold is the property count from which an all-optional
+// request body triggers a body_params suggestion.
+const broadBodyPropertyThreshold = 50
+
+// additionalFieldsVariableName is the placeholder name of the catch-all body
+// input holding the properties cut by max_body_inputs.
+const additionalFieldsVariableName = "additional_fields"
+
+// isMapSchema reports whether schema is a map-like object: additionalProperties
+// without declared properties, such as NetBox's custom_fields or a tag map.
+// Such objects take one JSON-object input instead of an input per property.
+func isMapSchema(schema Schema) bool {
+	if schema.Type != "" && schema.Type != "object" || len(schema.Properties) > 0 {
+		return false
+	}
+	switch additional := schema.AdditionalProperties.(type) {
+	case nil:
+		return false
+	case bool:
+		return additional
+	}
+	return true
+}
+
+// mapValueHint describes the JSON-object input of a map-like schema, naming
+// the value type when additionalProperties declares one.
+func mapValueHint(schema Schema) string {
+	if additional, ok := schema.AdditionalProperties.(map[string]interface{}); ok {
+		if valueType, ok := additional["type"].(string); ok && valueType != "" {
+			return fmt.Sprintf("JSON object of key/value pairs with %s values.", valueType)
+		}
+	}
+	return "JSON object of key/value pairs, e.g. {\"key\": \"value\"}."
+}
+
+// mapBodyVariableName is the placeholder name of the single input of a
+// map-like request body.
+const mapBodyVariableName = "request_body"
+
+// mapBodyVariable is the JSON-object input of a map-like request body.
+func (s *renderSettings) mapBodyVariable(schema Schema) VariableData {
+	return VariableData{
+		SchemaID: "datatype.string",
+		Properties: VariableProperties{
+			Scope:                "input",
+			Name:                 "Input - Request Body (JSON)",
+			Type:                 "datatype.string",
+			Description:          appendSentence(schema.Description, mapValueHint(schema)),
+			Value:                map[string]interface{}{},
+			VariableStringFormat: "json",
+			IsRequired:           true,
+			DisplayOnWizard:      true,
+		},
+		UniqueName: s.variableUniqueName(placeholderKindBody, mapBodyVariableName),
+		ObjectType: "variable_workflow",
+	}
+}
+
+// bodyObjectSchema returns the object schema whose properties become body inputs.
+func bodyObjectSchema(schema Schema) *Schema {
+	switch schema.Type {
+	case "object":
+		return &schema
+	case "array":
+		if schema.Items != nil && schema.Items.Type == "object" {
+			return schema.Items
+		}
+	}
+	return nil
+}
+
+// limitBodyInputs keeps at most maxBodyInputs body properties (required ones
+// first, then alphabetically) and returns the names of the others, which the
+// prep step accepts through the "Additional Fields (JSON)" input. Static body
+// templates have no catch-all, so only prep-step connectors are limited. The
+// minimal variant keeps the required properties only, on every connector.
+func (s *renderSettings) limitBodyInputs(schema Schema, method string) (Schema, []string) {
+	object := bodyObjectSchema(schema)
+	limit := s.maxBodyInputs
+	if s.minimalVariant(method) {
+		limit = 0
+	} else if s.maxBodyInputs <= 0 || !s.connectorUsesBodyPrep(method) {
+		return schema, nil
+	}
+	if object == nil || len(object.Properties) <= limit {
+		return schema, nil
+	}
+	keys := sortedSchemaKeys(object.Properties)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return contains(object.Required, keys[i]) && !contains(object.Required, keys[j])
+	})
+	limited := *object
+	limited.Properties = make(map[string]Schema, s.maxBodyInputs)
+	var overflow []string
+	for _, key := range keys {
+		if len(limited.Properties) < limit || contains(object.Required, key) {
+			limited.Properties[key] = object.Properties[key]
+			continue
+		}
+		overflow = append(overflow, key)
+	}
+	sort.Strings(overflow)
+	if schema.Type == "array" {
+		schema.Items = &limited
+		return schema, overflow
+	}
+	return limited, overflow
+}
+
+// flattenedObjectFields returns the fields -flattenObjects gives their own
+// inputs: those of an object property with 1 to flattenObjects writable
+// fields, all scalars. Read-only fields are skipped; ok is false for objects
+// that keep their JSON input.
+func (s *renderSettings) flattenedObjectFields(schema Schema) (fields []string, ok bool) {
+	if s.flattenObjects <= 0 || schema.Type != "object" || schema.Truncated != "" || isMapSchema(schema) {
+		return nil, false
+	}
+	for _, name := range sortedSchemaKeys(schema.Properties) {
+		field := schema.Properties[name]
+		if field.ReadOnly {
+			continue
+		}
+		switch field.Type {
+		case "string", "integer", "number", "boolean":
+		default:
+			return nil, false
+		}
+		fields = append(fields, name)
+	}
+	return fields, len(fields) > 0 && len(fields) <= s.flattenObjects
+}
+
+// flattenedFieldName is the placeholder name of a flattened object's field.
+func flattenedFieldName(propName, field string) string {
+	return propName + "." + field
+}
+
+// hasFlattenedObjects reports whether a request body has a property flattened
+// by -flattenObjects.
+func (s *renderSettings) hasFlattenedObjects(schema Schema) bool {
+	object := bodyObjectSchema(schema)
+	if object == nil {
+		return false
+	}
+	for _, propSchema := range object.Properties {
+		if _, ok := s.flattenedObjectFields(propSchema); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newBodyParam describes a body property for the prep step, with the fields
+// of a flattened object.
+func (s *renderSettings) newBodyParam(propName string, propSchema Schema, isRequired bool) BodyParam {
+	param := BodyParam{
+		Name:     propName,
+		Required: isRequired,
+		Type:     propSchema.Type,
+		Map:      isMapSchema(propSchema),
+	}
+	fields, ok := s.flattenedObjectFields(propSchema)
+	if !ok {
+		return param
+	}
+	for _, field := range fields {
+		fieldSchema := propSchema.Properties[field]
+		param.Fields = append(param.Fields, BodyParam{
+			Name:     field,
+			Required: contains(propSchema.Required, field),
+			Type:     fieldSchema.Type,
+		})
+	}
+	return param
+}
+
+// additionalFieldsVariable is the catch-all JSON input for body properties
+// without their own input.
+func (s *renderSettings) additionalFieldsVariable(fields []string) VariableData {
+	return VariableData{
+		SchemaID: "datatype.string",
+		Properties: VariableProperties{
+			Scope:                "input",
+			Name:                 "Input - Additional Fields (JSON)",
+			Type:                 "datatype.string",
+			Description:          "JSON object with further request body fields, merged under the explicit inputs. Accepted fields: " + strings.Join(fields, ", ") + ".",
+			Value:                map[string]interface{}{},
+			VariableStringFormat: "json",
+			DisplayOnWizard:      true,
+		},
+		UniqueName: s.variableUniqueName(placeholderKindBody, additionalFieldsVariableName),
+		ObjectType: "variable_workflow",
+	}
+}
+
+// rangeCheck is a numeric input whose schema declares a minimum and/or maximum.
+type rangeCheck struct {
+	Label     string
+	Reference string
+	Minimum   *float64
+	Maximum   *float64
+}
+
+// newRangeCheck returns the check for an input, or false when its schema is not
+// a bounded number.
+func newRangeCheck(label, reference string, schema Schema) (rangeCheck, bool) {
+	if schema.Type != "integer" && schema.Type != "number" {
+		return rangeCheck{}, false
+	}
+	if schema.Minimum == nil && schema.Maximum == nil {
+		return rangeCheck{}, false
+	}
+	return rangeCheck{Label: label, Reference: reference, Minimum: schema.Minimum, Maximum: schema.Maximum}, true
+}
+
+// expected describes the allowed range ("between 1 and 128", "at least 1").
+func (c rangeCheck) expected() string {
+	switch {
+	case c.Minimum != nil && c.Maximum != nil:
+		return fmt.Sprintf("between %s and %s", formatBound(c.Minimum), formatBound(c.Maximum))
+	case c.Minimum != nil:
+		return "at least " + formatBound(c.Minimum)
+	default:
+		return "at most " + formatBound(c.Maximum)
+	}
+}
+
+// formatBound renders a bound as a Python literal, None when unset.
+func formatBound(bound *float64) string {
+	if bound == nil {
+		return "None"
+	}
+	return strconv.FormatFloat(*bound, 'f', -1, 64)
+}
+
+// buildRangeCheckAction returns the step failing the run before any request is
+// prepared when a bounded input is out of range, listing every offending input
+// instead of leaving the API to answer 400.
+func buildRangeCheckAction(checks []rangeCheck) ActionData {
+	var builder strings.Builder
+	builder.WriteString("import sys\n\n")
+	builder.WriteString("def check_range(label, value, minimum, maximum, expected):\n")
+	builder.WriteString("    value = str(value).strip()\n")
+	builder.WriteString("    if value == '':\n        return None\n")
+	builder.WriteString("    try:\n        number = float(value)\n")
+	builder.WriteString("    except ValueError:\n        return label + ' must be a number, got ' + repr(value)\n")
+	builder.WriteString("    if (minimum is not None and number < minimum) or (maximum is not None and number > maximum):\n")
+	builder.WriteString("        return label + ' must be ' + expected + ', got ' + value\n")
+	builder.WriteString("    return None\n\n")
+	builder.WriteString("errors = [error for error in (\n")
+	arguments := make([]string, len(checks))
+	for i, check := range checks {
+		builder.WriteString(fmt.Sprintf("    check_range(%q, sys.argv[%d], %s, %s, %q),\n",
+			check.Label, i+1, formatBound(check.Minimum), formatBound(check.Maximum), check.expected()))
+		arguments[i] = check.Reference
+	}
+	builder.WriteString(") if error]\n\n")
+	builder.WriteString("if errors:\n    raise ValueError('; '.join(errors))\n\n")
+	builder.WriteString("valid = 'true'\n")
+	builder.WriteString("print(valid)\n")
+
+	return ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Validate Input Ranges",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Validate Input Ranges",
+			"script":              builder.String(),
+			"script_arguments":    arguments,
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "valid",
+					"script_query_name": "valid",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+}
+
+// objectURLVariableName is the placeholder name of the "Input - Object URL" input.
+const objectURLVariableName = "object_url"
+
+// objectURLParam returns the path parameter an Object URL input stands in for:
+// the trailing {id} of a NetBox GET, PUT, PATCH or DELETE by ID.
+func (s *renderSettings) objectURLParam(operation *Operation, path, method string) (Parameter, bool) {
+	if !s.objectURLInput || s.currentConnector.ActionType != "netbox.invoke_api" {
+		return Parameter{}, false
+	}
+	switch strings.ToUpper(method) {
+	case "GET", "PUT", "PATCH", "DELETE":
+	default:
+		return Parameter{}, false
+	}
+	var pathParams []Parameter
+	for _, param := range operation.Parameters {
+		if param.In == "path" {
+			pathParams = append(pathParams, param)
+		}
+	}
+	if len(pathParams) != 1 || !strings.HasSuffix(strings.TrimSuffix(path, "/"), "{"+pathParams[0].Name+"}") {
+		return Parameter{}, false
+	}
+	return pathParams[0], true
+}
+
+// buildObjectURLVariable returns the "Input - Object URL" input of path.
+func (s *renderSettings) buildObjectURLVariable(path string, param Parameter) VariableData {
+	example := "https://netbox.example.com" + strings.Replace(path, "{"+param.Name+"}", "1", 1)
+	return VariableData{
+		SchemaID: "datatype.string",
+		Properties: VariableProperties{
+			Value:                "",
+			Scope:                "input",
+			Name:                 "Input - Object URL",
+			Type:                 "datatype.string",
+			Description:          fmt.Sprintf("URL of the object as NetBox returns it in url fields, e.g. %s. Used instead of the ID when set.", example),
+			IsRequired:           false,
+			VariableStringFormat: "text",
+			DisplayOnWizard:      false,
+			IsInvisible:          false,
+		},
+		UniqueName: s.variableUniqueName(placeholderKindParam, objectURLVariableName),
+		ObjectType: "variable_workflow",
+	}
+}
+
+// buildObjectPathAction returns a "Build Object Path" step resolving the
+// request path from the Object URL input, checked to address an object of
+// path, or else from the ID input, and the reference to the resolved path.
+func (s *renderSettings) buildObjectPathAction(path string, param Parameter, idLabel string) (ActionData, string) {
+	placeholder := "{" + param.Name + "}"
+	index := strings.LastIndex(path, placeholder)
+	prefix, suffix := path[:index], path[index+len(placeholder):]
+	var builder strings.Builder
+	builder.WriteString("import sys\nimport urllib.parse\n\n")
+	builder.WriteString("(object_url, object_id) = sys.argv[1:3]\n")
+	builder.WriteString("object_url, object_id = object_url.strip(), object_id.strip()\n\n")
+	builder.WriteString("if object_url != '':\n")
+	builder.WriteString("    object_path = urllib.parse.urlparse(object_url).path\n")
+	builder.WriteString("    if '/api/' in object_path:\n")
+	builder.WriteString("        object_path = object_path[object_path.index('/api/'):]\n")
+	if strings.HasSuffix(suffix, "/") {
+		builder.WriteString("    if not object_path.endswith('/'):\n        object_path += '/'\n")
+	}
+	builder.WriteString(fmt.Sprintf("    object_key = object_path[%d:len(object_path) - %d]\n", len(prefix), len(suffix)))
+	builder.WriteString(fmt.Sprintf("    if not object_path.startswith(%q) or not object_path.endswith(%q) or object_key == '' or '/' in object_key:\n", prefix, suffix))
+	builder.WriteString(fmt.Sprintf("        raise ValueError(%q + repr(object_url))\n", "Object URL must address a "+path+" object, got "))
+	builder.WriteString("elif object_id != '':\n")
+	builder.WriteString(fmt.Sprintf("    object_path = %q + urllib.parse.quote(object_id, safe='') + %q\n", prefix, suffix))
+	builder.WriteString("else:\n")
+	builder.WriteString(fmt.Sprintf("    raise ValueError(%q)\n\n", "Set "+idLabel+" or Input - Object URL"))
+	builder.WriteString("print(object_path)\n")
+
+	action := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Build Object Path",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Build Object Path",
+			"script":              builder.String(),
+			"script_arguments": []string{
+				s.inputVariableRef(placeholderKindParam, objectURLVariableName),
+				s.inputVariableRef(placeholderKindParam, param.Name),
+			},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "object_path",
+					"script_query_name": "object_path",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	return action, fmt.Sprintf("$activity.%s.output.script_queries.object_path$", action.UniqueName)
+}
+
+func (s *renderSettings) buildRequestBodyPrepAction(bodySchema Schema, operationId string, additionalFields bool) (ActionData, string) {
+	// Extract properties from schema
+	var bodyParams []BodyParam
+	switch bodySchema.Type {
+	case "object":
+		for propName, propSchema := range bodySchema.Properties {
+			isRequired := contains(bodySchema.Required, propName)
+			bodyParams = append(bodyParams, s.newBodyParam(propName, propSchema, isRequired))
+		}
+	case "array":
+		if bodySchema.Items != nil && bodySchema.Items.Type == "object" {
+			for propName, propSchema := range bodySchema.Items.Properties {
+				isRequired := contains(bodySchema.Items.Required, propName)
+				bodyParams = append(bodyParams, s.newBodyParam(propName, propSchema, isRequired))
+			}
+		}
+	}
+
+	// Sort bodyParams for consistent output
+	sort.Slice(bodyParams, func(i, j int) bool {
+		return bodyParams[i].Name < bodyParams[j].Name
+	})
+
+	// Generate Python script
+	var scriptBuilder strings.Builder
+	scriptBuilder.WriteString("import json\n\n")
+	hasStringIDs, hasBooleans := false, false
+	for _, param := range bodyParams {
+		for _, leaf := range append([]BodyParam{param}, param.Fields...) {
+			hasStringIDs = hasStringIDs || s.isStringID(leaf.Name, leaf.Type)
+			hasBooleans = hasBooleans || leaf.Type == "boolean"
+		}
+	}
+	if hasStringIDs {
+		// Python ints are exact, so the ID reaches the JSON body digit for digit.
+		scriptBuilder.WriteString("def numeric_id(name, value):\n")
+		scriptBuilder.WriteString("    value = value.strip()\n")
+		scriptBuilder.WriteString("    if not value.isdigit():\n")
+		scriptBuilder.WriteString("        raise ValueError(name + ' must be a numeric ID, got ' + repr(value))\n")
+		scriptBuilder.WriteString("    return int(value)\n\n")
+	}
+	if hasBooleans {
+		scriptBuilder.WriteString(pythonToBool)
+	}
+
+	// Import input variables
+	for _, param := range bodyParams {
+		if len(param.Fields) > 0 {
+			for _, field := range param.Fields {
+				fieldName := flattenedFieldName(param.Name, field.Name)
+				scriptBuilder.WriteString(fmt.Sprintf("%s = '%s'\n", pythonIdentifier(fieldName, "param", 0), s.inputVariableRef(placeholderKindBody, fieldName)))
+			}
+			continue
+		}
+		variableRef := s.inputVariableRef(placeholderKindBody, param.Name)
+		pyVar := pythonIdentifier(param.Name, "param", 0)
+		scriptBuilder.WriteString(fmt.Sprintf("%s = '%s'\n", pyVar, variableRef))
+	}
+
+	if additionalFields {
+		scriptBuilder.WriteString(fmt.Sprintf("additional_fields = '%s'\n", s.inputVariableRef(placeholderKindBody, additionalFieldsVariableName)))
+	}
+	object := bodyObjectSchema(bodySchema)
+	mapBody := object != nil && isMapSchema(*object)
+	if mapBody {
+		scriptBuilder.WriteString(fmt.Sprintf("map_body = '%s'\n", s.inputVariableRef(placeholderKindBody, mapBodyVariableName)))
+	}
+
+	scriptBuilder.WriteString("\nrequest_body_object = {}\n")
+	if mapBody {
+		scriptBuilder.WriteString("if map_body.strip() != '':\n")
+		scriptBuilder.WriteString("    request_body_object = json.loads(map_body)\n")
+		scriptBuilder.WriteString("if not isinstance(request_body_object, dict):\n")
+		scriptBuilder.WriteString("    raise ValueError('Request Body (JSON) must be a JSON object')\n")
+	}
+	if additionalFields {
+		// Explicit inputs are applied afterwards and win over the catch-all.
+		scriptBuilder.WriteString("if additional_fields.strip() not in ('', '{}'):\n")
+		scriptBuilder.WriteString("    request_body_object.update(json.loads(additional_fields))\n")
+	}
+
+	// Build conditional field additions
+	for _, param := range bodyParams {
+		pyVar := pythonIdentifier(param.Name, "param", 0)
+		if len(param.Fields) == 0 {
+			s.writeBodyParamAssignment(&scriptBuilder, "request_body_object", pyVar, param)
+			continue
+		}
+		// Reassemble the flattened object from its field inputs
+		scriptBuilder.WriteString(fmt.Sprintf("%s = {}\n", pyVar))
+		for _, field := range param.Fields {
+			s.writeBodyParamAssignment(&scriptBuilder, pyVar, pythonIdentifier(flattenedFieldName(param.Name, field.Name), "param", 0), field)
+		}
+		if param.Required {
+			scriptBuilder.WriteString(fmt.Sprintf("request_body_object[\"%s\"] = %s\n", param.Name, pyVar))
+		} else {
+			scriptBuilder.WriteString(fmt.Sprintf("if %s:\n", pyVar))
+			scriptBuilder.WriteString(fmt.Sprintf("    request_body_object[\"%s\"] = %s\n", param.Name, pyVar))
+		}
+	}
+
+	// Determine if we need array wrapping
+	needsArrayWrap := bodySchema.Type == "array" || strings.Contains(operationId, "_available_")
+	if needsArrayWrap {
+		scriptBuilder.WriteString("request_body_string = json.dumps([request_body_object])\n")
+	} else {
+		scriptBuilder.WriteString("request_body_string = json.dumps(request_body_object)\n")
+	}
+
+	scriptAction := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Prepare Request Body",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Prepare Request Body",
+			"script":              scriptBuilder.String(),
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "request_body_string",
+					"script_query_name": "request_body",
+					"script_query_type": "string",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+
+	bodyReference := fmt.Sprintf("$activity.%s.output.script_queries.request_body$", scriptAction.UniqueName)
+	return scriptAction, bodyReference
+}
+
+// writeBodyParamAssignment writes the statements setting body field param of
+// the target dict from the input held by pyVar, converted to the field's type;
+// optional fields are left out while empty.
+func (s *renderSettings) writeBodyParamAssignment(scriptBuilder *strings.Builder, target, pyVar string, param BodyParam) {
+	// Determine how to add the value based on type
+	var valueExpr string
+	switch param.Type {
+	case "array", "object":
+		// Parse JSON strings for arrays and objects
+		valueExpr = fmt.Sprintf("json.loads(%s) if %s != '' else None", pyVar, pyVar)
+	case "integer", "number":
+		// Convert to int/float
+		if s.isStringID(param.Name, param.Type) {
+			valueExpr = fmt.Sprintf("numeric_id('%s', %s) if %s != '' else None", param.Name, pyVar, pyVar)
+		} else if param.Type == "integer" {
+			valueExpr = fmt.Sprintf("int(%s) if %s != '' else None", pyVar, pyVar)
+		} else {
+			valueExpr = fmt.Sprintf("float(%s) if %s != '' else None", pyVar, pyVar)
+		}
+	case "boolean":
+		// Convert to boolean
+		valueExpr = fmt.Sprintf("to_bool('%s', %s) if %s != '' else None", param.Name, pyVar, pyVar)
+	default:
+		// Keep as string
+		valueExpr = pyVar
+	}
+
+	if param.Required {
+		// Required fields: add with appropriate type conversion
+		if param.Type == "array" || param.Type == "object" {
+			scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
+			scriptBuilder.WriteString(fmt.Sprintf("    %s[\"%s\"] = %s\n", target, param.Name, valueExpr))
+		} else if param.Type == "integer" || param.Type == "number" || param.Type == "boolean" {
+			scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
+			scriptBuilder.WriteString(fmt.Sprintf("    %s[\"%s\"] = %s\n", target, param.Name, valueExpr))
+		} else {
+			scriptBuilder.WriteString(fmt.Sprintf("%s[\"%s\"] = %s\n", target, param.Name, pyVar))
+		}
+	} else {
+		// Optional fields: only add if non-empty; a map's {} default means unset
+		if param.Map {
+			scriptBuilder.WriteString(fmt.Sprintf("if %s.strip() not in ('', '{}'):\n", pyVar))
+		} else {
+			scriptBuilder.WriteString(fmt.Sprintf("if %s != '':\n", pyVar))
+		}
+		if param.Type == "array" || param.Type == "object" || param.Type == "integer" || param.Type == "number" || param.Type == "boolean" {
+			scriptBuilder.WriteString(fmt.Sprintf("    value = %s\n", valueExpr))
+			scriptBuilder.WriteString(fmt.Sprintf("    if value is not None:\n"))
+			scriptBuilder.WriteString(fmt.Sprintf("        %s[\"%s\"] = value\n", target, param.Name))
+		} else {
+			scriptBuilder.WriteString(fmt.Sprintf("    %s[\"%s\"] = %s\n", target, param.Name, pyVar))
+		}
+	}
+}
+
+func loadQueryParamConfig(path string) (map[string][]string, error) {
+	data, err := fsutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseQueryParamConfig(data)
+}
+
+// mustParseQueryParamConfig parses a query params mapping embedded in the binary.
+func mustParseQueryParamConfig(data []byte) map[string][]string {
+	parsed, err := parseQueryParamConfig(data)
+	if err != nil {
+		panic(fmt.Sprintf("embedded query params config: %v", err))
+	}
+	return parsed
+}
+
+// parseQueryParamConfig parses a YAML/JSON mapping of operationIds to query params.
+func parseQueryParamConfig(data []byte) (map[string][]string, error) {
+	var err error
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return map[string][]string{}, nil
+	}
+	if data[0] != '{' && data[0] != '[' {
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var parsed map[string][]string
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	for key, values := range parsed {
+		set := make(map[string]struct{})
+		for _, v := range values {
+			value := strings.TrimSpace(v)
+			if value == "" {
+				continue
+			}
+			set[value] = struct{}{}
+		}
+		if len(set) == 0 {
+			delete(parsed, key)
+			continue
+		}
+		filtered := make([]string, 0, len(set))
+		for val := range set {
+			filtered = append(filtered, val)
+		}
+		sort.Strings(filtered)
+		parsed[key] = filtered
+	}
+	return parsed, nil
+}
+
+func loadWorkflowConfig(path string) (*workflowConfigFile, error) {
+	cfg, err := loadWorkflowConfigFile(path, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Workflows) == 0 && len(cfg.Composites) == 0 && len(cfg.Recipes) == 0 && len(cfg.Bulk) == 0 {
+		return nil, fmt.Errorf("config file %s defines no workflows, composites, recipes or bulk workflows", path)
+	}
+	return cfg, nil
+}
+
+// loadWorkflowConfigFile parses one config file and merges in the files listed
+// under include (resolved relative to it) first, so a per-app file such as
+// dcim.yaml can share defaults from common.yaml and override them.
+func loadWorkflowConfigFile(path string, loading map[string]bool) (*workflowConfigFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if loading[absPath] {
+		return nil, fmt.Errorf("config include cycle at %s", path)
+	}
+	loading[absPath] = true
+	defer delete(loading, absPath)
+
+	raw, err := fsutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkflowConfigFile(path, raw, loading)
+}
+
+// parseWorkflowConfigFile parses the content of the config file at path; path
+// locates relative includes.
+func parseWorkflowConfigFile(path string, raw []byte, loading map[string]bool) (*workflowConfigFile, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("config file %s is empty", path)
+	}
+
+	// Includes and variables are read before ${name} references are expanded;
+	// the rest of the file is only parsed afterwards.
+	var header struct {
+		Include   []string               `json:"include"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := unmarshalConfigData(raw, &header); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	merged := &workflowConfigFile{}
+	for _, include := range header.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		included, err := loadWorkflowConfigFile(include, loading)
+		if err != nil {
+			return nil, err
+		}
+		mergeWorkflowConfigFile(merged, included)
+	}
+	variables := make(map[string]interface{}, len(merged.Variables)+len(header.Variables))
+	for name, value := range merged.Variables {
+		variables[name] = value
+	}
+	for name, value := range header.Variables {
+		variables[name] = value
+	}
+
+	data, err := expandConfigVariables(raw, variables)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var cfg workflowConfigFile
+	if err := unmarshalConfigData(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Variables = variables
+	for i, spec := range cfg.Specs {
+		location := strings.TrimSpace(spec.OpenAPI)
+		if location != "" && !filepath.IsAbs(location) && !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+			cfg.Specs[i].OpenAPI = filepath.Join(filepath.Dir(path), location)
+		}
+	}
+	mergeWorkflowConfigFile(merged, &cfg)
+	return merged, nil
+}
+
+// unmarshalConfigData decodes a YAML or JSON config; a top-level list is the
+// legacy form holding only workflow entries.
+func unmarshalConfigData(data []byte, target interface{}) error {
+	var err error
+	if data[0] != '{' && data[0] != '[' {
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return err
+		}
+	}
+	if data[0] == '[' {
+		if cfg, ok := target.(*workflowConfigFile); ok {
+			return json.Unmarshal(data, &cfg.Workflows)
+		}
+		return nil
+	}
+	return json.Unmarshal(data, target)
+}
+
+// configVariablePattern matches ${name} references; $${name} escapes a literal.
+var configVariablePattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// expandConfigVariables replaces ${name} references in a raw config file with
+// the values declared under variables (here or in an included file).
+func expandConfigVariables(data []byte, variables map[string]interface{}) ([]byte, error) {
+	var missing []string
+	expanded := configVariablePattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		if bytes.HasPrefix(match, []byte("$$")) {
+			return match[1:]
+		}
+		name := string(configVariablePattern.FindSubmatch(match)[1])
+		value, ok := variables[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return []byte(fmt.Sprint(value))
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined config variable(s) %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// mergeWorkflowConfigFile appends overlay's entries to cfg; overlay's defaults
+// override the ones already merged.
+func mergeWorkflowConfigFile(cfg, overlay *workflowConfigFile) {
+	cfg.Defaults = mergeWorkflowDefaults(cfg.Defaults, overlay.Defaults)
+	if len(overlay.Variables) > 0 && cfg.Variables == nil {
+		cfg.Variables = make(map[string]interface{}, len(overlay.Variables))
+	}
+	for name, value := range overlay.Variables {
+		cfg.Variables[name] = value
+	}
+	cfg.Workflows = append(cfg.Workflows, overlay.Workflows...)
+	cfg.Composites = append(cfg.Composites, overlay.Composites...)
+	cfg.Recipes = append(cfg.Recipes, overlay.Recipes...)
+	cfg.Bulk = append(cfg.Bulk, overlay.Bulk...)
+	cfg.Triggers = append(cfg.Triggers, overlay.Triggers...)
+	cfg.Specs = append(cfg.Specs, overlay.Specs...)
+}
+
+func (s *renderSettings) getQueryParamAllowSet(operationId string) map[string]struct{} {
+	var allowed []string
+	if s.queryParamFilter != nil {
+		if vals, ok := s.queryParamFilter[operationId]; ok {
+			allowed = vals
+		}
+	}
+	if len(allowed) == 0 && s.currentConnector.ActionType == "netbox.invoke_api" {
+		if vals, ok := defaultNetboxQueryFilters[operationId]; ok {
+			allowed = vals
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(allowed))
+	for _, val := range allowed {
+		val = strings.TrimSpace(val)
+		if val == "" {
+			continue
+		}
+		set[val] = struct{}{}
+	}
+	if _, exists := set["q"]; !exists {
+		set["q"] = struct{}{}
+	}
+	return set
+}
+
+func (s *renderSettings) setBodyParamFilter(operationId string, params []string) {
+	operationId = strings.TrimSpace(operationId)
+	if operationId == "" {
+		return
+	}
+	cleaned := make(map[string]struct{})
+	for _, param := range params {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		cleaned[param] = struct{}{}
+	}
+	// The filter is replaced rather than changed: copies of the settings share it.
+	filter := maps.Clone(s.bodyParamFilter)
+	if len(cleaned) == 0 {
+		delete(filter, operationId)
+	} else {
+		if filter == nil {
+			filter = make(map[string]map[string]struct{})
+		}
+		filter[operationId] = cleaned
+	}
+	s.bodyParamFilter = filter
+}
+
+func (s *renderSettings) getBodyParamAllowSet(operationId string) map[string]struct{} {
+	if len(s.bodyParamFilter) == 0 {
+		return nil
+	}
+	return s.bodyParamFilter[operationId]
+}
+
+func (s *renderSettings) applyBodyParamFilter(operationId string, schema *Schema) {
+	if schema == nil {
+		return
+	}
+	allowed := s.getBodyParamAllowSet(operationId)
+	if allowed == nil || len(allowed) == 0 {
+		return
+	}
+	filterSchemaProperties(schema, allowed)
+}
+
+// requiredFieldsReportFile is the per-run report of the spec-required body
+// fields of each generated workflow, written next to the workflows.
+const requiredFieldsReportFile = "required-fields.json"
+
+// requiredFieldReport is one row of the required fields report.
+type requiredFieldReport struct {
+	Workflow    string          `json:"workflow"`
+	OperationID string          `json:"operation_id"`
+	Method      string          `json:"method"`
+	Fields      map[string]bool `json:"fields"`
+	Excluded    []string        `json:"excluded,omitempty"`
+	Added       []string        `json:"added,omitempty"`
+}
+
+// requiredFieldPresence maps each required property of an unfiltered request
+// body to whether the operation's body_params filter keeps it, and returns the
+// excluded ones sorted. Bodies without required properties return nil.
+func (s *renderSettings) requiredFieldPresence(operationId string, body Schema) (map[string]bool, []string) {
+	object := bodyObjectSchema(body)
+	if object == nil || len(object.Required) == 0 {
+		return nil, nil
+	}
+	allowed := s.getBodyParamAllowSet(operationId)
+	fields := make(map[string]bool, len(object.Required))
+	var excluded []string
+	for _, name := range object.Required {
+		_, kept := allowed[name]
+		fields[name] = len(allowed) == 0 || kept
+		if !fields[name] {
+			excluded = append(excluded, name)
+		}
+	}
+	sort.Strings(excluded)
+	return fields, excluded
+}
+
+// includeRequiredFields adds the required properties of body to the
+// operation's body_params filter and returns the ones it added, sorted.
+func (s *renderSettings) includeRequiredFields(operationId string, body Schema) []string {
+	object := bodyObjectSchema(body)
+	allowed := s.getBodyParamAllowSet(operationId)
+	if object == nil || len(allowed) == 0 {
+		return nil
+	}
+	var added []string
+	for _, name := range object.Required {
+		if _, ok := allowed[name]; !ok {
+			allowed[name] = struct{}{}
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// writeRequiredFieldsReport writes the report of a config run; runs without
+// required body fields write none.
+// loadRequiredFieldsReport reads the required-fields report of outputDir by
+// workflow file; a missing report is empty.
+func loadRequiredFieldsReport(outputDir string) (map[string]requiredFieldReport, error) {
+	data, err := fsutil.ReadFile(filepath.Join(outputDir, requiredFieldsReportFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rows []requiredFieldReport
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("%s: %w", requiredFieldsReportFile, err)
+	}
+	byWorkflow := make(map[string]requiredFieldReport, len(rows))
+	for _, row := range rows {
+		byWorkflow[row.Workflow] = row
+	}
+	return byWorkflow, nil
+}
+
+func writeRequiredFieldsReport(outputDir string, rows []requiredFieldReport) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(filepath.Join(outputDir, requiredFieldsReportFile), append(data, '\n'))
+}
+
+func filterSchemaProperties(schema *Schema, allowed map[string]struct{}) {
+	if schema == nil {
+		return
+	}
+	if schema.Type == "object" {
+		if len(schema.Properties) > 0 {
+			for key, prop := range schema.Properties {
+				if _, ok := allowed[key]; !ok {
+					delete(schema.Properties, key)
+					continue
+				}
+				filterSchemaProperties(&prop, allowed)
+				schema.Properties[key] = prop
+			}
+		}
+		if len(schema.Required) > 0 {
+			var filtered []string
+			for _, req := range schema.Required {
+				if _, ok := allowed[req]; ok {
+					filtered = append(filtered, req)
+				}
+			}
+			schema.Required = filtered
+		}
+	}
+	if schema.Type == "array" && schema.Items != nil {
+		filterSchemaProperties(schema.Items, allowed)
+	}
+}
+
+func (s *renderSettings) renderWorkflow(ctx context.Context, openAPISpec OpenAPISpec, operationId string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	// Placeholders are numbered per workflow, in a copy of the settings so
+	// renders sharing them do not share the registry.
+	render := *s
+	render.placeholders = newPlaceholderRegistry()
+	render.truncatedVariables = nil
+	s = &render
+	operation, path, method, err := ExtractOperation(openAPISpec, operationId)
+	if err != nil {
+		return "", err
+	}
+	operation = resolveOperationSchemas(openAPISpec, operation)
+	if err := forceOperationSchemas(openAPISpec, operation, s.requestSchemaName, s.responseSchemaName); err != nil {
+		return "", generator.NewError(generator.ErrUnsupportedSchema, operationId, err)
+	}
+	applySchemaViews(operation)
+	if s.strictMode {
+		if issues := unsupportedConstructs(openAPISpec, operation); len(issues) > 0 {
+			return "", generator.NewError(generator.ErrUnsupportedSchema, operationId, fmt.Errorf("strict mode: %s", strings.Join(issues, "; ")))
+		}
+	}
+	operation.Parameters = skipUnresolvedParameters(operationId, operation.Parameters)
+	s.limitOperationSchemaDepth(openAPISpec, operation)
+	applyOperationSchemaOverrides(operationId, operation)
+	s.currentConnector.applyListQueryParams(operation)
+	schema := &operation.RequestBody.Content.ApplicationJSON.Schema
+	var added []string
+	if s.alwaysIncludeRequired {
+		added = s.includeRequiredFields(operationId, *schema)
+	}
+	if fields, excluded := s.requiredFieldPresence(operationId, *schema); fields != nil {
+		if s.onRequiredFields != nil {
+			s.onRequiredFields(requiredFieldReport{OperationID: operationId, Method: method, Fields: fields, Excluded: excluded, Added: added})
+		}
+		if len(excluded) > 0 {
+			if s.strictMode {
+				return "", generator.NewError(generator.ErrUnsupportedSchema, operationId, fmt.Errorf("strict mode: body_params excludes required body fields %s", strings.Join(excluded, ", ")))
+			}
+			log.Printf("Warning: %s: body_params excludes required body fields %s; requests will be rejected", operationId, strings.Join(excluded, ", "))
+		}
+	}
+	if schema != nil && (schema.Type != "" || len(schema.Properties) > 0 || schema.Items != nil) {
+		s.applyBodyParamFilter(operationId, schema)
+	}
+
+	workflowData := s.GenerateWorkflowData(operation, path, method)
+	for _, location := range s.truncatedVariables {
+		log.Printf("Warning: %s: %s is truncated and handled as a JSON value", operationId, location)
+	}
+	if s.waitForSettings != nil {
+		if !strings.EqualFold(method, "GET") {
+			return "", fmt.Errorf("%s: wait_for requires a GET operation, got %s", operationId, method)
+		}
+		s.applyWaitFor(&workflowData, path, *s.waitForSettings)
+	}
+	if err := validateVariableUniqueNames(workflowData.Variables); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	s.applyInputSections(&workflowData)
+	s.hideOptionalWizardInputs(&workflowData)
+	if err := s.applyNameTemplate(&workflowData, operationId, path, method); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	if s.minimalVariant(method) {
+		workflowData.Name += minimalVariantSuffix
+		workflowData.Title += minimalVariantSuffix
+		workflowData.Properties.DisplayName += minimalVariantSuffix
+	}
+	if err := s.applyCategoryPath(&workflowData, operation, path, method); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	s.capitalizeAcronyms(&workflowData)
+	s.applyPlatformPrefix(&workflowData)
+	return s.renderWorkflowData(ctx, operationId, workflowData)
+}
+
+// renderWorkflowData executes the workflow template for workflowData, replaces
+// KSUID placeholders, runs the post-processors and returns the indented JSON.
+// operationId only labels errors.
+func (s *renderSettings) renderWorkflowData(ctx context.Context, operationId string, workflowData WorkflowData) (string, error) {
+	content, err := s.executeWorkflowTemplate(operationId, workflowData)
+	if err != nil {
+		return "", err
+	}
+	processed, err := s.runPostProcessors(ctx, []byte(content))
+	if err != nil {
+		return "", err
+	}
+	return finishWorkflow(operationId, string(processed))
+}
+
+// executeWorkflowTemplate renders workflowData with the workflow template and
+// replaces the KSUID placeholders.
+func (s *renderSettings) executeWorkflowTemplate(operationId string, workflowData WorkflowData) (string, error) {
+	tmpl, err := template.New("workflow").Funcs(sprig.TxtFuncMap()).Funcs(s.templateFuncMap()).Parse(s.workflowTemplateText)
+	if err != nil {
+		return "", generator.NewError(generator.ErrTemplateRender, operationId, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, workflowData); err != nil {
+		return "", generator.NewError(generator.ErrTemplateRender, operationId, err)
+	}
+	return ReplaceKSUIDs(buf.String()), nil
+}
+
+// finishBuiltWorkflow runs the post-processors on a composite or bulk workflow
+// and checks the result like a rendered atomic; name labels errors.
+func (s *renderSettings) finishBuiltWorkflow(ctx context.Context, name string, content []byte) ([]byte, error) {
+	processed, err := s.runPostProcessors(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	finished, err := finishWorkflow(name, string(processed))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(finished), nil
+}
+
+// finishWorkflow checks the references of a rendered workflow and indents it.
+func finishWorkflow(operationId, finalContent string) (string, error) {
+	if err := validatePlaceholders([]byte(finalContent)); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	if err := validateReferences([]byte(finalContent)); err != nil {
+		return "", fmt.Errorf("%s: %w", operationId, err)
+	}
+	var formattedContent bytes.Buffer
+	if err := json.Indent(&formattedContent, []byte(finalContent), "", "  "); err != nil {
+		_ = fsutil.WriteFile(filepath.Join(os.TempDir(), "debug_workflow_raw.json"), []byte(finalContent))
+		return "", generator.NewError(generator.ErrTemplateRender, operationId, fmt.Errorf("rendered workflow is not valid JSON: %w", err))
+	}
+
+	return formattedContent.String(), nil
+}
+
+// exportedWorkflow mirrors the JSON the workflow template renders, so exports
+// can be read back into WorkflowData.
+type exportedWorkflow struct {
+	Workflow struct {
+		UniqueName string             `json:"unique_name"`
+		Name       string             `json:"name"`
+		Title      string             `json:"title"`
+		Type       string             `json:"type"`
+		BaseType   string             `json:"base_type"`
+		Variables  []exportedVariable `json:"variables"`
+		Properties struct {
+			Atomic struct {
+				AtomicGroup string `json:"atomic_group"`
+				IsAtomic    bool   `json:"is_atomic"`
+			} `json:"atomic"`
+			Description string          `json:"description"`
+			DisplayName string          `json:"display_name"`
+			RuntimeUser RuntimeUserData `json:"runtime_user"`
+			Target      TargetData      `json:"target"`
+		} `json:"properties"`
+		ObjectType string           `json:"object_type"`
+		Actions    []exportedAction `json:"actions"`
+		Categories []string         `json:"categories"`
+	} `json:"workflow"`
+	Categories map[string]CategoryData  `json:"categories"`
+	Types      map[string]TableTypeData `json:"types"`
+}
+
+type exportedVariable struct {
+	SchemaID   string `json:"schema_id"`
+	Properties struct {
+		Value                interface{} `json:"value"`
+		Scope                string      `json:"scope"`
+		Name                 string      `json:"name"`
+		Type                 string      `json:"type"`
+		Description          string      `json:"description"`
+		IsRequired           bool        `json:"is_required"`
+		VariableStringFormat string      `json:"variable_string_format"`
+		DisplayOnWizard      bool        `json:"display_on_wizard"`
+		IsInvisible          bool        `json:"is_invisible"`
+	} `json:"properties"`
+	UniqueName string `json:"unique_name"`
+	ObjectType string `json:"object_type"`
+}
+
+type exportedAction struct {
+	UniqueName string                 `json:"unique_name"`
+	Name       string                 `json:"name"`
+	Title      string                 `json:"title"`
+	Type       string                 `json:"type"`
+	BaseType   string                 `json:"base_type"`
+	Properties map[string]interface{} `json:"properties"`
+	ObjectType string                 `json:"object_type"`
+	Blocks     []exportedBlock        `json:"blocks"`
+	Actions    []exportedAction       `json:"actions"`
+}
+
+type exportedBlock struct {
+	UniqueName string           `json:"unique_name"`
+	Name       string           `json:"name"`
+	Title      string           `json:"title"`
+	Type       string           `json:"type"`
+	BaseType   string           `json:"base_type"`
+	Properties BlockProperties  `json:"properties"`
+	ObjectType string           `json:"object_type"`
+	Actions    []exportedAction `json:"actions"`
+}
+
+// importWorkflow parses a workflow export back into WorkflowData, keeping every
+// unique name, so it can be linted, diffed or rendered again (e.g. through a
+// different -template) without new IDs. Action properties become generic maps.
+func importWorkflow(content []byte) (WorkflowData, error) {
+	var export exportedWorkflow
+	if err := json.Unmarshal(content, &export); err != nil {
+		return WorkflowData{}, err
+	}
+	wf := export.Workflow
+	if wf.UniqueName == "" {
+		return WorkflowData{}, errors.New("document has no workflow object")
+	}
+	data := WorkflowData{
+		UniqueName: wf.UniqueName,
+		Name:       wf.Name,
+		Title:      wf.Title,
+		Type:       wf.Type,
+		BaseType:   wf.BaseType,
+		Properties: WorkflowProperties{
+			Atomic:      AtomicData{AtomicGroup: wf.Properties.Atomic.AtomicGroup, IsAtomic: wf.Properties.Atomic.IsAtomic},
+			Description: wf.Properties.Description,
+			DisplayName: wf.Properties.DisplayName,
+			RuntimeUser: wf.Properties.RuntimeUser,
+			Target:      wf.Properties.Target,
+		},
+		ObjectType:    wf.ObjectType,
+		Actions:       importActions(wf.Actions),
+		Categories:    wf.Categories,
+		CategoriesMap: export.Categories,
+		TableTypes:    export.Types,
+	}
+	if data.Categories == nil {
+		data.Categories = []string{}
+	}
+	if data.CategoriesMap == nil {
+		data.CategoriesMap = map[string]CategoryData{}
+	}
+	for _, v := range wf.Variables {
+		value := v.Properties.Value
+		// The template renders non-array JSON values as a JSON string; decode it
+		// so rendering again does not quote it twice.
+		if text, ok := value.(string); ok && v.Properties.VariableStringFormat == "json" && v.Properties.Type != "datatype.array" && v.Properties.Type != "datatype.table" {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(text), &decoded); err == nil {
+				value = decoded
+			}
+		}
+		data.Variables = append(data.Variables, VariableData{
+			SchemaID: v.SchemaID,
+			Properties: VariableProperties{
+				Value:                value,
+				Scope:                v.Properties.Scope,
+				Name:                 v.Properties.Name,
+				Type:                 v.Properties.Type,
+				Description:          v.Properties.Description,
+				IsRequired:           v.Properties.IsRequired,
+				VariableStringFormat: v.Properties.VariableStringFormat,
+				DisplayOnWizard:      v.Properties.DisplayOnWizard,
+				IsInvisible:          v.Properties.IsInvisible,
+			},
+			UniqueName: v.UniqueName,
+			ObjectType: v.ObjectType,
+		})
+	}
+	return data, nil
+}
+
+func importActions(exported []exportedAction) []ActionData {
+	var actions []ActionData
+	for _, a := range exported {
+		action := ActionData{
+			UniqueName: a.UniqueName,
+			Name:       a.Name,
+			Title:      a.Title,
+			Type:       a.Type,
+			BaseType:   a.BaseType,
+			Properties: a.Properties,
+			ObjectType: a.ObjectType,
+			Actions:    importActions(a.Actions),
+		}
+		if action.Properties == nil {
+			action.Properties = map[string]interface{}{}
+		}
+		for _, b := range a.Blocks {
+			action.Blocks = append(action.Blocks, BlockData{
+				UniqueName: b.UniqueName,
+				Name:       b.Name,
+				Title:      b.Title,
+				Type:       b.Type,
+				BaseType:   b.BaseType,
+				Properties: b.Properties,
+				ObjectType: b.ObjectType,
+				Actions:    importActions(b.Actions),
+			})
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// customActionPrefix marks actions added by hand; -merge carries them over into
+// regenerated workflows.
+const customActionPrefix = "Custom - "
+
+// mergeWorkflow merges a freshly generated workflow into the existing export of
+// the same file. The result keeps the existing unique names (matched by
+// variable name and action title path), so re-importing updates the workflow in
+// AO, and it keeps hand edits: workflow, variable and action descriptions,
+// variable defaults, and actions titled "Custom - ..." at their position after
+// the same preceding action. base is the content last generated for the file
+// (from the lockfile) or nil; with it, only fields that differ from base count
+// as hand edits and the rest take the new render's values.
+func (s *renderSettings) mergeWorkflow(ctx context.Context, existing, generated, base []byte, operationId string) (string, error) {
+	previous, err := importWorkflow(existing)
+	if err != nil {
+		return "", fmt.Errorf("existing workflow: %w", err)
+	}
+	fresh, err := importWorkflow(generated)
+	if err != nil {
+		return "", err
+	}
+	var original *WorkflowData
+	if base != nil {
+		if data, err := importWorkflow(base); err == nil {
+			original = &data
+		}
+	}
+
+	// Point the new render at the existing unique names before reading it again.
+	var pairs []string
+	addPair := func(newID, oldID string) {
+		if newID != "" && oldID != "" && newID != oldID {
+			pairs = append(pairs, newID, oldID)
+		}
+	}
+	addPair(fresh.UniqueName, previous.UniqueName)
+	for id, tableType := range fresh.TableTypes {
+		for oldID, old := range previous.TableTypes {
+			if old.Name == tableType.Name {
+				addPair(id, oldID)
+			}
+		}
+	}
+	previousVariables := make(map[string]VariableData)
+	for _, v := range previous.Variables {
+		previousVariables[v.Properties.Scope+"/"+v.Properties.Name] = v
+	}
+	for _, v := range fresh.Variables {
+		addPair(v.UniqueName, previousVariables[v.Properties.Scope+"/"+v.Properties.Name].UniqueName)
+	}
+	previousActions := actionIDsByPath(previous.Actions)
+	for path, id := range actionIDsByPath(fresh.Actions) {
+		if old, ok := previousActions[path]; ok {
+			addPair(id, old)
+		}
+	}
+	merged, err := importWorkflow([]byte(strings.NewReplacer(pairs...).Replace(string(generated))))
+	if err != nil {
+		return "", err
+	}
+
+	// edited reports whether the user changed a field from what was generated;
+	// without a base every existing value counts as edited.
+	edited := func(current, generated interface{}, known bool) bool {
+		return original == nil || !known || !reflect.DeepEqual(current, generated)
+	}
+	generatedDescription := ""
+	if original != nil {
+		generatedDescription = original.Properties.Description
+	}
+	if previous.Properties.Description != "" && edited(previous.Properties.Description, generatedDescription, true) {
+		merged.Properties.Description = previous.Properties.Description
+	}
+	originalVariables := make(map[string]VariableData)
+	if original != nil {
+		for _, v := range original.Variables {
+			originalVariables[v.Properties.Scope+"/"+v.Properties.Name] = v
+		}
+	}
+	for i, v := range merged.Variables {
+		key := v.Properties.Scope + "/" + v.Properties.Name
+		old, ok := previousVariables[key]
+		if !ok || old.Properties.Type != v.Properties.Type {
+			continue
+		}
+		generatedVariable, known := originalVariables[key]
+		if edited(old.Properties.Description, generatedVariable.Properties.Description, known) {
+			merged.Variables[i].Properties.Description = old.Properties.Description
+		}
+		if edited(old.Properties.Value, generatedVariable.Properties.Value, known) {
+			merged.Variables[i].Properties.Value = old.Properties.Value
+		}
+	}
+	descriptions := previousActionDescriptions(previous.Actions, "")
+	if original != nil {
+		generatedDescriptions := previousActionDescriptions(original.Actions, "")
+		for path, description := range descriptions {
+			if generatedDescription, known := generatedDescriptions[path]; !edited(description, generatedDescription, known) {
+				delete(descriptions, path)
+			}
+		}
+	}
+	keepActionDescriptions(merged.Actions, descriptions, "")
+	for _, custom := range customActions(previous.Actions, "") {
+		insertCustomAction(&merged, custom)
+	}
+	return s.renderWorkflowData(ctx, operationId, merged)
+}
+
+// actionIDsByPath maps the title path of every action and block ("If / Block /
+// Action") to its unique name; repeated paths get " (again)" appended.
+func actionIDsByPath(actions []ActionData) map[string]string {
+	ids := make(map[string]string)
+	var walkActions func(actions []ActionData, parent string)
+	add := func(parent, title, id string) string {
+		path := title
+		if parent != "" {
+			path = parent + " / " + title
+		}
+		for _, exists := ids[path]; exists; _, exists = ids[path] {
+			path += " (again)"
+		}
+		ids[path] = id
+		return path
+	}
+	walkActions = func(actions []ActionData, parent string) {
+		for _, action := range actions {
+			path := add(parent, action.Title, action.UniqueName)
+			for _, block := range action.Blocks {
+				walkActions(block.Actions, add(path, block.Title, block.UniqueName))
+			}
+			walkActions(action.Actions, path)
+		}
+	}
+	walkActions(actions, "")
+	return ids
+}
+
+// previousActionDescriptions maps action title paths to their descriptions.
+func previousActionDescriptions(actions []ActionData, parent string) map[string]string {
+	descriptions := make(map[string]string)
+	for _, action := range actions {
+		path := joinTitlePath(parent, action.Title)
+		if properties, ok := action.Properties.(map[string]interface{}); ok {
+			if description, ok := properties["description"].(string); ok {
+				descriptions[path] = description
+			}
+		}
+		for _, block := range action.Blocks {
+			for key, value := range previousActionDescriptions(block.Actions, joinTitlePath(path, block.Title)) {
+				descriptions[key] = value
+			}
+		}
+		for key, value := range previousActionDescriptions(action.Actions, path) {
+			descriptions[key] = value
+		}
+	}
+	return descriptions
+}
+
+func keepActionDescriptions(actions []ActionData, descriptions map[string]string, parent string) {
+	for _, action := range actions {
+		path := joinTitlePath(parent, action.Title)
+		if properties, ok := action.Properties.(map[string]interface{}); ok {
+			if description, ok := descriptions[path]; ok {
+				if _, has := properties["description"]; has {
+					properties["description"] = description
+				}
+			}
+		}
+		for _, block := range action.Blocks {
+			keepActionDescriptions(block.Actions, descriptions, joinTitlePath(path, block.Title))
+		}
+		keepActionDescriptions(action.Actions, descriptions, path)
+	}
+}
+
+func joinTitlePath(parent, title string) string {
+	if parent == "" {
+		return title
+	}
+	return parent + " / " + title
+}
+
+// customAction is a hand-added action with the title path of the list holding
+// it and the title of the action it follows ("" when it comes first).
+type customAction struct {
+	container string
+	after     string
+	action    ActionData
+}
+
+func customActions(actions []ActionData, container string) []customAction {
+	var found []customAction
+	for i, action := range actions {
+		if strings.HasPrefix(action.Title, customActionPrefix) {
+			after := ""
+			if i > 0 {
+				after = actions[i-1].Title
+			}
+			found = append(found, customAction{container: container, after: after, action: action})
+			continue
+		}
+		path := joinTitlePath(container, action.Title)
+		for _, block := range action.Blocks {
+			found = append(found, customActions(block.Actions, joinTitlePath(path, block.Title))...)
+		}
+		found = append(found, customActions(action.Actions, path)...)
+	}
+	return found
+}
+
+// insertCustomAction puts a custom action back after the action it followed,
+// or at the end of its list (or of the workflow) when that is gone.
+func insertCustomAction(data *WorkflowData, custom customAction) {
+	list := actionList(&data.Actions, "", custom.container)
+	if list == nil {
+		list = &data.Actions
+	}
+	position := len(*list)
+	if custom.after == "" {
+		position = 0
+	}
+	for i, action := range *list {
+		if action.Title == custom.after {
+			position = i + 1
+			break
+		}
+	}
+	*list = append((*list)[:position], append([]ActionData{custom.action}, (*list)[position:]...)...)
+}
+
+// actionList finds the action list with the given title path.
+func actionList(list *[]ActionData, path, want string) *[]ActionData {
+	if path == want {
+		return list
+	}
+	for i := range *list {
+		action := &(*list)[i]
+		actionPath := joinTitlePath(path, action.Title)
+		if !strings.HasPrefix(want, actionPath) {
+			continue
+		}
+		for j := range action.Blocks {
+			if found := actionList(&action.Blocks[j].Actions, joinTitlePath(actionPath, action.Blocks[j].Title), want); found != nil {
+				return found
+			}
+		}
+		if found := actionList(&action.Actions, actionPath, want); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// PostProcessor mutates a rendered workflow (JSON) before it is written.
+type PostProcessor interface {
+	Process(content []byte) ([]byte, error)
+}
+
+// PostProcessorFunc adapts a plain function to the PostProcessor interface.
+type PostProcessorFunc func(content []byte) ([]byte, error)
+
+func (f PostProcessorFunc) Process(content []byte) ([]byte, error) {
+	return f(content)
+}
+
+// commandPostProcessor pipes the workflow JSON through an external command and
+// uses its stdout as the new workflow.
+type commandPostProcessor struct {
+	ctx     context.Context
+	command string
+}
+
+func (p commandPostProcessor) Process(content []byte) ([]byte, error) {
+	args, err := splitCommandLine(p.command)
+	if err != nil {
+		return nil, fmt.Errorf("post-processor %q: %w", p.command, err)
+	}
+	if len(args) == 0 {
+		return content, nil
+	}
+	cmd := exec.CommandContext(p.ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("post-processor %q failed: %v: %s", p.command, err, strings.TrimSpace(stderr.String()))
+	}
+	if !json.Valid(out) {
+		return nil, fmt.Errorf("post-processor %q did not return valid JSON", p.command)
+	}
+	return out, nil
+}
+
+// splitCommandLine splits a post-processor command into its arguments the way a
+// POSIX shell would, without expanding anything: single quotes keep their text
+// as is, double quotes keep it apart from \", \\, \$ and \` escapes, and a
+// backslash outside quotes escapes the next character.
+func splitCommandLine(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			arg.WriteRune(runes[i])
+			inArg = true
+		case r == '\'':
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				arg.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errors.New("unterminated single quote")
+			}
+			inArg = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				arg.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inArg = true
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// postProcessors run on every rendered workflow; RegisterPostProcessor adds to it.
+var postProcessors []PostProcessor
+
+// RegisterPostProcessor adds a post-processor applied to every rendered workflow.
+// Call it from an init function or before generating: renders read the list
+// without locking.
+func RegisterPostProcessor(p PostProcessor) {
+	postProcessors = append(postProcessors, p)
+}
+
+func (s *renderSettings) runPostProcessors(ctx context.Context, content []byte) ([]byte, error) {
+	chain := append([]PostProcessor{}, postProcessors...)
+	for _, command := range s.postProcessCommands {
+		if strings.TrimSpace(command) != "" {
+			chain = append(chain, commandPostProcessor{ctx: ctx, command: command})
+		}
+	}
+	var err error
+	for _, processor := range chain {
+		content, err = processor.Process(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// stringListFlag collects repeated occurrences of a string flag.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// fileModeFlag is a permission flag given in octal.
+type fileModeFlag os.FileMode
+
+func (f *fileModeFlag) String() string {
+	return fmt.Sprintf("%#o", uint32(*f))
+}
+
+func (f *fileModeFlag) Set(value string) error {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("%q is not an octal permission such as 0644", value)
+	}
+	*f = fileModeFlag(mode)
+	return nil
+}
+
+func (s *renderSettings) generateFromConfig(ctx context.Context, openAPISpec OpenAPISpec, configPath, outputDir string) error {
+	cfg, err := loadWorkflowConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Workflows) == 0 && len(cfg.Composites) == 0 && len(cfg.Recipes) == 0 && len(recipeNames) == 0 && len(cfg.Bulk) == 0 && len(bulkConfigs) == 0 {
+		return fmt.Errorf("config %s contains no workflows", configPath)
+	}
+	defaultQueryParams := ensureQueryParamList(cfg.Defaults.QueryParams)
+	workflows := cfg.Workflows
+	if outputDir == "" {
+		outputDir = "outputs"
+	}
+	if err := fsutil.MkdirAll(outputDir); err != nil {
+		return err
+	}
+	importManifest := manifest.NewBuilder()
+	rendered := make(map[string]string)
+	var requiredFields []requiredFieldReport
+	// Fingerprints are taken before rendering narrows shared schemas to the
+	// body_params of an entry.
+	fingerprints := specDiffOperations(openAPISpec)
+	lock, err := lockFor(outputDir)
+	if err != nil {
+		return err
+	}
+	var previousRequiredFields map[string]requiredFieldReport
+	if regenerateChanged {
+		if previousRequiredFields, err = loadRequiredFieldsReport(outputDir); err != nil {
+			return err
+		}
+	}
+	var regenerated, unchanged int
+	// Entries with their own connector render from its -spec; their files are
+	// tracked so two platforms' operations of the same name cannot overwrite
+	// each other.
+	connectorFingerprints := map[string]map[string]specdiff.Operation{"": fingerprints}
+	fileConnectors := make(map[string]string)
+
+	for _, wf := range workflows {
+		wf = applyWorkflowDefaults(cfg.Defaults, wf)
+		entrySpec, entryConnector, foreign, err := s.specForConnector(ctx, openAPISpec, wf.Connector)
+		if err != nil {
+			return fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+		}
+		connectorName := ""
+		if foreign {
+			connectorName = strings.ToLower(strings.TrimSpace(wf.Connector))
+			if _, ok := connectorFingerprints[connectorName]; !ok {
+				connectorFingerprints[connectorName] = specDiffOperations(entrySpec)
+			}
+		}
+		entryOps, err := resolveWorkflowEntry(entrySpec, wf)
+		if err != nil {
+			return err
+		}
+		for _, entryOp := range entryOps {
+			operationId, method := entryOp.OperationId, entryOp.Method
+			variants, err := s.entryVariants(wf, method)
+			if err != nil {
+				return err
+			}
+			key := renderedKey(connectorName, operationId)
+			facts := connectorFingerprints[connectorName][operationId]
+			if wf.RequestSchema != "" || wf.ResponseSchema != "" {
+				if facts, err = pinnedOperationFacts(entrySpec, wf, operationId); err != nil {
+					return fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+				}
+			}
+			fingerprint := specdiff.Fingerprint(facts)
+			for _, variant := range variants {
+				filename := atomicFileName(connectorName, fsutil.SafeFileName(atomicName(wf, operationId, variant))+".json")
+				if other, ok := fileConnectors[filename]; ok && other != connectorName {
+					return fmt.Errorf("endpoint %s: %s is generated for two connectors (%s and %s)", wf.Endpoint, filename, connectorLabel(other), connectorLabel(connectorName))
+				}
+				fileConnectors[filename] = connectorName
+				// Composites and bulk workflows call the full atomic.
+				reusable := wf.WaitFor == nil && variant == variantFull
+				if regenerateChanged {
+					previous, err := unchangedWorkflow(outputDir, filename, fingerprint)
+					if err != nil {
+						return err
+					}
+					if previous != nil {
+						if err := importManifest.AddWorkflow(filename, previous); err != nil {
+							return err
+						}
+						if row, ok := previousRequiredFields[filename]; ok {
+							requiredFields = append(requiredFields, row)
+						}
+						if reusable {
+							rendered[key] = string(previous)
+						}
+						unchanged++
+						continue
+					}
+				}
+
+				queryParams := append(append([]string{}, defaultQueryParams...), wf.QueryParams...)
+				settings := s.withConnector(entryConnector)
+				settings.workflowVariant = variant
+				settings.onRequiredFields = func(row requiredFieldReport) {
+					row.Workflow = filename
+					requiredFields = append(requiredFields, row)
+				}
+				content, err := settings.renderConfiguredOperation(ctx, entrySpec, wf, operationId, method, queryParams)
+				if err != nil {
+					return err
+				}
+				// -merge re-renders with the entry's options, post-processors included.
+				writer, err := settings.withOptions(wf)
+				if err != nil {
+					return err
+				}
+				if err := writer.writeWorkflowFile(ctx, outputDir, filename, []byte(content), importManifest); err != nil {
+					return err
+				}
+				lock.SetSpec(filename, fingerprint)
+				if reusable {
+					rendered[key] = content
+				}
+				regenerated++
+			}
+		}
+	}
+	if regenerateChanged {
+		fmt.Printf("Regenerated %d atomics, kept %d whose spec operation is unchanged\n", regenerated, unchanged)
+	}
+
+	recipes, err := selectRecipes(cfg.Composites, append(append([]string{}, cfg.Recipes...), recipeNames...))
+	if err != nil {
+		return err
+	}
+	if err := s.generateComposites(ctx, openAPISpec, recipes, outputDir, rendered, importManifest); err != nil {
+		return err
+	}
+	if err := s.generateBulkWorkflows(ctx, openAPISpec, append(append([]bulk.Config{}, cfg.Bulk...), bulkConfigs...), outputDir, rendered, importManifest); err != nil {
+		return err
+	}
+	if err := writeTriggers(cfg.Triggers, outputDir, importManifest); err != nil {
+		return err
+	}
+	if err := writeRequiredFieldsReport(outputDir, requiredFields); err != nil {
+		return err
+	}
+
+	return writeImportManifest(outputDir, importManifest.Build())
+}
+
+// unchangedWorkflow returns the content of filename in outputDir if the
+// lockfile records it as rendered from a spec operation with fingerprint, and
+// nil if it has to be rendered again.
+func unchangedWorkflow(outputDir, filename, fingerprint string) ([]byte, error) {
+	lock, err := lockFor(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if lock.Spec(filename) != fingerprint {
+		return nil, nil
+	}
+	existing, err := fsutil.ReadFile(filepath.Join(outputDir, filename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(existing, "\n"), nil
+}
+
+// writeTriggers writes each trigger definition next to the workflow it starts,
+// which must exist in outputDir by then (generated in this run or earlier). A
+// trigger file written before keeps its unique name.
+func writeTriggers(triggers []trigger.Config, outputDir string, importManifest *manifest.Builder) error {
+	for _, t := range triggers {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		workflowFile := findWorkflowFile(outputDir, fsutil.SafeFileName(t.Workflow)+".json")
+		workflow, err := fsutil.ReadFile(filepath.Join(outputDir, workflowFile))
+		if err != nil {
+			return fmt.Errorf("trigger %s: %w", t.Name, err)
+		}
+		filename := path.Join(path.Dir(workflowFile), trigger.FileName(t.Name))
+		uniqueName := "trigger_" + KSUIDGenerator()
+		if previous, err := fsutil.ReadFile(filepath.Join(outputDir, filename)); err == nil {
+			if name := trigger.UniqueName(previous); name != "" {
+				uniqueName = name
+			}
+		}
+		content, warnings, err := trigger.Build(t, workflow, uniqueName)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			log.Printf("Warning: %s", warning)
+		}
+		if err := fsutil.WriteFile(filepath.Join(outputDir, filename), append(content, '\n')); err != nil {
+			return err
+		}
+		var bound struct {
+			Workflow struct {
+				UniqueName string `json:"unique_name"`
+			} `json:"workflow"`
+		}
+		_ = json.Unmarshal(workflow, &bound)
+		importManifest.AddTrigger(filename, uniqueName, t.Name, bound.Workflow.UniqueName)
+	}
+	return nil
+}
+
+// findWorkflowFile returns where filename is in outputDir: at its root, else
+// in the first connector subdirectory holding it. A missing file is reported
+// at the root.
+func findWorkflowFile(outputDir, filename string) string {
+	if _, err := os.Stat(filepath.Join(outputDir, filename)); err == nil {
+		return filename
+	}
+	dirs := make([]string, 0, len(connectorDirs))
+	for _, dir := range connectorDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(outputDir, dir, filename)); err == nil {
+			return path.Join(dir, filename)
+		}
+	}
+	return filename
+}
+
+// selectRecipes combines config-defined composites with the named built-in recipes.
+func selectRecipes(defined []composite.Recipe, names []string) ([]composite.Recipe, error) {
+	recipes := append([]composite.Recipe{}, defined...)
+	for _, name := range names {
+		recipe, ok := composite.Builtin(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown recipe %q (available: %s)", name, strings.Join(composite.BuiltinNames(), ", "))
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+// generateComposites writes each recipe's composite workflow next to the atomics
+// it calls. Atomics not generated earlier in the run are rendered with the run
+// defaults first, since the composite must reference their unique names.
+func (s *renderSettings) generateComposites(ctx context.Context, openAPISpec OpenAPISpec, recipes []composite.Recipe, outputDir string, rendered map[string]string, importManifest *manifest.Builder) error {
+	for _, recipe := range recipes {
+		if err := recipe.Validate(); err != nil {
+			return err
+		}
+		atomics := make(map[string][]byte)
+		for _, step := range recipe.Operations() {
+			operationId := step.Operation
+			stepSpec, stepConnector, foreign, err := s.recipeStepConnector(ctx, openAPISpec, step)
+			if err != nil {
+				return fmt.Errorf("composite %s: %w", recipe.Name, err)
+			}
+			connectorName := ""
+			if foreign {
+				connectorName = strings.ToLower(strings.TrimSpace(step.Connector))
+			}
+			key := renderedKey(connectorName, operationId)
+			content, ok := rendered[key]
+			if !ok {
+				content, err = s.renderWithConnector(ctx, stepSpec, stepConnector, operationId)
+				if err != nil {
+					return fmt.Errorf("composite %s: %w", recipe.Name, err)
+				}
+				filename := atomicFileName(connectorName, fsutil.SafeFileName(operationId)+".json")
+				if err := s.writeWorkflowFile(ctx, outputDir, filename, []byte(content), importManifest); err != nil {
+					return err
+				}
+				rendered[key] = content
+			}
+			atomics[operationId] = []byte(content)
+		}
+		content, err := composite.Build(recipe, atomics, KSUIDGenerator)
+		if err != nil {
+			return err
+		}
+		finished, err := s.finishBuiltWorkflow(ctx, "composite "+recipe.Name, content)
+		if err != nil {
+			return err
+		}
+		filename := fsutil.SafeFileName(recipe.Name) + ".json"
+		if err := s.writeWorkflowFile(ctx, outputDir, filename, finished, importManifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateBulkWorkflows writes each CSV bulk workflow next to the atomics it
+// calls, rendering the atomics not generated earlier in the run with the run
+// defaults first.
+func (s *renderSettings) generateBulkWorkflows(ctx context.Context, openAPISpec OpenAPISpec, configs []bulk.Config, outputDir string, rendered map[string]string, importManifest *manifest.Builder) error {
+	for _, c := range configs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		atomics := make(map[string][]byte)
+		for _, operationId := range c.Operations() {
+			content, ok := rendered[operationId]
+			if !ok {
+				var err error
+				content, err = s.renderWorkflow(ctx, openAPISpec, operationId)
+				if err != nil {
+					return fmt.Errorf("bulk %s: %w", c.FileName(), err)
+				}
+				filename := atomicFileName("", fsutil.SafeFileName(operationId)+".json")
+				if err := s.writeWorkflowFile(ctx, outputDir, filename, []byte(content), importManifest); err != nil {
+					return err
+				}
+				rendered[operationId] = content
+			}
+			atomics[operationId] = []byte(content)
+		}
+		content, err := bulk.Build(c, atomics, KSUIDGenerator)
+		if err != nil {
+			return err
+		}
+		finished, err := s.finishBuiltWorkflow(ctx, "bulk "+c.FileName(), content)
+		if err != nil {
+			return err
+		}
+		filename := fsutil.SafeFileName(c.FileName()) + ".json"
+		if err := s.writeWorkflowFile(ctx, outputDir, filename, finished, importManifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withOptions returns a copy of the settings with a config entry's options
+// applied over them.
+func (s *renderSettings) withOptions(wf WorkflowConfig) (renderSettings, error) {
+	settings := *s
+	if wf.WaitFor != nil {
+		wait, err := normalizeWaitFor(*wf.WaitFor)
+		if err != nil {
+			return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+		}
+		settings.waitForSettings = &wait
+	}
+
+	if wf.Table != nil {
+		table, err := normalizeTable(*wf.Table)
+		if err != nil {
+			return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+		}
+		settings.tableOutput = &table
+	}
+
+	if len(wf.Assert) > 0 {
+		settings.responseAssertions = nil
+		for _, expression := range wf.Assert {
+			assertion, err := parseResponseAssertion(expression)
+			if err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.responseAssertions = append(settings.responseAssertions, assertion)
+		}
+	}
+
+	if strings.TrimSpace(wf.QueryMode) != "" {
+		settings.queryMode = strings.ToLower(strings.TrimSpace(wf.QueryMode))
+		if settings.queryMode != queryModeFields && settings.queryMode != queryModeJSON {
+			return renderSettings{}, fmt.Errorf("unsupported query_mode %q for endpoint %s", wf.QueryMode, wf.Endpoint)
+		}
+	}
+
+	if wf.Options != nil {
+		if wf.Options.SupportIdempotency != nil {
+			settings.supportIdempotency = *wf.Options.SupportIdempotency
+		}
+		if strings.TrimSpace(wf.Options.IdempotencyCondition) != "" {
+			settings.idempotencyCondition = wf.Options.IdempotencyCondition
+		}
+		if strings.TrimSpace(wf.Options.CategoryId) != "" {
+			settings.categoryId = wf.Options.CategoryId
+		}
+		if strings.TrimSpace(wf.Options.CategoryName) != "" {
+			settings.categoryName = wf.Options.CategoryName
+		}
+		if wf.Options.CategoryPath != nil {
+			if _, err := parseCategoryPath(wf.Options.CategoryPath); err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.categoryPath = wf.Options.CategoryPath
+		}
+		if strings.TrimSpace(wf.Options.Platform) != "" {
+			settings.platformName = wf.Options.Platform
+		}
+		if len(wf.Options.PostProcess) > 0 {
+			settings.postProcessCommands = append(append([]string{}, settings.postProcessCommands...), wf.Options.PostProcess...)
+		}
+		if wf.Options.CommaSeparatedParams != nil {
+			settings.commaSeparatedQueryParams = wf.Options.CommaSeparatedParams
+		}
+		if wf.Options.StatusCondition != nil {
+			condition, err := mergeStatusCondition(settings.statusConditionSettings, *wf.Options.StatusCondition)
+			if err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.statusConditionSettings = condition
+		}
+		if wf.Options.Summary != nil {
+			settings.generateSummary = *wf.Options.Summary
+		}
+		if wf.Options.Timeout != nil {
+			if *wf.Options.Timeout <= 0 {
+				return renderSettings{}, fmt.Errorf("endpoint %s: timeout must be positive", wf.Endpoint)
+			}
+			settings.apiRequestTimeout = *wf.Options.Timeout
+		}
+		if wf.Options.MaxBodyInputs != nil {
+			settings.maxBodyInputs = *wf.Options.MaxBodyInputs
+		}
+		if wf.Options.FlattenObjects != nil {
+			if *wf.Options.FlattenObjects < 0 {
+				return renderSettings{}, fmt.Errorf("endpoint %s: flatten_objects must be 0 or more", wf.Endpoint)
+			}
+			settings.flattenObjects = *wf.Options.FlattenObjects
+		}
+		if wf.Options.Scaffold != nil {
+			settings.generateScaffold = *wf.Options.Scaffold
+		}
+		if strings.TrimSpace(wf.Options.NameTemplate) != "" {
+			if _, err := parseNameTemplate(wf.Options.NameTemplate); err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.nameTemplate = wf.Options.NameTemplate
+		}
+		if wf.Options.PrefixTargets != nil {
+			targets, err := parsePrefixTargets(wf.Options.PrefixTargets)
+			if err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.prefixTargets = targets
+		}
+		if wf.Options.FixedOutputs != nil {
+			outputs, err := connector.ParseOutputs(wf.Options.FixedOutputs)
+			if err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.fixedOutputs = outputs
+		}
+		if wf.Options.NormalizeOutputs != nil {
+			settings.normalizeOutputs = *wf.Options.NormalizeOutputs
+		}
+		if wf.Options.LocalVariables != nil {
+			settings.localVariables = *wf.Options.LocalVariables
+		}
+		if wf.Options.IDsAsStrings != nil {
+			settings.idsAsStrings = *wf.Options.IDsAsStrings
+		}
+		if wf.Options.IncludeRequired != nil {
+			settings.alwaysIncludeRequired = *wf.Options.IncludeRequired
+		}
+		if wf.Options.InputSections != nil {
+			for _, section := range wf.Options.InputSections {
+				if strings.TrimSpace(section.Name) == "" {
+					return renderSettings{}, fmt.Errorf("endpoint %s: input_sections: every section needs a name", wf.Endpoint)
+				}
+			}
+			settings.inputSections = wf.Options.InputSections
+		}
+		if wf.Options.HideOptionalInputs != nil {
+			settings.hideOptionalInputs = *wf.Options.HideOptionalInputs
+		}
+		if wf.Options.ObjectURLInput != nil {
+			settings.objectURLInput = *wf.Options.ObjectURLInput
+		}
+		if wf.Options.ResponseExamples != nil {
+			settings.responseExamples = *wf.Options.ResponseExamples
+		}
+		if wf.Options.SensitiveFields != nil {
+			settings.sensitiveFields = wf.Options.SensitiveFields
+		}
+		if wf.Options.DateFormat != nil {
+			if format := strings.TrimSpace(wf.Options.DateFormat.Default); format != "" {
+				settings.dateFormat = format
+			}
+			if wf.Options.DateFormat.Fields != nil {
+				settings.dateFormatFields = wf.Options.DateFormat.Fields
+			}
+		}
+		if wf.Options.Approval != nil {
+			approval, err := normalizeApproval(*wf.Options.Approval)
+			if err != nil {
+				return renderSettings{}, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+			}
+			settings.approvalSettings = &approval
+		}
+	}
+	return settings, nil
+}
+
+// entryOperation is one operation a workflow config entry generates.
+type entryOperation struct {
+	Method      string
+	OperationId string
+}
+
+// resolveWorkflowEntry finds the operations a config entry generates: the listed
+// methods of its endpoint, or every method the endpoint has.
+func resolveWorkflowEntry(openAPISpec OpenAPISpec, wf WorkflowConfig) ([]entryOperation, error) {
+	if strings.TrimSpace(wf.Endpoint) == "" {
+		return nil, fmt.Errorf("workflow entry missing endpoint")
+	}
+	normalizedPath := normalizeEndpointPath(wf.Endpoint)
+	if normalizedPath == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", wf.Endpoint)
+	}
+	pathKey, pathItem, err := findPathItem(openAPISpec, normalizedPath)
+	if err != nil {
+		// Specs other than NetBox's have no /api/ prefix: try the path as written.
+		written := "/" + strings.TrimPrefix(strings.TrimSpace(wf.Endpoint), "/")
+		if key, item, writtenErr := findPathItem(openAPISpec, written); writtenErr == nil {
+			pathKey, pathItem, err = key, item, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ops := availableOperations(pathItem)
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operations found for endpoint %s", pathKey)
+	}
+
+	methods := make([]string, 0)
+	if len(wf.Methods) == 0 {
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+	} else {
+		for _, method := range wf.Methods {
+			method = strings.ToUpper(strings.TrimSpace(method))
+			if method == "" {
+				continue
+			}
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no valid methods specified for endpoint %s", wf.Endpoint)
+	}
+
+	var resolved []entryOperation
+	for _, method := range methods {
+		op := ops[method]
+		if op == nil {
+			return nil, generator.NewError(generator.ErrOperationNotFound, "", fmt.Errorf("method %s not available for endpoint %s", method, pathKey))
+		}
+		operationId := op.OperationId
+		if operationId == "" {
+			return nil, fmt.Errorf("operation id missing for %s %s", method, pathKey)
+		}
+		resolved = append(resolved, entryOperation{Method: method, OperationId: operationId})
+	}
+	return resolved, nil
+}
+
+// renderConfiguredOperation renders operationId with a config entry's filters and
+// options applied over the settings.
+func (s *renderSettings) renderConfiguredOperation(ctx context.Context, openAPISpec OpenAPISpec, wf WorkflowConfig, operationId, method string, queryParams []string) (string, error) {
+	settings, err := s.withOptions(wf)
+	if err != nil {
+		return "", err
+	}
+	settings = settings.withOperationFilters(operationId, method, queryParams, wf.BodyParams)
+	if name := strings.TrimSpace(wf.RequestSchema); name != "" && (method == "POST" || method == "PUT" || method == "PATCH") {
+		settings.requestSchemaName = name
+	}
+	if name := strings.TrimSpace(wf.ResponseSchema); name != "" {
+		settings.responseSchemaName = name
+	}
+	return settings.renderWorkflow(ctx, openAPISpec, operationId)
+}
+
+// withOperationFilters returns a copy of the settings narrowing the query
+// params (GET) and body properties (POST/PUT/PATCH) generated for operationId.
+// A nil queryParams keeps the current query filter; an empty one still exposes
+// the q search param like a config entry without query_params.
+func (s *renderSettings) withOperationFilters(operationId, method string, queryParams, bodyParams []string) renderSettings {
+	settings := *s
+	if strings.EqualFold(method, "GET") && queryParams != nil {
+		if params := ensureQueryParamList(queryParams); len(params) > 0 {
+			filter := maps.Clone(settings.queryParamFilter)
+			if filter == nil {
+				filter = make(map[string][]string)
+			}
+			filter[operationId] = params
+			settings.queryParamFilter = filter
+		}
+	}
+	if method == "POST" || method == "PUT" || method == "PATCH" {
+		if params := ensureBodyParamList(bodyParams); len(params) > 0 {
+			settings.setBodyParamFilter(operationId, params)
+		}
+	}
+	return settings
+}
+
+// writeWorkflowFile writes a generated workflow into outputDir, records it in the
+// import manifest and, with -mermaid, writes its flowchart alongside. With
+// -merge, an existing file's IDs and hand edits are merged in first.
+func (s *renderSettings) writeWorkflowFile(ctx context.Context, outputDir, filename string, content []byte, importManifest *manifest.Builder) error {
+	lock, err := lockFor(outputDir)
+	if err != nil {
+		return err
+	}
+	existing, err := fsutil.ReadFile(filepath.Join(outputDir, filename))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	base, hasBase := lock.Base(filename)
+	switch {
+	case existing == nil:
+	case mergeEdits:
+		if hasBase {
+			if err := reportThreeWay(filename, base, existing, content); err != nil {
+				return err
+			}
+		}
+		merged, err := s.mergeWorkflow(ctx, existing, content, base, strings.TrimSuffix(filename, filepath.Ext(filename)))
+		if err != nil {
+			return fmt.Errorf("merging %s: %w", filename, err)
+		}
+		content = []byte(merged)
+	case hasBase && !lock.Unchanged(filename, existing):
+		if changes, err := workflowdiff.Compare(base, existing); err == nil && len(changes) > 0 {
+			log.Printf("Warning: overwriting %d hand edits in %s (use -merge to keep them)", len(changes), filepath.Join(outputDir, filename))
+		}
+	}
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := fsutil.MkdirAll(filepath.Join(outputDir, dir)); err != nil {
+			return err
+		}
+	}
+	written := append(content[:len(content):len(content)], '\n')
+	if err := fsutil.WriteFile(filepath.Join(outputDir, filename), written); err != nil {
+		return err
+	}
+	if err := lock.Set(filename, written); err != nil {
+		return err
+	}
+	if err := importManifest.AddWorkflow(filename, content); err != nil {
+		return err
+	}
+	if !emitMermaid {
+		return nil
+	}
+	outline, err := explain.Parse(content, s.currentConnector.ActionType)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	chart := strings.TrimSuffix(filename, filepath.Ext(filename)) + explain.MermaidExtension
+	return fsutil.WriteFile(filepath.Join(outputDir, chart), []byte(outline.Mermaid()))
+}
+
+// generatedLocks holds the lockfile of every output directory written during
+// the run; writeImportManifest saves it.
+var generatedLocks = make(map[string]*lockfile.Lockfile)
+var generatedLocksMu sync.Mutex
+
+func lockFor(outputDir string) (*lockfile.Lockfile, error) {
+	generatedLocksMu.Lock()
+	defer generatedLocksMu.Unlock()
+	if lock, ok := generatedLocks[outputDir]; ok {
+		return lock, nil
+	}
+	lock, err := lockfile.Load(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	generatedLocks[outputDir] = lock
+	return lock, nil
+}
+
+// reportThreeWay prints what the user changed in file since it was generated
+// and where a new render conflicts with those edits.
+func reportThreeWay(file string, base, current, next []byte) error {
+	result, err := workflowdiff.CompareThreeWay(base, current, next)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if len(result.User) == 0 {
+		return nil
+	}
+	fmt.Printf("%s: %d hand edits, %d generator changes\n", file, len(result.User), len(result.Generator))
+	for _, change := range result.User {
+		fmt.Printf("  edited %s\n", change)
+	}
+	for _, change := range result.Conflicts {
+		fmt.Printf("  conflict %s\n", change)
+	}
+	return nil
+}
+
+// writeImportManifest records the dependency-ordered import sequence next to the
+// generated workflows, and saves the directory's lockfile.
+func writeImportManifest(outputDir string, m manifest.Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(filepath.Join(outputDir, manifest.FileName), append(data, '\n')); err != nil {
+		return err
+	}
+	generatedLocksMu.Lock()
+	lock, ok := generatedLocks[outputDir]
+	generatedLocksMu.Unlock()
+	if ok {
+		return lock.Save(outputDir)
+	}
+	return nil
+}
+
+func normalizeEndpointPath(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return ""
+	}
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+	if !strings.HasPrefix(endpoint, "/api/") {
+		if strings.HasPrefix(endpoint, "/api") {
+			endpoint = "/api" + strings.TrimPrefix(endpoint, "/api")
+		} else {
+			endpoint = "/api" + endpoint
+		}
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint = endpoint + "/"
+	}
+	return endpoint
+}
+
+func findPathItem(openAPISpec OpenAPISpec, endpoint string) (string, PathItem, error) {
+	candidates := []string{endpoint}
+	if strings.HasSuffix(endpoint, "/") {
+		candidates = append(candidates, strings.TrimSuffix(endpoint, "/"))
+	} else {
+		candidates = append(candidates, endpoint+"/")
+	}
+	for _, candidate := range candidates {
+		if item, ok := openAPISpec.Paths[candidate]; ok {
+			return candidate, item, nil
+		}
+	}
+	return "", PathItem{}, generator.NewError(generator.ErrOperationNotFound, "", fmt.Errorf("path %s is not in the OpenAPI spec", endpoint))
+}
+
+func availableOperations(item PathItem) map[string]*Operation {
+	result := make(map[string]*Operation)
+	if item.Get != nil {
+		result["GET"] = item.Get
+	}
+	if item.Post != nil {
+		result["POST"] = item.Post
+	}
+	if item.Put != nil {
+		result["PUT"] = item.Put
+	}
+	if item.Patch != nil {
+		result["PATCH"] = item.Patch
+	}
+	if item.Delete != nil {
+		result["DELETE"] = item.Delete
+	}
+	return result
+}
+
+func ensureQueryParamList(list []string) []string {
+	set := make(map[string]struct{})
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		set[v] = struct{}{}
+	}
+	if _, ok := set["q"]; !ok {
+		set["q"] = struct{}{}
+	}
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func ensureBodyParamList(list []string) []string {
+	set := make(map[string]struct{})
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		set[v] = struct{}{}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// successResponse returns the response a generated workflow checks for: the
+// lowest 2xx code, else "default", else the lowest code.
+func successResponse(responses map[string]Response) (string, Response, bool) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return "", Response{}, false
+	}
+	sort.Strings(codes)
+	code := codes[0]
+	if _, ok := responses["default"]; ok {
+		code = "default"
+	}
+	for _, candidate := range codes {
+		if strings.HasPrefix(candidate, "2") {
+			code = candidate
+			break
+		}
+	}
+	return code, responses[code], true
+}
+
+// unwrapResponseEnvelope lifts the payload out of a response wrapped in the
+// connector's envelope property, as vManage's {"header": ..., "data": [...]} or
+// APIC's {"totalCount": ..., "imdata": [...]}. A list payload keeps its own
+// array output under the envelope name and adds the fields of its first record;
+// an object payload contributes its fields. Scalar siblings of the envelope
+// such as totalCount stay outputs. With managedObjects, a record of the form
+// {"<class>": {"attributes": {...}}} contributes its attributes. paths maps each
+// output property to its JSONPath below $, and isList reports a list payload.
+// Responses without the envelope are returned as they are.
+func unwrapResponseEnvelope(schema Schema, envelope string, managedObjects bool) (unwrapped Schema, paths map[string]string, isList bool) {
+	payload, ok := schema.Properties[envelope]
+	if envelope == "" || !ok {
+		return schema, nil, false
+	}
+	unwrapped = Schema{Type: "object", Description: schema.Description, Properties: make(map[string]Schema)}
+	paths = make(map[string]string)
+	for _, name := range sortedSchemaKeys(schema.Properties) {
+		if sibling := schema.Properties[name]; name != envelope && sibling.Type != "object" && sibling.Type != "array" && len(sibling.Properties) == 0 {
+			unwrapped.Properties[name] = sibling
+			paths[name] = name
+		}
+	}
+	record, prefix := payload, envelope
+	isList = payload.Type == "array"
+	if isList || len(payload.Properties) == 0 {
+		if isList && payload.Description == "" {
+			payload.Description = "All returned records."
+		}
+		unwrapped.Properties[envelope] = payload
+		paths[envelope] = envelope
+	}
+	if isList {
+		if payload.Items == nil {
+			return unwrapped, paths, true
+		}
+		record, prefix = *payload.Items, envelope+"[0]"
+	}
+	if class, attributes, ok := managedObjectAttributes(record); ok && managedObjects {
+		record, prefix = attributes, prefix+"."+class+".attributes"
+	}
+	for _, name := range sortedSchemaKeys(record.Properties) {
+		if _, taken := unwrapped.Properties[name]; taken {
+			continue
+		}
+		property := record.Properties[name]
+		if isList {
+			property.Description = appendSentence(property.Description, "Taken from the first record.")
+		}
+		unwrapped.Properties[name] = property
+		paths[name] = prefix + "." + name
+	}
+	return unwrapped, paths, isList
+}
+
+// managedObjectAttributes returns the class and attributes schema of an APIC
+// managed object record, {"<class>": {"attributes": {...}}}.
+func managedObjectAttributes(record Schema) (class string, attributes Schema, ok bool) {
+	if len(record.Properties) != 1 {
+		return "", Schema{}, false
+	}
+	for class, object := range record.Properties {
+		attributes = object.Properties["attributes"]
+		return class, attributes, len(attributes.Properties) > 0
+	}
+	return "", Schema{}, false
+}
+
+// isPaginatedListSchema reports whether a response schema has the shape of a
+// NetBox Paginated*List envelope (count plus a results array).
+func isPaginatedListSchema(schema Schema) bool {
+	if schema.Type != "object" || len(schema.Properties) == 0 {
+		return false
+	}
+	count, hasCount := schema.Properties["count"]
+	results, hasResults := schema.Properties["results"]
+	if !hasCount || !hasResults {
+		return false
+	}
+	if count.Type != "integer" && count.Type != "number" {
+		return false
+	}
+	return results.Type == "array"
+}
+
+func ensureNetboxPagination(schema *Schema) {
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]Schema)
+	}
+	if schema.Type == "" {
+		schema.Type = "object"
+	}
+	for key, def := range netboxPaginationSchema {
+		if _, exists := schema.Properties[key]; !exists {
+			schema.Properties[key] = def
+		}
+	}
+}
+
+func (s *renderSettings) appendRequestBodyObjectVariables(variables []VariableData, schema Schema) []VariableData {
+	if len(schema.Properties) == 0 {
+		return variables
+	}
+	propKeys := sortedSchemaKeys(schema.Properties)
+	for _, propName := range propKeys {
+		propSchema := schema.Properties[propName]
+		isRequired := contains(schema.Required, propName)
+		if fields, ok := s.flattenedObjectFields(propSchema); ok {
+			variables = append(variables, s.flattenedFieldVariables(propName, propSchema, fields, isRequired)...)
+			continue
+		}
+		variable := s.buildRequestBodyVariable(propName, propSchema, isRequired)
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// flattenedFieldVariables returns the inputs of an object flattened by
+// -flattenObjects, "Input - <Object> - <Field>"; a field is required when both
+// it and the object are.
+func (s *renderSettings) flattenedFieldVariables(propName string, propSchema Schema, fields []string, isRequired bool) []VariableData {
+	variables := make([]VariableData, 0, len(fields))
+	for _, field := range fields {
+		label := HumanReadableName(propName) + " - " + HumanReadableName(field)
+		required := isRequired && contains(propSchema.Required, field)
+		variables = append(variables, s.bodyInputVariable(flattenedFieldName(propName, field), label, field, propSchema.Properties[field], required))
+	}
+	return variables
+}
+
+func (s *renderSettings) buildRequestBodyVariable(propName string, propSchema Schema, isRequired bool) VariableData {
+	return s.bodyInputVariable(propName, HumanReadableName(propName), propName, propSchema, isRequired)
+}
+
+// bodyInputVariable builds the input of a body field: placeholder names the
+// variable, label its "Input - " title and propName is the field's own name,
+// which ID and sensitivity checks look at.
+func (s *renderSettings) bodyInputVariable(placeholder, label, propName string, propSchema Schema, isRequired bool) VariableData {
+	name := "Input - " + label
+	descriptionPostFix := ""
+	if propSchema.Type == "string" && len(propSchema.Enum) > 0 {
+		enumValues := make([]string, len(propSchema.Enum))
+		for i, val := range propSchema.Enum {
+			enumValues[i] = fmt.Sprint(val)
+		}
+		descriptionPostFix = " Valid options are: " + strings.Join(enumValues, ", ") + "."
+	}
+
+	schemaId := "datatype.string"
+	varType := "datatype.string"
+	varValue := interface{}("")
+	variableStringFormat := "text"
+
+	originalType := propSchema.Type
+	switch propSchema.Type {
+	case "integer", "number":
+		schemaId = "datatype.integer"
+		varType = "datatype.integer"
+		varValue = 0
+	case "boolean":
+		schemaId = "datatype.boolean"
+		varType = "datatype.boolean"
+		varValue = false
+	case "array":
+		schemaId = "datatype.string"
+		varType = "datatype.string"
+		varValue = []interface{}{}
+		variableStringFormat = "json"
+	case "object":
+		schemaId = "datatype.string"
+		varType = "datatype.string"
+		varValue = map[string]interface{}{}
+		variableStringFormat = "json"
+	}
+	if s.stringifyBodyInputs && (originalType == "integer" || originalType == "number" || originalType == "boolean") {
+		schemaId = "datatype.string"
+		varType = "datatype.string"
+		varValue = ""
+		variableStringFormat = "text"
+	}
+	if s.isStringID(propName, propSchema.Type) {
+		schemaId = "datatype.string"
+		varType = "datatype.string"
+		varValue = ""
+		variableStringFormat = "text"
+	}
+	if varType == "datatype.string" && variableStringFormat == "text" && s.isSensitive(propName, propSchema) {
+		schemaId = "datatype.secure_string"
+		varType = "datatype.secure_string"
+	}
+
+	description := propSchema.Description + descriptionPostFix
+	if s.isStringID(propName, propSchema.Type) {
+		description = appendSentence(description, stringIDHint)
+	}
+	if originalType == "boolean" && varType == "datatype.string" {
+		description = appendSentence(description, booleanTextHint)
+	}
+	if isMapSchema(propSchema) {
+		description = appendSentence(description, mapValueHint(propSchema))
+	}
+	if propSchema.Truncated != "" {
+		name += " (JSON)"
+		description = appendSentence(description, truncatedSchemaHint)
+		s.truncatedVariables = append(s.truncatedVariables, propSchema.Truncated)
+	}
+
+	return VariableData{
+		SchemaID: schemaId,
+		Properties: VariableProperties{
+			Scope:                "input",
+			Name:                 name,
+			Type:                 varType,
+			Description:          description,
+			IsRequired:           isRequired,
+			Value:                varValue,
+			VariableStringFormat: variableStringFormat,
+			DisplayOnWizard:      true,
+			IsInvisible:          false,
+		},
+		UniqueName: s.variableUniqueName(placeholderKindBody, placeholder),
+		ObjectType: "variable_workflow",
+	}
+}
+
+// stringIDHint ends the description of ID inputs taken as text.
+const stringIDHint = "Numeric ID, entered as text so large values keep their precision."
+
+// isStringID reports whether a body field is an integer ID that -idsAsStrings
+// takes as a text input: id or a name ending in _id.
+func (s *renderSettings) isStringID(name, schemaType string) bool {
+	if !s.idsAsStrings || schemaType != "integer" {
+		return false
+	}
+	name = strings.ToLower(name)
+	return name == "id" || strings.HasSuffix(name, "_id")
+}
+
+func schemaHasRequestBody(schema Schema) bool {
+	object := bodyObjectSchema(schema)
+	return object != nil && (len(object.Properties) > 0 || isMapSchema(*object))
+}
+
+// GenerateWorkflowData generates the workflow data structure from the OpenAPI spec operation.
+// isParameterlessOperation reports whether an operation takes no path or query
+// parameters and no request body.
+func isParameterlessOperation(operation *Operation) bool {
+	for _, param := range operation.Parameters {
+		if param.In == "path" || param.In == "query" {
+			return false
+		}
+	}
+	return !schemaHasRequestBody(operation.RequestBody.Content.ApplicationJSON.Schema)
+}
+
+func (s *renderSettings) GenerateWorkflowData(operation *Operation, path string, method string) WorkflowData {
+	var variables []VariableData
+	var actions []ActionData
+	var outputVariables []VariableData
+	operationDisplayName := buildOperationDisplayName(operation.OperationId, path, method)
+	if strings.TrimSpace(operationDisplayName) == "" {
+		operationDisplayName = HumanReadableName(operation.OperationId)
+	}
+
+	var queryParams []Parameter
+	var rangeChecks []rangeCheck
+	allowedQuerySet := s.getQueryParamAllowSet(operation.OperationId)
+	objectParam, hasObjectURL := s.objectURLParam(operation, path, method)
+
+	// Add parameters as input variables (path and query)
+	for _, param := range operation.Parameters {
+		// Only handle path and query parameters
+		if param.In != "path" && param.In != "query" {
+			continue
+		}
+
+		if param.In == "query" && allowedQuerySet != nil {
+			if _, ok := allowedQuerySet[param.Name]; !ok {
+				continue
+			}
+		}
+		if param.In == "query" && s.queryMode == queryModeJSON {
+			// Collected into the single "Filters (JSON)" input below
+			queryParams = append(queryParams, param)
+			continue
+		}
+
+		isRequired := param.Required
+		displayOnWizard := true
+		var displayNamePrefix string
+		if param.In == "path" {
+			// Path parameters are always required
+			isRequired = true
+			// Historically hidden in wizard; keep behavior
+			displayOnWizard = false
+			displayNamePrefix = "Input - "
+		} else {
+			// Query parameters visible and prefixed with "Query - "
+			displayNamePrefix = "Query - "
+		}
+
+		name := displayNamePrefix + HumanReadableName(param.Name)
+
+		variable := VariableData{
+			SchemaID: "datatype." + param.Schema.Type,
+			Properties: VariableProperties{
+				Value:           "",
+				Scope:           "input",
+				Name:            name,
+				Type:            "datatype." + param.Schema.Type,
+				Description:     param.Description,
+				IsRequired:      isRequired,
+				DisplayOnWizard: displayOnWizard,
+				IsInvisible:     false,
+			},
+			UniqueName: s.variableUniqueName(placeholderKindParam, param.Name),
+			ObjectType: "variable_workflow",
+		}
+
+		switch param.Schema.Type {
+		case "string":
+			variable.Properties.Value = ""
+			variable.Properties.VariableStringFormat = "text"
+		case "integer", "number":
+			variable.SchemaID = "datatype.integer"
+			variable.Properties.Type = "datatype.integer"
+			variable.Properties.Value = 0
+			variable.Properties.VariableStringFormat = ""
+		case "boolean":
+			variable.SchemaID = "datatype.boolean"
+			variable.Properties.Type = "datatype.boolean"
+			variable.Properties.Value = false
+			variable.Properties.VariableStringFormat = ""
+		case "array":
+			variable.SchemaID = arrayVariableSchemaID
+			variable.Properties.Type = "datatype.array"
+			variable.Properties.Value = []interface{}{}
+			variable.Properties.VariableStringFormat = "json"
+		case "object":
+			variable.Properties.Type = "datatype.string"
+			variable.Properties.Value = map[string]interface{}{}
+			variable.Properties.VariableStringFormat = "json"
+		}
+		// For path/query params, default to string presentation to simplify UI and avoid numeric quoting issues.
+		// Array query params keep their array type when the prep script can serialize them.
+		keepArray := param.In == "query" && param.Schema.Type == "array" && s.connectorUsesQueryPrep(method)
+		if keepArray && s.isCommaSeparatedParam(param) {
+			variable.SchemaID = "datatype.string"
+			variable.Properties.Type = "datatype.string"
+			variable.Properties.Value = ""
+			variable.Properties.VariableStringFormat = "text"
+			hint := fmt.Sprintf("Comma-separated list (e.g. a,b,c); each value is sent as a separate %s= query parameter.", param.Name)
+			if !queryParamExplode(param) {
+				hint = fmt.Sprintf("Comma-separated list (e.g. a,b,c), sent as one %s= value.", param.Name)
+			}
+			variable.Properties.Description = appendSentence(variable.Properties.Description, hint)
+		} else if keepArray {
+			variable.Properties.Description = appendSentence(variable.Properties.Description, arrayQueryParamHint(param))
+		} else if isDeepObjectParam(param) {
+			variable.SchemaID = "datatype.string"
+			variable.Properties.Type = "datatype.string"
+			variable.Properties.Value = map[string]interface{}{}
+			variable.Properties.VariableStringFormat = "json"
+			variable.Properties.Description = appendSentence(variable.Properties.Description,
+				fmt.Sprintf("JSON object; each key is sent as %s[key]=value.", param.Name))
+		} else if param.In == "path" || param.In == "query" {
+			variable.SchemaID = "datatype.string"
+			variable.Properties.Type = "datatype.string"
+			variable.Properties.Value = ""
+			variable.Properties.VariableStringFormat = "text"
+			if param.In == "query" && param.Schema.Type == "boolean" && s.connectorUsesQueryPrep(method) {
+				variable.Properties.Description = appendSentence(variable.Properties.Description, booleanTextHint)
+			}
+		}
+		if variable.Properties.Type == "datatype.string" && variable.Properties.VariableStringFormat == "text" && s.isSensitive(param.Name, param.Schema) {
+			variable.SchemaID = "datatype.secure_string"
+			variable.Properties.Type = "datatype.secure_string"
+		}
+		if hasObjectURL && param.In == "path" && param.Name == objectParam.Name {
+			variable.Properties.IsRequired = false
+			variable.Properties.Description = appendSentence(variable.Properties.Description, "Not needed when Input - Object URL is set.")
+		}
+		if value, ok := s.currentConnector.PathDefaults[param.Name]; ok && param.In == "path" {
+			variable.Properties.Value = value
+			variable.Properties.Description = appendSentence(variable.Properties.Description, fmt.Sprintf("Defaults to %s.", value))
+		}
+		if hint, ok := s.currentConnector.QueryParamHints[param.Name]; ok && param.In == "query" {
+			variable.Properties.Description = appendSentence(variable.Properties.Description, hint)
+		}
+
+		variables = append(variables, variable)
+		if check, ok := newRangeCheck(name, s.inputVariableRef(placeholderKindParam, param.Name), param.Schema); ok {
+			rangeChecks = append(rangeChecks, check)
+		}
+
+		if param.In == "query" {
+			queryParams = append(queryParams, param)
+		}
+	}
+	if s.queryMode == queryModeJSON && len(queryParams) > 0 {
+		variables = append(variables, s.buildQueryFiltersVariable(queryParams))
+	}
+	if hasObjectURL {
+		variables = append(variables, s.buildObjectURLVariable(path, objectParam))
+	}
+
+	IdempotencyInputName := "Input - Ignore If Exists"
+	if method == "DELETE" || method == "GET" || method == "PUT" {
+		IdempotencyInputName = "Input - Ignore If Does Not Exists"
+	}
+
+	IdempotancyInput := VariableData{
+		SchemaID: "datatype.boolean",
+		Properties: VariableProperties{
+			Value:           false,
+			Scope:           "input",
+			Name:            IdempotencyInputName,
+			Type:            "datatype.boolean",
+			Description:     "When enabled, the workflow will be marked as successful if the same operation has been completed before.",
+			IsRequired:      false,
+			DisplayOnWizard: false,
+			IsInvisible:     false,
+		},
+		UniqueName: "variable_workflow_$ignoreIfExistKSUID",
+		ObjectType: "variable_workflow",
+	}
+	// Parameterless operations get the minimal request/condition/outputs shape: there
+	// is no object whose (non-)existence an idempotency switch could refer to.
+	idempotent := s.supportIdempotency && !isParameterlessOperation(operation)
+	if idempotent {
+		variables = append(variables, IdempotancyInput)
+	}
+
+	bodySchema, additionalFields := s.limitBodyInputs(operation.RequestBody.Content.ApplicationJSON.Schema, method)
+	if object := bodyObjectSchema(bodySchema); object != nil && len(additionalFields) == 0 && len(object.Required) == 0 && len(object.Properties) >= broadBodyPropertyThreshold {
+		log.Printf("Warning: %s: request body has %d properties and none are required; consider a body_params filter or max_body_inputs to limit the generated inputs", operation.OperationId, len(object.Properties))
+	}
+	switch bodySchema.Type {
+	case "object":
+		variables = s.appendRequestBodyObjectVariables(variables, bodySchema)
+	case "array":
+		if bodySchema.Items != nil && bodySchema.Items.Type == "object" {
+			variables = s.appendRequestBodyObjectVariables(variables, *bodySchema.Items)
+		}
+	}
+	if object := bodyObjectSchema(bodySchema); object != nil && isMapSchema(*object) {
+		variables = append(variables, s.mapBodyVariable(*object))
+	}
+	if len(additionalFields) > 0 {
+		variables = append(variables, s.additionalFieldsVariable(additionalFields))
+	}
+	if object := bodyObjectSchema(bodySchema); object != nil {
+		for _, propName := range sortedSchemaKeys(object.Properties) {
+			propSchema := object.Properties[propName]
+			if fields, ok := s.flattenedObjectFields(propSchema); ok {
+				for _, field := range fields {
+					label := "Input - " + HumanReadableName(propName) + " - " + HumanReadableName(field)
+					if check, ok := newRangeCheck(label, s.inputVariableRef(placeholderKindBody, flattenedFieldName(propName, field)), propSchema.Properties[field]); ok {
+						rangeChecks = append(rangeChecks, check)
+					}
+				}
+				continue
+			}
+			label := "Input - " + HumanReadableName(propName)
+			if check, ok := newRangeCheck(label, s.inputVariableRef(placeholderKindBody, propName), propSchema); ok {
+				rangeChecks = append(rangeChecks, check)
+			}
+		}
+	}
+
+	// Determine the success response code from the available responses
+	var successCode interface{}
+	var responseSchema Schema
+	var example interface{}
+	hasExample := false
+	if code, response, ok := successResponse(operation.Responses); ok {
+		if numeric, err := strconv.Atoi(code); err == nil {
+			successCode = numeric
+		} else {
+			successCode = code
+		}
+		responseSchema = response.Content.ApplicationJSON.Schema
+		if s.responseExamples {
+			example, hasExample = responseExample(response)
+		}
+	}
+
+	isNetboxList := s.currentConnector.paginatedLists() && strings.EqualFold(method, "GET") && isPaginatedListSchema(responseSchema)
+	// listProperty is the response property holding the returned items.
+	var listProperty string
+	if isNetboxList {
+		ensureNetboxPagination(&responseSchema)
+		listProperty = "results"
+	}
+	responseSchema, queryPaths, isEnvelopeList := unwrapResponseEnvelope(responseSchema, s.currentConnector.ResponseEnvelope, s.currentConnector.ManagedObjects)
+	if isEnvelopeList {
+		listProperty = s.currentConnector.ResponseEnvelope
+	}
+
+	// Add output variables based on the response schema
+	// Skip individual property extraction for POST/PATCH/PUT - just return the full result
+	isCreateOrUpdate := strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT")
+	queryNames := jsonpathQueryNames(responseSchema.Properties)
+	outputQueryNames := make(map[string]string)
+	if responseSchema.Type == "object" && !isCreateOrUpdate {
+		for _, propName := range sortedSchemaKeys(responseSchema.Properties) {
+			propSchema := responseSchema.Properties[propName]
+			name := "Output - " + HumanReadableName(propName)
+			if propSchema.Truncated != "" {
+				s.truncatedVariables = append(s.truncatedVariables, propSchema.Truncated)
+			}
+			var dataType string
+			schemaId := ""
+			if propSchema.Type == "boolean" {
+				dataType = "datatype.boolean"
+			} else if propName == listProperty && propSchema.Type == "array" {
+				// Paginated results and enveloped lists surface as a real array so for-each blocks can iterate them
+				dataType = "datatype.array"
+				schemaId = arrayVariableSchemaID
+			} else {
+				dataType = "datatype.string"
+			}
+			if schemaId == "" {
+				schemaId = dataType
+			}
+			outputVariable := VariableData{
+				SchemaID: schemaId,
+				Properties: VariableProperties{
+					Scope:                "output",
+					Name:                 name,
+					Type:                 dataType,
+					Description:          propSchema.Description,
+					IsRequired:           false,
+					VariableStringFormat: "text", // Adjust as needed based on type
+					DisplayOnWizard:      false,
+					IsInvisible:          false,
+				},
+				UniqueName: s.variableUniqueName(placeholderKindOutput, propName),
+				ObjectType: "variable_workflow",
+			}
+			if sentence := outputExampleSentence(example, propName); sentence != "" {
+				outputVariable.Properties.Description = appendSentence(outputVariable.Properties.Description, sentence)
+			}
+			if propSchema.Type == "boolean" {
+				outputVariable.Properties.Value = false
+			} else if dataType == "datatype.array" {
+				outputVariable.Properties.Value = []interface{}{}
+				outputVariable.Properties.VariableStringFormat = "json"
+			} else {
+				outputVariable.Properties.Value = ""
+			}
+			variables = append(variables, outputVariable)
+			outputVariables = append(outputVariables, outputVariable)
+			outputQueryNames[outputVariable.UniqueName] = queryNames[propName]
+		}
+	}
+
+	// The response body as echoed into outputs and result messages; masked by a
+	// Redact Response step when the operation handles sensitive fields.
+	responseBodyRef := fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", s.currentConnector.ResponseBodyField)
+	var redactAction *ActionData
+	if keys := s.sensitiveKeys(operation.Parameters, bodySchema); len(keys) > 0 {
+		action, reference := buildRedactionAction(keys, responseBodyRef)
+		redactAction, responseBodyRef = &action, reference
+	}
+
+	var tableTypes map[string]TableTypeData
+	var tableAction *ActionData
+	var tableUpdate VariableUpdate
+	if s.tableOutput != nil {
+		if strings.EqualFold(method, "GET") && (listProperty != "" || responseSchema.Type == "array") {
+			tableType, tableVariable, action, update := s.buildTableOutput(path, *s.tableOutput, responseBodyRef, listProperty)
+			tableTypes = map[string]TableTypeData{tableType.UniqueName: tableType}
+			variables = append(variables, tableVariable)
+			tableAction, tableUpdate = &action, update
+		} else {
+			log.Printf("Warning: %s: table output ignored, %s %s does not return a list", operation.OperationId, method, path)
+		}
+	}
+
+	hasRequestBody := schemaHasRequestBody(bodySchema) || len(additionalFields) > 0
+
+	if len(rangeChecks) > 0 {
+		actions = append(actions, buildRangeCheckAction(rangeChecks))
+	}
+	endpointPath := path
+	if hasObjectURL {
+		idLabel := acronymReplacer()("Input - " + HumanReadableName(objectParam.Name))
+		objectPathAction, reference := s.buildObjectPathAction(path, objectParam, idLabel)
+		actions = append(actions, objectPathAction)
+		endpointPath = reference
+	}
+
+	needsQueryPrep := (s.connectorUsesQueryPrep(method) || hasDeepObjectParam(queryParams) || s.queryMode == queryModeJSON) && len(queryParams) > 0
+	var queryReference string
+	if needsQueryPrep && s.queryMode == queryModeJSON {
+		scriptAction, reference := s.buildJSONQueryPrepAction()
+		actions = append(actions, scriptAction)
+		queryReference = reference
+	} else if needsQueryPrep {
+		scriptAction, reference := s.buildQueryPrepAction(queryParams)
+		actions = append(actions, scriptAction)
+		queryReference = reference
+	}
+
+	// Add body preparation for POST/PATCH/PUT in NetBox, and wherever flattened
+	// object fields or the additional fields of the minimal variant have to be
+	// merged
+	needsBodyPrep := (s.connectorUsesBodyPrep(method) || s.hasFlattenedObjects(bodySchema) || len(additionalFields) > 0) && hasRequestBody
+	var bodyReference string
+	if needsBodyPrep {
+		bodyPrepAction, bodyRef := s.buildRequestBodyPrepAction(bodySchema, operation.OperationId, len(additionalFields) > 0)
+		actions = append(actions, bodyPrepAction)
+		bodyReference = bodyRef
+	}
+
+	if s.localVariables {
+		locals := []localValue{
+			{Name: "Local - Query String", Placeholder: "query_string", Reference: &queryReference},
+			{Name: "Local - Request Body", Placeholder: "request_body", Reference: &bodyReference},
+		}
+		if localVars, setLocals := s.materializeLocalVariables(locals); len(localVars) > 0 {
+			variables = append(variables, localVars...)
+			actions = append(actions, setLocals)
+		}
+	}
+
+	activeOutputs := s.activeFixedOutputs(needsQueryPrep)
+	if s.hasSensitiveURLParam(operation.Parameters) {
+		// The request URL would echo the secret; keep it out of the outputs.
+		var kept []string
+		for _, output := range activeOutputs {
+			if output != fixedOutputRequestURL {
+				kept = append(kept, output)
+			}
+		}
+		activeOutputs = kept
+	}
+	workflowDescription := operation.Description
+	if s.generateScaffold {
+		workflowDescription = scaffoldDescription(workflowDescription)
+	}
+	fixedOutputs := fixedOutputVariables(activeOutputs)
+	if hasExample {
+		snippet := exampleSnippet(example, responseExampleMaxLength)
+		workflowDescription = appendSentence(workflowDescription, "Sample response: "+snippet)
+		for i := range fixedOutputs {
+			if fixedOutputs[i].Properties.Name == fixedOutputDefinitions[fixedOutputResponseBody].Name {
+				fixedOutputs[i].Properties.Description = appendSentence(fixedOutputs[i].Properties.Description, "Sample: "+snippet)
+			}
+		}
+	}
+	// Query params dropped by the allow list must not reach the endpoint either;
+	// their input variables do not exist.
+	endpointParams := make([]Parameter, 0, len(operation.Parameters))
+	for _, param := range operation.Parameters {
+		if param.In == "path" {
+			endpointParams = append(endpointParams, param)
+		}
+	}
+	endpointParams = append(endpointParams, queryParams...)
+	endpoint := s.GenerateAPIEndpoint(endpointPath, endpointParams, !needsQueryPrep)
+	if needsQueryPrep && queryReference != "" {
+		if strings.Contains(endpoint, "?") {
+			endpoint = endpoint + "&" + queryReference
+		} else {
+			endpoint = endpoint + "?" + queryReference
+		}
+	}
+
+	apiRequestAction := s.buildAPIRequestAction(operation, endpoint, method, hasRequestBody, operationDisplayName, bodyReference)
+	if s.approvalSettings != nil && needsApproval(method, bodySchema) {
+		actions = append(actions, buildApprovalActions(*s.approvalSettings, strings.ToUpper(method)+" "+endpoint)...)
+	}
+
+	var durationRef string
+	if contains(activeOutputs, fixedOutputDuration) {
+		startTimer, measureDuration, reference := buildDurationActions()
+		actions = append(actions, startTimer, apiRequestAction, measureDuration)
+		durationRef = reference
+	} else {
+		actions = append(actions, apiRequestAction)
+	}
+	if redactAction != nil {
+		actions = append(actions, *redactAction)
+	}
+	responseBodyExpr := responseBodyRef
+	diagnosticUpdates := diagnosticOutputUpdates(activeOutputs, endpoint, responseBodyExpr, durationRef)
+
+	// Find the first API request action unique name
+	var apiRequestActionUniqueName string
+	for _, action := range actions {
+		if action.Type == s.currentConnector.ActionType {
+			apiRequestActionUniqueName = "activity." + action.UniqueName
+			break
+		}
+	}
+
+	ConditionalSuccessBlockJsonPathQueryUniqueName := "definition_activity_" + KSUIDGenerator()
+	statusOperand := fmt.Sprintf("$%s.output.status_code$", apiRequestActionUniqueName)
+	successCondition, failedCondition := s.statusComparison(statusOperand, successCode)
+	successTitle := fmt.Sprintf("%v/Success", successCondition.RightOperand)
+
+	responseBodyPath := fmt.Sprintf("$%s.output.%s$", apiRequestActionUniqueName, s.currentConnector.ResponseBodyField)
+	if redactAction != nil {
+		responseBodyPath = responseBodyRef
+	}
+
+	// Define the Set Variables action for the fixed output. The payload is only
+	// carried by the Set Output Variables step; Completed just reports the result.
+	var setOutputVariablesToUpdateForSuccessBlock []VariableUpdate
+	if contains(activeOutputs, fixedOutputStatusMessage) {
+		setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, VariableUpdate{
+			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$",
+			VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", s.statusMessageField()),
+		})
+	}
+	setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock,
+		VariableUpdate{
+			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$",
+			VariableValueNew: "$activity.definition_activity_$ApiRequestKSUID.output.status_code$",
+		},
+		VariableUpdate{
+			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
+			VariableValueNew: responseBodyExpr,
+		},
+		VariableUpdate{
+			VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+			VariableValueNew: "completed-successfully",
+		},
+	)
+	setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, diagnosticUpdates...)
+	for _, outputVar := range outputVariables {
+		setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, VariableUpdate{
+			VariableToUpdate: fmt.Sprintf("$workflow.%s.output.%s$", "definition_workflow_$WorkflowKSUID", outputVar.UniqueName),
+			VariableValueNew: fmt.Sprintf("$activity.%s.output.jsonpath_queries.%s$", ConditionalSuccessBlockJsonPathQueryUniqueName, outputQueryNames[outputVar.UniqueName]),
+		})
+	}
+	if tableAction != nil {
+		setOutputVariablesToUpdateForSuccessBlock = append(setOutputVariablesToUpdateForSuccessBlock, tableUpdate)
+	}
+	successQueries := s.GenerateJsonpathQueries(responseSchema, method, queryPaths, listProperty)
+	var assertionsPassed Condition
+	var assertionMessage string
+	if len(s.responseAssertions) > 0 {
+		successQueries, assertionsPassed, assertionMessage = s.buildAssertionCheck(successQueries, ConditionalSuccessBlockJsonPathQueryUniqueName)
+	}
+	successActions := []ActionData{
+		{
+			UniqueName: ConditionalSuccessBlockJsonPathQueryUniqueName,
+			Name:       "JSONPath Query",
+			Title:      "Extract API Results",
+			Type:       "corejava.jsonpathquery",
+			BaseType:   "activity",
+			Properties: JsonpathQueryProperties{
+				ActionTimeout:     180,
+				DisplayName:       "Extract API Results",
+				ContinueOnFailure: true,
+				InputJSON:         responseBodyPath,
+				JsonpathQueries:   successQueries,
+				SkipExecution:     false,
+			},
+			ObjectType: "definition_activity",
+		},
+	}
+	if tableAction != nil {
+		successActions = append(successActions, *tableAction)
+	}
+	successActions = append(successActions, ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Set Variables",
+		Title:      "Set Output Variables",
+		Type:       "core.set_multiple_variables",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        "Set Output Variables",
+			"skip_execution":      false,
+			"variables_to_update": setOutputVariablesToUpdateForSuccessBlock,
+		},
+		ObjectType: "definition_activity",
+	})
+	// Steps completing the run successfully; nested under the assertion check when configured.
+	var completionActions []ActionData
+	successResultMessage := "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$"
+	if s.generateSummary {
+		summaryAction, summaryReference := buildSummaryAction(path, method, responseBodyPath)
+		completionActions = append(completionActions, summaryAction)
+		successResultMessage = summaryReference
+	}
+	completionActions = append(completionActions, ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Completed",
+		Title:      "Completed - Success",
+		Type:       "logic.completed",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        "Completed - Success",
+			"skip_execution":      false,
+			"completion_type":     "succeeded",
+			"result_message":      successResultMessage,
+		},
+		ObjectType: "definition_activity",
+	})
+	if len(s.responseAssertions) > 0 {
+		successActions = append(successActions, buildAssertionActions(assertionsPassed, assertionMessage, completionActions)...)
+	} else {
+		successActions = append(successActions, completionActions...)
+	}
+
+	conditionalBlock := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Condition Block",
+		Title:      "Was the Request Successful?",
+		Type:       "logic.if_else",
+		BaseType:   "activity",
+		Properties: LogicIfElseProperties{
+			Conditions:        []interface{}{},
+			ContinueOnFailure: false,
+			Description:       "Was The Request Successful?",
+			DisplayName:       "Was the Request Successful?",
+			SkipExecution:     false,
+		},
+		ObjectType: "definition_activity",
+		Blocks: []BlockData{
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      successTitle,
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: BlockProperties{
+					Condition:         successCondition,
+					DisplayName:       successTitle,
+					ContinueOnFailure: false,
+					SkipExecution:     false,
+					Operator:          s.statusConditionSettings.BlockOperator,
+				},
+				ObjectType: "definition_activity",
+				Actions:    successActions,
+			},
+			// Branches are evaluated in order, so the connectivity check must precede the
+			// failed branch, which would otherwise match the missing status code too.
+			s.buildConnectionFailureBranch(statusOperand, diagnosticUpdates),
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      "Failed",
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: BlockProperties{
+					Condition:         failedCondition,
+					DisplayName:       "Failed",
+					ContinueOnFailure: false,
+					SkipExecution:     false,
+					Operator:          s.statusConditionSettings.BlockOperator,
+				},
+				ObjectType: "definition_activity",
+				Actions: []ActionData{
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Set Variables",
+						Title:      "Set Output Variables",
+						Type:       "core.set_multiple_variables",
+						BaseType:   "activity",
+						Properties: func() map[string]interface{} {
+							failedUpdates := []VariableUpdate{
+								{
+									VariableToUpdate: fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$"),
+									VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.status_code$"),
+								},
+							}
+							if contains(activeOutputs, fixedOutputStatusMessage) {
+								failedUpdates = append(failedUpdates, VariableUpdate{
+									VariableToUpdate: fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$"),
+									VariableValueNew: fmt.Sprintf("$activity.definition_activity_$ApiRequestKSUID.output.%s$", s.statusMessageField()),
+								})
+							}
+							failedUpdates = append(failedUpdates,
+								VariableUpdate{
+									VariableToUpdate: fmt.Sprintf("$workflow.%s.output.%s$", "definition_workflow_$WorkflowKSUID", "variable_workflow_$ErrorMessageKSUID"),
+									VariableValueNew: s.errorMessageExpr(responseBodyExpr),
+								},
+								VariableUpdate{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
+									VariableValueNew: responseBodyExpr,
+								},
+								VariableUpdate{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+									VariableValueNew: "workflow-errored",
+								},
+							)
+							failedUpdates = append(failedUpdates, diagnosticUpdates...)
+							return map[string]interface{}{
+								"continue_on_failure": false,
+								"display_name":        "Set Output Variables",
+								"skip_execution":      false,
+								"variables_to_update": failedUpdates,
+							}
+						}(),
+						ObjectType: "definition_activity",
+					},
+				},
+			},
+		},
+	}
+
+	for i := range conditionalBlock.Blocks {
+		block := &conditionalBlock.Blocks[i]
+		if block.Name == "Condition Branch" && block.Title == "Failed" {
+			block.Actions = append(block.Actions, s.buildAuthFailureCheck(statusOperand))
+
+			// if idempotency is needed, we need to add the behavior to allow skipping if failures.
+			if idempotent {
+
+				idempotencyIndicator := Condition{
+					LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+					Operator:     "mregex",
+					RightOperand: s.idempotencyCondition,
+				}
+				// Connectors without a status text report the ignored error instead.
+				ignoredResultMessage := "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$"
+				if contains(activeOutputs, fixedOutputStatusMessage) && s.currentConnector.StatusMessageField != "" {
+					ignoredResultMessage = "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$"
+				}
+				blockTitle := "Ignore If Exists"
+				if method == "DELETE" || method == "GET" || method == "PUT" {
 					idempotencyIndicator = Condition{
 						LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$",
 						Operator:     "eq",
-						RightOperand: idempotencyCondition,
+						RightOperand: s.idempotencyCondition,
+					}
+					blockTitle = "Ignore If Not Exists"
+				}
+
+				block.Actions = append(block.Actions, ActionData{
+					UniqueName: "definition_activity_" + KSUIDGenerator(),
+					Name:       "Condition Block",
+					Title:      "Skip Errors?",
+					Type:       "logic.if_else",
+					BaseType:   "activity",
+					Properties: map[string]interface{}{
+						"conditions":          []interface{}{},
+						"continue_on_failure": false,
+						"display_name":        "Skip Errors?",
+						"skip_execution":      false,
+					},
+					ObjectType: "definition_activity",
+					Blocks: []BlockData{
+						{
+							UniqueName: "definition_activity_" + KSUIDGenerator(),
+							Name:       "Condition Branch",
+							Title:      blockTitle,
+							Type:       "logic.condition_block",
+							BaseType:   "activity",
+							Properties: BlockProperties{
+								Condition: Condition{
+									LeftOperand: Condition{
+										LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$ignoreIfExistKSUID$",
+										Operator:     "eq",
+										RightOperand: true,
+									},
+									Operator:     "and",
+									RightOperand: idempotencyIndicator,
+								},
+								DisplayName:       blockTitle,
+								ContinueOnFailure: false,
+								SkipExecution:     false,
+							},
+							ObjectType: "definition_activity",
+							Actions: []ActionData{
+								{
+									UniqueName: "definition_activity_" + KSUIDGenerator(),
+									Name:       "Completed",
+									Title:      "Completed - Success",
+									Type:       "logic.completed",
+									BaseType:   "activity",
+									Properties: map[string]interface{}{
+										"completion_type":     "succeeded",
+										"continue_on_failure": false,
+										"display_name":        "Completed - Success",
+										"result_message":      ignoredResultMessage,
+										"skip_execution":      false,
+									},
+								},
+							},
+						},
+						{
+							UniqueName: "definition_activity_" + KSUIDGenerator(),
+							Name:       "Condition Branch",
+							Title:      "Failed",
+							Type:       "logic.condition_block",
+							BaseType:   "activity",
+							Properties: BlockProperties{
+								Condition: Condition{
+									LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$ignoreIfExistKSUID$",
+									Operator:     "eq",
+									RightOperand: false,
+								},
+								DisplayName:       "Failed",
+								ContinueOnFailure: false,
+								SkipExecution:     false,
+							},
+							ObjectType: "definition_activity",
+							Actions: []ActionData{
+								{
+									UniqueName: "definition_activity_" + KSUIDGenerator(),
+									Name:       "Completed",
+									Title:      "Completed - Failed",
+									Type:       "logic.completed",
+									BaseType:   "activity",
+									Properties: map[string]interface{}{
+										"completion_type":     "failed-completed",
+										"continue_on_failure": false,
+										"display_name":        "Completed - Failed",
+										"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+										"skip_execution":      false,
+									},
+									ObjectType: "definition_activity",
+								},
+							},
+						},
+					},
+				})
+			} else {
+				// if no imdepotency is needed we can fail directly.
+				block.Actions = append(block.Actions, ActionData{
+					UniqueName: "definition_activity_" + KSUIDGenerator(),
+					Name:       "Completed",
+					Title:      "Completed - Failed",
+					Type:       "logic.completed",
+					BaseType:   "activity",
+					Properties: map[string]interface{}{
+						"completion_type":     "failed-completed",
+						"continue_on_failure": false,
+						"display_name":        "Completed - Failed",
+						"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+						"skip_execution":      false,
+					},
+					ObjectType: "definition_activity",
+				})
+			}
+		}
+
+	}
+
+	actions = append(actions, conditionalBlock)
+
+	// Construct the workflow data
+	categories := []string{}
+	categoriesMap := map[string]CategoryData{}
+	if strings.TrimSpace(s.categoryId) != "" && strings.TrimSpace(s.categoryName) != "" {
+		categories = []string{s.categoryId}
+		categoriesMap[s.categoryId] = CategoryData{
+			UniqueName:   s.categoryId,
+			Name:         s.categoryName,
+			Title:        s.categoryName,
+			Type:         "basic.category",
+			BaseType:     "category",
+			CategoryType: "custom",
+			ObjectType:   "category",
+		}
+	}
+
+	return WorkflowData{
+		SupportIdempotency: idempotent,
+		FixedOutputs:       fixedOutputs,
+		UniqueName:         "definition_workflow_$WorkflowKSUID",
+		Name:               operationDisplayName,
+		Title:              operationDisplayName,
+		Type:               "generic.workflow",
+		BaseType:           "workflow",
+		Variables:          variables,
+		Properties: WorkflowProperties{
+			Atomic: AtomicData{
+				AtomicGroup: s.currentConnector.AtomicGroup,
+				IsAtomic:    true,
+			},
+			Description: workflowDescription,
+			DisplayName: operationDisplayName,
+			RuntimeUser: RuntimeUserData{
+				TargetDefault: true,
+			},
+			Target: TargetData{
+				TargetType:             s.currentConnector.TargetType,
+				SpecifyOnWorkflowStart: true,
+			},
+		},
+		ObjectType:    "definition_workflow",
+		Actions:       actions,
+		Categories:    categories,
+		CategoriesMap: categoriesMap,
+		TableTypes:    tableTypes,
+	}
+}
+
+// waitVariable returns an output variable tracking polling state; its type follows
+// the expected field value so the loop comparisons stay typed.
+func (s *renderSettings) waitVariable(name, placeholder string, sample interface{}) VariableData {
+	variable := VariableData{
+		SchemaID: "datatype.string",
+		Properties: VariableProperties{
+			Value:                "",
+			Scope:                "output",
+			Name:                 name,
+			Type:                 "datatype.string",
+			VariableStringFormat: "text",
+		},
+		UniqueName: s.variableUniqueName(placeholderKindOutput, placeholder),
+		ObjectType: "variable_workflow",
+	}
+	switch assertionQueryType(sample) {
+	case "boolean":
+		variable.SchemaID, variable.Properties.Type, variable.Properties.Value = "datatype.boolean", "datatype.boolean", false
+	case "integer":
+		variable.SchemaID, variable.Properties.Type, variable.Properties.Value = "datatype.integer", "datatype.integer", 0
+	}
+	return variable
+}
+
+// waitPollEntry is the accumulator entry of one wait_for attempt, from the
+// attempt number, the request's status code and the value read.
+const waitPollEntry = `(attempt, status_code, value) = sys.argv[5:8]
+ok = str(status_code).strip().startswith('2')
+entries = [({'attempt': int(attempt or 0), 'status_code': status_code, 'value': value}, ok)]`
+
+// applyWaitFor rewrites a generated GET workflow into a "Wait for <Resource>
+// <Field> = <Value>" atomic: a while loop repeating the request, extracting the
+// field and sleeping between attempts, followed by a reached/timed-out check.
+// Every attempt is recorded by the result accumulator; a request that fails or
+// gets no status ends the run from inside the loop.
+func (s *renderSettings) applyWaitFor(data *WorkflowData, path string, wait WaitForConfig) {
+	resourceSegment, _ := extractResourceFromPath(path)
+	resource := singularize(HumanReadableName(resourceSegment))
+	fieldPath := strings.TrimPrefix(strings.TrimPrefix(wait.Field, "$"), ".")
+	fieldName := HumanReadableName(strings.ReplaceAll(fieldPath, ".", "_"))
+	title := fmt.Sprintf("Wait for %s %s = %v", resource, fieldName, wait.Value)
+
+	// Response-property outputs are never set by the loop; keep inputs only.
+	variables := make([]VariableData, 0, len(data.Variables)+2)
+	for _, variable := range data.Variables {
+		if variable.Properties.Scope != "output" {
+			variables = append(variables, variable)
+		}
+	}
+	currentVar := s.waitVariable("Output - "+fieldName, "wait_current_value", wait.Value)
+	attemptsVar := s.waitVariable("Output - Attempts", "wait_attempts", 0)
+	pollResultsVar := s.waitVariable("Output - Poll Results", "wait_poll_results", "")
+	pollResultsVar.Properties.Value, pollResultsVar.Properties.VariableStringFormat = []interface{}{}, "json"
+	pollResultsVar.Properties.Description = fmt.Sprintf("JSON array with one entry per attempt: attempt, status (succeeded or failed), status_code and the %s read. Keeps the last %d attempts.", fieldPath, accumulator.DefaultLimit)
+	failedPollsVar := s.waitVariable("Output - Failed Polls", "wait_failed_polls", "")
+	failedPollsVar.Properties.Value, failedPollsVar.Properties.VariableStringFormat = []interface{}{}, "json"
+	failedPollsVar.Properties.Description = "JSON array of the attempts whose request failed."
+	succeededVar := s.waitVariable("Output - Polls Succeeded", "wait_polls_succeeded", 0)
+	failedVar := s.waitVariable("Output - Polls Failed", "wait_polls_failed", 0)
+	data.Variables = append(variables, currentVar, attemptsVar, pollResultsVar, failedPollsVar, succeededVar, failedVar)
+	// The loop only tracks the status code; the error message is set on time-out.
+	data.FixedOutputs = fixedOutputVariables(requiredFixedOutputs)
+	currentRef := fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.%s$", currentVar.UniqueName)
+	attemptsRef := fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.%s$", attemptsVar.UniqueName)
+
+	var prepActions []ActionData
+	var apiRequest ActionData
+	var failedProperties BlockProperties
+	for _, action := range data.Actions {
+		switch {
+		case apiRequest.UniqueName != "":
+			// The loop's request check reuses the generated failed condition.
+			for _, block := range action.Blocks {
+				if block.Title == "Failed" {
+					failedProperties = block.Properties
+				}
+			}
+		case action.Type == s.currentConnector.ActionType:
+			apiRequest = action
+		case action.Title != startTimerTitle:
+			prepActions = append(prepActions, action)
+		}
+	}
+
+	var script strings.Builder
+	script.WriteString("import sys\nimport time\n\n")
+	script.WriteString("(attempts,) = sys.argv[1:2]\n\n")
+	script.WriteString("attempts = int(attempts or 0) + 1\n")
+	script.WriteString(fmt.Sprintf("if attempts > 1:\n    time.sleep(%d)\n\n", wait.Interval))
+	script.WriteString("print(attempts)\n")
+	nextAttempt := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Next Attempt",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      wait.Interval + 60,
+			"continue_on_failure": false,
+			"display_name":        "Next Attempt",
+			"script":              script.String(),
+			"script_arguments":    []string{attemptsRef},
+			"script_queries": []map[string]string{
+				{
+					"script_query":      "attempts",
+					"script_query_name": "attempts",
+					"script_query_type": "integer",
+				},
+			},
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	// A failed request ends the loop: polling cannot reach the desired state
+	// once the target errors or cannot be reached.
+	statusOperand := fmt.Sprintf("$activity.%s.output.status_code$", apiRequest.UniqueName)
+	responseBodyRef := fmt.Sprintf("$activity.%s.output.%s$", apiRequest.UniqueName, s.currentConnector.ResponseBodyField)
+	requestCheck := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Condition Block",
+		Title:      "Did the Request Fail?",
+		Type:       "logic.if_else",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"conditions":          []interface{}{},
+			"continue_on_failure": false,
+			"display_name":        "Did the Request Fail?",
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Blocks: []BlockData{
+			s.buildConnectionFailureBranch(statusOperand, nil),
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      "Failed",
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: failedProperties,
+				ObjectType: "definition_activity",
+				Actions: []ActionData{
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Set Variables",
+						Title:      "Set Output Variables",
+						Type:       "core.set_multiple_variables",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"continue_on_failure": false,
+							"display_name":        "Set Output Variables",
+							"skip_execution":      false,
+							"variables_to_update": []VariableUpdate{
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$",
+									VariableValueNew: statusOperand,
+								},
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+									VariableValueNew: s.errorMessageExpr(responseBodyRef),
+								},
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
+									VariableValueNew: responseBodyRef,
+								},
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+									VariableValueNew: "workflow-errored",
+								},
+							},
+						},
+						ObjectType: "definition_activity",
+					},
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Completed",
+						Title:      "Completed - Failed",
+						Type:       "logic.completed",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"completion_type":     "failed-completed",
+							"continue_on_failure": false,
+							"display_name":        "Completed - Failed",
+							"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+							"skip_execution":      false,
+						},
+						ObjectType: "definition_activity",
+					},
+				},
+			},
+		},
+	}
+
+	extractUniqueName := "definition_activity_" + KSUIDGenerator()
+	outputRef := func(variable VariableData) string {
+		return fmt.Sprintf("$workflow.definition_workflow_$WorkflowKSUID.output.%s$", variable.UniqueName)
+	}
+	queries := make([]map[string]string, 0, len(accumulator.Queries))
+	for _, query := range accumulator.Queries {
+		queries = append(queries, map[string]string{
+			"script_query":      query[0],
+			"script_query_name": query[0],
+			"script_query_type": query[1],
+		})
+	}
+	recordAttempt := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Execute Python Script",
+		Title:      "Record Attempt",
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        "Record Attempt",
+			"script":              accumulator.Script(waitPollEntry, accumulator.DefaultLimit),
+			"script_arguments": []string{
+				outputRef(pollResultsVar), outputRef(failedPollsVar), outputRef(succeededVar), outputRef(failedVar),
+				fmt.Sprintf("$activity.%s.output.script_queries.attempts$", nextAttempt.UniqueName),
+				statusOperand,
+				fmt.Sprintf("$activity.%s.output.jsonpath_queries.Current Value$", extractUniqueName),
+			},
+			"script_queries": queries,
+			"skip_execution": false,
+		},
+		ObjectType: "definition_activity",
+	}
+	recorded := func(name string) string {
+		return fmt.Sprintf("$activity.%s.output.script_queries.%s$", recordAttempt.UniqueName, name)
+	}
+	updateResults := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Set Variables",
+		Title:      "Update Poll Results",
+		Type:       "core.set_multiple_variables",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        "Update Poll Results",
+			"skip_execution":      false,
+			"variables_to_update": []VariableUpdate{
+				{VariableToUpdate: outputRef(pollResultsVar), VariableValueNew: recorded("results_json")},
+				{VariableToUpdate: outputRef(failedPollsVar), VariableValueNew: recorded("failed_items_json")},
+				{VariableToUpdate: outputRef(succeededVar), VariableValueNew: recorded("succeeded")},
+				{VariableToUpdate: outputRef(failedVar), VariableValueNew: recorded("failed")},
+			},
+		},
+		ObjectType: "definition_activity",
+	}
+	loop := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "While Loop",
+		Title:      fmt.Sprintf("Until %s = %v", fieldName, wait.Value),
+		Type:       "logic.while",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"condition": Condition{
+				LeftOperand:  Condition{LeftOperand: currentRef, Operator: "ne", RightOperand: wait.Value},
+				Operator:     "and",
+				RightOperand: Condition{LeftOperand: attemptsRef, Operator: "lt", RightOperand: wait.Attempts},
+			},
+			"continue_on_failure": false,
+			"display_name":        fmt.Sprintf("Until %s = %v", fieldName, wait.Value),
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Actions: []ActionData{
+			nextAttempt,
+			apiRequest,
+			{
+				UniqueName: extractUniqueName,
+				Name:       "JSONPath Query",
+				Title:      "Extract " + fieldName,
+				Type:       "corejava.jsonpathquery",
+				BaseType:   "activity",
+				Properties: JsonpathQueryProperties{
+					ActionTimeout:     180,
+					DisplayName:       "Extract " + fieldName,
+					ContinueOnFailure: true,
+					InputJSON:         responseBodyRef,
+					JsonpathQueries: []JsonpathQuery{
+						{
+							JsonpathQuery:     wait.Field,
+							JsonpathQueryName: "Current Value",
+							JsonpathQueryType: assertionQueryType(wait.Value),
+							ZdateTypeFormat:   s.dateFormat,
+						},
+					},
+					SkipExecution: false,
+				},
+				ObjectType: "definition_activity",
+			},
+			recordAttempt,
+			updateResults,
+			requestCheck,
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Set Variables",
+				Title:      "Set Polling State",
+				Type:       "core.set_multiple_variables",
+				BaseType:   "activity",
+				Properties: map[string]interface{}{
+					"continue_on_failure": false,
+					"display_name":        "Set Polling State",
+					"skip_execution":      false,
+					"variables_to_update": []VariableUpdate{
+						{
+							VariableToUpdate: currentRef,
+							VariableValueNew: fmt.Sprintf("$activity.%s.output.jsonpath_queries.Current Value$", extractUniqueName),
+						},
+						{
+							VariableToUpdate: attemptsRef,
+							VariableValueNew: fmt.Sprintf("$activity.%s.output.script_queries.attempts$", nextAttempt.UniqueName),
+						},
+						{
+							VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusCodeKSUID$",
+							VariableValueNew: fmt.Sprintf("$activity.%s.output.status_code$", apiRequest.UniqueName),
+						},
+					},
+				},
+				ObjectType: "definition_activity",
+			},
+		},
+	}
+
+	reachedTitle := fmt.Sprintf("%s = %v", fieldName, wait.Value)
+	timeoutMessage := fmt.Sprintf("Timed out after %d attempts (every %ds) waiting for %s %s to become %v; last value: %s", wait.Attempts, wait.Interval, strings.ToLower(resource), strings.ToLower(fieldName), wait.Value, currentRef)
+	outcome := ActionData{
+		UniqueName: "definition_activity_" + KSUIDGenerator(),
+		Name:       "Condition Block",
+		Title:      "Reached Desired State?",
+		Type:       "logic.if_else",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"conditions":          []interface{}{},
+			"continue_on_failure": false,
+			"display_name":        "Reached Desired State?",
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Blocks: []BlockData{
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      reachedTitle,
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: BlockProperties{
+					Condition:   Condition{LeftOperand: currentRef, Operator: "eq", RightOperand: wait.Value},
+					DisplayName: reachedTitle,
+				},
+				ObjectType: "definition_activity",
+				Actions: []ActionData{
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Set Variables",
+						Title:      "Set Output Variables",
+						Type:       "core.set_multiple_variables",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"continue_on_failure": false,
+							"display_name":        "Set Output Variables",
+							"skip_execution":      false,
+							"variables_to_update": []VariableUpdate{
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results$",
+									VariableValueNew: fmt.Sprintf("$activity.%s.output.%s$", apiRequest.UniqueName, s.currentConnector.ResponseBodyField),
+								},
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+									VariableValueNew: "completed-successfully",
+								},
+							},
+						},
+						ObjectType: "definition_activity",
+					},
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Completed",
+						Title:      "Completed - Success",
+						Type:       "logic.completed",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"completion_type":     "succeeded",
+							"continue_on_failure": false,
+							"display_name":        "Completed - Success",
+							"result_message":      fmt.Sprintf("%s %s is %v", resource, strings.ToLower(fieldName), wait.Value),
+							"skip_execution":      false,
+						},
+						ObjectType: "definition_activity",
+					},
+				},
+			},
+			{
+				UniqueName: "definition_activity_" + KSUIDGenerator(),
+				Name:       "Condition Branch",
+				Title:      "Timed Out",
+				Type:       "logic.condition_block",
+				BaseType:   "activity",
+				Properties: BlockProperties{
+					Condition:   Condition{LeftOperand: currentRef, Operator: "ne", RightOperand: wait.Value},
+					DisplayName: "Timed Out",
+				},
+				ObjectType: "definition_activity",
+				Actions: []ActionData{
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Set Variables",
+						Title:      "Set Error Message",
+						Type:       "core.set_multiple_variables",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"continue_on_failure": false,
+							"display_name":        "Set Error Message",
+							"skip_execution":      false,
+							"variables_to_update": []VariableUpdate{
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+									VariableValueNew: timeoutMessage,
+								},
+								{
+									VariableToUpdate: "$workflow.definition_workflow_$WorkflowKSUID.output.workflow_results_code$",
+									VariableValueNew: "workflow-errored",
+								},
+							},
+						},
+						ObjectType: "definition_activity",
+					},
+					{
+						UniqueName: "definition_activity_" + KSUIDGenerator(),
+						Name:       "Completed",
+						Title:      "Completed - Failed",
+						Type:       "logic.completed",
+						BaseType:   "activity",
+						Properties: map[string]interface{}{
+							"completion_type":     "failed-completed",
+							"continue_on_failure": false,
+							"display_name":        "Completed - Failed",
+							"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
+							"skip_execution":      false,
+						},
+						ObjectType: "definition_activity",
+					},
+				},
+			},
+		},
+	}
+
+	data.Actions = append(prepActions, loop, outcome)
+	data.Name = title
+	data.Title = title
+	data.Properties.DisplayName = title
+	data.Properties.Description = fmt.Sprintf("Polls the %s every %d seconds (up to %d attempts) until %s is %v.", strings.ToLower(resource), wait.Interval, wait.Attempts, fieldPath, wait.Value)
+}
+
+// dateExampleRegex matches example dates and timestamps such as 2024-05-01 or
+// 2024-05-01T12:30:00.123456+00:00, capturing the date/time separator, the
+// seconds, the fraction digits and the offset.
+var dateExampleRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:([T ])\d{2}:\d{2}(:\d{2})?(?:\.(\d+))?(Z|[+-]\d{2}:\d{2}|[+-]\d{4})?)?$`)
+
+// dateFormatFor returns the date format of the query extracting a response
+// property: the date_format fields entry for it first, then a pattern matching
+// the spec's example, yyyy-MM-dd for format: date, and the run's date format
+// otherwise.
+func (s *renderSettings) dateFormatFor(name string, schema Schema) string {
+	if format, ok := s.dateFormatFields[name]; ok {
+		return format
+	}
+	if example, ok := schema.Example.(string); ok && (schema.Format == "date" || schema.Format == "date-time") {
+		if format := dateFormatFromExample(example); format != "" {
+			return format
+		}
+	}
+	if schema.Format == "date" {
+		return "yyyy-MM-dd"
+	}
+	return s.dateFormat
+}
+
+// dateFormatFromExample derives a Java date pattern from an example value; it
+// returns "" when the example is not a date.
+func dateFormatFromExample(example string) string {
+	match := dateExampleRegex.FindStringSubmatch(strings.TrimSpace(example))
+	if match == nil {
+		return ""
+	}
+	if match[1] == "" {
+		return "yyyy-MM-dd"
+	}
+	format := "yyyy-MM-dd'T'HH:mm"
+	if match[1] == " " {
+		format = "yyyy-MM-dd HH:mm"
+	}
+	if match[2] != "" {
+		format += ":ss"
+	}
+	if match[3] != "" {
+		format += "." + strings.Repeat("S", len(match[3]))
+	}
+	switch {
+	case match[4] == "":
+	case match[4] == "Z" || strings.Contains(match[4], ":"):
+		format += "XXX"
+	default:
+		format += "Z"
+	}
+	return format
+}
+
+// GenerateJsonpathQueries returns the queries extracting the response outputs.
+// The listProperty array is queried as an array, matching its datatype.array
+// output.
+func (s *renderSettings) GenerateJsonpathQueries(responseSchema Schema, method string, paths map[string]string, listProperty string) []JsonpathQuery {
+	var queries []JsonpathQuery
+
+	// Add the fixed "Result" query
+	queries = append(queries, JsonpathQuery{
+		JsonpathQuery:     "$",
+		JsonpathQueryName: "Result",
+		JsonpathQueryType: "string",
+		ZdateTypeFormat:   s.dateFormat,
+	})
+
+	isCreateOrUpdate := strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT")
+	
+	if !isCreateOrUpdate {
+		// Generate queries for each property in the response schema (skip for POST/PATCH/PUT)
+		queryNames := jsonpathQueryNames(responseSchema.Properties)
+		for _, propName := range sortedSchemaKeys(responseSchema.Properties) {
+			propSchema := responseSchema.Properties[propName]
+			queryName := queryNames[propName]
+
+			queryType := "string"
+			if propSchema.Type == "boolean" {
+				queryType = "boolean"
+			} else if propName == listProperty && propSchema.Type == "array" {
+				queryType = "array"
+			}
+
+			path := propName
+			if unwrapped, ok := paths[propName]; ok {
+				path = unwrapped
+			}
+			queries = append(queries, JsonpathQuery{
+				JsonpathQuery:     "$." + path,
+				JsonpathQueryName: queryName,
+				JsonpathQueryType: queryType,
+				ZdateTypeFormat:   s.dateFormatFor(propName, propSchema),
+			})
+		}
+	}
+
+	return queries
+}
+
+var jsonpathQueryNameRegex = regexp.MustCompile(`[^A-Za-z0-9 _]`)
+
+// sanitizeJsonpathQueryName replaces characters AO rejects in query names or that
+// would terminate a $activity...jsonpath_queries.<name>$ reference.
+func sanitizeJsonpathQueryName(name string) string {
+	clean := jsonpathQueryNameRegex.ReplaceAllString(name, "_")
+	clean = whitespaceRegex.ReplaceAllString(strings.TrimSpace(clean), " ")
+	if clean == "" {
+		clean = "Value"
+	}
+	return clean
+}
+
+// jsonpathQueryNames maps response property names to sanitized, unique JSONPath
+// query names. The extraction step and the set-variables references both read
+// from it so they cannot drift apart.
+func jsonpathQueryNames(props map[string]Schema) map[string]string {
+	names := make(map[string]string, len(props))
+	used := map[string]bool{"result": true}
+	for _, propName := range sortedSchemaKeys(props) {
+		base := sanitizeJsonpathQueryName(HumanReadableName(propName))
+		name := base
+		for i := 2; used[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s %d", base, i)
+		}
+		used[strings.ToLower(name)] = true
+		names[propName] = name
+	}
+	return names
+}
+
+//go:embed networking_acronyms.csv
+var embeddedAcronymsCSV []byte
+
+// acronymsCSV returns networking_acronyms.csv from next to the executable or the
+// working directory when one exists, so the list can be edited without a rebuild,
+// and the copy embedded at build time otherwise.
+func acronymsCSV() []byte {
+	data, err := fsutil.ReadFile(fsutil.ResourcePath("networking_acronyms.csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return embeddedAcronymsCSV
+	}
+	if err != nil {
+		log.Fatalf("Error opening CSV file: %v", err)
+	}
+	return data
+}
+
+// acronymReplacer returns a function writing the acronyms of
+// networking_acronyms.csv in their listed case ("Vlan Id" becomes "VLAN ID").
+func acronymReplacer() func(string) string {
+	// Parse the CSV file to get acronyms
+	r := csv.NewReader(bytes.NewReader(acronymsCSV()))
+	acronyms, err := r.Read()
+	if err != nil {
+		log.Fatalf("Error reading CSV: %v", err)
+	}
+
+	// Create a map of acronyms for quick lookup
+	acronymMap := make(map[string]string)
+	for _, acronym := range acronyms {
+		acronymMap[strings.ToLower(acronym)] = acronym
+	}
+
+	return func(text string) string {
+		for fullName, acronym := range acronymMap {
+			pattern := fmt.Sprintf(`(?i)\b%s\b|^%s\b|\b%s$`, regexp.QuoteMeta(fullName), regexp.QuoteMeta(fullName), regexp.QuoteMeta(fullName))
+			re := regexp.MustCompile(pattern)
+			text = re.ReplaceAllStringFunc(text, func(match string) string {
+				return acronym
+			})
+		}
+		return text
+	}
+}
+
+func (s *renderSettings) capitalizeAcronyms(workflowData *WorkflowData) {
+	replaceTextWithAcronyms := acronymReplacer()
+
+	// Replace names in VariableData
+	for i := range workflowData.Variables {
+		workflowData.Variables[i].Properties.Name = replaceTextWithAcronyms(workflowData.Variables[i].Properties.Name)
+	}
+
+	// Replace names and titles in ActionData
+	for i := range workflowData.Actions {
+		if workflowData.Actions[i].Type == s.currentConnector.ActionType {
+			workflowData.Actions[i].Name = replaceTextWithAcronyms(workflowData.Actions[i].Name)
+			workflowData.Actions[i].Title = replaceTextWithAcronyms(workflowData.Actions[i].Title)
+		}
+	}
+
+	// Apply the same replacement to WorkflowData Name and Title
+	workflowData.Name = replaceTextWithAcronyms(workflowData.Name)
+	workflowData.Title = replaceTextWithAcronyms(workflowData.Title)
+
+}
+
+// renderSettings are the settings workflows are rendered with. setupGenerate
+// fills the run's settings from the flags; a config entry, a composite step on
+// another connector or a -serve request renders with a copy carrying its own
+// connector and options, so renders share no state and may run concurrently.
+type renderSettings struct {
+	// currentConnector is the connector the workflows target; platformName
+	// names its platform in workflow names and descriptions.
+	currentConnector connectorConfig
+	platformName     string
+
+	supportIdempotency   bool
+	idempotencyCondition string
+	categoryId           string
+	categoryName         string
+	// categoryPath replaces categoryId and categoryName when set: each level is a
+	// template over CategoryPathData, e.g. ["NetBox", "{{.Group}}"].
+	categoryPath []string
+	// nameTemplate replaces the workflow names and titles when set, e.g.
+	// "{{.Platform}} - {{.Action}} {{.Resource}} [Generated]".
+	nameTemplate string
+	// prefixTargets lists what applyPlatformPrefix prefixes besides the workflow.
+	prefixTargets []string
+
+	// workflowTemplateText is the template used for rendering; -template replaces it.
+	workflowTemplateText string
+	// postProcessCommands are external commands for the run or workflow.
+	postProcessCommands []string
+
+	// queryParamFilter and bodyParamFilter narrow the query params and body
+	// properties generated per operationId. Copies share them, so they are
+	// replaced rather than changed once set.
+	queryParamFilter map[string][]string
+	bodyParamFilter  map[string]map[string]struct{}
+	// commaSeparatedQueryParams lists multi-value filters exposed as comma-separated
+	// strings; overridable per workflow with comma_separated_params.
+	commaSeparatedQueryParams []string
+	queryMode                 string
+	statusConditionSettings   StatusCondition
+	generateSummary           bool
+	generateScaffold          bool
+	responseAssertions        []ResponseAssertion
+	waitForSettings           *WaitForConfig
+
+	// apiRequestTimeout is the action_timeout in seconds of the API request step.
+	apiRequestTimeout int
+	// maxBodyInputs caps the generated request body inputs; 0 means no limit.
+	maxBodyInputs int
+	// maxSchemaDepth (-maxSchemaDepth) is how many levels of request and response
+	// schemas below the root are expanded; deeper structures become JSON values.
+	// 0 disables the limit.
+	maxSchemaDepth int
+	// flattenObjects turns object body properties with at most this many writable
+	// scalar fields into one input per field; 0 keeps them JSON inputs.
+	flattenObjects int
+	// stringifyBodyInputs (-stringifyBodyInputs) takes integer, number and
+	// boolean body fields as text inputs, converted back before the request.
+	stringifyBodyInputs bool
+	// strictMode fails generation on schema constructs that would otherwise be degraded.
+	strictMode bool
+	// httpHeaders are the custom headers (-httpHeader) of the generic connector's
+	// requests, e.g. Accept: application/json.
+	httpHeaders []connector.HTTPHeader
+
+	// generateVariants are the variants -config renders of the write operations
+	// of entries without options.variant; workflowVariant is the one rendering.
+	generateVariants []string
+	workflowVariant  string
+	// requestSchemaName and responseSchemaName (-requestSchema, -responseSchema)
+	// name component schemas replacing the request body and success response
+	// schemas of the rendered operations.
+	requestSchemaName, responseSchemaName string
+	// alwaysIncludeRequired adds spec-required body fields to a body_params filter
+	// that leaves them out.
+	alwaysIncludeRequired bool
+
+	// dateFormat is the date pattern of JSONPath queries; dateFormatFields
+	// overrides it per response property.
+	dateFormat       string
+	dateFormatFields map[string]string
+	// sensitiveFields names fields treated as secrets on top of those the spec marks
+	// writeOnly or format password.
+	sensitiveFields []string
+	// localVariables copies prepared query strings and bodies into local workflow variables.
+	localVariables bool
+	// idsAsStrings takes integer ID body fields as text inputs so large IDs keep
+	// their precision; the prep script still checks they are numeric.
+	idsAsStrings bool
+	// approvalSettings gates destructive requests behind a human approval; nil disables it.
+	approvalSettings *ApprovalConfig
+	// tableOutput adds a table output to list endpoints; nil generates none.
+	tableOutput *TableOutput
+	// fixedOutputs overrides the connector's standard output set; nil keeps the default.
+	fixedOutputs []string
+	// normalizeOutputs adds normalizedFixedOutputs to every atomic, including the
+	// status message on connectors that return no status text.
+	normalizeOutputs bool
+	// responseExamples adds the success response's example from the spec to the
+	// workflow description and the output descriptions, so consumers know the
+	// shape of the results before running the workflow.
+	responseExamples bool
+	// objectURLInput adds an "Input - Object URL" input to NetBox detail workflows,
+	// so callers can pass the url field NetBox returns instead of parsing an ID out of it.
+	objectURLInput bool
+	// inputSections groups the wizard inputs of large workflows; nil disables it.
+	inputSections []InputSection
+	// hideOptionalInputs keeps optional inputs off the wizard.
+	hideOptionalInputs bool
+
+	// placeholders hands out the KSUID placeholders of the workflow being
+	// rendered; renderWorkflow starts a registry for every workflow.
+	placeholders *placeholderRegistry
+	// truncatedVariables collects where the truncated structures that became
+	// inputs or outputs of the workflow being rendered were cut.
+	truncatedVariables []string
+	// onRequiredFields, when set, receives the required fields report row of
+	// every rendered operation with spec-required body fields.
+	onRequiredFields func(requiredFieldReport)
+}
+
+// defaultRenderSettings returns the settings of a run without flags.
+func defaultRenderSettings() renderSettings {
+	return renderSettings{
+		workflowTemplateText:      workflowTemplate,
+		commaSeparatedQueryParams: defaultCommaSeparatedQueryParams,
+		queryMode:                 queryModeFields,
+		apiRequestTimeout:         180,
+		maxSchemaDepth:            8,
+		generateVariants:          []string{variantFull},
+		workflowVariant:           variantFull,
+		dateFormat:                defaultDateFormat,
+		responseExamples:          true,
+	}
+}
+
+//go:embed resources/netbox_query_filters.yaml
+var embeddedNetboxQueryFilters []byte
+
+// defaultNetboxQueryFilters are the query params NetBox list operations expose
+// when neither -queryParamsConfig nor a workflow config names them.
+var defaultNetboxQueryFilters = mustParseQueryParamConfig(embeddedNetboxQueryFilters)
+
+// emitMermaid writes a Mermaid flowchart next to every workflow written to disk.
+var emitMermaid = false
+
+// mergeEdits merges workflows into existing files of the same name instead of
+// overwriting them.
+var mergeEdits = false
+
+// regenerateChanged limits config generation to the atomics whose spec
+// operation changed since the lockfile recorded them.
+var regenerateChanged = false
+
+// Atomic variants (-variant, options.variant): full gives every writable body
+// field an input, minimal only the required ones plus the "Additional Fields
+// (JSON)" pass-through.
+const (
+	variantFull    = "full"
+	variantMinimal = "minimal"
+)
+
+// minimalVariantSuffix ends the names of minimal atomics.
+const minimalVariantSuffix = " (Minimal)"
+
+// parseVariants validates -variant or options.variant values, dropping
+// repeats.
+func parseVariants(values []string) ([]string, error) {
+	var variants []string
+	for _, value := range values {
+		variant := strings.ToLower(strings.TrimSpace(value))
+		if variant != variantFull && variant != variantMinimal {
+			return nil, fmt.Errorf("unsupported variant %q (expected %s or %s)", value, variantFull, variantMinimal)
+		}
+		if !contains(variants, variant) {
+			variants = append(variants, variant)
+		}
+	}
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("no variant given (expected %s or %s)", variantFull, variantMinimal)
+	}
+	return variants, nil
+}
+
+// minimalVariant reports whether the minimal variant applies to an operation
+// of method; only write methods have body fields to leave out.
+func (s *renderSettings) minimalVariant(method string) bool {
+	if s.workflowVariant != variantMinimal {
+		return false
+	}
+	switch strings.ToUpper(method) {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
+
+// entryVariants returns the variants a config entry renders of an operation of
+// method: options.variant, else -variant. Operations without a body render the
+// same in both, so they get the full one only.
+func (s *renderSettings) entryVariants(wf WorkflowConfig, method string) ([]string, error) {
+	variants := s.generateVariants
+	if wf.Options != nil && wf.Options.Variant != nil {
+		parsed, err := parseVariants(wf.Options.Variant)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+		}
+		variants = parsed
+	}
+	if method != "POST" && method != "PUT" && method != "PATCH" {
+		return []string{variantFull}, nil
+	}
+	return variants, nil
+}
+
+// atomicName is the file name, without extension, of a config entry's atomic
+// of operationId in variant.
+func atomicName(wf WorkflowConfig, operationId, variant string) string {
+	name := operationId
+	if wf.WaitFor != nil {
+		name += "_wait"
+	}
+	if variant == variantMinimal {
+		name += "_minimal"
+	}
+	return name
+}
+
+// defaultDateFormat is the date pattern of JSONPath queries unless -dateFormat
+// or options.date_format replaces it.
+const defaultDateFormat = "yyyy-MM-dd'T'HH:mm:ssZ"
+
+// recipeNames lists built-in composite recipes requested with -recipe.
+var recipeNames []string
+
+// bulkConfigs lists the CSV bulk workflows requested with -bulk.
+var bulkConfigs []bulk.Config
+var defaultCommaSeparatedQueryParams = []string{"id", "site_id", "tag"}
+var netboxPaginationSchema = map[string]Schema{
+	"count": {
+		Type:        "integer",
+		Description: "Total number of records available.",
+	},
+	"next": {
+		Type:        "string",
+		Description: "URL for the next page of results.",
+	},
+	"previous": {
+		Type:        "string",
+		Description: "URL for the previous page of results.",
+	},
+	"results": {
+		Type:        "array",
+		Items:       &Schema{Type: "object"},
+		Description: "Paginated results array.",
+	},
+}
+
+// command is a generate_workflow subcommand. setup defines its flags on fs and
+// returns the body, which runs after fs has parsed the arguments with a context
+// that is cancelled on interrupt or when -deadline passes.
+type command struct {
+	name    string
+	args    string
+	summary string
+	setup   func(fs *flag.FlagSet) func(ctx context.Context)
+}
+
+// commands returns the subcommands in the order help lists them.
+func commands() []command {
+	return []command{
+		{"generate", "[flags]", "Generate workflows from an OpenAPI spec (the default without a subcommand).", setupGenerate},
+		{"list", "-openapi=<spec> [flags] [search terms]", "List the spec's operations (method, path, operationId).", setupList},
+		{"validate", "[dir|file ...]", "Lint generated workflow JSON (dangling references, duplicate unique names, unset outputs).", setupValidate},
+		{"upload", "-url=<import endpoint> [flags] [dir]", "POST generated workflows to AO in import-manifest order.", setupUpload},
+		{"diff", "<old> <new>", "Compare two workflow files or output directories, ignoring generated unique names.", setupDiff},
+		{"diff3", "<dir> [new dir]", "Show hand edits since generation (from the lockfile) and, given a new render, generator changes and conflicts.", setupDiff3},
+		{"bundle", "[-o=<file>] [dir]", "Zip an output directory with its import manifest.", setupBundle},
+		{"retemplate", "[-template=<file>] [-outputDir=<dir>] <dir|file ...>", "Load generated workflows back and render them again, keeping their unique names.", setupRetemplate},
+		{"completion", "bash|zsh", "Print a shell completion script.", setupCompletion},
+	}
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", filepath.Base(os.Args[0]))
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	name := "generate"
+	if !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	fs, deadline, run := newCommandFlagSet(cmd, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\n%s\n\n", filepath.Base(os.Args[0]), cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *deadline)
+		defer cancel()
+	}
+	run(ctx)
+}
+
+// newCommandFlagSet returns the FlagSet of cmd with the flags every command
+// shares, the -deadline value and the command body.
+func newCommandFlagSet(cmd command, handling flag.ErrorHandling) (*flag.FlagSet, *time.Duration, func(ctx context.Context)) {
+	fs := flag.NewFlagSet(cmd.name, handling)
+	deadline := fs.Duration("deadline", 0, "Cancel the command after this long (e.g. 5m); 0 means no limit.")
+	fs.Var((*fileModeFlag)(&fsutil.FileMode), "fileMode", "Octal permission `mode` of the files written (e.g. 0600).")
+	fs.Var((*fileModeFlag)(&fsutil.DirMode), "dirMode", "Octal permission `mode` of the directories created (e.g. 0700).")
+	return fs, deadline, cmd.setup(fs)
+}
+
+func setupList(fs *flag.FlagSet) func(ctx context.Context) {
+	openAPIFile := fs.String("openapi", "", "Path to the OpenAPI JSON/YAML file.")
+	methodPtr := fs.String("method", "", "Only list operations with this HTTP method.")
+	tagPtr := fs.String("tag", "", "Only list operations with this tag.")
+	return func(ctx context.Context) {
+		if strings.TrimSpace(*openAPIFile) == "" {
+			log.Fatal("OpenAPI file path must be provided.")
+		}
+		openAPISpec, err := loadOpenAPISpec(ctx, *openAPIFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, op := range listOperations(openAPISpec, *methodPtr, *tagPtr, strings.Join(fs.Args(), " ")) {
+			fmt.Fprintf(out, "%s\t%s\t%s\n", op.Method, op.Path, op.OperationID)
+		}
+		out.Flush()
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func setupValidate(fs *flag.FlagSet) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		paths := fs.Args()
+		if len(paths) == 0 {
+			paths = []string{"outputs"}
+		}
+		ok := true
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				issues, err := workflowlint.LintFile(path)
+				if err != nil {
+					log.Fatalf("Failed to lint workflows: %v", err)
+				}
+				for _, issue := range issues {
+					fmt.Println(issue.String())
+				}
+				ok = ok && !workflowlint.HasErrors(issues)
+				continue
+			}
+			ok = runLint(path) && ok
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	}
+}
+
+func setupUpload(fs *flag.FlagSet) func(ctx context.Context) {
+	urlPtr := fs.String("url", "", "Workflow import endpoint of the AO tenant; each workflow JSON is POSTed to it.")
+	tokenPtr := fs.String("token", "", "Bearer token for the import endpoint (default $AO_API_TOKEN).")
+	dryRunPtr := fs.Bool("dryRun", false, "Print the upload order without sending anything.")
+	lookupURLPtr := fs.String("lookupUrl", "", "URL answering 2xx when an object exists on the tenant, with {unique_name} in place of its unique name; checks references outside the output directory before uploading.")
+	return func(ctx context.Context) {
+		dir := "outputs"
+		if fs.NArg() > 0 {
+			dir = fs.Arg(0)
+		}
+		importManifest, external, err := loadCheckedManifest(dir)
+		if err != nil {
+			log.Fatalf("Failed to read the import manifest (generate with -config, -recipe or -interactive first): %v", err)
+		}
+		if *dryRunPtr {
+			for i, file := range importManifest.WorkflowFiles() {
+				fmt.Printf("%d. %s\n", i+1, file)
+			}
+			for _, name := range external {
+				fmt.Printf("external: %s\n", name)
+			}
+			return
+		}
+		if strings.TrimSpace(*urlPtr) == "" {
+			log.Fatal("upload needs -url.")
+		}
+		token := *tokenPtr
+		if token == "" {
+			token = os.Getenv("AO_API_TOKEN")
+		}
+		if len(external) > 0 {
+			if strings.TrimSpace(*lookupURLPtr) == "" {
+				log.Fatalf("Upload stopped: dependencies not in %s: %s (pass -lookupUrl to check the tenant for them)", dir, strings.Join(external, ", "))
+			}
+			missing, err := upload.Missing(ctx, external, *lookupURLPtr, token)
+			if err != nil {
+				log.Fatalf("Upload stopped: %v", err)
+			}
+			if len(missing) > 0 {
+				log.Fatalf("Upload stopped: dependencies neither in %s nor on the tenant: %s", dir, strings.Join(missing, ", "))
+			}
+		}
+		if err := upload.Workflows(ctx, os.Stdout, dir, importManifest.WorkflowFiles(), *urlPtr, token); err != nil {
+			log.Fatalf("Upload failed: %v", err)
+		}
+	}
+}
+
+// loadCheckedManifest loads the import manifest of dir and checks its run order
+// against the workflow files, returning the dependencies outside dir.
+func loadCheckedManifest(dir string) (manifest.Manifest, []string, error) {
+	importManifest, err := manifest.Load(dir)
+	if err != nil {
+		return importManifest, nil, err
+	}
+	external, err := importManifest.Check(func(file string) ([]byte, error) {
+		return fsutil.ReadFile(filepath.Join(dir, file))
+	})
+	return importManifest, external, err
+}
+
+func setupRetemplate(fs *flag.FlagSet) func(ctx context.Context) {
+	templatePtr := fs.String("template", "", "Workflow template to render with (default: the built-in one).")
+	outputDirPtr := fs.String("outputDir", "", "Directory to write the rendered workflows to (default: overwrite them in place).")
+	var postProcessFlags stringListFlag
+	fs.Var(&postProcessFlags, "postProcess", "Command that receives each rendered workflow JSON on stdin and prints the modified JSON (repeatable).")
+	return func(ctx context.Context) {
+		if fs.NArg() == 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		settings := defaultRenderSettings()
+		if strings.TrimSpace(*templatePtr) != "" {
+			text, err := loadWorkflowTemplate(*templatePtr)
+			if err != nil {
+				log.Fatalf("Failed to load workflow template: %v", err)
+			}
+			settings.workflowTemplateText = text
+		}
+		settings.postProcessCommands = postProcessFlags
+		for _, path := range fs.Args() {
+			if err := settings.retemplatePath(ctx, path, *outputDirPtr); err != nil {
+				log.Fatalf("Failed to retemplate %s: %v", path, err)
+			}
+		}
+	}
+}
+
+// retemplatePath imports the workflow file at path, or every workflow file of a
+// directory, renders it again and writes it to outputDir (or back in place),
+// printing how the result differs from the original.
+func (s *renderSettings) retemplatePath(ctx context.Context, path, outputDir string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		names, err := workflowFiles(path)
+		if err != nil {
+			return err
+		}
+		files = files[:0]
+		for name := range names {
+			files = append(files, filepath.Join(path, name))
+		}
+		sort.Strings(files)
+	}
+	if outputDir != "" {
+		if err := fsutil.MkdirAll(outputDir); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		original, err := fsutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		data, err := importWorkflow(original)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		content, err := s.renderWorkflowData(ctx, filepath.Base(file), data)
+		if err != nil {
+			return err
+		}
+		target := file
+		if outputDir != "" {
+			target = filepath.Join(outputDir, filepath.Base(file))
+		}
+		if err := fsutil.WriteFile(target, []byte(content+"\n")); err != nil {
+			return err
+		}
+		changes, err := workflowdiff.Compare(original, []byte(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fmt.Printf("Wrote %s (%d changes)\n", target, len(changes))
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+	return nil
+}
+
+func setupDiff(fs *flag.FlagSet) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if fs.NArg() != 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		changed, err := diffPaths(fs.Arg(0), fs.Arg(1))
+		if err != nil {
+			log.Fatalf("Diff failed: %v", err)
+		}
+		if changed {
+			os.Exit(1)
+		}
+	}
+}
+
+func setupDiff3(fs *flag.FlagSet) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if fs.NArg() < 1 || fs.NArg() > 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		conflicts, err := diffThreeWay(fs.Arg(0), fs.Arg(1))
+		if err != nil {
+			log.Fatalf("Diff failed: %v", err)
+		}
+		if conflicts {
+			os.Exit(1)
+		}
+	}
+}
+
+// diffThreeWay prints, for every workflow of dir recorded in its lockfile, the
+// hand edits made since generation and, when newDir holds a new render of the
+// same file, the generator's changes and the conflicts between the two. It
+// reports whether any conflicts were found.
+func diffThreeWay(dir, newDir string) (bool, error) {
+	lock, err := lockfile.Load(dir)
+	if err != nil {
+		return false, err
+	}
+	files, err := workflowFiles(dir)
+	if err != nil {
+		return false, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	conflicts := false
+	for _, name := range names {
+		base, ok := lock.Base(name)
+		if !ok {
+			fmt.Printf("%s: not in %s\n", name, lockfile.FileName)
+			continue
+		}
+		current, err := fsutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return false, err
+		}
+		next := []byte(nil)
+		if newDir != "" {
+			next, err = fsutil.ReadFile(filepath.Join(newDir, name))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return false, err
+			}
+		}
+		if next == nil {
+			changes, err := workflowdiff.Compare(base, current)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", name, err)
+			}
+			for _, change := range changes {
+				fmt.Printf("%s: edited %s\n", name, change)
+			}
+			continue
+		}
+		result, err := workflowdiff.CompareThreeWay(base, current, next)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, change := range result.User {
+			fmt.Printf("%s: edited %s\n", name, change)
+		}
+		for _, change := range result.Generator {
+			fmt.Printf("%s: generator %s\n", name, change)
+		}
+		for _, change := range result.Conflicts {
+			fmt.Printf("%s: conflict %s\n", name, change)
+		}
+		conflicts = conflicts || len(result.Conflicts) > 0
+	}
+	return conflicts, nil
+}
+
+// diffPaths prints the differences between two workflow files, or between the
+// workflow files of two directories matched by name, and reports whether any
+// were found.
+func diffPaths(oldPath, newPath string) (bool, error) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return diffFiles(oldPath, newPath, filepath.Base(newPath))
+	}
+	oldFiles, err := workflowFiles(oldPath)
+	if err != nil {
+		return false, err
+	}
+	newFiles, err := workflowFiles(newPath)
+	if err != nil {
+		return false, err
+	}
+	names := make([]string, 0, len(oldFiles)+len(newFiles))
+	for name := range oldFiles {
+		names = append(names, name)
+	}
+	for name := range newFiles {
+		if !oldFiles[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	changed := false
+	for _, name := range names {
+		switch {
+		case !newFiles[name]:
+			fmt.Printf("removed %s\n", name)
+			changed = true
+		case !oldFiles[name]:
+			fmt.Printf("added %s\n", name)
+			changed = true
+		default:
+			fileChanged, err := diffFiles(filepath.Join(oldPath, name), filepath.Join(newPath, name), name)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || fileChanged
+		}
+	}
+	return changed, nil
+}
+
+func diffFiles(oldPath, newPath, label string) (bool, error) {
+	oldContent, err := fsutil.ReadFile(oldPath)
+	if err != nil {
+		return false, err
+	}
+	newContent, err := fsutil.ReadFile(newPath)
+	if err != nil {
+		return false, err
+	}
+	changes, err := workflowdiff.Compare(oldContent, newContent)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", label, err)
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+	fmt.Printf("changed %s\n", label)
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+	return true, nil
+}
+
+// workflowFiles returns the names of the *.json files directly in dir, without
+// the import manifest.
+func workflowFiles(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(fsutil.LongPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(name), ".json") && name != manifest.FileName && name != lockfile.FileName && name != requiredFieldsReportFile && !strings.HasSuffix(name, trigger.FileSuffix) {
+			files[name] = true
+		}
+	}
+	return files, nil
+}
+
+func setupBundle(fs *flag.FlagSet) func(ctx context.Context) {
+	outPtr := fs.String("o", "", "Archive to write (default <dir>.zip).")
+	return func(ctx context.Context) {
+		dir := "outputs"
+		if fs.NArg() > 0 {
+			dir = fs.Arg(0)
+		}
+		out := *outPtr
+		if out == "" {
+			out = filepath.Clean(dir) + ".zip"
+		}
+		if err := writeBundle(dir, out); err != nil {
+			log.Fatalf("Failed to bundle %s: %v", dir, err)
+		}
+		fmt.Printf("Wrote %s\n", out)
+	}
+}
+
+// writeBundle zips the import manifest and the workflows it lists, in import
+// order, into out. The run order is checked first; the bundled manifest lists
+// the dependencies outside the bundle under external.
+func writeBundle(dir, out string) error {
+	importManifest, external, err := loadCheckedManifest(dir)
+	if err != nil {
+		return err
+	}
+	if len(external) > 0 {
+		log.Printf("Warning: the bundle references %s, which must already exist on the target platform", strings.Join(external, ", "))
+	}
+	importManifest.External = external
+	manifestContent, err := json.MarshalIndent(importManifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := fsutil.CreateAtomic(out)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	archive := zip.NewWriter(buffered)
+	err = writeBundleEntries(archive, dir, importManifest.Files(), append(manifestContent, '\n'))
+	if err == nil {
+		err = archive.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
+}
+
+// writeBundleEntries adds the manifest and then each workflow to archive,
+// copying the workflow files rather than reading them into memory.
+func writeBundleEntries(archive *zip.Writer, dir string, files []string, manifestContent []byte) error {
+	w, err := archive.Create(manifest.FileName)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(manifestContent); err != nil {
+		return err
+	}
+	for _, file := range files {
+		w, err := archive.Create(filepath.ToSlash(file))
+		if err != nil {
+			return err
+		}
+		if err := copyFile(w, filepath.Join(dir, file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile streams the file at path to w.
+func copyFile(w io.Writer, path string) error {
+	source, err := fsutil.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	_, err = io.Copy(w, source)
+	return err
+}
+
+func setupCompletion(fs *flag.FlagSet) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		switch fs.Arg(0) {
+		case "bash":
+			fmt.Print(bashCompletion())
+		case "zsh":
+			fmt.Print(zshCompletion())
+		default:
+			fs.Usage()
+			os.Exit(2)
+		}
+	}
+}
+
+// completionFlag is a flag as the completion scripts see it.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+}
+
+// commandFlags returns the flags of cmd, read from a throwaway FlagSet so the
+// scripts always match the flags the commands really define.
+func commandFlags(cmd command) []completionFlag {
+	fs, _, _ := newCommandFlagSet(cmd, flag.ContinueOnError)
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{name: f.Name, usage: f.Usage, isBool: ok && boolFlag.IsBoolFlag()})
+	})
+	return flags
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+	var names []string
+	for _, cmd := range commands() {
+		names = append(names, cmd.name)
+	}
+	b.WriteString("# bash completion for generate_workflow\n")
+	b.WriteString("_generate_workflow() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd=\"${COMP_WORDS[1]}\" flags\n")
+	b.WriteString("    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n")
+	b.WriteString(fmt.Sprintf("        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(names, " ")))
+	b.WriteString("        return\n    fi\n")
+	b.WriteString("    case \"$cmd\" in\n")
+	for _, cmd := range commands() {
+		var flags []string
+		for _, f := range commandFlags(cmd) {
+			flags = append(flags, "-"+f.name)
+		}
+		pattern := cmd.name
+		if cmd.name == "generate" {
+			pattern += "|-*"
+		}
+		if cmd.name == "completion" {
+			b.WriteString("    completion) COMPREPLY=($(compgen -W \"bash zsh\" -- \"$cur\")); return ;;\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("    %s) flags=\"%s\" ;;\n", pattern, strings.Join(flags, " ")))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ $cur == -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	b.WriteString("    else\n")
+	b.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	b.WriteString("    fi\n}\n")
+	b.WriteString("complete -o filenames -F _generate_workflow generate_workflow\n")
+	return b.String()
+}
+
+// zshDescription shortens a flag usage to its first sentence and escapes it for
+// an _arguments spec inside single quotes.
+func zshDescription(usage string) string {
+	if i := strings.Index(usage, ". "); i >= 0 {
+		usage = usage[:i]
+	}
+	usage = strings.TrimSuffix(usage, ".")
+	return strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace(usage)
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString("#compdef generate_workflow\n\n")
+	b.WriteString("_generate_workflow() {\n")
+	b.WriteString("    local -a commands\n    commands=(\n")
+	for _, cmd := range commands() {
+		b.WriteString(fmt.Sprintf("        '%s:%s'\n", cmd.name, zshDescription(cmd.summary)))
+	}
+	b.WriteString("    )\n")
+	b.WriteString("    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n")
+	b.WriteString("        _describe 'command' commands\n        return\n    fi\n")
+	b.WriteString("    local cmd=$words[2]\n")
+	b.WriteString("    if [[ $cmd == -* ]]; then\n        cmd=generate\n    else\n        shift words\n        (( CURRENT-- ))\n    fi\n")
+	b.WriteString("    case $cmd in\n")
+	for _, cmd := range commands() {
+		if cmd.name == "completion" {
+			b.WriteString("    completion) _values 'shell' bash zsh ;;\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("    %s)\n        _arguments \\\n", cmd.name))
+		for _, f := range commandFlags(cmd) {
+			if f.isBool {
+				b.WriteString(fmt.Sprintf("            '-%s[%s]' \\\n", f.name, zshDescription(f.usage)))
+			} else {
+				b.WriteString(fmt.Sprintf("            '-%s=[%s]:%s:_files' \\\n", f.name, zshDescription(f.usage), f.name))
+			}
+		}
+		b.WriteString("            '*:file:_files' ;;\n")
+	}
+	b.WriteString("    esac\n}\n\n")
+	b.WriteString("_generate_workflow \"$@\"\n")
+	return b.String()
+}
+
+// setupGenerate defines the generate flags (also accepted without a subcommand
+// for compatibility) and returns the command body.
+func setupGenerate(fs *flag.FlagSet) func(ctx context.Context) {
+	var openAPIFlags, connectorFlags stringListFlag
+	fs.Var(&openAPIFlags, "openapi", "Path to the OpenAPI JSON file. Repeat it with one -connector each to generate several platforms from -config into per-connector subdirectories of -outputDir.")
+	operationId := fs.String("operationId", "", "The operationId to use from the OpenAPI spec.")
+	supportIdempotencyPtr := fs.Bool("supportIdempotency", false, "whether the atomic should support idempotency.")
+	idempotencyConditionPtr := fs.String("idempotencyCondition", "", "Error Message to use decide if idempotency is enabled.")
+	categoryIdPtr := fs.String("categoryId", "", "the Category Id to put the atomic under.")
+	categoryNamePtr := fs.String("categoryName", "", "the Category Id to put the atomic under.")
+	categoryPathPtr := fs.String("categoryPath", "", "Comma-separated category levels (Go templates over .Platform, .Group, .Tag and .Resource) naming the category instead of -categoryId/-categoryName, e.g. 'NetBox,{{.Group}}' files /api/ipam/prefixes/ under \"NetBox / IPAM\".")
+	platformNamePtr := fs.String("platform", "", "Optional platform prefix for names and titles (e.g., 'Meraki')")
+	nameTemplatePtr := fs.String("nameTemplate", "", "Go template for workflow names and titles over .Platform, .Action, .Resource, .Name, .OperationID, .Method and .Path, e.g. '{{.Platform}} - {{.Action}} {{.Resource}} [Generated]'.")
+	prefixTargetsPtr := fs.String("prefixTargets", "", "Comma-separated extra targets of the platform prefix: categories (category names and titles) and actions (the API request step).")
+	fs.Var(&connectorFlags, "connector", "Connector to target ("+strings.Join(connector.Names(), "|")+", or one defined with -connectorDef; default meraki). Repeatable, paired in order with -openapi.")
+	queryParamConfigPtr := fs.String("queryParamsConfig", "", "Optional path to a YAML/JSON file mapping operationIds to allowed query parameters.")
+	stringifyBodyInputsPtr := fs.Bool("stringifyBodyInputs", false, "Coerce request body inputs to strings before serialization.")
+	configFilePtr := fs.String("config", "", "Path to YAML/JSON file describing workflows to generate.")
+	outputDirPtr := fs.String("outputDir", "outputs", "Directory to write generated workflows when using -config.")
+	templatePtr := fs.String("template", "", "Optional path to a custom workflow template (Go text/template) replacing the built-in one.")
+	queryModePtr := fs.String("queryMode", queryModeFields, "How query params become inputs: fields (one input each) or json (a single Filters (JSON) input).")
+	fixedOutputsPtr := fs.String("fixedOutputs", "", "Comma-separated standard outputs to declare instead of the connector default ("+strings.Join(connector.Outputs, ", ")+"); status_code and error_message are always included.")
+	normalizeOutputsPtr := fs.Bool("normalizeOutputs", false, "Declare the same standard outputs (status message, status code, error message, response body) on every atomic regardless of connector.")
+	dateFormatPtr := fs.String("dateFormat", defaultDateFormat, "Java date pattern of the JSONPath queries, e.g. yyyy-MM-dd'T'HH:mm:ss.SSSSSSXXX for NetBox timestamps with microseconds.")
+	sensitiveFieldsPtr := fs.String("sensitiveFields", "", "Comma-separated field names (e.g. password,secret,token) taken as secure-string inputs and masked in echoed response bodies.")
+	inputSectionsPtr := fs.Bool("inputSections", false, fmt.Sprintf("Group the wizard inputs of workflows with %d or more into Identification, Location, Status and Advanced sections (ordering and a [Section] description prefix).", inputSectionsMinInputs))
+	hideOptionalInputsPtr := fs.Bool("hideOptionalInputs", false, "Keep optional inputs off the wizard (display_on_wizard false); calling workflows can still set them.")
+	objectURLInputPtr := fs.Bool("objectUrlInput", false, "Give NetBox get/update/delete-by-ID workflows an \"Input - Object URL\" input used instead of the ID when set (e.g. the url field of another NetBox response).")
+	responseExamplesPtr := fs.Bool("responseExamples", true, "Add the success response's example from the spec (example, examples or schema examples) to the workflow description and output descriptions.")
+	idsAsStringsPtr := fs.Bool("idsAsStrings", false, "Take integer ID body fields (id, *_id) as text inputs validated as numeric, so large IDs keep their precision.")
+	localVariablesPtr := fs.Bool("localVariables", false, "Copy prepared query strings and request bodies into local workflow variables shown in the run view.")
+	strictPtr := fs.Bool("strict", false, "Fail on unresolvable refs, unsupported content types, parameter styles and allOf/oneOf/anyOf instead of silently degrading.")
+	timeoutPtr := fs.Int("timeout", 180, "action_timeout in seconds of the API request step.")
+	maxBodyInputsPtr := fs.Int("maxBodyInputs", 0, "Limit request body inputs to this many (required first); the rest go into an \"Additional Fields (JSON)\" input. 0 disables the limit.")
+	requestSchemaPtr := fs.String("requestSchema", "", "Component schema (e.g. WritableDeviceWithConfigContextRequest) to take the request body inputs from instead of the operation's own.")
+	responseSchemaPtr := fs.String("responseSchema", "", "Component schema (e.g. DeviceWithConfigContext) to take the outputs from instead of the operation's success response schema.")
+	variantPtr := fs.String("variant", variantFull, "Comma-separated atomic variants of write operations: full (an input per writable body field) or minimal (required body fields plus an \"Additional Fields (JSON)\" pass-through, named \"... (Minimal)\" and written as <operationId>_minimal.json). Only -config renders several.")
+	flattenObjectsPtr := fs.Int("flattenObjects", 0, "Give object body properties with at most this many writable scalar fields one input per field (e.g. \"Input - Address - City\"), reassembled by a Prepare Request Body step. 0 keeps them JSON inputs.")
+	scaffoldPtr := fs.Bool("scaffold", false, "Generate scaffolds: the API request is skipped (skip_execution) and the description starts with a review-before-enabling banner.")
+	summaryPtr := fs.Bool("summary", false, "Finish successful runs with a short human-readable summary instead of the raw response JSON.")
+	rpcPtr := fs.Bool("rpc", false, "Answer JSON-RPC 2.0 requests (operations, preview, validateConfig) on stdin/stdout for editor integrations.")
+	servePtr := fs.String("serve", "", "Serve GET /operations and POST /generate on this address (e.g. :8080) instead of generating once.")
+	interactivePtr := fs.Bool("interactive", false, "Pick operations with fuzzy search and checkboxes, generate them into -outputDir and optionally append them to -config.")
+	initConfigPtr := fs.String("initConfig", "", "Write a starter workflow config listing every spec endpoint (grouped by tag) to the given path and exit.")
+	listConnectorsPtr := fs.Bool("listConnectors", false, "Print every available connector (built-in, plugin or -connectorDef) with its action type, base path and supported features (query prep, body prep, idempotency, pagination), and exit.")
+	jsonPtr := fs.Bool("json", false, "Print -listConnectors as JSON.")
+	lintDirPtr := fs.String("lint", "", "Lint existing workflow JSON files under the given directory and exit.")
+	mergePtr := fs.Bool("merge", false, "Merge into existing workflow files in -outputDir: keep their unique names, edited descriptions, variable defaults and \"Custom - \" actions.")
+	mermaidPtr := fs.Bool("mermaid", false, "Write a Mermaid flowchart (.mmd) of actions, condition branches and loops next to each workflow written to -outputDir.")
+	regenerateChangedPtr := fs.Bool("regenerate-changed", false, "With -config, render only the atomics whose spec operation changed since the lockfile in -outputDir recorded them; the others keep their files.")
+	specDiffPtr := fs.String("specDiff", "", "Compare this older spec with the newer one given as the first argument (or -openapi): list added, removed and changed operations and schemas and the operationIds to regenerate, then exit.")
+	statsPtr := fs.Bool("stats", false, "Print spec statistics (operations by method and tag, request bodies, unsupported constructs) and, with -config, the estimated number of generated files, then exit.")
+	idFormatPtr := fs.String("idFormat", idgen.KSUID, "Format of the generated unique-name IDs ("+strings.Join(idgen.Formats, "|")+"); the $...KSUID placeholders are replaced with it.")
+	idPrefixPtr := fs.String("idPrefix", "", "Letters and digits put in front of every generated ID, e.g. gen to tell generated objects apart downstream.")
+	explainPtr := fs.String("explain", "", "Print a readable summary (inputs, prep steps, request, condition branches, outputs) of the workflow generated for this operationId instead of its JSON.")
+	var postProcessFlags stringListFlag
+	var recipeFlags stringListFlag
+	var bulkFlags stringListFlag
+	var specFlags stringListFlag
+	var httpHeaderFlags stringListFlag
+	var connectorDefFlags stringListFlag
+	maxSchemaDepthPtr := fs.Int("maxSchemaDepth", 8, "Levels of request and response schemas expanded below the root; deeper structures and recursive refs become JSON inputs, with a warning. 0 disables the limit.")
+	fs.Var(&connectorDefFlags, "connectorDef", "YAML `file` defining connectors (action and target type, base path, response fields, request property names) usable with -connector (repeatable).")
+	fs.Var(&httpHeaderFlags, "httpHeader", "Header of the generic connector's HTTP requests, as 'Name: value' (repeatable, e.g. 'Accept: application/json').")
+	httpBasePathPtr := fs.String("httpBasePath", "", "Path the generic connector puts in front of spec paths in its relative URLs, e.g. /api/v2.")
+	fs.Var(&specFlags, "spec", "OpenAPI spec for another connector used by composite steps and config entries with a connector, as connector=path (repeatable, e.g. meraki=spec3.json).")
+	fs.Var(&bulkFlags, "bulk", "CSV bulk workflow to generate with its atomics into -outputDir, as <create operationId>[,<update operationId>] (repeatable); rows with an id go to the update operation.")
+	fs.Var(&recipeFlags, "recipe", "Built-in composite recipe to generate with its atomics into -outputDir (repeatable): "+strings.Join(composite.BuiltinNames(), ", ")+".")
+	fs.Var(&postProcessFlags, "postProcess", "Command that receives each rendered workflow JSON on stdin and prints the modified JSON (repeatable).")
+
+	return func(ctx context.Context) {
+
+		// Dereference the pointers into the run's settings.
+		settings := defaultRenderSettings()
+		settings.supportIdempotency = *supportIdempotencyPtr
+		settings.idempotencyCondition = *idempotencyConditionPtr
+		settings.categoryId = *categoryIdPtr
+		settings.categoryName = *categoryNamePtr
+		settings.platformName = *platformNamePtr
+		var configuredSpecs []SpecConfig
+		if strings.TrimSpace(*configFilePtr) != "" && !*interactivePtr {
+			cfg, err := loadWorkflowConfig(*configFilePtr)
+			if err != nil {
+				log.Fatalf("Failed to load config: %v", err)
+			}
+			configuredSpecs = cfg.Specs
+		}
+		specs, err := connectordef.PairSpecs(openAPIFlags, connectorFlags, configuredSpecs)
+		if err != nil {
+			log.Fatalf("Invalid specs: %v", err)
+		}
+		connectorType, openAPIFile := specs[0].Connector, specs[0].OpenAPI
+		for _, value := range httpHeaderFlags {
+			header, err := parseHTTPHeader(value)
+			if err != nil {
+				log.Fatalf("Invalid -httpHeader: %v", err)
+			}
+			settings.httpHeaders = append(settings.httpHeaders, header)
+		}
+		httpBasePath = strings.TrimRight(strings.TrimSpace(*httpBasePathPtr), "/")
+		settings.stringifyBodyInputs = *stringifyBodyInputsPtr
+		settings.postProcessCommands = postProcessFlags
+		settings.generateSummary = *summaryPtr
+		settings.generateScaffold = *scaffoldPtr
+		emitMermaid = *mermaidPtr
+		mergeEdits = *mergePtr
+		regenerateChanged = *regenerateChangedPtr
+		settings.maxBodyInputs = *maxBodyInputsPtr
+		if *flattenObjectsPtr < 0 {
+			log.Fatalf("Invalid -flattenObjects %d (expected 0 or more)", *flattenObjectsPtr)
+		}
+		settings.flattenObjects = *flattenObjectsPtr
+		settings.requestSchemaName = strings.TrimSpace(*requestSchemaPtr)
+		variants, err := parseVariants(strings.Split(*variantPtr, ","))
+		if err != nil {
+			log.Fatalf("Invalid -variant: %v", err)
+		}
+		settings.generateVariants = variants
+		if len(variants) == 1 {
+			settings.workflowVariant = variants[0]
+		}
+		settings.responseSchemaName = strings.TrimSpace(*responseSchemaPtr)
+		if *maxSchemaDepthPtr < 0 {
+			log.Fatalf("Invalid -maxSchemaDepth %d (expected 0 or more)", *maxSchemaDepthPtr)
+		}
+		settings.maxSchemaDepth = *maxSchemaDepthPtr
+		if *timeoutPtr <= 0 {
+			log.Fatalf("Invalid -timeout %d (must be positive)", *timeoutPtr)
+		}
+		settings.apiRequestTimeout = *timeoutPtr
+		settings.strictMode = *strictPtr
+		settings.normalizeOutputs = *normalizeOutputsPtr
+		settings.localVariables = *localVariablesPtr
+		settings.idsAsStrings = *idsAsStringsPtr
+		settings.hideOptionalInputs = *hideOptionalInputsPtr
+		settings.objectURLInput = *objectURLInputPtr
+		settings.responseExamples = *responseExamplesPtr
+		if *inputSectionsPtr {
+			settings.inputSections = defaultInputSections
+		}
+		if strings.TrimSpace(*dateFormatPtr) == "" {
+			log.Fatal("Invalid -dateFormat (must not be empty)")
+		}
+		settings.dateFormat = strings.TrimSpace(*dateFormatPtr)
+		if strings.TrimSpace(*sensitiveFieldsPtr) != "" {
+			settings.sensitiveFields = strings.Split(*sensitiveFieldsPtr, ",")
+		}
+		if strings.TrimSpace(*categoryPathPtr) != "" {
+			levels := strings.Split(*categoryPathPtr, ",")
+			if _, err := parseCategoryPath(levels); err != nil {
+				log.Fatalf("Invalid -categoryPath: %v", err)
+			}
+			settings.categoryPath = levels
+		}
+		if strings.TrimSpace(*nameTemplatePtr) != "" {
+			if _, err := parseNameTemplate(*nameTemplatePtr); err != nil {
+				log.Fatalf("Invalid -nameTemplate: %v", err)
+			}
+			settings.nameTemplate = *nameTemplatePtr
+		}
+		if strings.TrimSpace(*prefixTargetsPtr) != "" {
+			targets, err := parsePrefixTargets(strings.Split(*prefixTargetsPtr, ","))
+			if err != nil {
+				log.Fatalf("Invalid -prefixTargets: %v", err)
+			}
+			settings.prefixTargets = targets
+		}
+		if strings.TrimSpace(*fixedOutputsPtr) != "" {
+			outputs, err := connector.ParseOutputs(strings.Split(*fixedOutputsPtr, ","))
+			if err != nil {
+				log.Fatalf("Invalid -fixedOutputs: %v", err)
+			}
+			settings.fixedOutputs = outputs
+		}
+		recipeNames = recipeFlags
+		for _, value := range bulkFlags {
+			c, err := bulk.ParseFlag(value)
+			if err != nil {
+				log.Fatalf("Invalid -bulk %q: %v", value, err)
+			}
+			bulkConfigs = append(bulkConfigs, c)
+		}
+		for _, spec := range specFlags {
+			name, path, ok := strings.Cut(spec, "=")
+			if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(path) == "" {
+				log.Fatalf("Invalid -spec %q (expected connector=path)", spec)
+			}
+			connectorSpecs.Set(name, path)
+		}
+		for _, spec := range specs[1:] {
+			if path, ok := connectorSpecs.Path(spec.Connector); ok && path != spec.OpenAPI {
+				log.Fatalf("Connector %s has two specs (%s and %s)", spec.Connector, path, spec.OpenAPI)
+			}
+			connectorSpecs.Set(spec.Connector, spec.OpenAPI)
+		}
+		if len(specs) > 1 {
+			connectorDirs = map[string]string{"": connectorType}
+			for _, name := range connectorSpecs.Names() {
+				connectorDirs[name] = name
+			}
+		}
+		generator, err := idgen.New(*idFormatPtr, *idPrefixPtr)
+		if err != nil {
+			log.Fatalf("Invalid -idFormat/-idPrefix: %v", err)
+		}
+		idGenerator = generator
+		settings.queryMode = strings.ToLower(strings.TrimSpace(*queryModePtr))
+		if settings.queryMode != queryModeFields && settings.queryMode != queryModeJSON {
+			log.Fatalf("Unsupported query mode %q (expected fields or json)", *queryModePtr)
+		}
+		if err := connectordef.Load(connectorDefFlags); err != nil {
+			log.Fatalf("Failed to load connector definitions: %v", err)
+		}
+		if *listConnectorsPtr {
+			if err := printConnectors(os.Stdout, *jsonPtr); err != nil {
+				log.Fatalf("Failed to list connectors: %v", err)
+			}
+			return
+		}
+		settings.currentConnector, err = getConnectorConfig(connectorType)
+		if err != nil {
+			log.Fatalf("Failed to initialize connector: %v", err)
+		}
+		if settings.platformName == "" {
+			settings.platformName = settings.currentConnector.PlatformDisplayName
+		}
+		if strings.TrimSpace(*lintDirPtr) != "" {
+			if !runLint(*lintDirPtr) {
+				os.Exit(1)
+			}
+			return
+		}
+		if strings.TrimSpace(*specDiffPtr) != "" {
+			newPath := openAPIFile
+			if fs.NArg() > 0 {
+				newPath = fs.Arg(0)
+			}
+			if strings.TrimSpace(newPath) == "" {
+				log.Fatal("-specDiff needs the newer spec as an argument or -openapi.")
+			}
+			if err := printSpecDiff(ctx, os.Stdout, *specDiffPtr, newPath); err != nil {
+				log.Fatalf("Failed to compare specs: %v", err)
+			}
+			return
+		}
+		if strings.TrimSpace(openAPIFile) == "" {
+			log.Fatal("OpenAPI file path must be provided.")
+		}
+		if strings.TrimSpace(*templatePtr) != "" {
+			settings.workflowTemplateText, err = loadWorkflowTemplate(*templatePtr)
+			if err != nil {
+				log.Fatalf("Failed to load workflow template: %v", err)
+			}
+		}
+
+		openAPISpec, err := loadOpenAPISpec(ctx, openAPIFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if strings.TrimSpace(*queryParamConfigPtr) != "" {
+			configMap, err := loadQueryParamConfig(*queryParamConfigPtr)
+			if err != nil {
+				log.Fatalf("Failed to parse query params config: %v", err)
+			}
+			settings.queryParamFilter = configMap
+		}
+
+		if *statsPtr {
+			if err := settings.printSpecStats(ctx, os.Stdout, openAPISpec, *configFilePtr); err != nil {
+				log.Fatalf("Failed to report spec statistics: %v", err)
+			}
+			return
+		}
+
+		if regenerateChanged && strings.TrimSpace(*configFilePtr) == "" {
+			log.Fatal("-regenerate-changed needs -config.")
+		}
+
+		if *rpcPtr {
+			if err := serveRPC(ctx, os.Stdin, os.Stdout, openAPISpec, settings); err != nil {
+				log.Fatalf("RPC failed: %v", err)
+			}
+			return
+		}
+
+		if strings.TrimSpace(*servePtr) != "" {
+			if err := serveGeneration(ctx, *servePtr, openAPISpec, settings); err != nil {
+				log.Fatalf("Server failed: %v", err)
+			}
+			return
+		}
+
+		if *interactivePtr {
+			err := settings.runInteractive(ctx, openAPISpec, *configFilePtr, *outputDirPtr)
+			if errors.Is(err, selector.ErrCancelled) {
+				fmt.Println("Selection cancelled; nothing generated.")
+				return
+			}
+			if err != nil {
+				log.Fatalf("Interactive selection failed: %v", err)
+			}
+			return
+		}
+
+		if strings.TrimSpace(*configFilePtr) != "" {
+			if err := settings.generateFromConfig(ctx, openAPISpec, *configFilePtr, *outputDirPtr); err != nil {
+				log.Fatalf("Failed to generate workflows from config: %v", err)
+			}
+			return
+		}
+
+		if len(settings.generateVariants) > 1 {
+			log.Fatal("-variant lists several variants; only -config renders more than one.")
+		}
+
+		if len(openAPIFlags) > 1 && len(recipeNames) == 0 && len(bulkConfigs) == 0 {
+			log.Fatal("Several -openapi/-connector pairs need -config (or -recipe/-bulk) to pick the operations of each.")
+		}
+
+		if strings.TrimSpace(*initConfigPtr) != "" {
+			if err := writeStarterConfig(openAPISpec, openAPIFile, *initConfigPtr); err != nil {
+				log.Fatalf("Failed to write starter config: %v", err)
+			}
+			return
+		}
+
+		if (len(recipeNames) > 0 || len(bulkConfigs) > 0) && strings.TrimSpace(*operationId) == "" {
+			if err := settings.generateRecipes(ctx, openAPISpec, *outputDirPtr); err != nil {
+				log.Fatalf("Failed to generate recipes: %v", err)
+			}
+			return
+		}
+
+		if strings.TrimSpace(*explainPtr) != "" {
+			if err := settings.explainWorkflow(ctx, os.Stdout, openAPISpec, strings.TrimSpace(*explainPtr)); err != nil {
+				log.Fatalf("Failed to explain workflow: %v", err)
+			}
+			return
+		}
+
+		if strings.TrimSpace(*operationId) == "" {
+			log.Fatal("operationId must be provided when not using -config.")
+		}
+
+		content, err := settings.renderWorkflow(ctx, openAPISpec, *operationId)
+		if err != nil {
+			log.Fatalf("Failed to render workflow: %v", err)
+		}
+		fmt.Println(content)
+	}
+}
+
+// explainWorkflow renders the workflow for operationId with the current settings
+// and writes its readable outline to w.
+func (s *renderSettings) explainWorkflow(ctx context.Context, w io.Writer, openAPISpec OpenAPISpec, operationId string) error {
+	content, err := s.renderWorkflow(ctx, openAPISpec, operationId)
+	if err != nil {
+		return err
+	}
+	outline, err := explain.Parse([]byte(content), s.currentConnector.ActionType)
+	if err != nil {
+		return err
+	}
+	return outline.WriteText(w)
+}
+
+// generateRequest is the body of POST /generate. The embedded WorkflowConfig
+// takes the same query_params, query_mode, body_params, assert, wait_for, table
+// and options as a config entry; endpoint plus a single method may replace
+// operation_id.
+type generateRequest struct {
+	// Spec names a spec registered with -spec (its connector is used too); empty
+	// means the -openapi spec and -connector.
+	Spec        string `json:"spec,omitempty"`
+	OperationID string `json:"operation_id,omitempty"`
+	WorkflowConfig
+}
+
+// operationInfo is one entry of GET /operations and the list command.
+type operationInfo struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	OperationID string   `json:"operation_id"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// listOperations returns the spec's operations sorted by path and method,
+// filtered by method, tag and search terms (each must appear verbatim).
+func listOperations(openAPISpec OpenAPISpec, method, tag, query string) []operationInfo {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	paths := make([]string, 0, len(openAPISpec.Paths))
+	for path := range openAPISpec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var result []operationInfo
+	for _, path := range paths {
+		ops := availableOperations(openAPISpec.Paths[path])
+		for _, opMethod := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+			op := ops[opMethod]
+			if op == nil || op.OperationId == "" || (method != "" && method != opMethod) {
+				continue
+			}
+			if tag != "" && !containsFold(op.Tags, tag) {
+				continue
+			}
+			if !selector.ContainsTerms(query, opMethod+" "+path+" "+op.OperationId) {
+				continue
+			}
+			result = append(result, operationInfo{Method: opMethod, Path: path, OperationID: op.OperationId, Tags: op.Tags})
+		}
+	}
+	return result
+}
+
+// specStats is the -stats report of a spec: operation counts by method and
+// tag, how many take a request body and which unsupported constructs occur.
+type specStats struct {
+	Operations  int
+	ByMethod    map[string]int
+	ByTag       map[string]map[string]int
+	WithBody    int
+	Unsupported int
+	IssueKinds  map[string]int
+}
+
+// statsMethods is the order methods are reported in.
+var statsMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// collectSpecStats counts the spec's operations. Operations without a tag are
+// counted under "(untagged)".
+func collectSpecStats(openAPISpec OpenAPISpec) specStats {
+	stats := specStats{ByMethod: make(map[string]int), ByTag: make(map[string]map[string]int), IssueKinds: make(map[string]int)}
+	for _, pathItem := range openAPISpec.Paths {
+		for method, op := range availableOperations(pathItem) {
+			stats.Operations++
+			stats.ByMethod[method]++
+			tags := op.Tags
+			if len(tags) == 0 {
+				tags = []string{"(untagged)"}
+			}
+			for _, tag := range tags {
+				if stats.ByTag[tag] == nil {
+					stats.ByTag[tag] = make(map[string]int)
+				}
+				stats.ByTag[tag][method]++
+			}
+			if len(op.RequestBody.Content.MediaTypes) > 0 {
+				stats.WithBody++
+			}
+			op = resolveOperationSchemas(openAPISpec, op)
+			issues := unsupportedConstructs(openAPISpec, op)
+			if len(issues) > 0 {
+				stats.Unsupported++
+			}
+			kinds := make(map[string]bool)
+			for _, issue := range issues {
+				kinds[unsupportedIssueKind(issue)] = true
+			}
+			for kind := range kinds {
+				stats.IssueKinds[kind]++
+			}
+		}
+	}
+	return stats
+}
+
+// unsupportedIssueKind groups an unsupportedConstructs issue for the report.
+func unsupportedIssueKind(issue string) string {
+	switch {
+	case strings.Contains(issue, "unresolvable $ref"):
+		return "unresolvable $ref"
+	case strings.Contains(issue, "unsupported content type"):
+		return "non-JSON content type"
+	case strings.Contains(issue, "unsupported style"):
+		return "unsupported parameter style"
+	case strings.HasSuffix(issue, "is not supported"):
+		return "unsupported parameter location"
+	}
+	for _, keyword := range []string{"allOf", "oneOf", "anyOf"} {
+		if strings.HasSuffix(issue, "unsupported "+keyword) {
+			return keyword
+		}
+	}
+	return "other"
+}
+
+// methodCounts formats counts as "GET 12, POST 3" in statsMethods order.
+func methodCounts(counts map[string]int) string {
+	var parts []string
+	for _, method := range statsMethods {
+		if counts[method] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", method, counts[method]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeSpecStats prints the report, busiest tags first.
+func writeSpecStats(w io.Writer, stats specStats) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Operations: %d (%s)\n", stats.Operations, methodCounts(stats.ByMethod))
+	fmt.Fprintf(&b, "With a request body: %d\n", stats.WithBody)
+	fmt.Fprintf(&b, "With unsupported constructs: %d\n", stats.Unsupported)
+	kinds := make([]string, 0, len(stats.IssueKinds))
+	for kind := range stats.IssueKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if stats.IssueKinds[kinds[i]] != stats.IssueKinds[kinds[j]] {
+			return stats.IssueKinds[kinds[i]] > stats.IssueKinds[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "  %s: %d\n", kind, stats.IssueKinds[kind])
+	}
+	tags := make([]string, 0, len(stats.ByTag))
+	totals := make(map[string]int)
+	for tag, counts := range stats.ByTag {
+		tags = append(tags, tag)
+		for _, count := range counts {
+			totals[tag] += count
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if totals[tags[i]] != totals[tags[j]] {
+			return totals[tags[i]] > totals[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	fmt.Fprintf(&b, "Tags: %d\n", len(tags))
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "  %s: %d (%s)\n", tag, totals[tag], methodCounts(stats.ByTag[tag]))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// generatedFileEstimate counts the files -config would write, without
+// rendering anything: workflows (the entries' atomics plus the atomics,
+// composites and bulk workflows built from them), triggers, Mermaid charts and
+// the lockfile, import manifest and required-fields report.
+type generatedFileEstimate struct {
+	Atomics    int
+	Composites int
+	Bulk       int
+	Triggers   int
+	Charts     int
+	Support    int
+}
+
+func (e generatedFileEstimate) total() int {
+	return e.Atomics + e.Composites + e.Bulk + e.Triggers + e.Charts + e.Support
+}
+
+// estimateGeneratedFiles resolves the config's entries against the spec, or
+// the -spec of an entry's connector.
+func (s *renderSettings) estimateGeneratedFiles(ctx context.Context, openAPISpec OpenAPISpec, cfg *workflowConfigFile) (generatedFileEstimate, error) {
+	var estimate generatedFileEstimate
+	atomics := make(map[string]bool)
+	withBody := false
+	for _, wf := range cfg.Workflows {
+		wf = applyWorkflowDefaults(cfg.Defaults, wf)
+		entrySpec, _, _, err := s.specForConnector(ctx, openAPISpec, wf.Connector)
+		if err != nil {
+			return estimate, fmt.Errorf("endpoint %s: %w", wf.Endpoint, err)
+		}
+		entryOps, err := resolveWorkflowEntry(entrySpec, wf)
+		if err != nil {
+			return estimate, err
+		}
+		for _, entryOp := range entryOps {
+			variants, err := s.entryVariants(wf, entryOp.Method)
+			if err != nil {
+				return estimate, err
+			}
+			for _, variant := range variants {
+				atomics[atomicName(wf, entryOp.OperationId, variant)] = true
+			}
+			if op, _, _, err := ExtractOperation(entrySpec, entryOp.OperationId); err == nil && len(op.RequestBody.Content.MediaTypes) > 0 {
+				withBody = true
+			}
+		}
+	}
+	recipes, err := selectRecipes(cfg.Composites, append(append([]string{}, cfg.Recipes...), recipeNames...))
+	if err != nil {
+		return estimate, err
+	}
+	for _, recipe := range recipes {
+		for _, step := range recipe.Operations() {
+			atomics[step.Operation] = true
+		}
+		estimate.Composites++
+	}
+	for _, c := range append(append([]bulk.Config{}, cfg.Bulk...), bulkConfigs...) {
+		for _, operationId := range c.Operations() {
+			atomics[operationId] = true
+		}
+		estimate.Bulk++
+	}
+	estimate.Atomics = len(atomics)
+	estimate.Triggers = len(cfg.Triggers)
+	if emitMermaid {
+		estimate.Charts = estimate.Atomics + estimate.Composites + estimate.Bulk
+	}
+	// The lockfile and import manifest, plus the required-fields report when
+	// a workflows entry has a request body.
+	estimate.Support = 2
+	if withBody {
+		estimate.Support++
+	}
+	return estimate, nil
+}
+
+// printSpecStats writes the -stats report, followed by the generated-file
+// estimate of configPath when one is given.
+func (s *renderSettings) printSpecStats(ctx context.Context, w io.Writer, openAPISpec OpenAPISpec, configPath string) error {
+	if err := writeSpecStats(w, collectSpecStats(openAPISpec)); err != nil {
+		return err
+	}
+	if strings.TrimSpace(configPath) == "" {
+		return nil
+	}
+	cfg, err := loadWorkflowConfig(configPath)
+	if err != nil {
+		return err
+	}
+	estimate, err := s.estimateGeneratedFiles(ctx, openAPISpec, cfg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Generated files for %s: about %d (%d atomics, %d composites, %d bulk workflows, %d triggers, %d Mermaid charts, %d support files)\n",
+		configPath, estimate.total(), estimate.Atomics, estimate.Composites, estimate.Bulk, estimate.Triggers, estimate.Charts, estimate.Support)
+	return err
+}
+
+// specDiffMaxDepth bounds how deep body and response schemas are flattened
+// into specdiff facts.
+const specDiffMaxDepth = 6
+
+// specDiffOperations flattens every operation of a spec into the facts its
+// generated workflow depends on.
+func specDiffOperations(openAPISpec OpenAPISpec) map[string]specdiff.Operation {
+	operations := make(map[string]specdiff.Operation)
+	for path, pathItem := range openAPISpec.Paths {
+		for method, op := range availableOperations(pathItem) {
+			if op.OperationId == "" {
+				continue
+			}
+			operations[op.OperationId] = operationFacts(openAPISpec, method, path, op)
+		}
+	}
+	return operations
+}
+
+// pinnedOperationFacts returns the facts of an operation with the schemas a
+// config entry pins (request_schema, response_schema) in place of its own, so
+// -regenerate-changed notices changes of the pinned components too.
+func pinnedOperationFacts(openAPISpec OpenAPISpec, wf WorkflowConfig, operationId string) (specdiff.Operation, error) {
+	op, path, method, err := ExtractOperation(openAPISpec, operationId)
+	if err != nil {
+		return specdiff.Operation{}, err
+	}
+	op = resolveOperationSchemas(openAPISpec, op)
+	requestSchema := wf.RequestSchema
+	if method != "POST" && method != "PUT" && method != "PATCH" {
+		requestSchema = ""
+	}
+	if err := forceOperationSchemas(openAPISpec, op, requestSchema, wf.ResponseSchema); err != nil {
+		return specdiff.Operation{}, err
+	}
+	return operationFacts(openAPISpec, method, path, op), nil
+}
+
+// operationFacts flattens one operation: endpoint, description, parameters,
+// request body fields and the fields of its lowest success response.
+func operationFacts(openAPISpec OpenAPISpec, method, path string, op *Operation) specdiff.Operation {
+	op = resolveOperationSchemas(openAPISpec, op)
+	facts := map[string]string{
+		"endpoint":    method + " " + path,
+		"description": op.Description,
+	}
+	for _, param := range op.Parameters {
+		facts["parameter "+param.In+" "+param.Name] = schemaShape(param.Schema, param.Required)
+	}
+	schemaFacts(facts, "body", op.RequestBody.Content.ApplicationJSON.Schema, false, 0)
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			facts["response status"] = code
+			schemaFacts(facts, "response", op.Responses[code].Content.ApplicationJSON.Schema, false, 0)
+			break
+		}
+	}
+	return specdiff.Operation{Method: method, Path: path, Facts: facts}
+}
+
+// schemaFacts adds the shape of schema and of its properties and items, named
+// by their dotted path under subject.
+func schemaFacts(facts map[string]string, subject string, schema Schema, required bool, depth int) {
+	if depth == 0 && schema.Type == "" && schema.Ref == "" && len(schema.Properties) == 0 && schema.Items == nil {
+		// No body or no response content.
+		return
+	}
+	facts[subject] = schemaShape(schema, required)
+	if depth >= specDiffMaxDepth {
+		return
+	}
+	if schema.Items != nil {
+		schemaFacts(facts, subject+"[]", *schema.Items, false, depth+1)
+	}
+	for name, property := range schema.Properties {
+		schemaFacts(facts, subject+"."+name, property, contains(schema.Required, name), depth+1)
+	}
+}
+
+// schemaShape describes a schema for comparison: type and format, whether it is
+// required, write-only and its enum values.
+func schemaShape(schema Schema, required bool) string {
+	parts := []string{schema.Type}
+	switch {
+	case schema.Ref != "":
+		parts[0] = schema.Ref
+	case schema.Type == "" && len(schema.OneOf) > 0:
+		parts[0] = "oneOf"
+	case schema.Type == "" && len(schema.AnyOf) > 0:
+		parts[0] = "anyOf"
+	case schema.Type == "" && len(schema.AllOf) > 0:
+		parts[0] = "allOf"
+	case schema.Type == "":
+		parts[0] = "any"
+	}
+	if schema.Format != "" {
+		parts[0] += "/" + schema.Format
+	}
+	if required {
+		parts = append(parts, "required")
+	}
+	if schema.WriteOnly {
+		parts = append(parts, "write-only")
+	}
+	if len(schema.Enum) > 0 {
+		values := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		parts = append(parts, "enum "+strings.Join(values, "|"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// specDiffSchemas returns the canonical JSON of every component schema, as
+// far as the generator reads schemas.
+func specDiffSchemas(openAPISpec OpenAPISpec) (map[string][]byte, error) {
+	schemas := make(map[string][]byte, len(openAPISpec.Components.Schemas))
+	for name, schema := range openAPISpec.Components.Schemas {
+		content, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		schemas[name] = content
+	}
+	return schemas, nil
+}
+
+// printSpecDiff writes the -specDiff report from the spec at oldPath to the
+// one at newPath.
+func printSpecDiff(ctx context.Context, w io.Writer, oldPath, newPath string) error {
+	oldSpec, err := loadOpenAPISpec(ctx, oldPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newSpec, err := loadOpenAPISpec(ctx, newPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", newPath, err)
+	}
+	// Schemas are compared before the operations resolve their refs in place.
+	oldSchemas, err := specDiffSchemas(oldSpec)
+	if err != nil {
+		return err
+	}
+	newSchemas, err := specDiffSchemas(newSpec)
+	if err != nil {
+		return err
+	}
+	operations := specdiff.CompareOperations(specDiffOperations(oldSpec), specDiffOperations(newSpec))
+	return specdiff.WriteText(w, operations, specdiff.CompareSchemas(oldSchemas, newSchemas))
+}
+
+// generationServer serves -serve and -rpc requests. Each request renders with
+// its own copy of the run's settings, so requests are generated concurrently.
+type generationServer struct {
+	spec     OpenAPISpec
+	settings renderSettings
+}
+
+// serveGeneration runs the HTTP API on addr until ctx is cancelled.
+func serveGeneration(ctx context.Context, addr string, openAPISpec OpenAPISpec, settings renderSettings) error {
+	srv := &generationServer{spec: openAPISpec, settings: settings}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           srv.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	log.Printf("Serving workflow generation on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handler routes GET /operations and POST /generate.
+func (s *generationServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /operations", s.handleOperations)
+	mux.HandleFunc("POST /generate", s.handleGenerate)
+	return mux
+}
+
+// specFor returns the spec and connector a request names.
+func (s *generationServer) specFor(ctx context.Context, name string) (OpenAPISpec, connectorConfig, error) {
+	spec, cfg, _, err := s.settings.specForConnector(ctx, s.spec, name)
+	return spec, cfg, err
+}
+
+func (s *generationServer) handleOperations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	spec, _, err := s.specFor(r.Context(), query.Get("spec"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	operations := listOperations(spec, query.Get("method"), query.Get("tag"), query.Get("q"))
+	if operations == nil {
+		operations = []operationInfo{}
+	}
+	writeJSON(w, http.StatusOK, operations)
+}
+
+func (s *generationServer) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeGenerateRequest(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	// Post-processors run commands on the server; they are CLI-only.
+	if req.Options != nil && len(req.Options.PostProcess) > 0 {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("options.post_process is not allowed over HTTP"))
+		return
+	}
+
+	content, err := s.generate(r.Context(), req)
+	if err != nil {
+		writeJSONError(w, generateErrorStatus(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, content+"\n")
+}
+
+// decodeGenerateRequest decodes a POST /generate body or the params of the
+// preview RPC. Unknown fields are rejected so a misspelled option fails instead
+// of being ignored.
+func decodeGenerateRequest(body io.Reader) (generateRequest, error) {
+	var req generateRequest
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+	return req, err
+}
+
+// generate renders one request.
+func (s *generationServer) generate(ctx context.Context, req generateRequest) (string, error) {
+	spec, connector, err := s.specFor(ctx, req.Spec)
+	if err != nil {
+		return "", err
+	}
+	operationId := strings.TrimSpace(req.OperationID)
+	if operationId == "" {
+		if strings.TrimSpace(req.Endpoint) == "" || len(req.Methods) != 1 {
+			return "", fmt.Errorf("set operation_id, or endpoint with exactly one method")
+		}
+		_, item, err := findPathItem(spec, normalizeEndpointPath(req.Endpoint))
+		if err != nil {
+			return "", err
+		}
+		method := strings.ToUpper(strings.TrimSpace(req.Methods[0]))
+		op := availableOperations(item)[method]
+		if op == nil {
+			return "", generator.NewError(generator.ErrOperationNotFound, "", fmt.Errorf("method %s not available for endpoint %s", method, req.Endpoint))
+		}
+		operationId = op.OperationId
+	}
+	_, _, method, err := ExtractOperation(spec, operationId)
+	if err != nil {
+		return "", err
+	}
+
+	settings := s.settings.withConnector(connector)
+	return settings.renderConfiguredOperation(ctx, spec, req.WorkflowConfig, operationId, method, req.QueryParams)
+}
+
+// generateErrorStatus maps generation failures to HTTP status codes.
+func generateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, generator.ErrOperationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, generator.ErrUnsupportedSchema):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, generator.ErrTemplateRender):
+		return http.StatusInternalServerError
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// configDiagnostic is one problem found by validateWorkflowConfig.
+type configDiagnostic struct {
+	Severity string `json:"severity"`
+	// Entry is the index in workflows, or -1 for file-level problems.
+	Entry    int    `json:"entry"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Message  string `json:"message"`
+}
+
+// validateWorkflowConfig checks a parsed config against the spec without writing
+// anything: every entry must resolve to operations that render, and filters
+// naming params the operation does not have are reported as warnings.
+func (s *renderSettings) validateWorkflowConfig(ctx context.Context, openAPISpec OpenAPISpec, cfg *workflowConfigFile) []configDiagnostic {
+	var diagnostics []configDiagnostic
+	defaultQueryParams := ensureQueryParamList(cfg.Defaults.QueryParams)
+	for i, wf := range cfg.Workflows {
+		wf = applyWorkflowDefaults(cfg.Defaults, wf)
+		report := func(severity, method, message string) {
+			diagnostics = append(diagnostics, configDiagnostic{Severity: severity, Entry: i, Endpoint: wf.Endpoint, Method: method, Message: message})
+		}
+		entrySpec, entryConnector, _, err := s.specForConnector(ctx, openAPISpec, wf.Connector)
+		if err != nil {
+			report("error", "", err.Error())
+			continue
+		}
+		entryOps, err := resolveWorkflowEntry(entrySpec, wf)
+		if err != nil {
+			report("error", "", err.Error())
+			continue
+		}
+		if _, err := s.entryVariants(wf, "POST"); err != nil {
+			report("error", "", err.Error())
+		}
+		for _, entryOp := range entryOps {
+			op, _, _, _ := ExtractOperation(entrySpec, entryOp.OperationId)
+			if entryOp.Method == "GET" {
+				known := make(map[string]bool)
+				for _, param := range op.Parameters {
+					known[param.Name] = true
+				}
+				for _, name := range wf.QueryParams {
+					if !known[strings.TrimSpace(name)] {
+						report("warning", entryOp.Method, fmt.Sprintf("query param %q is not a parameter of %s", name, entryOp.OperationId))
+					}
+				}
+			}
+			if entryOp.Method == "POST" || entryOp.Method == "PUT" || entryOp.Method == "PATCH" {
+				body := op.RequestBody.Content.ApplicationJSON.Schema
+				if name := strings.TrimSpace(wf.RequestSchema); name != "" {
+					body = Schema{Ref: "#/components/schemas/" + name}
+				}
+				body = resolveSchemaRefs(entrySpec, body)
+				for _, name := range wf.BodyParams {
+					if _, ok := body.Properties[strings.TrimSpace(name)]; !ok {
+						report("warning", entryOp.Method, fmt.Sprintf("body param %q is not a property of the %s request body", name, entryOp.OperationId))
 					}
-					blockTitle = "Ignore If Not Exists"
 				}
+			}
+			queryParams := append(append([]string{}, defaultQueryParams...), wf.QueryParams...)
+			settings := s.withConnector(entryConnector)
+			if _, err := settings.renderConfiguredOperation(ctx, entrySpec, wf, entryOp.OperationId, entryOp.Method, queryParams); err != nil {
+				report("error", entryOp.Method, err.Error())
+			}
+		}
+	}
+	for _, recipe := range cfg.Composites {
+		if err := recipe.Validate(); err != nil {
+			diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: err.Error()})
+		}
+	}
+	if _, err := selectRecipes(nil, cfg.Recipes); err != nil {
+		diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: err.Error()})
+	}
+	for _, c := range cfg.Bulk {
+		if err := c.Validate(); err != nil {
+			diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: err.Error()})
+		}
+	}
+	for _, t := range cfg.Triggers {
+		if err := t.Validate(); err != nil {
+			diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: err.Error()})
+		}
+	}
+	return diagnostics
+}
 
-				block.Actions = append(block.Actions, ActionData{
-					UniqueName: "definition_activity_" + KSUIDGenerator(),
-					Name:       "Condition Block",
-					Title:      "Skip Errors?",
-					Type:       "logic.if_else",
-					BaseType:   "activity",
-					Properties: map[string]interface{}{
-						"conditions":          []interface{}{},
-						"continue_on_failure": false,
-						"display_name":        "Skip Errors?",
-						"skip_execution":      false,
-					},
-					ObjectType: "definition_activity",
-					Blocks: []BlockData{
-						{
-							UniqueName: "definition_activity_" + KSUIDGenerator(),
-							Name:       "Condition Branch",
-							Title:      blockTitle,
-							Type:       "logic.condition_block",
-							BaseType:   "activity",
-							Properties: BlockProperties{
-								Condition: Condition{
-									LeftOperand: Condition{
-										LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$ignoreIfExistKSUID$",
-										Operator:     "eq",
-										RightOperand: true,
-									},
-									Operator:     "and",
-									RightOperand: idempotencyIndicator,
-								},
-								DisplayName:       blockTitle,
-								ContinueOnFailure: false,
-								SkipExecution:     false,
-							},
-							ObjectType: "definition_activity",
-							Actions: []ActionData{
-								{
-									UniqueName: "definition_activity_" + KSUIDGenerator(),
-									Name:       "Completed",
-									Title:      "Completed - Success",
-									Type:       "logic.completed",
-									BaseType:   "activity",
-									Properties: map[string]interface{}{
-										"completion_type":     "succeeded",
-										"continue_on_failure": false,
-										"display_name":        "Completed - Success",
-										"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$StatusMessageKSUID$",
-										"skip_execution":      false,
-									},
-								},
-							},
-						},
-						{
-							UniqueName: "definition_activity_" + KSUIDGenerator(),
-							Name:       "Condition Branch",
-							Title:      "Failed",
-							Type:       "logic.condition_block",
-							BaseType:   "activity",
-							Properties: BlockProperties{
-								Condition: Condition{
-									LeftOperand:  "$workflow.definition_workflow_$WorkflowKSUID.input.variable_workflow_$ignoreIfExistKSUID$",
-									Operator:     "eq",
-									RightOperand: false,
-								},
-								DisplayName:       "Failed",
-								ContinueOnFailure: false,
-								SkipExecution:     false,
-							},
-							ObjectType: "definition_activity",
-							Actions: []ActionData{
-								{
-									UniqueName: "definition_activity_" + KSUIDGenerator(),
-									Name:       "Completed",
-									Title:      "Completed - Failed",
-									Type:       "logic.completed",
-									BaseType:   "activity",
-									Properties: map[string]interface{}{
-										"completion_type":     "failed-completed",
-										"continue_on_failure": false,
-										"display_name":        "Completed - Failed",
-										"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
-										"skip_execution":      false,
-									},
-									ObjectType: "definition_activity",
-								},
-							},
-						},
-					},
-				})
-			} else {
-				// if no imdepotency is needed we can fail directly.
-				block.Actions = append(block.Actions, ActionData{
-					UniqueName: "definition_activity_" + KSUIDGenerator(),
-					Name:       "Completed",
-					Title:      "Completed - Failed",
-					Type:       "logic.completed",
-					BaseType:   "activity",
-					Properties: map[string]interface{}{
-						"completion_type":     "failed-completed",
-						"continue_on_failure": false,
-						"display_name":        "Completed - Failed",
-						"result_message":      "$workflow.definition_workflow_$WorkflowKSUID.output.variable_workflow_$ErrorMessageKSUID$",
-						"skip_execution":      false,
-					},
-					ObjectType: "definition_activity",
-				})
+// JSON-RPC error codes carrying the generator error categories.
+const (
+	rpcGenerationFailed    = -32000
+	rpcOperationNotFound   = -32001
+	rpcUnsupportedSchema   = -32002
+	rpcTemplateRenderError = -32003
+)
+
+// serveRPC answers JSON-RPC 2.0 requests on in/out until shutdown, EOF or ctx
+// ends. Messages are either single JSON lines or LSP-style Content-Length framed;
+// each reply uses the framing of its request. Methods:
+//
+//	operations     {spec, method, tag, q}        -> [{method, path, operation_id, tags}]
+//	preview        POST /generate request body   -> {title, workflow}
+//	validateConfig {path, text}                  -> {diagnostics: [...]}
+//	shutdown                                     -> null, then the loop ends
+func serveRPC(ctx context.Context, in io.Reader, out io.Writer, openAPISpec OpenAPISpec, settings renderSettings) error {
+	srv := &generationServer{spec: openAPISpec, settings: settings}
+	reader := bufio.NewReader(in)
+	for ctx.Err() == nil {
+		body, framed, err := jsonrpc.ReadMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if body == nil {
+			continue
+		}
+		var req jsonrpc.Request
+		var resp jsonrpc.Response
+		if err := json.Unmarshal(body, &req); err != nil {
+			resp = jsonrpc.Response{Error: &jsonrpc.Error{Code: jsonrpc.CodeParseError, Message: err.Error()}}
+		} else {
+			resp = srv.handleRPC(ctx, req)
+			if req.ID == nil {
+				// Notifications get no reply; a shutdown one still ends the loop.
+				if req.Method == "shutdown" {
+					return nil
+				}
+				continue
+			}
+		}
+		resp.JSONRPC = "2.0"
+		resp.ID = req.ID
+		if resp.ID == nil {
+			resp.ID = json.RawMessage("null")
+		}
+		if err := jsonrpc.WriteMessage(out, resp, framed); err != nil {
+			return err
+		}
+		if req.Method == "shutdown" {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *generationServer) handleRPC(ctx context.Context, req jsonrpc.Request) jsonrpc.Response {
+	if req.Method == "" {
+		return jsonrpc.Response{Error: &jsonrpc.Error{Code: jsonrpc.CodeInvalidRequest, Message: "missing method"}}
+	}
+	invalidParams := func(err error) jsonrpc.Response {
+		return jsonrpc.Response{Error: &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}}
+	}
+	params := req.Params
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("{}")
+	}
+	switch req.Method {
+	case "operations":
+		var p struct {
+			Spec   string `json:"spec"`
+			Method string `json:"method"`
+			Tag    string `json:"tag"`
+			Q      string `json:"q"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return invalidParams(err)
+		}
+		spec, _, err := s.specFor(ctx, p.Spec)
+		if err != nil {
+			return rpcFailure(err)
+		}
+		operations := listOperations(spec, p.Method, p.Tag, p.Q)
+		if operations == nil {
+			operations = []operationInfo{}
+		}
+		return jsonrpc.Response{Result: operations}
+	case "preview":
+		p, err := decodeGenerateRequest(bytes.NewReader(params))
+		if err != nil {
+			return invalidParams(err)
+		}
+		if p.Options != nil && len(p.Options.PostProcess) > 0 {
+			return invalidParams(fmt.Errorf("options.post_process is not allowed over RPC"))
+		}
+		content, err := s.generate(ctx, p)
+		if err != nil {
+			return rpcFailure(err)
+		}
+		var workflow struct {
+			Workflow struct {
+				Title string `json:"title"`
+			} `json:"workflow"`
+		}
+		_ = json.Unmarshal([]byte(content), &workflow)
+		return jsonrpc.Response{Result: map[string]interface{}{
+			"title":    workflow.Workflow.Title,
+			"workflow": json.RawMessage(content),
+		}}
+	case "validateConfig":
+		var p struct {
+			Path string  `json:"path"`
+			Text *string `json:"text"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return invalidParams(err)
+		}
+		if strings.TrimSpace(p.Path) == "" {
+			return invalidParams(fmt.Errorf("path is required (it also resolves relative includes)"))
+		}
+		var cfg *workflowConfigFile
+		var err error
+		if p.Text != nil {
+			cfg, err = parseWorkflowConfigFile(p.Path, []byte(*p.Text), make(map[string]bool))
+		} else {
+			cfg, err = loadWorkflowConfigFile(p.Path, make(map[string]bool))
+		}
+		diagnostics := []configDiagnostic{}
+		if err != nil {
+			diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: err.Error()})
+		} else {
+			// Validation renders the entries, and post-processors run commands on
+			// the server: the config's own are reported and left out.
+			if stripPostProcess(cfg) {
+				diagnostics = append(diagnostics, configDiagnostic{Severity: "error", Entry: -1, Message: "options.post_process is not allowed over RPC; the config was validated without it"})
 			}
+			diagnostics = append(diagnostics, s.settings.validateWorkflowConfig(ctx, s.spec, cfg)...)
 		}
-
+		return jsonrpc.Response{Result: map[string]interface{}{"diagnostics": diagnostics}}
+	case "shutdown":
+		return jsonrpc.Response{Result: json.RawMessage("null")}
 	}
+	return jsonrpc.Response{Error: &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}}
+}
 
-	actions = append(actions, conditionalBlock)
-
-	// Construct the workflow data
-	categories := []string{}
-	categoriesMap := map[string]CategoryData{}
-	if strings.TrimSpace(categoryId) != "" && strings.TrimSpace(categoryName) != "" {
-		categories = []string{categoryId}
-		categoriesMap[categoryId] = CategoryData{
-			UniqueName:   categoryId,
-			Name:         categoryName,
-			Title:        categoryName,
-			Type:         "basic.category",
-			BaseType:     "category",
-			CategoryType: "custom",
-			ObjectType:   "category",
+// stripPostProcess clears the post_process option of cfg's defaults and entries
+// and reports whether any was set.
+func stripPostProcess(cfg *workflowConfigFile) bool {
+	found := false
+	strip := func(options *WorkflowOptions) {
+		if options != nil && len(options.PostProcess) > 0 {
+			options.PostProcess = nil
+			found = true
 		}
 	}
-
-	return WorkflowData{
-		UniqueName: "definition_workflow_$WorkflowKSUID",
-		Name:       operationDisplayName,
-		Title:      operationDisplayName,
-		Type:       "generic.workflow",
-		BaseType:   "workflow",
-		Variables:  variables,
-		Properties: WorkflowProperties{
-			Atomic: AtomicData{
-				AtomicGroup: currentConnector.AtomicGroup,
-				IsAtomic:    true,
-			},
-			Description: operation.Description,
-			DisplayName: operationDisplayName,
-			RuntimeUser: RuntimeUserData{
-				TargetDefault: true,
-			},
-			Target: TargetData{
-				TargetType:             currentConnector.TargetType,
-				SpecifyOnWorkflowStart: true,
-			},
-		},
-		ObjectType:    "definition_workflow",
-		Actions:       actions,
-		Categories:    categories,
-		CategoriesMap: categoriesMap,
+	strip(cfg.Defaults.Options)
+	for i := range cfg.Workflows {
+		strip(cfg.Workflows[i].Options)
 	}
+	return found
 }
 
-func GenerateJsonpathQueries(responseSchema Schema, method string) []JsonpathQuery {
-	var queries []JsonpathQuery
+// rpcFailure maps generation failures to JSON-RPC error codes.
+func rpcFailure(err error) jsonrpc.Response {
+	code := rpcGenerationFailed
+	switch {
+	case errors.Is(err, generator.ErrOperationNotFound):
+		code = rpcOperationNotFound
+	case errors.Is(err, generator.ErrUnsupportedSchema):
+		code = rpcUnsupportedSchema
+	case errors.Is(err, generator.ErrTemplateRender):
+		code = rpcTemplateRenderError
+	}
+	return jsonrpc.Response{Error: &jsonrpc.Error{Code: code, Message: err.Error()}}
+}
 
-	// Add the fixed "Result" query
-	queries = append(queries, JsonpathQuery{
-		JsonpathQuery:     "$",
-		JsonpathQueryName: "Result",
-		JsonpathQueryType: "string",
-		ZdateTypeFormat:   "yyyy-MM-dd'T'HH:mm:ssZ",
-	})
+// connectorSpecs holds the OpenAPI specs of the connectors other than the
+// run's that entries and composite steps render for (-spec connector=path and
+// the run's further specs).
+var connectorSpecs = connectordef.NewSpecs(loadOpenAPISpec)
+
+// connectorDirs maps connectors to the subdirectory of the output directory
+// their atomics go to when a run covers several specs (-openapi/-connector
+// pairs or the config's specs); "" is the run's connector. Runs with one spec
+// leave it nil and write every file at the root. It is set at startup, before
+// the first file is written.
+var connectorDirs map[string]string
+
+// atomicFileName is the path, relative to the output directory, of the atomic
+// file filename rendered for connector.
+func atomicFileName(connector, filename string) string {
+	if dir, ok := connectorDirs[connector]; ok {
+		return path.Join(dir, filename)
+	}
+	return filename
+}
 
-	isCreateOrUpdate := strings.EqualFold(method, "POST") || strings.EqualFold(method, "PATCH") || strings.EqualFold(method, "PUT")
-	
-	if !isCreateOrUpdate {
-		// Generate queries for each property in the response schema (skip for POST/PATCH/PUT)
-		for propName, propSchema := range responseSchema.Properties {
-			queryName := HumanReadableName(propName)
+// recipeStepConnector returns the spec and connector a composite step renders
+// with, and whether they differ from the run's connector.
+func (s *renderSettings) recipeStepConnector(ctx context.Context, openAPISpec OpenAPISpec, step composite.Step) (OpenAPISpec, connectorConfig, bool, error) {
+	spec, cfg, foreign, err := s.specForConnector(ctx, openAPISpec, step.Connector)
+	if err != nil {
+		return OpenAPISpec{}, connectorConfig{}, false, fmt.Errorf("step %s: %w", step.ID, err)
+	}
+	return spec, cfg, foreign, nil
+}
 
-			queryType := "string"
-			if propSchema.Type == "boolean" {
-				queryType = "boolean"
-			}
+// specForConnector returns the spec and connector config for a connector name,
+// loading (once) the spec registered with -spec for connectors other than the
+// run's, and whether they differ from the run's. An empty name is the run's.
+func (s *renderSettings) specForConnector(ctx context.Context, openAPISpec OpenAPISpec, connector string) (OpenAPISpec, connectorConfig, bool, error) {
+	if strings.TrimSpace(connector) == "" {
+		return openAPISpec, s.currentConnector, false, nil
+	}
+	name := strings.ToLower(strings.TrimSpace(connector))
+	cfg, err := getConnectorConfig(name)
+	if err != nil {
+		return OpenAPISpec{}, connectorConfig{}, false, err
+	}
+	if cfg.TargetType == s.currentConnector.TargetType {
+		return openAPISpec, s.currentConnector, false, nil
+	}
+	spec, err := connectorSpecs.Get(ctx, name)
+	if err != nil {
+		return OpenAPISpec{}, connectorConfig{}, false, err
+	}
+	return spec, cfg, true, nil
+}
 
-			queries = append(queries, JsonpathQuery{
-				JsonpathQuery:     fmt.Sprintf("$.%s", propName),
-				JsonpathQueryName: queryName,
-				JsonpathQueryType: queryType,
-				ZdateTypeFormat:   "yyyy-MM-dd'T'HH:mm:ssZ",
-			})
-		}
+// renderWithConnector renders an operation with connector active.
+func (s *renderSettings) renderWithConnector(ctx context.Context, openAPISpec OpenAPISpec, connector connectorConfig, operationId string) (string, error) {
+	settings := s.withConnector(connector)
+	return settings.renderWorkflow(ctx, openAPISpec, operationId)
+}
+
+// withConnector returns a copy of the settings with connector active; a
+// connector of another platform also brings its platform name.
+func (s *renderSettings) withConnector(connector connectorConfig) renderSettings {
+	settings := *s
+	if connector.TargetType != s.currentConnector.TargetType {
+		settings.currentConnector = connector
+		settings.platformName = connector.PlatformDisplayName
+	}
+	return settings
+}
+
+// renderedKey is the key of an atomic rendered in this run: the operationId,
+// prefixed with "<connector>:" for a connector other than the run's.
+func renderedKey(connector, operationId string) string {
+	if connector == "" {
+		return operationId
 	}
+	return connector + ":" + operationId
+}
 
-	return queries
+// connectorLabel names a connector in messages; empty is the run's -connector.
+func connectorLabel(connector string) string {
+	if connector == "" {
+		return "-connector"
+	}
+	return connector
 }
 
-func capitalizeAcronyms(workflowData *WorkflowData) {
-	// Open the CSV file
-	file, err := os.Open("networking_acronyms.csv")
+// loadOpenAPISpec reads a JSON or YAML OpenAPI document from a file or an
+// http(s) URL.
+func loadOpenAPISpec(ctx context.Context, path string) (OpenAPISpec, error) {
+	var source io.ReadCloser
+	var err error
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		source, err = fetchOpenAPISpec(ctx, path)
+	} else {
+		source, err = fsutil.Open(path)
+	}
 	if err != nil {
-		log.Fatalf("Error opening CSV file: %v", err)
+		return OpenAPISpec{}, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+	defer source.Close()
+	openAPISpec, err := decodeOpenAPISpec(source)
+	if err != nil {
+		return openAPISpec, err
 	}
-	defer file.Close()
+	openAPISpec.resolveParameterRefs()
+	openAPISpec.mergePathParameters()
+	openAPISpec.resolvedSchemas = newSchemaMemo()
+	return openAPISpec, nil
+}
 
-	// Parse the CSV file to get acronyms
-	r := csv.NewReader(file)
-	acronyms, err := r.Read()
+// decodeOpenAPISpec parses a spec from r. JSON is decoded as it is read, so a
+// spec of tens of MB is not held twice; YAML has to be read whole to be
+// converted.
+func decodeOpenAPISpec(r io.Reader) (OpenAPISpec, error) {
+	var openAPISpec OpenAPISpec
+	reader := bufio.NewReader(r)
+	var leading bytes.Buffer
+	for {
+		c, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return openAPISpec, fmt.Errorf("failed to read OpenAPI file: %w", err)
+		}
+		if c == '{' || c == '[' {
+			_ = reader.UnreadByte()
+			if err := json.NewDecoder(reader).Decode(&openAPISpec); err != nil {
+				return openAPISpec, fmt.Errorf("failed to parse OpenAPI JSON: %w", err)
+			}
+			return openAPISpec, nil
+		}
+		leading.WriteByte(c)
+		if !strings.ContainsRune(" \t\r\n", rune(c)) {
+			break
+		}
+	}
+	content, err := io.ReadAll(io.MultiReader(&leading, reader))
 	if err != nil {
-		log.Fatalf("Error reading CSV: %v", err)
+		return openAPISpec, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+	content, err = normalizeOpenAPIContent(content)
+	if err != nil {
+		return openAPISpec, fmt.Errorf("failed to interpret OpenAPI file: %w", err)
+	}
+	if err := json.Unmarshal(content, &openAPISpec); err != nil {
+		return openAPISpec, fmt.Errorf("failed to parse OpenAPI JSON: %w", err)
 	}
+	return openAPISpec, nil
+}
 
-	// Create a map of acronyms for quick lookup
-	acronymMap := make(map[string]string)
-	for _, acronym := range acronyms {
-		acronymMap[strings.ToLower(acronym)] = acronym
+// fetchOpenAPISpec downloads a spec; ctx bounds the whole transfer, which lasts
+// until the returned body is read and closed.
+func fetchOpenAPISpec(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
 
-	// Function to replace text using acronyms
-	replaceTextWithAcronyms := func(text string, acronymMap map[string]string) string {
-		for fullName, acronym := range acronymMap {
-			pattern := fmt.Sprintf(`(?i)\b%s\b|^%s\b|\b%s$`, regexp.QuoteMeta(fullName), regexp.QuoteMeta(fullName), regexp.QuoteMeta(fullName))
-			re := regexp.MustCompile(pattern)
-			text = re.ReplaceAllStringFunc(text, func(match string) string {
-				return acronym
-			})
+// starterMaxFilters caps the commented-out query_params listed per GET entry.
+const starterMaxFilters = 20
+
+// writeStarterConfig writes a workflow config with one entry per spec path,
+// grouped by the first tag of its operations, as a starting point for -config.
+// PUT is left out where PATCH exists, and collections with an item path keep only
+// GET/POST (their PATCH/DELETE are bulk operations). GET entries list their base
+// filters (no lookup suffixes such as __ic) commented out.
+func writeStarterConfig(openAPISpec OpenAPISpec, specPath, configPath string) error {
+	if _, err := os.Stat(configPath); err == nil {
+		return fmt.Errorf("%s already exists", configPath)
+	}
+	groups := make(map[string][]string)
+	for path, item := range openAPISpec.Paths {
+		tag := "untagged"
+		for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+			if op := availableOperations(item)[method]; op != nil && len(op.Tags) > 0 {
+				tag = op.Tags[0]
+				break
+			}
 		}
-		return text
+		groups[tag] = append(groups[tag], path)
 	}
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
 
-	// Replace names in VariableData
-	for i := range workflowData.Variables {
-		workflowData.Variables[i].Properties.Name = replaceTextWithAcronyms(workflowData.Variables[i].Properties.Name, acronymMap)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Starter workflow config generated from %s with -initConfig.\n", filepath.Base(specPath)))
+	builder.WriteString("# Remove the endpoints you do not need and uncomment query_params to limit list filters.\n")
+	builder.WriteString("workflows:\n")
+	for _, tag := range tags {
+		paths := groups[tag]
+		sort.Strings(paths)
+		builder.WriteString(fmt.Sprintf("\n  # --- %s ---\n", tag))
+		for _, path := range paths {
+			ops := availableOperations(openAPISpec.Paths[path])
+			var methods []string
+			bulk := hasItemPath(openAPISpec, path)
+			for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+				if ops[method] == nil || (method == "PUT" && ops["PATCH"] != nil) || (bulk && method != "GET" && method != "POST") {
+					continue
+				}
+				methods = append(methods, method)
+			}
+			if len(methods) == 0 {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("  - endpoint: %s\n", path))
+			builder.WriteString(fmt.Sprintf("    methods: [%s]\n", strings.Join(methods, ", ")))
+			if get := ops["GET"]; get != nil {
+				var filters []string
+				for _, param := range get.Parameters {
+					if param.In == "query" && !strings.Contains(param.Name, "__") {
+						filters = append(filters, param.Name)
+					}
+				}
+				sort.Strings(filters)
+				if len(filters) > starterMaxFilters {
+					builder.WriteString(fmt.Sprintf("    # query_params: [%s]  # %d more in the spec\n", strings.Join(filters[:starterMaxFilters], ", "), len(filters)-starterMaxFilters))
+				} else if len(filters) > 0 {
+					builder.WriteString(fmt.Sprintf("    # query_params: [%s]\n", strings.Join(filters, ", ")))
+				}
+			}
+		}
 	}
+	return fsutil.WriteFile(configPath, []byte(builder.String()))
+}
 
-	// Replace names and titles in ActionData
-	for i := range workflowData.Actions {
-		if workflowData.Actions[i].Type == "meraki.api_request" || workflowData.Actions[i].Type == "netbox.invoke_api" {
-			workflowData.Actions[i].Name = replaceTextWithAcronyms(workflowData.Actions[i].Name, acronymMap)
-			workflowData.Actions[i].Title = replaceTextWithAcronyms(workflowData.Actions[i].Title, acronymMap)
+// runInteractive lets the user pick operations on the terminal, writes their
+// workflows into outputDir and offers to append them to configPath.
+func (s *renderSettings) runInteractive(ctx context.Context, openAPISpec OpenAPISpec, configPath, outputDir string) error {
+	type entry struct{ path, method, id string }
+	var entries []entry
+	for path, item := range openAPISpec.Paths {
+		for method, op := range availableOperations(item) {
+			if op.OperationId != "" {
+				entries = append(entries, entry{path, method, op.OperationId})
+			}
+		}
+	}
+	methodRank := map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].path != entries[j].path {
+			return entries[i].path < entries[j].path
 		}
+		return methodRank[entries[i].method] < methodRank[entries[j].method]
+	})
+	items := make([]selector.Item, len(entries))
+	for i, e := range entries {
+		items[i] = selector.Item{ID: e.id, Label: fmt.Sprintf("%-6s %s  (%s)", e.method, e.path, e.id)}
 	}
 
-	// Apply the same replacement to WorkflowData Name and Title
-	workflowData.Name = replaceTextWithAcronyms(workflowData.Name, acronymMap)
-	workflowData.Title = replaceTextWithAcronyms(workflowData.Title, acronymMap)
+	prompt := selector.New(os.Stdin, os.Stdout)
+	ids, err := prompt.Select(items)
+	if err != nil {
+		return err
+	}
+	if outputDir == "" {
+		outputDir = "outputs"
+	}
+	if err := fsutil.MkdirAll(outputDir); err != nil {
+		return err
+	}
+	importManifest := manifest.NewBuilder()
+	for _, id := range ids {
+		content, err := s.renderWorkflow(ctx, openAPISpec, id)
+		if err != nil {
+			return err
+		}
+		filename := fsutil.SafeFileName(id) + ".json"
+		if err := s.writeWorkflowFile(ctx, outputDir, filename, []byte(content), importManifest); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", filepath.Join(outputDir, filename))
+	}
+	if err := writeImportManifest(outputDir, importManifest.Build()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(configPath) == "" {
+		return nil
+	}
 
+	// One config entry per endpoint, methods in selection order.
+	var paths []string
+	methods := make(map[string][]string)
+	for _, id := range ids {
+		for _, e := range entries {
+			if e.id == id {
+				if _, ok := methods[e.path]; !ok {
+					paths = append(paths, e.path)
+				}
+				methods[e.path] = append(methods[e.path], e.method)
+			}
+		}
+	}
+	var snippet strings.Builder
+	for _, path := range paths {
+		snippet.WriteString(fmt.Sprintf("- endpoint: %s\n  methods: [%s]\n", path, strings.Join(methods[path], ", ")))
+	}
+	ok, err := prompt.Confirm(fmt.Sprintf("Append %d entries to %s?", len(paths), configPath))
+	if err != nil || !ok {
+		return err
+	}
+	return appendConfigEntries(configPath, snippet.String())
 }
 
-var supportIdempotency = false
-var idempotencyCondition = ""
-var categoryId = ""
-var categoryName = ""
-var platformName = ""
-var currentConnector connectorConfig
-var queryParamFilter map[string][]string
-var defaultNetboxQueryFilters = map[string][]string{
-	"dcim_devices_list": {"q", "name", "id", "site_id", "device_type_id", "role_id", "status", "tag", "has_primary_ip"},
+// appendConfigEntries appends YAML workflow entries to a config file whose last
+// top-level key is workflows (or that is a plain YAML list), creating it when
+// missing. Other layouts get the snippet printed for pasting by hand, which keeps
+// comments and formatting of hand-written configs intact.
+func appendConfigEntries(configPath, snippet string) error {
+	data, err := fsutil.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fsutil.WriteFile(configPath, []byte("workflows:\n"+indentMultilineString(strings.TrimSuffix(snippet, "\n"), "  ")+"\n"))
+	}
+	if err != nil {
+		return err
+	}
+	indent := ""
+	lastKey := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '#' && line[0] != '-' {
+			lastKey, _, _ = strings.Cut(line, ":")
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '-':
+	case lastKey == "workflows":
+		indent = "  "
+	default:
+		fmt.Printf("Could not find the workflows list at the end of %s; add these entries by hand:\n%s", configPath, snippet)
+		return nil
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	data = append(data, []byte(indentMultilineString(strings.TrimSuffix(snippet, "\n"), indent)+"\n")...)
+	return fsutil.WriteFile(configPath, data)
 }
-var netboxPaginationSchema = map[string]Schema{
-	"count": {
-		Type:        "integer",
-		Description: "Total number of records available.",
-	},
-	"next": {
-		Type:        "string",
-		Description: "URL for the next page of results.",
-	},
-	"previous": {
-		Type:        "string",
-		Description: "URL for the previous page of results.",
-	},
-	"results": {
-		Type:        "string",
-		Description: "Paginated results array (JSON string).",
-	},
+
+// hasItemPath reports whether the spec has an item path below a collection path,
+// e.g. /dcim/devices/{id}/ for /dcim/devices/.
+func hasItemPath(openAPISpec OpenAPISpec, path string) bool {
+	prefix := strings.TrimSuffix(path, "/") + "/{"
+	for other := range openAPISpec.Paths {
+		if strings.HasPrefix(other, prefix) && !strings.Contains(strings.TrimSuffix(strings.TrimPrefix(other, prefix), "/"), "/") {
+			return true
+		}
+	}
+	return false
 }
 
-func main() {
-	// Define command-line flags for input files and operation ID
-	openAPIFile := flag.String("openapi", "", "Path to the OpenAPI JSON file.")
-	operationId := flag.String("operationId", "", "The operationId to use from the OpenAPI spec.")
-	supportIdempotencyPtr := flag.Bool("supportIdempotency", false, "whether the atomic should support idempotency.")
-	idempotencyConditionPtr := flag.String("idempotencyCondition", "", "Error Message to use decide if idempotency is enabled.")
-	categoryIdPtr := flag.String("categoryId", "", "the Category Id to put the atomic under.")
-	categoryNamePtr := flag.String("categoryName", "", "the Category Id to put the atomic under.")
-	platformNamePtr := flag.String("platform", "", "Optional platform prefix for names and titles (e.g., 'Meraki')")
-	connectorTypePtr := flag.String("connector", "meraki", "Connector to target (meraki|netbox).")
-	queryParamConfigPtr := flag.String("queryParamsConfig", "", "Optional path to a YAML/JSON file mapping operationIds to allowed query parameters.")
-	stringifyBodyInputsPtr := flag.Bool("stringifyBodyInputs", false, "Coerce request body inputs to strings before serialization.")
-	configFilePtr := flag.String("config", "", "Path to YAML/JSON file describing workflows to generate.")
-	outputDirPtr := flag.String("outputDir", "outputs", "Directory to write generated workflows when using -config.")
-	flag.Parse()
-
-	// Dereference the pointers and assign them to global variables
-	supportIdempotency = *supportIdempotencyPtr
-	idempotencyCondition = *idempotencyConditionPtr
-	categoryId = *categoryIdPtr
-	categoryName = *categoryNamePtr
-	platformName = *platformNamePtr
-	connectorType := strings.ToLower(strings.TrimSpace(*connectorTypePtr))
-	stringifyBodyInputs = *stringifyBodyInputsPtr
-	var err error
-	currentConnector, err = getConnectorConfig(connectorType)
+// generateRecipes writes the -recipe composites, the -bulk workflows and their
+// atomics into outputDir without a workflow config.
+func (s *renderSettings) generateRecipes(ctx context.Context, openAPISpec OpenAPISpec, outputDir string) error {
+	recipes, err := selectRecipes(nil, recipeNames)
 	if err != nil {
-		log.Fatalf("Failed to initialize connector: %v", err)
+		return err
 	}
-	if platformName == "" {
-		platformName = currentConnector.PlatformDisplayName
+	if err := fsutil.MkdirAll(outputDir); err != nil {
+		return err
+	}
+	importManifest := manifest.NewBuilder()
+	rendered := make(map[string]string)
+	if err := s.generateComposites(ctx, openAPISpec, recipes, outputDir, rendered, importManifest); err != nil {
+		return err
 	}
-	if strings.TrimSpace(*openAPIFile) == "" {
-		log.Fatal("OpenAPI file path must be provided.")
+	if err := s.generateBulkWorkflows(ctx, openAPISpec, bulkConfigs, outputDir, rendered, importManifest); err != nil {
+		return err
 	}
+	return writeImportManifest(outputDir, importManifest.Build())
+}
 
-	openAPIContent, err := ioutil.ReadFile(*openAPIFile)
+// runLint prints lint issues for every workflow under dir and reports whether it
+// is free of errors.
+func runLint(dir string) bool {
+	issues, err := workflowlint.LintDir(dir)
 	if err != nil {
-		log.Fatalf("Failed to read OpenAPI file: %v", err)
+		log.Fatalf("Failed to lint workflows: %v", err)
 	}
-	openAPIContent, err = normalizeOpenAPIContent(openAPIContent)
-	if err != nil {
-		log.Fatalf("Failed to interpret OpenAPI file: %v", err)
+	for _, issue := range issues {
+		fmt.Println(issue.String())
 	}
-	var openAPISpec OpenAPISpec
-	if err := json.Unmarshal(openAPIContent, &openAPISpec); err != nil {
-		log.Fatalf("Failed to parse OpenAPI JSON: %v", err)
+	return !workflowlint.HasErrors(issues)
+}
+
+// inputSectionsMinInputs is how many wizard inputs a workflow needs before its
+// inputs are grouped into sections.
+const inputSectionsMinInputs = 8
+
+// advancedSectionName is the section collecting inputs no section lists.
+const advancedSectionName = "Advanced"
+
+// defaultInputSections are the sections -inputSections applies, named after
+// NetBox fields; anything else lands in Advanced.
+var defaultInputSections = []InputSection{
+	{Name: "Identification", Fields: []string{"id", "name", "slug", "label", "serial", "asset_tag", "description"}},
+	{Name: "Location", Fields: []string{"region", "site_group", "site", "location", "rack", "position", "face", "latitude", "longitude", "physical_address", "shipping_address", "time_zone", "facility"}},
+	{Name: "Status", Fields: []string{"status", "enabled", "role", "tenant_group", "tenant", "platform", "device_type"}},
+}
+
+// applyInputSections orders the wizard inputs of workflows with many inputs by
+// section, then by their position in the section's fields, and starts their descriptions with the section name. AO has no input
+// grouping in the workflow schema, so the order and the [Section] prefix are
+// the hints the wizard shows; variable names are left alone because calling
+// workflows reference them.
+func (s *renderSettings) applyInputSections(workflowData *WorkflowData) {
+	if len(s.inputSections) == 0 {
+		return
+	}
+	var slots []int
+	for i, variable := range workflowData.Variables {
+		if variable.Properties.Scope == "input" && variable.Properties.DisplayOnWizard {
+			slots = append(slots, i)
+		}
+	}
+	if len(slots) < inputSectionsMinInputs {
+		return
 	}
 
-	if strings.TrimSpace(*queryParamConfigPtr) != "" {
-		configMap, err := loadQueryParamConfig(*queryParamConfigPtr)
-		if err != nil {
-			log.Fatalf("Failed to parse query params config: %v", err)
+	type placement struct{ section, field int }
+	placements := make(map[string]placement)
+	names := make([]string, 0, len(s.inputSections)+1)
+	for i, section := range s.inputSections {
+		names = append(names, section.Name)
+		for j, field := range section.Fields {
+			key := strings.ToLower(HumanReadableName(strings.TrimSpace(field)))
+			if _, ok := placements[key]; !ok {
+				placements[key] = placement{section: i, field: j}
+			}
 		}
-		queryParamFilter = configMap
+	}
+	advanced := -1
+	for i, name := range names {
+		if strings.EqualFold(name, advancedSectionName) {
+			advanced = i
+		}
+	}
+	if advanced < 0 {
+		names = append(names, advancedSectionName)
+		advanced = len(names) - 1
 	}
 
-	if strings.TrimSpace(*configFilePtr) != "" {
-		if err := generateFromConfig(openAPISpec, *configFilePtr, *outputDirPtr); err != nil {
-			log.Fatalf("Failed to generate workflows from config: %v", err)
+	inputs := make([]VariableData, len(slots))
+	sections := make([]placement, len(slots))
+	for i, slot := range slots {
+		inputs[i] = workflowData.Variables[slot]
+		label := inputs[i].Properties.Name
+		for _, prefix := range []string{"Input - ", "Query - "} {
+			label = strings.TrimPrefix(label, prefix)
+		}
+		section, ok := placements[strings.ToLower(label)]
+		if !ok {
+			// Unlisted inputs keep their order after the fields Advanced lists.
+			section = placement{section: advanced, field: math.MaxInt}
 		}
+		sections[i] = section
+		inputs[i].Properties.Description = strings.TrimSpace("[" + names[section.section] + "] " + inputs[i].Properties.Description)
+	}
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := sections[order[a]], sections[order[b]]
+		if x.section != y.section {
+			return x.section < y.section
+		}
+		return x.field < y.field
+	})
+	for i, slot := range slots {
+		workflowData.Variables[slot] = inputs[order[i]]
+	}
+}
+
+// hideOptionalWizardInputs takes the optional inputs off the wizard so
+// interactive users see a short form of the required ones. The inputs still
+// exist, so calling workflows can set every one of them.
+func (s *renderSettings) hideOptionalWizardInputs(workflowData *WorkflowData) {
+	if !s.hideOptionalInputs {
 		return
 	}
+	for i, variable := range workflowData.Variables {
+		if variable.Properties.Scope == "input" && !variable.Properties.IsRequired {
+			workflowData.Variables[i].Properties.DisplayOnWizard = false
+		}
+	}
+}
+
+// Platform prefix targets beyond the workflow itself.
+const (
+	prefixTargetCategories = "categories"
+	prefixTargetActions    = "actions"
+)
 
-	if strings.TrimSpace(*operationId) == "" {
-		log.Fatal("operationId must be provided when not using -config.")
+// parsePrefixTargets validates -prefixTargets or options.prefix_targets.
+func parsePrefixTargets(names []string) ([]string, error) {
+	targets := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name != prefixTargetCategories && name != prefixTargetActions {
+			return nil, fmt.Errorf("unknown prefix target %q (expected %s or %s)", name, prefixTargetCategories, prefixTargetActions)
+		}
+		targets = append(targets, name)
 	}
+	return targets, nil
+}
 
-	content, err := renderWorkflow(openAPISpec, *operationId)
-	if err != nil {
-		log.Fatalf("Failed to render workflow: %v", err)
+// withPrefix prefixes text unless it already starts with the prefix, so
+// categories named after the platform are not prefixed twice.
+func withPrefix(prefix, text string) string {
+	if strings.HasPrefix(strings.ToLower(text), strings.ToLower(prefix)) {
+		return text
 	}
-	fmt.Println(content)
+	return prefix + text
 }
 
-// applyPlatformPrefix prefixes user-facing names and titles with the platform name, if provided.
-func applyPlatformPrefix(workflowData *WorkflowData) {
-	if strings.TrimSpace(platformName) == "" {
+// applyPlatformPrefix prefixes user-facing names and titles with the platform
+// name, if provided. -prefixTargets extends it to the categories and to the API
+// request step, so the run view of a composite mixing platforms shows which one
+// each request targets.
+func (s *renderSettings) applyPlatformPrefix(workflowData *WorkflowData) {
+	if strings.TrimSpace(s.platformName) == "" {
+		return
+	}
+	prefix := s.platformName + " - "
+	if contains(s.prefixTargets, prefixTargetCategories) {
+		for id, category := range workflowData.CategoriesMap {
+			category.Name = withPrefix(prefix, category.Name)
+			category.Title = withPrefix(prefix, category.Title)
+			workflowData.CategoriesMap[id] = category
+		}
+	}
+	if contains(s.prefixTargets, prefixTargetActions) {
+		for i, action := range workflowData.Actions {
+			if action.Type != s.currentConnector.ActionType {
+				continue
+			}
+			action.Title = withPrefix(prefix, action.Title)
+			switch props := action.Properties.(type) {
+			case connector.APIRequestProperties:
+				props.DisplayName = withPrefix(prefix, props.DisplayName)
+				action.Properties = props
+			case connector.NetboxAPIRequestProperties:
+				props.DisplayName = withPrefix(prefix, props.DisplayName)
+				action.Properties = props
+			case connector.HTTPRequestProperties:
+				props.DisplayName = withPrefix(prefix, props.DisplayName)
+				action.Properties = props
+			case connector.DefinedRequestProperties:
+				displayName, _ := props.Values["display_name"].(string)
+				action.Properties = props.With("display_name", withPrefix(prefix, displayName))
+			default:
+				if props, ok := pluginRequestProperties(props); ok {
+					displayName, _ := props.Values["display_name"].(string)
+					action.Properties = props.With("display_name", withPrefix(prefix, displayName))
+				}
+			}
+			workflowData.Actions[i] = action
+		}
+	}
+	if strings.TrimSpace(s.nameTemplate) != "" {
 		return
 	}
-	prefix := platformName + " - "
 
 	// Normalize "Partial Update" to "Update" before adding prefix
 	workflowData.Name = strings.ReplaceAll(workflowData.Name, "Partial Update", "Update")
@@ -0,0 +1,1183 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/manifest"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/pkg/connector"
+
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden workflows under testdata/golden")
+
+// sequentialIDs numbers the IDs of a render so workflows can be compared
+// with golden files and with each other.
+type sequentialIDs struct {
+	next int
+}
+
+func (g *sequentialIDs) New() string {
+	g.next++
+	return fmt.Sprintf("TEST%023d", g.next)
+}
+
+func (g *sequentialIDs) Derive(sum [32]byte) string {
+	return fmt.Sprintf("TEST%X", sum[:8])
+}
+
+// A plugin connector that declares no ErrorMessageField, like a third-party
+// adapter without an error output.
+func init() {
+	cfg, _ := connector.Lookup("generic")
+	cfg.AtomicGroup = "Plugin"
+	cfg.PlatformDisplayName = "Plugin"
+	cfg.ErrorMessageField = ""
+	connector.Register("plugin", cfg)
+}
+
+// testSettings returns the settings of a run with -connector=name and no other
+// flags.
+func testSettings(t *testing.T, name string) renderSettings {
+	t.Helper()
+	settings := defaultRenderSettings()
+	cfg, err := getConnectorConfig(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.currentConnector = cfg
+	settings.platformName = cfg.PlatformDisplayName
+	return settings
+}
+
+// loadTestSpec loads a trimmed spec from testdata/specs.
+func loadTestSpec(t *testing.T, name string) OpenAPISpec {
+	t.Helper()
+	spec, err := loadOpenAPISpec(context.Background(), filepath.Join("testdata", "specs", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// renderEntry renders the operations of a config entry the way -config does,
+// keyed by operationId, numbering each workflow's IDs from one.
+func renderEntry(t *testing.T, spec OpenAPISpec, settings renderSettings, entry string) map[string]string {
+	t.Helper()
+	var wf WorkflowConfig
+	if err := yaml.UnmarshalStrict([]byte(entry), &wf); err != nil {
+		t.Fatalf("entry %q: %v", entry, err)
+	}
+	ops, err := resolveWorkflowEntry(spec, wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := idGenerator
+	t.Cleanup(func() { idGenerator = saved })
+	rendered := make(map[string]string, len(ops))
+	for _, op := range ops {
+		idGenerator = &sequentialIDs{}
+		queryParams := append([]string{}, wf.QueryParams...)
+		content, err := settings.renderConfiguredOperation(context.Background(), spec, wf, op.OperationId, op.Method, queryParams)
+		if err != nil {
+			t.Fatalf("%s: %v", op.OperationId, err)
+		}
+		rendered[op.OperationId] = content
+	}
+	return rendered
+}
+
+func TestWorkflowGeneration(t *testing.T) {
+	tests := []struct {
+		name      string
+		connector string
+		spec      string
+		entry     string
+	}{
+		{
+			name:      "netbox_sites_list",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry:     "endpoint: /api/dcim/sites/\nmethods: [GET]\nquery_params: [name, status]",
+		},
+		{
+			name:      "netbox_sites_list_json_query_table",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry: `endpoint: /api/dcim/sites/
+methods: [GET]
+query_mode: json
+table:
+  name: Sites
+  fields: [name, Status=status.label]`,
+		},
+		{
+			name:      "netbox_sites_create",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry: `endpoint: /api/dcim/sites/
+methods: [POST]
+body_params: [name, slug, status, tags]
+options:
+  summary: true
+  fixed_outputs: [status_code, error_message, request_url]`,
+		},
+		{
+			name:      "netbox_sites_retrieve_assert_wait",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry: `endpoint: /api/dcim/sites/{id}/
+methods: [GET]
+assert: ['$.status.value == "active"']
+wait_for:
+  field: status.value
+  value: active
+  interval: 15`,
+		},
+		{
+			name:      "netbox_sites_update_sections",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry: `endpoint: /api/dcim/sites/{id}/
+methods: [PUT]
+options:
+  max_body_inputs: 4
+  hide_optional_inputs: true
+  ids_as_strings: true
+  input_sections:
+    - name: Identity
+      fields: [id, name, slug]`,
+		},
+		{
+			name:      "netbox_sites_destroy_approval",
+			connector: "netbox",
+			spec:      "netbox.json",
+			entry: `endpoint: /api/dcim/sites/{id}/
+methods: [DELETE]
+options:
+  approval:
+    channel: task
+    approvers: [netops]`,
+		},
+		{
+			name:      "aci_tenants_list",
+			connector: "aci",
+			spec:      "aci.json",
+			entry:     "endpoint: /class/fvTenant\nmethods: [GET]",
+		},
+		{
+			name:      "aci_tenant_create",
+			connector: "aci",
+			spec:      "aci.json",
+			entry:     "endpoint: /mo/{dn}\nmethods: [POST]",
+		},
+		{
+			name:      "vmanage_devices_list",
+			connector: "vmanage",
+			spec:      "vmanage.json",
+			entry: `endpoint: /device
+methods: [GET]
+table:
+  fields: [host-name, reachability]`,
+		},
+		{
+			name:      "catalystcenter_device_retrieve",
+			connector: "catalystcenter",
+			spec:      "catalystcenter.json",
+			entry:     "endpoint: /v1/network-device/{id}\nmethods: [GET]",
+		},
+		{
+			name:      "fmc_network_create",
+			connector: "fmc",
+			spec:      "fmc.json",
+			entry:     "endpoint: /api/fmc_config/v1/domain/{domainUUID}/object/networks\nmethods: [POST]",
+		},
+		{
+			name:      "servicenow_incidents_list",
+			connector: "servicenow",
+			spec:      "servicenow.json",
+			entry: `endpoint: /now/table/incident
+methods: [GET]
+options:
+  date_format:
+    fields:
+      opened_at: yyyy-MM-dd HH:mm:ss`,
+		},
+		{
+			name:      "generic_device_update",
+			connector: "generic",
+			spec:      "generic.json",
+			entry: `endpoint: /devices/{serial}
+methods: [PUT]
+options:
+  flatten_objects: 1
+  local_variables: true
+  normalize_outputs: true
+  sensitive_fields: [password]
+  timeout: 60`,
+		},
+		{
+			name:      "plugin_rack_retrieve",
+			connector: "plugin",
+			spec:      "generic.json",
+			entry:     "endpoint: /racks/{id}\nmethods: [GET]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := renderEntry(t, loadTestSpec(t, tt.spec), testSettings(t, tt.connector), tt.entry)
+			if len(rendered) != 1 {
+				t.Fatalf("got %d workflows, want 1", len(rendered))
+			}
+			var got string
+			for _, content := range rendered {
+				got = content + "\n"
+			}
+			golden := filepath.Join("testdata", "golden", tt.name+".json")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("workflow differs from %s (go test -run TestWorkflowGeneration -update rewrites it):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+var (
+	jsonpathQueryRef  = regexp.MustCompile(`^\$activity\.(definition_activity_\w+)\.output\.jsonpath_queries\.(.+)\$$`)
+	outputVariableRef = regexp.MustCompile(`\.output\.(variable_workflow_\w+)\$$`)
+)
+
+// TestGoldenQueryTypesMatchOutputTypes checks that every output variable set
+// from a JSONPath query in the golden workflows is extracted with the query
+// type matching its datatype, e.g. paginated results as an array.
+func TestGoldenQueryTypesMatchOutputTypes(t *testing.T) {
+	goldens, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := 0
+	for _, golden := range goldens {
+		content, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var export struct {
+			Workflow struct {
+				Variables []struct {
+					UniqueName string `json:"unique_name"`
+					Properties struct {
+						Type string `json:"type"`
+					} `json:"properties"`
+				} `json:"variables"`
+				Actions []interface{} `json:"actions"`
+			} `json:"workflow"`
+		}
+		if err := json.Unmarshal(content, &export); err != nil {
+			t.Fatalf("%s: %v", golden, err)
+		}
+		variableTypes := make(map[string]string)
+		for _, variable := range export.Workflow.Variables {
+			variableTypes[variable.UniqueName] = variable.Properties.Type
+		}
+		queryTypes := make(map[string]string) // activity/query name -> type
+		var updates []map[string]interface{}
+		var walk func(node interface{})
+		walk = func(node interface{}) {
+			switch n := node.(type) {
+			case []interface{}:
+				for _, child := range n {
+					walk(child)
+				}
+			case map[string]interface{}:
+				properties, _ := n["properties"].(map[string]interface{})
+				if queries, ok := properties["jsonpath_queries"].([]interface{}); ok {
+					for _, query := range queries {
+						q := query.(map[string]interface{})
+						queryTypes[fmt.Sprintf("%v/%v", n["unique_name"], q["jsonpath_query_name"])] = fmt.Sprint(q["jsonpath_query_type"])
+					}
+				}
+				if list, ok := properties["variables_to_update"].([]interface{}); ok {
+					for _, update := range list {
+						updates = append(updates, update.(map[string]interface{}))
+					}
+				}
+				walk(n["actions"])
+				walk(n["blocks"])
+			}
+		}
+		walk(export.Workflow.Actions)
+		for _, update := range updates {
+			query := jsonpathQueryRef.FindStringSubmatch(fmt.Sprint(update["variable_value_new"]))
+			variable := outputVariableRef.FindStringSubmatch(fmt.Sprint(update["variable_to_update"]))
+			if query == nil || variable == nil {
+				continue
+			}
+			queryType, ok := queryTypes[query[1]+"/"+query[2]]
+			if !ok {
+				t.Errorf("%s: %s is set from unknown query %s", golden, variable[1], query[0])
+				continue
+			}
+			if want := strings.TrimPrefix(variableTypes[variable[1]], "datatype."); queryType != want {
+				t.Errorf("%s: query %q has type %s, its output %s is %s", golden, query[2], queryType, variable[1], variableTypes[variable[1]])
+			}
+			checked++
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no output variable is set from a JSONPath query in the golden workflows")
+	}
+}
+
+// TestGoldenBlocksAreNested checks that the failed branch of every golden
+// workflow holds the 401/403 check as a nested condition block, and that every
+// action down to the innermost one, loop bodies included, carries a blocks list.
+func TestGoldenBlocksAreNested(t *testing.T) {
+	goldens, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested := 0
+	for _, golden := range goldens {
+		content, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var export struct {
+			Workflow struct {
+				Actions []interface{} `json:"actions"`
+			} `json:"workflow"`
+		}
+		if err := json.Unmarshal(content, &export); err != nil {
+			t.Fatalf("%s: %v", golden, err)
+		}
+		var walk func(nodes []interface{}, depth int)
+		walk = func(nodes []interface{}, depth int) {
+			for _, node := range nodes {
+				action := node.(map[string]interface{})
+				blocks, ok := action["blocks"].([]interface{})
+				if !ok {
+					t.Errorf("%s: action %v has no blocks list", golden, action["title"])
+				}
+				for _, b := range blocks {
+					block := b.(map[string]interface{})
+					if block["title"] == "401/403 Unauthorized" {
+						if depth != 1 {
+							t.Errorf("%s: 401/403 block at depth %d, want it inside the failed branch", golden, depth)
+						}
+						nested++
+					}
+					actions, _ := block["actions"].([]interface{})
+					walk(actions, depth+1)
+				}
+				if actions, ok := action["actions"].([]interface{}); ok {
+					walk(actions, depth)
+				}
+			}
+		}
+		walk(export.Workflow.Actions, 0)
+	}
+	if nested == 0 {
+		t.Fatal("no golden workflow has a nested 401/403 block")
+	}
+}
+
+// TestGenerationIsOrderIndependent renders operations that share component
+// schemas in several orders from one spec and compares each workflow with the
+// operation rendered alone from a fresh copy of the spec; an operation that
+// changed a shared schema would change the workflows rendered after it. The
+// shared spec memoizes its resolved schemas, so later renders are served from
+// the memo, whose schemas must not change either.
+func TestGenerationIsOrderIndependent(t *testing.T) {
+	entries := []string{
+		"endpoint: /api/dcim/sites/\nmethods: [GET]",
+		"endpoint: /api/dcim/sites/\nmethods: [POST]\nbody_params: [name, slug]",
+		"endpoint: /api/dcim/sites/{id}/\nmethods: [PUT]",
+		"endpoint: /api/dcim/sites/{id}/\nmethods: [GET]\noptions:\n  max_body_inputs: 1",
+		"endpoint: /api/dcim/sites/{id}/\nmethods: [PUT]\nbody_params: [name, slug, status]\noptions:\n  ids_as_strings: true\n  hide_optional_inputs: true",
+	}
+	settings := testSettings(t, "netbox")
+	want := make([]map[string]string, len(entries))
+	for i, entry := range entries {
+		want[i] = renderEntry(t, loadTestSpec(t, "netbox.json"), settings, entry)
+	}
+	spec := loadTestSpec(t, "netbox.json")
+	var memo []byte
+	for _, order := range [][]int{{0, 1, 2, 3, 4}, {4, 3, 2, 1, 0}, {1, 4, 2, 0, 3}, {2, 0, 3, 1, 4}} {
+		for _, idx := range order {
+			for operationId, content := range renderEntry(t, spec, settings, entries[idx]) {
+				if content != want[idx][operationId] {
+					t.Errorf("%s (entry %d) differs when rendered in order %v", operationId, idx, order)
+				}
+			}
+		}
+		snapshot, err := json.Marshal(spec.resolvedSchemas.schemas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if memo != nil && !bytes.Equal(snapshot, memo) {
+			t.Errorf("memoized schemas changed while rendering in order %v", order)
+		}
+		memo = snapshot
+	}
+	for _, name := range []string{"Site", "SiteStatus", "PaginatedSiteList", "WritableSiteRequest"} {
+		if _, ok := spec.resolvedSchemas.get(name); !ok {
+			t.Errorf("%s was not memoized; the renders above did not share it", name)
+		}
+	}
+}
+
+// TestMemoizedSchemasCanBeNarrowed narrows resolved schemas the way the
+// render narrows its copy of an operation, both the schema that filled the
+// memo and one served from it, and resolves them again. The renders above
+// take views of what they resolve before changing it, so they would not
+// notice a memo that hands out its own schemas.
+func TestMemoizedSchemasCanBeNarrowed(t *testing.T) {
+	spec := loadTestSpec(t, "netbox.json")
+	site := Schema{Ref: "#/components/schemas/Site"}
+	want, err := json.Marshal(resolveSchemaRefs(loadTestSpec(t, "netbox.json"), site))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lookup := range []string{"first", "memoized"} {
+		resolved := resolveSchemaRefs(spec, site)
+		status := resolved.Properties["status"]
+		delete(status.Properties, "label")
+		filterSchemaProperties(&resolved, map[string]struct{}{"name": {}, "status": {}, "value": {}})
+		got, err := json.Marshal(resolveSchemaRefs(spec, site))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("narrowing the %s resolution of Site changed it to %s, want %s", lookup, got, want)
+		}
+	}
+	if _, ok := spec.resolvedSchemas.get("SiteStatus"); !ok {
+		t.Error("SiteStatus was not memoized")
+	}
+}
+
+const recursiveSpec = `{"openapi": "3.0.3", "info": {"title": "nodes", "version": "1"},
+"paths": {"/nodes/{id}": {
+  "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+  "get": {"operationId": "getNode", "responses": {"200": {"description": "", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Node"}}}}}},
+  "put": {"operationId": "updateNode", "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Node"}}}}, "responses": {"200": {"description": ""}}}
+}},
+"components": {"schemas": {"Node": {"type": "object", "properties": {
+  "name": {"type": "string"},
+  "parent": {"$ref": "#/components/schemas/Node"},
+  "children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}},
+  "meta": {"type": "object", "properties": {"owner": {"type": "object", "properties": {"name": {"type": "string"}}}}}
+}}}}}`
+
+// TestTruncationWarnings renders a recursive schema cut at depth 2 and checks
+// that only the truncated structures that become inputs or outputs (parent)
+// are reported, not those nested in another JSON value (children[],
+// meta.owner).
+func TestTruncationWarnings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.json")
+	if err := os.WriteFile(path, []byte(recursiveSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := loadOpenAPISpec(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t, "generic")
+	settings.maxSchemaDepth = 2
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+	rendered := renderEntry(t, spec, settings, "endpoint: /nodes/{id}\nmethods: [GET, PUT]")
+	if !strings.Contains(rendered["updateNode"], `"Input - Parent (JSON)"`) || !strings.Contains(rendered["getNode"], `"Output - Meta"`) {
+		t.Fatal("the truncated structures did not become JSON variables")
+	}
+	want := []string{
+		"Warning: getNode: response 200.parent (recursive #/components/schemas/Node) is truncated and handled as a JSON value",
+		"Warning: updateNode: request body.parent (recursive #/components/schemas/Node) is truncated and handled as a JSON value",
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(logged.String()), "\n") {
+		if strings.Contains(line, "truncated") {
+			got = append(got, line)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// largeSpec writes a JSON spec with n list operations, each with its own
+// component schema, to w.
+func largeSpec(w io.Writer, n int) error {
+	description := strings.Repeat("Lorem ipsum dolor sit amet. ", 40)
+	if _, err := io.WriteString(w, `{"openapi":"3.0.3","info":{"title":"large","version":"1"},"paths":{`); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		fmt.Fprintf(w, `"/api/things%d/":{"get":{"operationId":"things%d_list","description":%q,"responses":{"200":{"description":"","content":{"application/json":{"schema":{"$ref":"#/components/schemas/Thing%d"}}}}}}}`, i, i, description, i)
+	}
+	io.WriteString(w, `},"components":{"schemas":{`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		fmt.Fprintf(w, `"Thing%d":{"type":"object","description":%q,"properties":{"id":{"type":"integer"},"name":{"type":"string"}}}`, i, description)
+	}
+	_, err := io.WriteString(w, "}}}")
+	return err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func TestDecodeOpenAPISpecStreamsLargeSpec(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		// The race detector instruments every copy of the decoder's buffer,
+		// which takes minutes for a spec this size.
+		t.Skip("large spec")
+	}
+	const operations = 20000
+	pr, pw := io.Pipe()
+	go func() {
+		buffered := bufio.NewWriter(pw)
+		err := largeSpec(buffered, operations)
+		if err == nil {
+			err = buffered.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	source := &countingReader{Reader: pr}
+	spec, err := decodeOpenAPISpec(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.n < 32<<20 {
+		t.Fatalf("spec is only %d bytes, want a spec of tens of MB", source.n)
+	}
+	if len(spec.Paths) != operations || len(spec.Components.Schemas) != operations {
+		t.Fatalf("decoded %d paths and %d schemas, want %d of each", len(spec.Paths), len(spec.Components.Schemas), operations)
+	}
+	spec.resolvedSchemas = newSchemaMemo()
+	rendered := renderEntry(t, spec, testSettings(t, "netbox"), fmt.Sprintf("endpoint: /api/things%d/\nmethods: [GET]", operations-1))
+	if !strings.Contains(rendered[fmt.Sprintf("things%d_list", operations-1)], "Output - Name") {
+		t.Error("the last operation of the spec renders without its outputs")
+	}
+}
+
+func TestWriteBundleEntriesCopiesLargeWorkflows(t *testing.T) {
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte(`{"name":"padding"},`), 1<<20)
+	if err := os.WriteFile(filepath.Join(dir, "large.json"), large, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	if err := writeBundleEntries(archive, dir, []string{"small.json", "large.json"}, []byte("{}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{manifest.FileName: []byte("{}\n"), "small.json": []byte("{}"), "large.json": large}
+	if len(reader.File) != len(want) {
+		t.Fatalf("bundle has %d entries, want %d", len(reader.File), len(want))
+	}
+	for _, file := range reader.File {
+		entry, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(entry)
+		entry.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, want[file.Name]) {
+			t.Errorf("entry %s has %d bytes that differ from the %d written", file.Name, len(content), len(want[file.Name]))
+		}
+	}
+}
+
+func TestSanitizeJsonpathQueryName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Status", "Status"},
+		{"Primary IP4", "Primary IP4"},
+		{"Config $ Context", "Config _ Context"},
+		{"a.b[c]", "a_b_c_"},
+		{"  spaced   out ", "spaced out"},
+		{"$", "_"},
+		{"", "Value"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeJsonpathQueryName(tt.name); got != tt.want {
+			t.Errorf("sanitizeJsonpathQueryName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJsonpathQueryNamesAreUnique(t *testing.T) {
+	names := jsonpathQueryNames(map[string]Schema{
+		"result":      {Type: "string"},
+		"status":      {Type: "string"},
+		"status_":     {Type: "string"},
+		"config$data": {Type: "string"},
+	})
+	want := map[string]string{
+		"config$data": "Config_Data",
+		"result":      "Result 2",
+		"status":      "Status",
+		"status_":     "Status 2",
+	}
+	for prop, name := range want {
+		if names[prop] != name {
+			t.Errorf("query name of %q = %q, want %q", prop, names[prop], name)
+		}
+	}
+}
+
+func TestPlaceholderRegistryTokens(t *testing.T) {
+	type registration struct{ kind, name, want string }
+	tests := []struct {
+		name          string
+		registrations []registration
+	}{
+		{
+			name: "distinct names",
+			registrations: []registration{
+				{placeholderKindParam, "id", "id"},
+				{placeholderKindBody, "name", "name"},
+				{placeholderKindOutput, "name", "nameoutput"},
+			},
+		},
+		{
+			name: "path parameter and body field share a name",
+			registrations: []registration{
+				{placeholderKindParam, "id", "id"},
+				{placeholderKindBody, "id", "id_2"},
+				{placeholderKindParam, "id", "id"},
+				{placeholderKindBody, "id", "id_2"},
+			},
+		},
+		{
+			name: "input named like an output",
+			registrations: []registration{
+				{placeholderKindBody, "nameoutput", "nameoutput"},
+				{placeholderKindOutput, "name", "nameoutput_2"},
+			},
+		},
+		{
+			name: "reserved token",
+			registrations: []registration{
+				{placeholderKindBody, "Workflow", "Workflow_2"},
+				{placeholderKindParam, "StatusCode", "StatusCode_2"},
+			},
+		},
+		{
+			name: "escaped name colliding with a spelled-out one",
+			registrations: []registration{
+				{placeholderKindParam, "site-id", "site_2d_id"},
+				{placeholderKindParam, "site_2d_id", "site_2d_id_2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newPlaceholderRegistry()
+			for _, r := range tt.registrations {
+				if got := registry.token(r.kind, r.name); got != r.want {
+					t.Errorf("token(%s, %s) = %q, want %q", r.kind, r.name, got, r.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceholderSafeName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"site_id", "site_id"},
+		{"site-id", "site_2d_id"},
+		{"x-custom.field", "x_2d_custom_2e_field"},
+		{"cf_site[owner]", "cf_site_5b_owner_5d_"},
+		{"tag name", "tag_20_name"},
+		{"größe", "gr_f6__df_e"},
+	}
+	for _, tt := range tests {
+		got := placeholderSafeName(tt.name)
+		if got != tt.want {
+			t.Errorf("placeholderSafeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		placeholder := "variable_workflow_$" + got + "KSUID"
+		if replaced := ReplaceKSUIDs(placeholder); strings.Contains(replaced, "KSUID") {
+			t.Errorf("%s is not replaced: %s", placeholder, replaced)
+		}
+	}
+}
+
+// TestPlaceholderSuffixIsStable renders an operation whose path id and body id
+// become two inputs, alone and after another operation, and checks that the
+// path parameter keeps the plain token in every order.
+func TestPlaceholderSuffixIsStable(t *testing.T) {
+	settings := testSettings(t, "generic")
+	spec := loadTestSpec(t, "generic.json")
+	operation, path, method, err := ExtractOperation(spec, "updateRack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.placeholders = newPlaceholderRegistry()
+	data := settings.GenerateWorkflowData(resolveOperationSchemas(spec, operation), path, method)
+	var ids []string
+	for _, variable := range data.Variables {
+		if variable.Properties.Name == "Input - Id" {
+			ids = append(ids, variable.UniqueName)
+		}
+	}
+	if want := []string{"variable_workflow_$idKSUID", "variable_workflow_$id_2KSUID"}; strings.Join(ids, " ") != strings.Join(want, " ") {
+		t.Errorf("ID inputs = %v, want %v", ids, want)
+	}
+
+	entries := []string{
+		"endpoint: /racks/{id}\nmethods: [GET]",
+		"endpoint: /racks/{id}\nmethods: [PUT]",
+	}
+	want := renderEntry(t, loadTestSpec(t, "generic.json"), settings, entries[1])["updateRack"]
+	for _, order := range [][]int{{0, 1}, {1, 0}} {
+		shared := loadTestSpec(t, "generic.json")
+		for _, idx := range order {
+			if got, ok := renderEntry(t, shared, settings, entries[idx])["updateRack"]; ok && got != want {
+				t.Errorf("updateRack differs when rendered in order %v", order)
+			}
+		}
+	}
+}
+
+func TestValidateVariableUniqueNames(t *testing.T) {
+	variable := func(name, uniqueName string) VariableData {
+		return VariableData{UniqueName: uniqueName, Properties: VariableProperties{Name: name}}
+	}
+	tests := []struct {
+		name      string
+		variables []VariableData
+		wantErr   string
+	}{
+		{
+			name:      "distinct",
+			variables: []VariableData{variable("Input - ID", "variable_workflow_$idKSUID"), variable("Input - ID", "variable_workflow_$id_2KSUID")},
+		},
+		{
+			name:      "shared placeholder",
+			variables: []VariableData{variable("Input - Name", "variable_workflow_$nameKSUID"), variable("Input - Name 2", "variable_workflow_$nameKSUID")},
+			wantErr:   `variables "Input - Name" and "Input - Name 2" share placeholder variable_workflow_$nameKSUID`,
+		},
+		{
+			name:      "reserved placeholder",
+			variables: []VariableData{variable("Output - Code", "variable_workflow_$StatusCodeKSUID")},
+			wantErr:   `variable "Output - Code" uses reserved placeholder variable_workflow_$StatusCodeKSUID`,
+		},
+		{
+			name:      "ignoreIfExist may repeat the reserved token",
+			variables: []VariableData{variable("Input - Ignore If Exists", "variable_workflow_$ignoreIfExistKSUID")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVariableUniqueNames(tt.variables)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidatePlaceholders replaces the IDs of a document holding a
+// placeholder ReplaceKSUIDs does not recognise, as a custom template with a
+// hyphenated name would, and checks that the render would fail on it.
+func TestValidatePlaceholders(t *testing.T) {
+	content := ReplaceKSUIDs(`{"workflow": {"unique_name": "definition_workflow_$WorkflowKSUID", "variables": [{"unique_name": "variable_workflow_$site-idKSUID"}]}}`)
+	err := validatePlaceholders([]byte(content))
+	want := "unreplaced placeholders in rendered workflow:\n  workflow.variables[0].unique_name: unreplaced placeholder $site-idKSUID"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+	if err := validatePlaceholders([]byte(ReplaceKSUIDs(`{"workflow": {"unique_name": "definition_workflow_$WorkflowKSUID"}}`))); err != nil {
+		t.Errorf("replaced document: %v", err)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "python3 scripts/strip.py", want: []string{"python3", "scripts/strip.py"}},
+		{command: "  jq   '.workflow.description = \"\"'  ", want: []string{"jq", `.workflow.description = ""`}},
+		{command: `python3 "My Scripts/strip.py" --keep "a \"b\" \$c"`, want: []string{"python3", "My Scripts/strip.py", "--keep", `a "b" $c`}},
+		{command: `tool a\ b 'it'\''s'`, want: []string{"tool", "a b", "it's"}},
+		{command: `tool "" ''`, want: []string{"tool", "", ""}},
+		{command: `tool "C:\path"`, want: []string{"tool", `C:\path`}},
+		{command: "", want: nil},
+		{command: "tool 'open", wantErr: true},
+		{command: `tool "open`, wantErr: true},
+		{command: `tool \`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitCommandLine(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+// TestPostProcessorsRunBeforeChecks runs a quoted command post-processor that
+// leaves a placeholder behind; the placeholder check must see its output.
+func TestPostProcessorsRunBeforeChecks(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed not found")
+	}
+	settings := testSettings(t, "generic")
+	settings.postProcessCommands = []string{`sed 's/"title": "/"title": "$StaleKSUID /'`}
+	_, err := settings.renderWorkflow(context.Background(), loadTestSpec(t, "generic.json"), "getRack")
+	if err == nil || !strings.Contains(err.Error(), "unreplaced placeholders") {
+		t.Fatalf("error = %v, want unreplaced placeholders", err)
+	}
+}
+
+func TestArrayQueryParamSerialization(t *testing.T) {
+	no := false
+	yes := true
+	tests := []struct {
+		name      string
+		param     Parameter
+		explode   bool
+		delimiter string
+		hint      string
+	}{
+		{"default form", Parameter{Name: "tag"}, true, ",", "each value is sent as a separate tag= query parameter"},
+		{"form no explode", Parameter{Name: "tag", Style: "form", Explode: &no}, false, ",", `joined with "," into a single tag= query parameter`},
+		{"space delimited", Parameter{Name: "tag", Style: "spaceDelimited"}, false, " ", `joined with " " into a single tag= query parameter`},
+		{"pipe delimited", Parameter{Name: "tag", Style: "pipeDelimited"}, false, "|", `joined with "|" into a single tag= query parameter`},
+		{"pipe delimited explode", Parameter{Name: "tag", Style: "pipeDelimited", Explode: &yes}, true, "|", "each value is sent as a separate tag= query parameter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryParamExplode(tt.param); got != tt.explode {
+				t.Errorf("queryParamExplode = %v, want %v", got, tt.explode)
+			}
+			if got := queryParamDelimiter(tt.param); got != tt.delimiter {
+				t.Errorf("queryParamDelimiter = %q, want %q", got, tt.delimiter)
+			}
+			if got := arrayQueryParamHint(tt.param); !strings.Contains(got, tt.hint) {
+				t.Errorf("arrayQueryParamHint = %q, want it to contain %q", got, tt.hint)
+			}
+		})
+	}
+}
+
+func TestDeepObjectQueryParam(t *testing.T) {
+	tests := []struct {
+		param Parameter
+		want  bool
+	}{
+		{Parameter{Name: "filter", In: "query", Style: "deepObject"}, true},
+		{Parameter{Name: "filter", In: "query", Style: "form"}, false},
+		{Parameter{Name: "filter", In: "query"}, false},
+		{Parameter{Name: "filter", In: "header", Style: "deepObject"}, false},
+	}
+	for _, tt := range tests {
+		if got := isDeepObjectParam(tt.param); got != tt.want {
+			t.Errorf("isDeepObjectParam(in=%s, style=%s) = %v, want %v", tt.param.In, tt.param.Style, got, tt.want)
+		}
+		if got := hasDeepObjectParam([]Parameter{{Name: "limit", In: "query"}, tt.param}); got != tt.want {
+			t.Errorf("hasDeepObjectParam(in=%s, style=%s) = %v, want %v", tt.param.In, tt.param.Style, got, tt.want)
+		}
+	}
+
+	var builder strings.Builder
+	writeDeepObjectQueryParam(&builder, Parameter{Name: "filter", In: "query", Style: "deepObject"}, "filter_value")
+	for _, want := range []string{
+		"if filter_value != '' and filter_value != '{}':",
+		`for key, value in flatten_deep("filter", json.loads(filter_value)):`,
+		`queryStr += key + "=" + urllib.parse.quote_plus(str(value))`,
+	} {
+		if !strings.Contains(builder.String(), want) {
+			t.Errorf("deepObject prep code is missing %q:\n%s", want, builder.String())
+		}
+	}
+}
+
+func TestIsCommaSeparatedParam(t *testing.T) {
+	settings := renderSettings{
+		commaSeparatedQueryParams: []string{"tag"},
+		currentConnector:          connectorConfig{connector.Config{ListQueryParams: []string{"site"}}},
+	}
+	array := Schema{Type: "array", Items: &Schema{Type: "string"}}
+	tests := []struct {
+		name  string
+		param Parameter
+		want  bool
+	}{
+		{"configured", Parameter{Name: "tag", In: "query", Schema: array}, true},
+		{"connector list param", Parameter{Name: "site", In: "query", Schema: array}, true},
+		{"not listed", Parameter{Name: "role", In: "query", Schema: array}, false},
+		{"not an array", Parameter{Name: "tag", In: "query", Schema: Schema{Type: "string"}}, false},
+		{"not a query parameter", Parameter{Name: "tag", In: "path", Schema: array}, false},
+	}
+	for _, tt := range tests {
+		if got := settings.isCommaSeparatedParam(tt.param); got != tt.want {
+			t.Errorf("%s: isCommaSeparatedParam = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// writeConfigFiles writes name -> content under a temporary directory and
+// returns the directory.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadWorkflowConfigIncludes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"common/common.yaml": `defaults:
+  query_params: [name]
+  options:
+    summary: true
+    max_body_inputs: 5
+specs:
+  - connector: netbox
+    openapi: netbox.json
+workflows:
+  - endpoint: /api/status/
+    methods: [GET]`,
+		"dcim.yaml": `include: [common/common.yaml]
+defaults:
+  options:
+    max_body_inputs: 8
+workflows:
+  - endpoint: /api/dcim/sites/
+    methods: [GET]`,
+	})
+	cfg, err := loadWorkflowConfig(filepath.Join(dir, "dcim.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var endpoints []string
+	for _, wf := range cfg.Workflows {
+		endpoints = append(endpoints, wf.Endpoint)
+	}
+	if got := strings.Join(endpoints, " "); got != "/api/status/ /api/dcim/sites/" {
+		t.Errorf("workflows = %s, want the included file's first", got)
+	}
+	if got := strings.Join(cfg.Defaults.QueryParams, ","); got != "name" {
+		t.Errorf("query_params default = %q, want the included name", got)
+	}
+	if options := cfg.Defaults.Options; options == nil || options.Summary == nil || !*options.Summary || options.MaxBodyInputs == nil || *options.MaxBodyInputs != 8 {
+		t.Errorf("options default = %+v, want the included summary and the including file's max_body_inputs", options)
+	}
+	if len(cfg.Specs) != 1 || cfg.Specs[0].OpenAPI != filepath.Join(dir, "common", "netbox.json") {
+		t.Errorf("specs = %+v, want openapi resolved against common/common.yaml", cfg.Specs)
+	}
+}
+
+func TestLoadWorkflowConfigIncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml": "include: [b.yaml]\nworkflows:\n  - endpoint: /a\n    methods: [GET]",
+		"b.yaml": "include: [a.yaml]\nworkflows:\n  - endpoint: /b\n    methods: [GET]",
+	})
+	_, err := loadWorkflowConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "config include cycle") {
+		t.Fatalf("err = %v, want an include cycle", err)
+	}
+}
+
+func TestExpandConfigVariables(t *testing.T) {
+	variables := map[string]interface{}{"site": "dc1", "limit": 50, "app.name": "dcim"}
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "endpoint: /api/${app.name}/sites/", want: "endpoint: /api/dcim/sites/"},
+		{in: "query: site=${site}&limit=${limit}", want: "query: site=dc1&limit=50"},
+		{in: "template: $${site}", want: "template: ${site}"},
+		{in: "no references", want: "no references"},
+		{in: "${site} ${region} ${tenant}", wantErr: "undefined config variable(s) region, tenant"},
+	}
+	for _, tt := range tests {
+		got, err := expandConfigVariables([]byte(tt.in), variables)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expandConfigVariables(%q) err = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandConfigVariables(%q): %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("expandConfigVariables(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadWorkflowConfigVariables(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"common.yaml": "variables:\n  app: dcim\n  status: active\n",
+		"sites.yaml": `include: [common.yaml]
+variables:
+  status: planned
+workflows:
+  - endpoint: /api/${app}/sites/
+    methods: [GET]
+    query_params: [status]
+    wait_for:
+      field: status.value
+      value: ${status}`,
+	})
+	cfg, err := loadWorkflowConfig(filepath.Join(dir, "sites.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Workflows) != 1 {
+		t.Fatalf("got %d workflows, want 1", len(cfg.Workflows))
+	}
+	wf := cfg.Workflows[0]
+	if wf.Endpoint != "/api/dcim/sites/" {
+		t.Errorf("endpoint = %q, want the included app variable expanded", wf.Endpoint)
+	}
+	if wf.WaitFor == nil || fmt.Sprint(wf.WaitFor.Value) != "planned" {
+		t.Errorf("wait_for = %+v, want the including file's status to override the included one", wf.WaitFor)
+	}
+}
+
+// generatedID matches the KSUIDs of a workflow rendered with the default
+// generator.
+var generatedID = regexp.MustCompile(`[0-9A-Za-z]{27}`)
+
+// TestServeGeneratesConcurrently posts /generate requests in parallel and
+// compares each response with the same request rendered alone; run with -race
+// to catch state shared between requests.
+func TestServeGeneratesConcurrently(t *testing.T) {
+	settings := testSettings(t, "netbox")
+	srv := httptest.NewServer((&generationServer{spec: loadTestSpec(t, "netbox.json"), settings: settings}).handler())
+	defer srv.Close()
+
+	post := func(body string) (string, error) {
+		resp, err := http.Post(srv.URL+"/generate", "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("status %d: %s", resp.StatusCode, content)
+		}
+		return generatedID.ReplaceAllString(string(content), "ID"), nil
+	}
+	requests := []string{
+		`{"operation_id": "dcim_sites_list", "query_params": ["name", "status"]}`,
+		`{"operation_id": "dcim_sites_create", "body_params": ["name", "slug", "status"]}`,
+		`{"operation_id": "dcim_sites_retrieve", "wait_for": {"field": "status.value", "value": "active"}}`,
+		`{"endpoint": "/api/dcim/sites/{id}/", "methods": ["PUT"], "options": {"summary": true}}`,
+		`{"operation_id": "dcim_sites_destroy"}`,
+	}
+	want := make([]string, len(requests))
+	for i, body := range requests {
+		content, err := post(body)
+		if err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		want[i] = content
+	}
+
+	var wg sync.WaitGroup
+	for round := 0; round < 8; round++ {
+		for i, body := range requests {
+			wg.Add(1)
+			go func(i int, body string) {
+				defer wg.Done()
+				got, err := post(body)
+				if err != nil {
+					t.Errorf("%s: %v", body, err)
+					return
+				}
+				if got != want[i] {
+					t.Errorf("%s: concurrent response differs from the one rendered alone", body)
+				}
+			}(i, body)
+		}
+	}
+	wg.Wait()
+}
@@ -5,6 +5,7 @@ go 1.23.4
 require github.com/Masterminds/sprig/v3 v3.3.0
 
 require (
+	github.com/google/uuid v1.6.0
 	github.com/segmentio/ksuid v1.0.4
 	sigs.k8s.io/yaml v1.4.0
 )
@@ -13,7 +14,6 @@ require (
 	dario.cat/mergo v1.0.1 // indirect
 	github.com/Masterminds/goutils v1.1.1 // indirect
 	github.com/Masterminds/semver/v3 v3.3.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/huandu/xstrings v1.5.0 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
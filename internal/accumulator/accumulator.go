@@ -0,0 +1,66 @@
+// Package accumulator is the result accumulator of generated loops: a Python
+// step run once per pass that appends the pass's outcomes to a JSON array
+// variable, keeps the failed ones in a second array and counts both. The
+// arrays are capped so long runs cannot outgrow a workflow variable; the
+// counts stay exact.
+//
+// Bulk workflows record each CSV row, or each batch of rows run in parallel;
+// wait_for atomics record each polling attempt. The generator emits no other
+// loops: list outputs are arrays for the for-each loops users build, and
+// NetBox lists are read one page at a time.
+package accumulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLimit is how many entries each array keeps unless a loop sets its own.
+const DefaultLimit = 500
+
+// Queries are the script queries of the accumulator step as name/type pairs:
+// the updated arrays and counts to write back to the loop's variables.
+var Queries = [][2]string{
+	{"results_json", "string"},
+	{"failed_items_json", "string"},
+	{"succeeded", "integer"},
+	{"failed", "integer"},
+	{"processed", "integer"},
+}
+
+// Script returns the accumulator step. Its first four arguments are the
+// references to the current results, failed items and counts. entry is Python
+// that reads the loop's own arguments from sys.argv[5:] and sets entries, a
+// list of (entry, ok) pairs where entry is a dict and ok whether the item
+// succeeded. Once an array holds limit entries the oldest succeeded one is
+// dropped first, then the oldest.
+func Script(entry string, limit int) string {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	var b strings.Builder
+	b.WriteString("import json\nimport sys\n\n")
+	b.WriteString(fmt.Sprintf("LIMIT = %d\n\n", limit))
+	b.WriteString("def append_capped(items, item):\n")
+	b.WriteString("    items.append(item)\n")
+	b.WriteString("    while len(items) > LIMIT:\n")
+	b.WriteString("        index = next((i for i, kept in enumerate(items) if kept.get('status') == 'succeeded'), 0)\n")
+	b.WriteString("        items.pop(index)\n")
+	b.WriteString("    return items\n\n")
+	b.WriteString("(results_json, failed_items_json, succeeded, failed) = sys.argv[1:5]\n\n")
+	b.WriteString(strings.TrimRight(entry, "\n") + "\n\n")
+	b.WriteString("results = json.loads(results_json or '[]')\n")
+	b.WriteString("failed_items = json.loads(failed_items_json or '[]')\n")
+	b.WriteString("succeeded = int(succeeded or 0)\n")
+	b.WriteString("failed = int(failed or 0)\n")
+	b.WriteString("for entry, ok in entries:\n")
+	b.WriteString("    entry['status'] = 'succeeded' if ok else 'failed'\n")
+	b.WriteString("    results = append_capped(results, entry)\n")
+	b.WriteString("    if ok:\n        succeeded += 1\n")
+	b.WriteString("    else:\n        failed += 1\n        failed_items = append_capped(failed_items, entry)\n\n")
+	b.WriteString("results_json = json.dumps(results)\n")
+	b.WriteString("failed_items_json = json.dumps(failed_items)\n")
+	b.WriteString("processed = succeeded + failed\n")
+	b.WriteString("print(processed)\n")
+	return b.String()
+}
@@ -0,0 +1,808 @@
+// Package bulk builds CSV-driven provisioning workflows: a CSV input is parsed
+// in a Python step and every row calls a generated create atomic, or an update
+// atomic for rows that set the key column, as a workflow.atomic_workflow step.
+// Each row's outcome is recorded by the shared accumulator in JSON results and
+// failed-items outputs next to succeeded and failed counts; OnFailure decides
+// whether a failed row stops the run and how the workflow completes.
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/accumulator"
+)
+
+// DefaultKey is the column whose value sends a row to the update atomic.
+const DefaultKey = "id"
+
+// MaxParallelism is the largest fan-out of a bulk workflow. Every parallel row
+// adds its columns to the Next Rows queries, so the batch is kept small.
+const MaxParallelism = 20
+
+// Failure modes of a bulk workflow.
+const (
+	// OnFailureFailFast completes the workflow as failed at the first failed row.
+	OnFailureFailFast = "fail_fast"
+	// OnFailureReport runs every row and completes as failed when any row failed.
+	OnFailureReport = "report"
+	// OnFailureWarn runs every row and completes as succeeded, with a warning
+	// message when any row failed. It is the default.
+	OnFailureWarn = "warn"
+)
+
+// Config is one entry of the config's bulk list. Create and Update are
+// operationIds; Update is optional. Columns are matched to the atomics' inputs
+// by name ("asset_tag", "Asset Tag" and "Input - Asset Tag" are the same column).
+// MaxResults caps the entries kept in the results and failed-items outputs
+// (accumulator.DefaultLimit when unset). OnFailure is one of the OnFailure
+// modes, OnFailureWarn when unset. Parallelism above 1 runs that many rows at
+// once in a parallel block (up to MaxParallelism).
+type Config struct {
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Create      string `json:"create" yaml:"create"`
+	Update      string `json:"update,omitempty" yaml:"update,omitempty"`
+	Key         string `json:"key,omitempty" yaml:"key,omitempty"`
+	MaxResults  int    `json:"max_results,omitempty" yaml:"max_results,omitempty"`
+	OnFailure   string `json:"on_failure,omitempty" yaml:"on_failure,omitempty"`
+	Parallelism int    `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+}
+
+// Validate checks the entry before any atomic is rendered.
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Create) == "" {
+		return errors.New("bulk: create operation is required")
+	}
+	if c.Key != "" && strings.TrimSpace(c.Update) == "" {
+		return fmt.Errorf("bulk %s: key needs an update operation", c.FileName())
+	}
+	if c.MaxResults < 0 {
+		return fmt.Errorf("bulk %s: max_results must not be negative", c.FileName())
+	}
+	if c.Parallelism < 0 || c.Parallelism > MaxParallelism {
+		return fmt.Errorf("bulk %s: parallelism must be between 1 and %d", c.FileName(), MaxParallelism)
+	}
+	switch c.OnFailure {
+	case "", OnFailureFailFast, OnFailureReport, OnFailureWarn:
+	default:
+		return fmt.Errorf("bulk %s: unknown on_failure %q (use %s, %s or %s)", c.FileName(), c.OnFailure, OnFailureFailFast, OnFailureReport, OnFailureWarn)
+	}
+	return nil
+}
+
+// failureMode returns OnFailure, defaulting to OnFailureWarn.
+func (c Config) failureMode() string {
+	if c.OnFailure == "" {
+		return OnFailureWarn
+	}
+	return c.OnFailure
+}
+
+// FileName returns the workflow name written to <name>.json, <create>_bulk by default.
+func (c Config) FileName() string {
+	if strings.TrimSpace(c.Name) != "" {
+		return strings.TrimSpace(c.Name)
+	}
+	return strings.TrimSpace(c.Create) + "_bulk"
+}
+
+// Operations returns the operationIds the workflow calls.
+func (c Config) Operations() []string {
+	ops := []string{c.Create}
+	if strings.TrimSpace(c.Update) != "" && c.Update != c.Create {
+		ops = append(ops, c.Update)
+	}
+	return ops
+}
+
+// ParseFlag reads a -bulk value: the create operationId, optionally followed by
+// a comma and the update operationId.
+func ParseFlag(value string) (Config, error) {
+	create, update, _ := strings.Cut(value, ",")
+	c := Config{Create: strings.TrimSpace(create), Update: strings.TrimSpace(update)}
+	return c, c.Validate()
+}
+
+// atomicExport is the subset of a rendered atomic the bulk workflow needs.
+type atomicExport struct {
+	Workflow struct {
+		UniqueName string     `json:"unique_name"`
+		Title      string     `json:"title"`
+		Variables  []variable `json:"variables"`
+		Properties struct {
+			Description string `json:"description"`
+			Target      struct {
+				TargetType string `json:"target_type"`
+			} `json:"target"`
+		} `json:"properties"`
+	} `json:"workflow"`
+}
+
+type variable struct {
+	SchemaID   string             `json:"schema_id"`
+	Properties variableProperties `json:"properties"`
+	UniqueName string             `json:"unique_name"`
+	ObjectType string             `json:"object_type"`
+}
+
+type variableProperties struct {
+	Value                interface{} `json:"value"`
+	Scope                string      `json:"scope"`
+	Name                 string      `json:"name"`
+	Type                 string      `json:"type"`
+	Description          string      `json:"description"`
+	IsRequired           bool        `json:"is_required"`
+	VariableStringFormat string      `json:"variable_string_format"`
+	DisplayOnWizard      bool        `json:"display_on_wizard"`
+	IsInvisible          bool        `json:"is_invisible"`
+}
+
+type action struct {
+	UniqueName string                 `json:"unique_name"`
+	Name       string                 `json:"name"`
+	Title      string                 `json:"title"`
+	Type       string                 `json:"type"`
+	BaseType   string                 `json:"base_type"`
+	Properties map[string]interface{} `json:"properties"`
+	ObjectType string                 `json:"object_type"`
+	Blocks     []action               `json:"blocks,omitempty"`
+	Actions    []action               `json:"actions,omitempty"`
+}
+
+type condition struct {
+	LeftOperand  interface{} `json:"left_operand"`
+	Operator     string      `json:"operator"`
+	RightOperand interface{} `json:"right_operand"`
+}
+
+// column is a CSV column feeding the atomic input(s) of the same name.
+type column struct {
+	key          string
+	query        string
+	kind         string
+	defaultValue interface{}
+}
+
+// columnKey normalizes an input or header name for matching: "Input - Asset
+// Tag", "asset_tag" and "Asset-Tag" all become "asset tag".
+func columnKey(name string) string {
+	name = strings.TrimSpace(name)
+	if idx := strings.Index(name, " - "); idx >= 0 {
+		name = name[idx+3:]
+	}
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// columnKind is the value kind a column is converted to before it reaches an
+// input of dataType.
+func columnKind(dataType string) string {
+	switch dataType {
+	case "datatype.integer":
+		return "integer"
+	case "datatype.boolean":
+		return "boolean"
+	}
+	return "string"
+}
+
+// Build renders the bulk workflow export. atomics maps operationIds to the
+// rendered atomic exports; newID returns a fresh KSUID for generated objects.
+func Build(c Config, atomics map[string][]byte, newID func() string) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	parsed := make(map[string]atomicExport)
+	for _, op := range c.Operations() {
+		content, ok := atomics[op]
+		if !ok {
+			return nil, fmt.Errorf("bulk %s: atomic %s was not generated", c.FileName(), op)
+		}
+		var atomic atomicExport
+		if err := json.Unmarshal(content, &atomic); err != nil {
+			return nil, fmt.Errorf("bulk %s: %s: %w", c.FileName(), op, err)
+		}
+		parsed[op] = atomic
+	}
+	create := parsed[c.Create]
+	hasUpdate := strings.TrimSpace(c.Update) != ""
+	key := DefaultKey
+	if strings.TrimSpace(c.Key) != "" {
+		key = columnKey(c.Key)
+	}
+
+	// One column per distinct input name across the atomics, in input order.
+	var columns []column
+	byKey := make(map[string]int)
+	for _, op := range c.Operations() {
+		for _, v := range parsed[op].Workflow.Variables {
+			if v.Properties.Scope != "input" {
+				continue
+			}
+			k := columnKey(v.Properties.Name)
+			if _, ok := byKey[k]; ok {
+				continue
+			}
+			byKey[k] = len(columns)
+			columns = append(columns, column{key: k, query: fmt.Sprintf("c_%d", len(columns)), kind: columnKind(v.Properties.Type), defaultValue: v.Properties.Value})
+		}
+	}
+	if hasUpdate {
+		if _, ok := byKey[key]; !ok {
+			return nil, fmt.Errorf("bulk %s: %s has no input for the key column %q", c.FileName(), c.Update, key)
+		}
+	}
+
+	workflowID := "definition_workflow_" + newID()
+	title := c.Title
+	if strings.TrimSpace(title) == "" {
+		title = "Bulk " + create.Workflow.Title
+	}
+	description := c.Description
+	if strings.TrimSpace(description) == "" {
+		description = fmt.Sprintf("Runs %s for every row of a CSV.", create.Workflow.Title)
+		if hasUpdate {
+			description = fmt.Sprintf("Runs %s for every row of a CSV, or %s for rows with a %s value.", create.Workflow.Title, parsed[c.Update].Workflow.Title, key)
+		}
+	}
+	targetType := create.Workflow.Properties.Target.TargetType
+
+	csvInput := newVariable("input", "Input - CSV", "datatype.string", "", "text", true, newID)
+	csvInput.Properties.Description = "CSV with a header row; columns are named after the inputs (" + columnList(columns) + "). Empty cells leave an input unset."
+	limit := c.MaxResults
+	if limit == 0 {
+		limit = accumulator.DefaultLimit
+	}
+	resultsOutput := newVariable("output", "Output - Results", "datatype.string", "[]", "json", false, newID)
+	resultsOutput.Properties.Description = fmt.Sprintf("JSON array with one entry per row: row, action, status (succeeded or failed), status_code and error. Keeps the last %d rows, dropping succeeded ones first.", limit)
+	failedItemsOutput := newVariable("output", "Output - Failed Items", "datatype.string", "[]", "json", false, newID)
+	failedItemsOutput.Properties.Description = fmt.Sprintf("JSON array of the failed rows' entries (the last %d).", limit)
+	succeededOutput := newVariable("output", "Output - Succeeded", "datatype.integer", 0, "", false, newID)
+	failedOutput := newVariable("output", "Output - Failed", "datatype.integer", 0, "", false, newID)
+	processedOutput := newVariable("output", "Output - Rows Processed", "datatype.integer", 0, "", false, newID)
+	variables := []variable{csvInput, resultsOutput, failedItemsOutput, succeededOutput, failedOutput, processedOutput}
+	ref := func(v variable) string {
+		return fmt.Sprintf("$workflow.%s.%s.%s$", workflowID, v.Properties.Scope, v.UniqueName)
+	}
+
+	parseCSV := scriptAction(newID, "Parse CSV", parseScript(columns), []string{ref(csvInput)},
+		[][2]string{{"rows_json", "string"}, {"row_count", "integer"}})
+	rowsRef := fmt.Sprintf("$activity.%s.output.script_queries.rows_json$", parseCSV.UniqueName)
+	rowCountRef := fmt.Sprintf("$activity.%s.output.script_queries.row_count$", parseCSV.UniqueName)
+
+	// slotQueries are the Next Row queries of the row run in slot (empty
+	// without parallelism), read by query.
+	slotQueries := func(slot string) [][2]string {
+		queries := [][2]string{{slot + "row_number", "integer"}, {slot + "row_action", "string"}}
+		for _, col := range columns {
+			queries = append(queries, [2]string{slot + col.query, col.kind})
+		}
+		return queries
+	}
+	slots := []string{""}
+	nextRowTitle := "Next Row"
+	if c.Parallelism > 1 {
+		slots = make([]string, c.Parallelism)
+		for i := range slots {
+			slots[i] = fmt.Sprintf("s%d_", i)
+		}
+		nextRowTitle = "Next Rows"
+	}
+	var queries [][2]string
+	for _, slot := range slots {
+		queries = append(queries, slotQueries(slot)...)
+	}
+	nextRow := scriptAction(newID, nextRowTitle, nextRowScript(columns, hasUpdate, key, slots), []string{rowsRef, ref(processedOutput)}, queries)
+	rowQuery := func(slot string) func(string) string {
+		return func(name string) string {
+			return fmt.Sprintf("$activity.%s.output.script_queries.%s%s$", nextRow.UniqueName, slot, name)
+		}
+	}
+
+	// callAtomic runs one atomic with the inputs read by query and returns the
+	// references to its status code and error message.
+	callAtomic := func(atomic atomicExport, query func(string) string) (action, string, string, error) {
+		inputs := make(map[string]interface{})
+		outputs := make(map[string]string)
+		for _, v := range atomic.Workflow.Variables {
+			switch v.Properties.Scope {
+			case "input":
+				inputs[v.UniqueName] = query(columns[byKey[columnKey(v.Properties.Name)]].query)
+			case "output":
+				outputs[columnKey(v.Properties.Name)] = v.UniqueName
+			}
+		}
+		statusCode, ok := outputs["status code"]
+		if !ok {
+			return action{}, "", "", fmt.Errorf("bulk %s: %s has no Output - Status Code", c.FileName(), atomic.Workflow.Title)
+		}
+		call := action{
+			UniqueName: "definition_activity_" + newID(),
+			Name:       atomic.Workflow.Title,
+			Title:      atomic.Workflow.Title,
+			Type:       "workflow.atomic_workflow",
+			BaseType:   "subworkflow",
+			Properties: map[string]interface{}{
+				"continue_on_failure": true,
+				"description":         atomic.Workflow.Properties.Description,
+				"display_name":        atomic.Workflow.Title,
+				"input":               inputs,
+				"runtime_user":        map[string]bool{"target_default": true},
+				"skip_execution":      false,
+				"target":              map[string]interface{}{"target_type": atomic.Workflow.Properties.Target.TargetType, "use_workflow_target": true},
+				"workflow_id":         atomic.Workflow.UniqueName,
+				"workflow_name":       atomic.Workflow.Title,
+			},
+			ObjectType: "definition_activity",
+		}
+		errorMessage := ""
+		if name, ok := outputs["error message"]; ok {
+			errorMessage = fmt.Sprintf("$activity.%s.output.%s$", call.UniqueName, name)
+		}
+		return call, fmt.Sprintf("$activity.%s.output.%s$", call.UniqueName, statusCode), errorMessage, nil
+	}
+
+	// recordRows records the outcomes passed as rowArguments (row number, action,
+	// status code and error message of each row) and updates the results.
+	recordRows := func(title string, rowArguments []string) []action {
+		arguments := append([]string{ref(resultsOutput), ref(failedItemsOutput), ref(succeededOutput), ref(failedOutput)}, rowArguments...)
+		record := scriptAction(newID, title, accumulator.Script(rowEntries, limit), arguments, accumulator.Queries)
+		recorded := func(name string) string {
+			return fmt.Sprintf("$activity.%s.output.script_queries.%s$", record.UniqueName, name)
+		}
+		return []action{record, setVariables(newID, "Update Results", [][2]string{
+			{ref(resultsOutput), recorded("results_json")},
+			{ref(failedItemsOutput), recorded("failed_items_json")},
+			{ref(succeededOutput), recorded("succeeded")},
+			{ref(failedOutput), recorded("failed")},
+			{ref(processedOutput), recorded("processed")},
+		})}
+	}
+
+	// runRow returns the steps running the create or update atomic for the row in
+	// slot; each branch ends with then(row action, status code, error message).
+	runRow := func(slot string, then func(string, string, string) []action) (action, []action, error) {
+		query := rowQuery(slot)
+		branch := func(atomic atomicExport, rowAction string) ([]action, error) {
+			call, statusCode, errorMessage, err := callAtomic(atomic, query)
+			if err != nil {
+				return nil, err
+			}
+			return append([]action{call}, then(rowAction, statusCode, errorMessage)...), nil
+		}
+		createActions, err := branch(create, "create")
+		if err != nil {
+			return action{}, nil, err
+		}
+		createBranch := conditionBranch(newID, "Create", condition{LeftOperand: query("row_action"), Operator: "eq", RightOperand: "create"}, createActions)
+		if !hasUpdate {
+			return createBranch, createActions, nil
+		}
+		updateActions, err := branch(parsed[c.Update], "update")
+		if err != nil {
+			return action{}, nil, err
+		}
+		return ifElse(newID, "Create or Update?",
+			conditionBranch(newID, "Update", condition{LeftOperand: query("row_action"), Operator: "eq", RightOperand: "update"}, updateActions),
+			createBranch,
+		), nil, nil
+	}
+
+	loopActions := []action{nextRow}
+	if len(slots) == 1 {
+		query := rowQuery("")
+		choice, createActions, err := runRow("", func(rowAction, statusCode, errorMessage string) []action {
+			return recordRows("Record Row", []string{query("row_number"), rowAction, statusCode, errorMessage})
+		})
+		if err != nil {
+			return nil, err
+		}
+		if hasUpdate {
+			loopActions = append(loopActions, choice)
+		} else {
+			loopActions = append(loopActions, createActions...)
+		}
+	} else {
+		// Each slot saves its outcome to its own local variables, so the
+		// branches never write the same variable; Record Rows then adds the
+		// whole batch to the results.
+		var branches []action
+		var rowArguments []string
+		for i, slot := range slots {
+			statusVariable := newVariable("local", fmt.Sprintf("Row %d Status Code", i+1), "datatype.integer", 0, "", false, newID)
+			errorVariable := newVariable("local", fmt.Sprintf("Row %d Error Message", i+1), "datatype.string", "", "", false, newID)
+			variables = append(variables, statusVariable, errorVariable)
+			choice, _, err := runRow(slot, func(_, statusCode, errorMessage string) []action {
+				return []action{setVariables(newID, fmt.Sprintf("Save Row %d Outcome", i+1), [][2]string{
+					{ref(statusVariable), statusCode},
+					{ref(errorVariable), errorMessage},
+				})}
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !hasUpdate {
+				choice = ifElse(newID, "Has Row?", choice)
+			}
+			branches = append(branches, parallelBranch(newID, fmt.Sprintf("Row %d", i+1), choice))
+			query := rowQuery(slot)
+			rowArguments = append(rowArguments, query("row_number"), query("row_action"), ref(statusVariable), ref(errorVariable))
+		}
+		loopActions = append(loopActions, parallel(newID, "Run Rows", branches))
+		loopActions = append(loopActions, recordRows("Record Rows", rowArguments)...)
+	}
+
+	summary := fmt.Sprintf("processed %s rows, %s succeeded, %s failed", ref(processedOutput), ref(succeededOutput), ref(failedOutput))
+	anyFailed := condition{LeftOperand: ref(failedOutput), Operator: "ne", RightOperand: 0}
+	mode := c.failureMode()
+	if mode == OnFailureFailFast {
+		stopMessage := fmt.Sprintf("Stopped at failed row %s: %s. Failed items: %s", rowQuery("")("row_number"), summary, ref(failedItemsOutput))
+		if len(slots) > 1 {
+			stopMessage = fmt.Sprintf("Stopped after a batch with failed rows: %s. Failed items: %s", summary, ref(failedItemsOutput))
+		}
+		loopActions = append(loopActions, ifElse(newID, "Stop on Failure?", conditionBranch(newID, "Row Failed", anyFailed, []action{
+			completed(newID, false, stopMessage),
+		})))
+	}
+
+	actions := []action{
+		parseCSV,
+		{
+			UniqueName: "definition_activity_" + newID(),
+			Name:       "While Loop",
+			Title:      "For Each Row",
+			Type:       "logic.while",
+			BaseType:   "activity",
+			Properties: map[string]interface{}{
+				"condition":           condition{LeftOperand: ref(processedOutput), Operator: "lt", RightOperand: rowCountRef},
+				"continue_on_failure": false,
+				"display_name":        "For Each Row",
+				"skip_execution":      false,
+			},
+			ObjectType: "definition_activity",
+			Blocks:     []action{},
+			Actions:    loopActions,
+		},
+	}
+	switch mode {
+	case OnFailureReport:
+		actions = append(actions, ifElse(newID, "Any Failures?", conditionBranch(newID, "Failures", anyFailed, []action{
+			completed(newID, false, fmt.Sprintf("Some rows failed: %s. Failed items: %s", summary, ref(failedItemsOutput))),
+		})))
+	case OnFailureWarn:
+		actions = append(actions, ifElse(newID, "Any Failures?", conditionBranch(newID, "Warnings", anyFailed, []action{
+			completed(newID, true, fmt.Sprintf("Completed with warnings: %s. Failed items: %s", summary, ref(failedItemsOutput))),
+		})))
+	}
+	actions = append(actions, completed(newID, true, fmt.Sprintf("Processed %s rows: %s succeeded, %s failed", ref(processedOutput), ref(succeededOutput), ref(failedOutput))))
+
+	var atomicIDs []string
+	for _, op := range c.Operations() {
+		atomicIDs = append(atomicIDs, parsed[op].Workflow.UniqueName)
+	}
+	export := map[string]interface{}{
+		"workflow": map[string]interface{}{
+			"unique_name": workflowID,
+			"name":        title,
+			"title":       title,
+			"type":        "generic.workflow",
+			"base_type":   "workflow",
+			"variables":   variables,
+			"properties": map[string]interface{}{
+				"atomic":       map[string]bool{"is_atomic": false},
+				"description":  description,
+				"display_name": title,
+				"runtime_user": map[string]bool{"target_default": true},
+				"target": map[string]interface{}{
+					"target_type":               targetType,
+					"specify_on_workflow_start": true,
+				},
+			},
+			"object_type": "definition_workflow",
+			"actions":     actions,
+			"categories":  []string{},
+		},
+		"categories":          map[string]interface{}{},
+		"atomic_workflows":    atomicIDs,
+		"dependent_workflows": atomicIDs,
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
+
+func newVariable(scope, name, dataType string, value interface{}, format string, required bool, newID func() string) variable {
+	return variable{
+		SchemaID: dataType,
+		Properties: variableProperties{
+			Value:                value,
+			Scope:                scope,
+			Name:                 name,
+			Type:                 dataType,
+			IsRequired:           required,
+			VariableStringFormat: format,
+			DisplayOnWizard:      scope == "input",
+		},
+		UniqueName: "variable_workflow_" + newID(),
+		ObjectType: "variable_workflow",
+	}
+}
+
+// scriptAction returns a python3.script step reading queries as name/type pairs.
+func scriptAction(newID func() string, title, script string, arguments []string, queries [][2]string) action {
+	scriptQueries := make([]map[string]string, len(queries))
+	for i, query := range queries {
+		scriptQueries[i] = map[string]string{
+			"script_query":      query[0],
+			"script_query_name": query[0],
+			"script_query_type": query[1],
+		}
+	}
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Execute Python Script",
+		Title:      title,
+		Type:       "python3.script",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"action_timeout":      180,
+			"continue_on_failure": false,
+			"display_name":        title,
+			"script":              script,
+			"script_arguments":    arguments,
+			"script_queries":      scriptQueries,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+	}
+}
+
+func conditionBranch(newID func() string, title string, cond condition, actions []action) action {
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Condition Branch",
+		Title:      title,
+		Type:       "logic.condition_block",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"condition":           cond,
+			"continue_on_failure": false,
+			"display_name":        title,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Actions:    actions,
+	}
+}
+
+// ifElse returns a logic.if_else step with the given branches.
+func ifElse(newID func() string, title string, branches ...action) action {
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Condition Block",
+		Title:      title,
+		Type:       "logic.if_else",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"conditions":          []interface{}{},
+			"continue_on_failure": false,
+			"display_name":        title,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Blocks:     branches,
+	}
+}
+
+// parallel returns a logic.parallel step running branches at the same time.
+func parallel(newID func() string, title string, branches []action) action {
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Parallel Block",
+		Title:      title,
+		Type:       "logic.parallel",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        title,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Blocks:     branches,
+	}
+}
+
+// parallelBranch returns one branch of a parallel step.
+func parallelBranch(newID func() string, title string, actions ...action) action {
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Parallel Branch",
+		Title:      title,
+		Type:       "logic.parallel_block",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        title,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+		Actions:    actions,
+	}
+}
+
+// setVariables returns a core.set_multiple_variables step; updates are
+// variable/value pairs.
+func setVariables(newID func() string, title string, updates [][2]string) action {
+	variablesToUpdate := make([]map[string]string, len(updates))
+	for i, update := range updates {
+		variablesToUpdate[i] = map[string]string{"variable_to_update": update[0], "variable_value_new": update[1]}
+	}
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Set Variables",
+		Title:      title,
+		Type:       "core.set_multiple_variables",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"continue_on_failure": false,
+			"display_name":        title,
+			"skip_execution":      false,
+			"variables_to_update": variablesToUpdate,
+		},
+		ObjectType: "definition_activity",
+	}
+}
+
+// completed returns a Completed - Success or Completed - Failed step.
+func completed(newID func() string, succeeded bool, message string) action {
+	title, completionType := "Completed - Failed", "failed-completed"
+	if succeeded {
+		title, completionType = "Completed - Success", "succeeded"
+	}
+	return action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Completed",
+		Title:      title,
+		Type:       "logic.completed",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"completion_type":     completionType,
+			"continue_on_failure": false,
+			"display_name":        title,
+			"result_message":      message,
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+	}
+}
+
+// columnList names the columns for the CSV input's description.
+func columnList(columns []column) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = strings.ReplaceAll(col.key, " ", "_")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+const pythonNormalize = `def normalize(name):
+    return ' '.join(name.replace('_', ' ').replace('-', ' ').lower().split())
+
+`
+
+// parseScript reads the CSV into a JSON array of rows keyed by column and
+// rejects headers no atomic input matches.
+func parseScript(columns []column) string {
+	keys := make([]string, len(columns))
+	for i, col := range columns {
+		keys[i] = col.key
+	}
+	known, _ := json.Marshal(keys)
+	var b strings.Builder
+	b.WriteString("import csv\nimport io\nimport json\nimport sys\n\n")
+	b.WriteString(pythonNormalize)
+	b.WriteString("(csv_text,) = sys.argv[1:2]\n\n")
+	b.WriteString(fmt.Sprintf("columns = set(json.loads(%q))\n", string(known)))
+	b.WriteString("reader = csv.DictReader(io.StringIO(csv_text.strip()))\n")
+	b.WriteString("if not reader.fieldnames:\n    raise ValueError('The CSV needs a header row')\n")
+	b.WriteString("headers = {header: normalize(header) for header in reader.fieldnames}\n")
+	b.WriteString("unknown = [header for header, key in headers.items() if key not in columns]\n")
+	b.WriteString("if unknown:\n    raise ValueError('Unknown CSV columns: ' + ', '.join(unknown))\n\n")
+	b.WriteString("rows = []\n")
+	b.WriteString("for row in reader:\n")
+	b.WriteString("    values = {headers[header]: (value or '').strip() for header, value in row.items() if header in headers}\n")
+	b.WriteString("    if any(values.values()):\n        rows.append(values)\n")
+	b.WriteString("if not rows:\n    raise ValueError('The CSV has no data rows')\n\n")
+	b.WriteString("rows_json = json.dumps(rows)\nrow_count = len(rows)\n")
+	b.WriteString("print(row_count)\n")
+	return b.String()
+}
+
+// nextRowScript reads the rows at the processed count into the queries of
+// each slot: the row number, create or update and the cells converted to the
+// input types, empty cells falling back to the inputs' defaults. Slots past
+// the last row get row number 0 and no action.
+func nextRowScript(columns []column, hasUpdate bool, key string, slots []string) string {
+	var b strings.Builder
+	b.WriteString("import json\nimport sys\n\n")
+	b.WriteString("(rows_json, processed) = sys.argv[1:3]\n\n")
+	b.WriteString("rows = json.loads(rows_json)\nstart = int(processed or 0)\n\n")
+	b.WriteString("def cell(row, row_number, key, kind, default):\n")
+	b.WriteString("    value = row.get(key, '')\n")
+	b.WriteString("    if value == '':\n        return default\n")
+	b.WriteString("    if kind == 'integer':\n")
+	b.WriteString("        try:\n            return int(value)\n")
+	b.WriteString("        except ValueError:\n")
+	b.WriteString("            raise ValueError('Row %d: %s must be a whole number, got %r' % (row_number, key, value))\n")
+	b.WriteString("    if kind == 'boolean':\n")
+	b.WriteString("        lowered = value.lower()\n")
+	b.WriteString("        if lowered in ('true', '1', 'yes', 'y', 'on'):\n            return True\n")
+	b.WriteString("        if lowered in ('false', '0', 'no', 'n', 'off'):\n            return False\n")
+	b.WriteString("        raise ValueError('Row %d: %s must be true or false, got %r' % (row_number, key, value))\n")
+	b.WriteString("    return value\n\n")
+	b.WriteString("def row_values(index):\n")
+	b.WriteString("    row = rows[index] if index < len(rows) else {}\n")
+	b.WriteString("    row_number = index + 1 if index < len(rows) else 0\n")
+	b.WriteString("    if not row_number:\n        row_action = ''\n")
+	if hasUpdate {
+		b.WriteString(fmt.Sprintf("    elif row.get(%q, '') != '':\n        row_action = 'update'\n", key))
+	}
+	b.WriteString("    else:\n        row_action = 'create'\n")
+	b.WriteString("    return (row_number, row_action, [\n")
+	for _, col := range columns {
+		b.WriteString(fmt.Sprintf("        cell(row, row_number, %q, '%s', %s),\n", col.key, col.kind, pythonDefault(col)))
+	}
+	b.WriteString("    ])\n")
+	for i, slot := range slots {
+		b.WriteString(fmt.Sprintf("\n(%srow_number, %srow_action, values) = row_values(start + %d)\n", slot, slot, i))
+		for j, col := range columns {
+			b.WriteString(fmt.Sprintf("%s%s = values[%d]\n", slot, col.query, j))
+		}
+	}
+	b.WriteString("\nprint(start + 1)\n")
+	return b.String()
+}
+
+// pythonDefault is the Python literal of a column's default input value.
+func pythonDefault(col column) string {
+	switch value := col.defaultValue.(type) {
+	case bool:
+		if value {
+			return "True"
+		}
+		return "False"
+	case float64:
+		if col.kind == "integer" {
+			return fmt.Sprintf("%d", int64(value))
+		}
+		return fmt.Sprintf("'%v'", value)
+	case string:
+		return fmt.Sprintf("%q", value)
+	}
+	if col.kind == "integer" {
+		return "0"
+	}
+	if col.kind == "boolean" {
+		return "False"
+	}
+	return "''"
+}
+
+// rowEntries are the accumulator entries of the rows passed as groups of row
+// number, action, the atomic's status code and its error message. Groups
+// without a row (the empty slots of a last parallel batch) are skipped.
+const rowEntries = `entries = []
+arguments = sys.argv[5:]
+for i in range(0, len(arguments), 4):
+    (row_number, row_action, status_code, error_message) = arguments[i:i + 4]
+    if row_action == '':
+        continue
+    ok = str(status_code).strip().startswith('2')
+    entry = {'row': int(row_number), 'action': row_action, 'status_code': status_code}
+    if not ok:
+        entry['error'] = error_message or 'The row failed before the API answered'
+    entries.append((entry, ok))`
@@ -0,0 +1,380 @@
+// Package composite builds non-atomic AO workflows that chain generated atomics
+// as workflow.atomic_workflow steps. A Recipe names the atomics by operationId
+// and wires their inputs from composite inputs or earlier steps' outputs.
+package composite
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Recipe describes a composite workflow.
+type Recipe struct {
+	Name        string  `json:"name" yaml:"name"`
+	Title       string  `json:"title" yaml:"title"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Inputs      []Input `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Steps       []Step  `json:"steps" yaml:"steps"`
+}
+
+// Input is a wizard input of the composite workflow.
+type Input struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Step calls one generated atomic. Inputs maps the atomic's input names (with or
+// without the "Input - " style prefix) to values; values may embed
+// {{ input.<Name> }} and {{ steps.<id>.<Output Name> }} expressions. Connector
+// selects the spec the atomic is generated from when it differs from the run's.
+type Step struct {
+	ID        string            `json:"id" yaml:"id"`
+	Operation string            `json:"operation" yaml:"operation"`
+	Connector string            `json:"connector,omitempty" yaml:"connector,omitempty"`
+	Title     string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Inputs    map[string]string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// Operations returns the distinct steps (by operationId) the recipe calls, in
+// step order.
+func (r Recipe) Operations() []Step {
+	seen := make(map[string]bool)
+	var ops []Step
+	for _, step := range r.Steps {
+		if !seen[step.Operation] {
+			seen[step.Operation] = true
+			ops = append(ops, step)
+		}
+	}
+	return ops
+}
+
+// Validate checks the recipe structure before any atomic is rendered.
+func (r Recipe) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("composite recipe missing name")
+	}
+	if len(r.Steps) == 0 {
+		return fmt.Errorf("composite %s has no steps", r.Name)
+	}
+	ids := make(map[string]bool)
+	for i, step := range r.Steps {
+		if strings.TrimSpace(step.ID) == "" {
+			return fmt.Errorf("composite %s: step %d missing id", r.Name, i+1)
+		}
+		if ids[step.ID] {
+			return fmt.Errorf("composite %s: duplicate step id %q", r.Name, step.ID)
+		}
+		ids[step.ID] = true
+		if strings.TrimSpace(step.Operation) == "" {
+			return fmt.Errorf("composite %s: step %s missing operation", r.Name, step.ID)
+		}
+	}
+	return nil
+}
+
+// atomicExport is the subset of a rendered atomic the composite needs.
+type atomicExport struct {
+	Workflow struct {
+		UniqueName string     `json:"unique_name"`
+		Title      string     `json:"title"`
+		Variables  []variable `json:"variables"`
+		Properties struct {
+			Description string `json:"description"`
+			Target      struct {
+				TargetType string `json:"target_type"`
+			} `json:"target"`
+		} `json:"properties"`
+	} `json:"workflow"`
+}
+
+type variable struct {
+	SchemaID   string             `json:"schema_id"`
+	Properties variableProperties `json:"properties"`
+	UniqueName string             `json:"unique_name"`
+	ObjectType string             `json:"object_type"`
+}
+
+type variableProperties struct {
+	Value                interface{} `json:"value"`
+	Scope                string      `json:"scope"`
+	Name                 string      `json:"name"`
+	Type                 string      `json:"type"`
+	Description          string      `json:"description"`
+	IsRequired           bool        `json:"is_required"`
+	VariableStringFormat string      `json:"variable_string_format"`
+	DisplayOnWizard      bool        `json:"display_on_wizard"`
+	IsInvisible          bool        `json:"is_invisible"`
+}
+
+type action struct {
+	UniqueName string                 `json:"unique_name"`
+	Name       string                 `json:"name"`
+	Title      string                 `json:"title"`
+	Type       string                 `json:"type"`
+	BaseType   string                 `json:"base_type"`
+	Properties map[string]interface{} `json:"properties"`
+	ObjectType string                 `json:"object_type"`
+}
+
+var expressionPattern = regexp.MustCompile(`\{\{\s*(input|steps)\.([^}]+?)\s*\}\}`)
+
+// variableKey normalizes a variable name for matching: "Input - Serials",
+// "Body - Serials" and "serials" all become "serials".
+func variableKey(name string) string {
+	name = strings.TrimSpace(name)
+	if idx := strings.Index(name, " - "); idx >= 0 {
+		name = name[idx+3:]
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// builtStep records a rendered step for later output references.
+type builtStep struct {
+	activity string
+	outputs  map[string]string
+}
+
+// Build renders the composite workflow export. atomics maps operationIds to the
+// rendered atomic exports; newID returns a fresh KSUID for generated objects.
+func Build(recipe Recipe, atomics map[string][]byte, newID func() string) ([]byte, error) {
+	if err := recipe.Validate(); err != nil {
+		return nil, err
+	}
+	workflowID := "definition_workflow_" + newID()
+	title := recipe.Title
+	if strings.TrimSpace(title) == "" {
+		title = recipe.Name
+	}
+
+	inputRefs := make(map[string]string)
+	variables := []variable{}
+	for _, input := range recipe.Inputs {
+		uniqueName := "variable_workflow_" + newID()
+		inputRefs[variableKey(input.Name)] = fmt.Sprintf("$workflow.%s.input.%s$", workflowID, uniqueName)
+		variables = append(variables, variable{
+			SchemaID: "datatype.string",
+			Properties: variableProperties{
+				Value:                "",
+				Scope:                "input",
+				Name:                 "Input - " + input.Name,
+				Type:                 "datatype.string",
+				Description:          input.Description,
+				IsRequired:           input.Required,
+				VariableStringFormat: "text",
+				DisplayOnWizard:      true,
+			},
+			UniqueName: uniqueName,
+			ObjectType: "variable_workflow",
+		})
+	}
+
+	// Steps on a target type other than the composite's run against a target
+	// chosen through an extra "<Target Type> Target" input.
+	targetInputs := make(map[string]string)
+
+	steps := make(map[string]builtStep)
+	var actions []action
+	var atomicIDs []string
+	targetType := ""
+	for _, step := range recipe.Steps {
+		content, ok := atomics[step.Operation]
+		if !ok {
+			return nil, fmt.Errorf("composite %s: step %s: atomic %s was not generated", recipe.Name, step.ID, step.Operation)
+		}
+		var atomic atomicExport
+		if err := json.Unmarshal(content, &atomic); err != nil {
+			return nil, fmt.Errorf("composite %s: step %s: %w", recipe.Name, step.ID, err)
+		}
+		if targetType == "" {
+			targetType = atomic.Workflow.Properties.Target.TargetType
+		}
+
+		resolve := func(value string) (string, error) {
+			var resolveErr error
+			resolved := expressionPattern.ReplaceAllStringFunc(value, func(match string) string {
+				parts := expressionPattern.FindStringSubmatch(match)
+				if parts[1] == "input" {
+					ref, ok := inputRefs[variableKey(parts[2])]
+					if !ok {
+						resolveErr = fmt.Errorf("unknown composite input %q", parts[2])
+					}
+					return ref
+				}
+				stepID, output, found := strings.Cut(parts[2], ".")
+				previous, ok := steps[stepID]
+				if !found || !ok {
+					resolveErr = fmt.Errorf("%q must reference an earlier step as steps.<id>.<Output Name>", match)
+					return match
+				}
+				outputName, ok := previous.outputs[variableKey(output)]
+				if !ok {
+					resolveErr = fmt.Errorf("step %s has no output %q", stepID, output)
+					return match
+				}
+				return fmt.Sprintf("$activity.%s.output.%s$", previous.activity, outputName)
+			})
+			return resolved, resolveErr
+		}
+
+		inputNames := make(map[string]string)
+		outputs := make(map[string]string)
+		for _, v := range atomic.Workflow.Variables {
+			switch v.Properties.Scope {
+			case "input":
+				inputNames[variableKey(v.Properties.Name)] = v.UniqueName
+			case "output":
+				outputs[variableKey(v.Properties.Name)] = v.UniqueName
+			}
+		}
+		mapped := make(map[string]interface{})
+		for _, v := range atomic.Workflow.Variables {
+			if v.Properties.Scope == "input" {
+				mapped[v.UniqueName] = v.Properties.Value
+			}
+		}
+		keys := make([]string, 0, len(step.Inputs))
+		for key := range step.Inputs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			uniqueName, ok := inputNames[variableKey(key)]
+			if !ok {
+				return nil, fmt.Errorf("composite %s: step %s: %s has no input %q", recipe.Name, step.ID, step.Operation, key)
+			}
+			value, err := resolve(step.Inputs[key])
+			if err != nil {
+				return nil, fmt.Errorf("composite %s: step %s: input %q: %w", recipe.Name, step.ID, key, err)
+			}
+			mapped[uniqueName] = value
+		}
+
+		target := map[string]interface{}{
+			"target_type":         atomic.Workflow.Properties.Target.TargetType,
+			"use_workflow_target": true,
+		}
+		if stepTarget := atomic.Workflow.Properties.Target.TargetType; stepTarget != targetType {
+			ref, ok := targetInputs[stepTarget]
+			if !ok {
+				uniqueName := "variable_workflow_" + newID()
+				ref = fmt.Sprintf("$workflow.%s.input.%s$", workflowID, uniqueName)
+				targetInputs[stepTarget] = ref
+				variables = append(variables, variable{
+					SchemaID: "datatype.string",
+					Properties: variableProperties{
+						Value:                "",
+						Scope:                "input",
+						Name:                 "Input - " + targetInputName(stepTarget) + " Target",
+						Type:                 "datatype.string",
+						Description:          fmt.Sprintf("ID of the %s target used by the %s steps.", stepTarget, stepTarget),
+						IsRequired:           true,
+						VariableStringFormat: "text",
+						DisplayOnWizard:      true,
+					},
+					UniqueName: uniqueName,
+					ObjectType: "variable_workflow",
+				})
+			}
+			target = map[string]interface{}{
+				"target_type":              stepTarget,
+				"override_workflow_target": true,
+				"target_id":                ref,
+			}
+		}
+
+		stepTitle := step.Title
+		if strings.TrimSpace(stepTitle) == "" {
+			stepTitle = atomic.Workflow.Title
+		}
+		activityID := "definition_activity_" + newID()
+		actions = append(actions, action{
+			UniqueName: activityID,
+			Name:       atomic.Workflow.Title,
+			Title:      stepTitle,
+			Type:       "workflow.atomic_workflow",
+			BaseType:   "subworkflow",
+			Properties: map[string]interface{}{
+				"continue_on_failure": false,
+				"description":         atomic.Workflow.Properties.Description,
+				"display_name":        stepTitle,
+				"input":               mapped,
+				"runtime_user":        map[string]bool{"target_default": true},
+				"skip_execution":      false,
+				"target":              target,
+				"workflow_id":         atomic.Workflow.UniqueName,
+				"workflow_name":       atomic.Workflow.Title,
+			},
+			ObjectType: "definition_activity",
+		})
+		steps[step.ID] = builtStep{activity: activityID, outputs: outputs}
+		atomicIDs = appendUnique(atomicIDs, atomic.Workflow.UniqueName)
+	}
+
+	actions = append(actions, action{
+		UniqueName: "definition_activity_" + newID(),
+		Name:       "Completed",
+		Title:      "Completed - Success",
+		Type:       "logic.completed",
+		BaseType:   "activity",
+		Properties: map[string]interface{}{
+			"completion_type":     "succeeded",
+			"continue_on_failure": false,
+			"display_name":        "Completed - Success",
+			"result_message":      title + " completed",
+			"skip_execution":      false,
+		},
+		ObjectType: "definition_activity",
+	})
+
+	export := map[string]interface{}{
+		"workflow": map[string]interface{}{
+			"unique_name": workflowID,
+			"name":        title,
+			"title":       title,
+			"type":        "generic.workflow",
+			"base_type":   "workflow",
+			"variables":   variables,
+			"properties": map[string]interface{}{
+				"atomic":       map[string]bool{"is_atomic": false},
+				"description":  recipe.Description,
+				"display_name": title,
+				"runtime_user": map[string]bool{"target_default": true},
+				"target": map[string]interface{}{
+					"target_type":               targetType,
+					"specify_on_workflow_start": true,
+				},
+			},
+			"object_type": "definition_workflow",
+			"actions":     actions,
+			"categories":  []string{},
+		},
+		"categories":          map[string]interface{}{},
+		"atomic_workflows":    atomicIDs,
+		"dependent_workflows": atomicIDs,
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// targetInputName turns a target type such as "meraki.endpoint" into the wizard
+// label prefix "Meraki Endpoint".
+func targetInputName(targetType string) string {
+	words := strings.FieldsFunc(targetType, func(r rune) bool { return r == '.' || r == '_' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,157 @@
+package composite
+
+import "sort"
+
+// builtins are the recipes shipped with the generator, selectable with -recipe
+// or a config file's recipes list.
+var builtins = map[string]Recipe{
+	"meraki-device-onboarding": {
+		Name:        "meraki-device-onboarding",
+		Title:       "Cisco Meraki - Onboard Device",
+		Description: "Claims a device into the organization inventory, assigns it to a network and configures its management interface.",
+		Inputs: []Input{
+			{Name: "Organization ID", Description: "Organization to claim the device into.", Required: true},
+			{Name: "Network ID", Description: "Network the device is assigned to.", Required: true},
+			{Name: "Serial", Description: "Serial number of the device.", Required: true},
+			{Name: "WAN1 Settings", Description: `Management interface WAN 1 settings as JSON, e.g. {"wanEnabled": "enabled", "usingStaticIp": false}.`, Required: true},
+		},
+		Steps: []Step{
+			{
+				ID:        "claim",
+				Operation: "claimIntoOrganization",
+				Connector: "meraki",
+				Title:     "Claim Device into Organization",
+				Inputs: map[string]string{
+					"Organization ID": "{{ input.Organization ID }}",
+					"Serials":         `["{{ input.Serial }}"]`,
+				},
+			},
+			{
+				ID:        "assign",
+				Operation: "claimNetworkDevices",
+				Connector: "meraki",
+				Title:     "Assign Device to Network",
+				Inputs: map[string]string{
+					"Network ID": "{{ input.Network ID }}",
+					"Serials":    `["{{ input.Serial }}"]`,
+				},
+			},
+			{
+				ID:        "management",
+				Operation: "updateDeviceManagementInterface",
+				Connector: "meraki",
+				Title:     "Update Management Interface",
+				Inputs: map[string]string{
+					"Serial": "{{ input.Serial }}",
+					"Wan1":   "{{ input.WAN1 Settings }}",
+				},
+			},
+		},
+	},
+	"netbox-to-meraki-device-sync": {
+		Name:        "netbox-to-meraki-device-sync",
+		Title:       "NetBox to Meraki - Sync Device",
+		Description: "Reads a device from NetBox and pushes its name, description and coordinates to the Meraki device with the same serial.",
+		Inputs: []Input{
+			{Name: "NetBox Device ID", Description: "ID of the NetBox device to sync.", Required: true},
+		},
+		Steps: []Step{
+			{
+				ID:        "device",
+				Operation: "dcim_devices_retrieve",
+				Connector: "netbox",
+				Title:     "Get NetBox Device",
+				Inputs:    map[string]string{"ID": "{{ input.NetBox Device ID }}"},
+			},
+			{
+				ID:        "update",
+				Operation: "updateDevice",
+				Connector: "meraki",
+				Title:     "Update Meraki Device",
+				Inputs: map[string]string{
+					"Serial": "{{ steps.device.Serial }}",
+					"Name":   "{{ steps.device.Name }}",
+					"Notes":  "{{ steps.device.Description }}",
+					"Lat":    "{{ steps.device.Latitude }}",
+					"Lng":    "{{ steps.device.Longitude }}",
+				},
+			},
+		},
+	},
+	"netbox-to-meraki-site-sync": {
+		Name:        "netbox-to-meraki-site-sync",
+		Title:       "NetBox to Meraki - Sync Site to Network",
+		Description: "Reads a site from NetBox and pushes its name, time zone and description to a Meraki network.",
+		Inputs: []Input{
+			{Name: "NetBox Site ID", Description: "ID of the NetBox site to sync.", Required: true},
+			{Name: "Meraki Network ID", Description: "Meraki network representing the site.", Required: true},
+		},
+		Steps: []Step{
+			{
+				ID:        "site",
+				Operation: "dcim_sites_retrieve",
+				Connector: "netbox",
+				Title:     "Get NetBox Site",
+				Inputs:    map[string]string{"ID": "{{ input.NetBox Site ID }}"},
+			},
+			{
+				ID:        "update",
+				Operation: "updateNetwork",
+				Connector: "meraki",
+				Title:     "Update Meraki Network",
+				Inputs: map[string]string{
+					"Network ID": "{{ input.Meraki Network ID }}",
+					"Name":       "{{ steps.site.Name }}",
+					"Time Zone":  "{{ steps.site.Time Zone }}",
+					"Notes":      "{{ steps.site.Description }}",
+				},
+			},
+		},
+	},
+	"meraki-to-netbox-device-sync": {
+		Name:        "meraki-to-netbox-device-sync",
+		Title:       "Meraki to NetBox - Sync Device",
+		Description: "Reads a device from Meraki and writes its name, serial and notes back to the NetBox device.",
+		Inputs: []Input{
+			{Name: "Meraki Serial", Description: "Serial of the Meraki device to sync.", Required: true},
+			{Name: "NetBox Device ID", Description: "ID of the NetBox device to update.", Required: true},
+		},
+		Steps: []Step{
+			{
+				ID:        "device",
+				Operation: "getDevice",
+				Connector: "meraki",
+				Title:     "Get Meraki Device",
+				Inputs:    map[string]string{"Serial": "{{ input.Meraki Serial }}"},
+			},
+			{
+				ID:        "update",
+				Operation: "dcim_devices_partial_update",
+				Connector: "netbox",
+				Title:     "Update NetBox Device",
+				Inputs: map[string]string{
+					"ID":          "{{ input.NetBox Device ID }}",
+					"Name":        "{{ steps.device.Name }}",
+					"Serial":      "{{ steps.device.Serial }}",
+					"Description": "{{ steps.device.Notes }}",
+				},
+			},
+		},
+	},
+}
+
+// Builtin returns the built-in recipe with the given name.
+func Builtin(name string) (Recipe, bool) {
+	recipe, ok := builtins[name]
+	return recipe, ok
+}
+
+// BuiltinNames lists the built-in recipes in sorted order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
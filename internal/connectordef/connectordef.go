@@ -0,0 +1,194 @@
+// Package connectordef loads connector definitions from YAML: the adapter
+// action and target type of a platform, its base path, response fields and the
+// names of the request properties its action takes, so new adapters can be
+// supported without recompiling the generator. Load registers the definitions
+// with the connector registry. The package also pairs a run's connectors with
+// their OpenAPI specs (PairSpecs) and holds the specs of the connectors other
+// than the run's (Specs).
+//
+// A file holds one definition, or several under a top-level connectors list:
+//
+//	name: infoblox
+//	aliases: [nios]
+//	atomic_group: Infoblox
+//	platform_display_name: Infoblox NIOS
+//	target_type: infoblox.endpoint
+//	action_type: infoblox.api_request
+//	api_base_path: /wapi/v2.12
+//	status_message_field: status_text
+//	request_properties:
+//	  method: api_method
+//	  url: api_url
+//	  body: api_body
+package connectordef
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Definition describes one connector. The fields mirror the generator's
+// connector settings; empty ones take the defaults applied by Parse.
+type Definition struct {
+	Name                string            `json:"name"`
+	Aliases             []string          `json:"aliases,omitempty"`
+	AtomicGroup         string            `json:"atomic_group,omitempty"`
+	PlatformDisplayName string            `json:"platform_display_name,omitempty"`
+	TargetType          string            `json:"target_type"`
+	ActionType          string            `json:"action_type"`
+	ResponseBodyField   string            `json:"response_body_field,omitempty"`
+	StatusMessageField  string            `json:"status_message_field,omitempty"`
+	ErrorMessageField   string            `json:"error_message_field,omitempty"`
+	APIBasePath         string            `json:"api_base_path,omitempty"`
+	APIRoot             string            `json:"api_root,omitempty"`
+	ResponseEnvelope    string            `json:"response_envelope,omitempty"`
+	ManagedObjects      bool              `json:"managed_objects,omitempty"`
+	PathSuffix          string            `json:"path_suffix,omitempty"`
+	ContinueOnFailure   bool              `json:"continue_on_failure,omitempty"`
+	QueryPrep           bool              `json:"query_prep,omitempty"`
+	ListQueryParams     []string          `json:"list_query_params,omitempty"`
+	QueryParamHints     map[string]string `json:"query_param_hints,omitempty"`
+	PathDefaults        map[string]string `json:"path_defaults,omitempty"`
+	FixedOutputs        []string          `json:"fixed_outputs,omitempty"`
+	RequestProperties   RequestProperties `json:"request_properties,omitempty"`
+}
+
+// RequestProperties names the properties of the connector's API request action
+// that carry the method, URL, body and description. Extra properties are sent
+// as they are, e.g. a content_type the adapter expects.
+type RequestProperties struct {
+	Method         string                 `json:"method,omitempty"`
+	URL            string                 `json:"url,omitempty"`
+	Body           string                 `json:"body,omitempty"`
+	Description    string                 `json:"description,omitempty"`
+	AlwaysSendBody bool                   `json:"always_send_body,omitempty"`
+	Extra          map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Defaults of the request property names, those of the Cisco adapters.
+const (
+	DefaultMethodProperty      = "api_method"
+	DefaultURLProperty         = "api_url"
+	DefaultBodyProperty        = "api_body"
+	DefaultDescriptionProperty = "description"
+	DefaultResponseBodyField   = "response_body"
+	DefaultErrorMessageField   = "error.message"
+)
+
+// StandardProperties are the request properties every API request action
+// gets from the generator; definitions cannot rename or override them.
+var StandardProperties = []string{"action_timeout", "continue_on_failure", "display_name", "runtime_user", "skip_execution", "target"}
+
+// Names returns the definition's name followed by its aliases, lowercased.
+func (d Definition) Names() []string {
+	names := []string{strings.ToLower(d.Name)}
+	for _, alias := range d.Aliases {
+		names = append(names, strings.ToLower(strings.TrimSpace(alias)))
+	}
+	return names
+}
+
+// Parse parses one definition or a connectors list, rejecting unknown fields so
+// a misspelled key is not silently ignored, and fills in the defaults.
+func Parse(data []byte) ([]Definition, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty connector definition")
+	}
+	var probe map[string]interface{}
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	var definitions []Definition
+	if _, ok := probe["connectors"]; ok {
+		var list struct {
+			Connectors []Definition `json:"connectors"`
+		}
+		if err := yaml.UnmarshalStrict(data, &list); err != nil {
+			return nil, err
+		}
+		definitions = list.Connectors
+	} else {
+		var definition Definition
+		if err := yaml.UnmarshalStrict(data, &definition); err != nil {
+			return nil, err
+		}
+		definitions = []Definition{definition}
+	}
+	seen := make(map[string]bool)
+	for i := range definitions {
+		if err := definitions[i].normalize(); err != nil {
+			return nil, err
+		}
+		for _, name := range definitions[i].Names() {
+			if seen[name] {
+				return nil, fmt.Errorf("connector name %q is defined twice", name)
+			}
+			seen[name] = true
+		}
+	}
+	return definitions, nil
+}
+
+func (d *Definition) normalize() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Name == "" {
+		return fmt.Errorf("connector definition missing name")
+	}
+	if strings.TrimSpace(d.TargetType) == "" {
+		return fmt.Errorf("connector %s: missing target_type", d.Name)
+	}
+	if strings.TrimSpace(d.ActionType) == "" {
+		return fmt.Errorf("connector %s: missing action_type", d.Name)
+	}
+	for _, alias := range d.Aliases {
+		if strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("connector %s: empty alias", d.Name)
+		}
+	}
+	if d.AtomicGroup == "" {
+		d.AtomicGroup = d.Name
+	}
+	if d.PlatformDisplayName == "" {
+		d.PlatformDisplayName = d.AtomicGroup
+	}
+	if d.ResponseBodyField == "" {
+		d.ResponseBodyField = DefaultResponseBodyField
+	}
+	if d.ErrorMessageField == "" {
+		d.ErrorMessageField = DefaultErrorMessageField
+	}
+	d.APIBasePath = strings.TrimRight(d.APIBasePath, "/")
+	properties := &d.RequestProperties
+	if properties.Method == "" {
+		properties.Method = DefaultMethodProperty
+	}
+	if properties.URL == "" {
+		properties.URL = DefaultURLProperty
+	}
+	if properties.Body == "" {
+		properties.Body = DefaultBodyProperty
+	}
+	if properties.Description == "" {
+		properties.Description = DefaultDescriptionProperty
+	}
+	named := make(map[string]bool)
+	for _, name := range StandardProperties {
+		named[name] = true
+	}
+	for _, name := range []string{properties.Method, properties.URL, properties.Body, properties.Description} {
+		if named[name] {
+			return fmt.Errorf("connector %s: request property %q is used twice or is a standard property", d.Name, name)
+		}
+		named[name] = true
+	}
+	for name := range properties.Extra {
+		if named[name] {
+			return fmt.Errorf("connector %s: extra request property %q clashes with a named one", d.Name, name)
+		}
+	}
+	return nil
+}
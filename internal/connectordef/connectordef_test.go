@@ -0,0 +1,157 @@
+package connectordef
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/pkg/connector"
+)
+
+const infoblox = `name: infoblox
+target_type: infoblox.endpoint
+action_type: infoblox.api_request
+api_base_path: /wapi/v2.12/
+request_properties:
+  method: http_method
+  url: path
+  extra:
+    content_type: application/json`
+
+func TestConfig(t *testing.T) {
+	definitions, err := Parse([]byte(infoblox))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := definitions[0].Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIBasePath != "/wapi/v2.12" || cfg.AtomicGroup != "infoblox" || cfg.ErrorMessageField != DefaultErrorMessageField {
+		t.Errorf("config = %+v, want the parse defaults", cfg)
+	}
+	if got := strings.Join(cfg.FixedOutputs, ","); got != "status_code,error_message" {
+		t.Errorf("fixed outputs = %s, want status_code,error_message without a status message field", got)
+	}
+	for _, req := range []connector.Request{
+		{Method: "GET", Endpoint: "/network", Description: "List networks.", DisplayName: "List Networks", Timeout: 30},
+		{Method: "POST", Endpoint: "/network", Body: `{"network": "10.0.0.0/8"}`, HasBody: true},
+	} {
+		props, ok := cfg.BuildActionProps(req).(connector.DefinedRequestProperties)
+		if !ok || props.DescriptionKey != DefaultDescriptionProperty {
+			t.Fatalf("request properties = %#v, want DefinedRequestProperties", props)
+		}
+		data, err := json.Marshal(props)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			t.Fatal(err)
+		}
+		if values["http_method"] != req.Method || values["path"] != req.Endpoint || values["content_type"] != "application/json" {
+			t.Errorf("%s properties = %s", req.Method, data)
+		}
+		if body, ok := values[DefaultBodyProperty]; ok != req.HasBody || ok && body != req.Body {
+			t.Errorf("%s properties = %s, want the body only when the request has one", req.Method, data)
+		}
+	}
+
+	definitions[0].FixedOutputs = []string{"status_code", "headers"}
+	if _, err := definitions[0].Config(); err == nil || !strings.Contains(err.Error(), `unknown fixed output "headers"`) {
+		t.Errorf("error = %v, want the unknown fixed output", err)
+	}
+}
+
+func TestPairSpecs(t *testing.T) {
+	tests := []struct {
+		name       string
+		openAPIs   []string
+		connectors []string
+		configured []Spec
+		want       string
+		wantErr    string
+	}{
+		{name: "no spec", want: "meraki="},
+		{name: "connector only", connectors: []string{"netbox"}, want: "netbox="},
+		{name: "single openapi", openAPIs: []string{"meraki.json"}, want: "meraki=meraki.json"},
+		{
+			name:       "pairs and config",
+			openAPIs:   []string{"netbox.json", "aci.json"},
+			connectors: []string{"NetBox", "aci"},
+			configured: []Spec{{Connector: " servicenow ", OpenAPI: "snow.json "}},
+			want:       "netbox=netbox.json aci=aci.json servicenow=snow.json",
+		},
+		{
+			name:       "unpaired flags",
+			openAPIs:   []string{"netbox.json", "aci.json"},
+			connectors: []string{"netbox"},
+			wantErr:    "give one -connector per -openapi (got 2 -openapi and 1 -connector)",
+		},
+		{
+			name:       "connector without openapi",
+			connectors: []string{"netbox"},
+			configured: []Spec{{Connector: "aci", OpenAPI: "aci.json"}},
+			wantErr:    "-connector netbox needs an -openapi when the config lists specs",
+		},
+		{
+			name:       "connector twice",
+			openAPIs:   []string{"netbox.json"},
+			connectors: []string{"netbox"},
+			configured: []Spec{{Connector: "netbox", OpenAPI: "other.json"}},
+			wantErr:    "connector netbox has two specs",
+		},
+		{
+			name:       "missing path",
+			configured: []Spec{{Connector: "aci"}},
+			wantErr:    "spec 1 needs both a connector and an openapi path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := PairSpecs(tt.openAPIs, tt.connectors, tt.configured)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, spec := range specs {
+				got = append(got, spec.Connector+"="+spec.OpenAPI)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("specs = %s, want %s", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecsLoadOnce(t *testing.T) {
+	var loads []string
+	specs := NewSpecs(func(ctx context.Context, path string) (string, error) {
+		loads = append(loads, path)
+		return "spec of " + path, nil
+	})
+	specs.Set(" ACI ", " aci.json")
+	specs.Set("netbox", "netbox.json")
+	for i := 0; i < 2; i++ {
+		spec, err := specs.Get(context.Background(), "aci")
+		if err != nil || spec != "spec of aci.json" {
+			t.Fatalf("Get(aci) = %q, %v", spec, err)
+		}
+	}
+	if strings.Join(loads, " ") != "aci.json" {
+		t.Errorf("loaded %q, want aci.json once", loads)
+	}
+	if got := strings.Join(specs.Names(), " "); got != "aci netbox" {
+		t.Errorf("Names() = %s, want aci netbox", got)
+	}
+	if _, err := specs.Get(context.Background(), "fmc"); err == nil || err.Error() != "the fmc connector has no spec; pass it with -spec=fmc=<path>" {
+		t.Errorf("Get(fmc) error = %v, want the missing -spec", err)
+	}
+}
@@ -0,0 +1,97 @@
+package connectordef
+
+import (
+	"fmt"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/pkg/connector"
+)
+
+// Load registers the connectors defined in the YAML files at paths under their
+// names and aliases; they replace the registered connectors of the same name.
+func Load(paths []string) error {
+	for _, path := range paths {
+		data, err := fsutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		definitions, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, definition := range definitions {
+			cfg, err := definition.Config()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			for _, name := range definition.Names() {
+				connector.Register(name, cfg)
+			}
+		}
+	}
+	return nil
+}
+
+// Config builds the connector config of a parsed definition. Without
+// fixed_outputs it declares the status code and error message, plus the status
+// message when the adapter returns a status text.
+func (d Definition) Config() (connector.Config, error) {
+	outputs, err := connector.ParseOutputs(d.FixedOutputs)
+	if err != nil {
+		return connector.Config{}, fmt.Errorf("connector %s: %w", d.Name, err)
+	}
+	if len(outputs) == 0 {
+		outputs = []string{connector.OutputStatusCode, connector.OutputErrorMessage}
+		if d.StatusMessageField != "" {
+			outputs = append([]string{connector.OutputStatusMessage}, outputs...)
+		}
+	}
+	return connector.Config{
+		AtomicGroup:         d.AtomicGroup,
+		TargetType:          d.TargetType,
+		ActionType:          d.ActionType,
+		ResponseBodyField:   d.ResponseBodyField,
+		StatusMessageField:  d.StatusMessageField,
+		ErrorMessageField:   d.ErrorMessageField,
+		APIBasePath:         d.APIBasePath,
+		APIRoot:             d.APIRoot,
+		ResponseEnvelope:    d.ResponseEnvelope,
+		ManagedObjects:      d.ManagedObjects,
+		PathSuffix:          d.PathSuffix,
+		ContinueOnFailure:   d.ContinueOnFailure,
+		PlatformDisplayName: d.PlatformDisplayName,
+		FixedOutputs:        outputs,
+		PathDefaults:        d.PathDefaults,
+		QueryPrep:           d.QueryPrep,
+		ListQueryParams:     d.ListQueryParams,
+		QueryParamHints:     d.QueryParamHints,
+		BuildActionProps:    d.RequestProperties.builder(d.ContinueOnFailure),
+	}, nil
+}
+
+// builder returns the request builder of a defined connector: the standard
+// properties plus the method, URL, description and (for methods that take
+// one, unless always_send_body is set) body under the names in p, and the
+// extra properties as they are.
+func (p RequestProperties) builder(continueOnFailure bool) func(req connector.Request) interface{} {
+	return func(req connector.Request) interface{} {
+		values := map[string]interface{}{
+			"action_timeout":      req.Timeout,
+			"continue_on_failure": continueOnFailure,
+			"display_name":        req.DisplayName,
+			"runtime_user":        connector.RuntimeUser{TargetDefault: true},
+			"skip_execution":      false,
+			"target":              map[string]bool{"use_workflow_target": true},
+			p.Method:              req.Method,
+			p.URL:                 req.Endpoint,
+			p.Description:         req.Description,
+		}
+		if req.HasBody || p.AlwaysSendBody {
+			values[p.Body] = req.Body
+		}
+		for key, value := range p.Extra {
+			values[key] = value
+		}
+		return connector.DefinedRequestProperties{Values: values, DescriptionKey: p.Description}
+	}
+}
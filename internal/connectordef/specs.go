@@ -0,0 +1,128 @@
+package connectordef
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Spec pairs a connector with the OpenAPI spec its operations render from.
+type Spec struct {
+	Connector string `json:"connector" yaml:"connector"`
+	OpenAPI   string `json:"openapi" yaml:"openapi"`
+}
+
+// PairSpecs pairs the -openapi and -connector flags in order and appends the
+// config's specs; the first is the run's spec and connector. A single -openapi
+// without -connector targets meraki. Without any spec the result holds just
+// the connector, for modes that need no spec such as -lint.
+func PairSpecs(openAPIs, connectors []string, configured []Spec) ([]Spec, error) {
+	var specs []Spec
+	switch {
+	case len(openAPIs) > 1 || len(connectors) > 1:
+		if len(openAPIs) != len(connectors) {
+			return nil, fmt.Errorf("give one -connector per -openapi (got %d -openapi and %d -connector)", len(openAPIs), len(connectors))
+		}
+		for i := range openAPIs {
+			specs = append(specs, Spec{Connector: connectors[i], OpenAPI: openAPIs[i]})
+		}
+	case len(openAPIs) == 1:
+		spec := Spec{Connector: "meraki", OpenAPI: openAPIs[0]}
+		if len(connectors) == 1 {
+			spec.Connector = connectors[0]
+		}
+		specs = append(specs, spec)
+	case len(connectors) == 1 && len(configured) > 0:
+		return nil, fmt.Errorf("-connector %s needs an -openapi when the config lists specs", connectors[0])
+	}
+	specs = append(specs, configured...)
+	if len(specs) == 0 {
+		spec := Spec{Connector: "meraki"}
+		if len(connectors) == 1 {
+			spec.Connector = connectors[0]
+		}
+		return []Spec{spec}, nil
+	}
+	seen := make(map[string]bool)
+	for i, spec := range specs {
+		name := strings.ToLower(strings.TrimSpace(spec.Connector))
+		if name == "" || strings.TrimSpace(spec.OpenAPI) == "" {
+			return nil, fmt.Errorf("spec %d needs both a connector and an openapi path", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("connector %s has two specs", name)
+		}
+		seen[name] = true
+		specs[i] = Spec{Connector: name, OpenAPI: strings.TrimSpace(spec.OpenAPI)}
+	}
+	return specs, nil
+}
+
+// Specs holds the specs of the connectors a run renders for besides its own
+// (-spec connector=path), by connector name, and loads each once on first
+// use. Paths are set at startup; Get may be called concurrently.
+type Specs[T any] struct {
+	load   func(ctx context.Context, path string) (T, error)
+	mu     sync.Mutex
+	paths  map[string]string
+	loaded map[string]T
+}
+
+// NewSpecs returns an empty set whose specs are read with load.
+func NewSpecs[T any](load func(ctx context.Context, path string) (T, error)) *Specs[T] {
+	return &Specs[T]{load: load, paths: map[string]string{}, loaded: map[string]T{}}
+}
+
+func specKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Set registers the spec path of a connector, replacing an earlier one.
+func (s *Specs[T]) Set(name, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths[specKey(name)] = strings.TrimSpace(path)
+}
+
+// Path returns the spec path registered for a connector.
+func (s *Specs[T]) Path(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, ok := s.paths[specKey(name)]
+	return path, ok
+}
+
+// Names returns the connectors with a registered spec, sorted.
+func (s *Specs[T]) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.paths))
+	for name := range s.paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the spec of a connector, loading it the first time.
+func (s *Specs[T]) Get(ctx context.Context, name string) (T, error) {
+	name = specKey(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if spec, ok := s.loaded[name]; ok {
+		return spec, nil
+	}
+	var spec T
+	path, ok := s.paths[name]
+	if !ok {
+		return spec, fmt.Errorf("the %s connector has no spec; pass it with -spec=%s=<path>", name, name)
+	}
+	spec, err := s.load(ctx, path)
+	if err != nil {
+		return spec, err
+	}
+	s.loaded[name] = spec
+	return spec, nil
+}
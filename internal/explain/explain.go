@@ -0,0 +1,372 @@
+// Package explain summarizes a rendered AO workflow export for review: its
+// inputs, the steps leading up to the API request, the request itself, the
+// condition branches that follow and the outputs, with generated unique names
+// replaced by the titles and variable names they stand for.
+package explain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrNotWorkflow is returned for JSON documents that are not workflow exports.
+var ErrNotWorkflow = errors.New("document has no workflow object")
+
+// Variable is one workflow input or output.
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	// Hidden is set for variables not shown on the run wizard.
+	Hidden bool
+}
+
+// Request is the method, endpoint and body of an API request step.
+type Request struct {
+	Method   string
+	Endpoint string
+	Body     string
+}
+
+// Step is one action. Condition is set for condition blocks and loops, Request
+// for the connector's API request steps, and Steps holds nested actions and
+// blocks in order.
+type Step struct {
+	Title     string
+	Type      string
+	Condition string
+	Request   *Request
+	Skipped   bool
+	Steps     []Step
+}
+
+// Outline is the reviewable view of a workflow export.
+type Outline struct {
+	Title       string
+	Description string
+	Inputs      []Variable
+	Outputs     []Variable
+	Steps       []Step
+}
+
+// Requests returns the API request steps in the order they run.
+func (o *Outline) Requests() []Step {
+	var requests []Step
+	var walk func(steps []Step)
+	walk = func(steps []Step) {
+		for _, step := range steps {
+			if step.Request != nil {
+				requests = append(requests, step)
+			}
+			walk(step.Steps)
+		}
+	}
+	walk(o.Steps)
+	return requests
+}
+
+// Prep returns the top-level steps that run before the first API request.
+func (o *Outline) Prep() []Step {
+	for i, step := range o.Steps {
+		if step.Request != nil || containsRequest(step.Steps) {
+			return o.Steps[:i]
+		}
+	}
+	return nil
+}
+
+func containsRequest(steps []Step) bool {
+	for _, step := range steps {
+		if step.Request != nil || containsRequest(step.Steps) {
+			return true
+		}
+	}
+	return false
+}
+
+// referencePattern matches AO references such as
+// $activity.definition_activity_<KSUID>.output.status_code$.
+var referencePattern = regexp.MustCompile(`\$(workflow|activity)\.[A-Za-z0-9_-]+\.[A-Za-z0-9_.\[\]-]+\$`)
+
+// Parse reads a workflow export. Actions of requestType (the connector's API
+// request action, e.g. meraki.api_request) are reported as requests.
+func Parse(content []byte, requestType string) (*Outline, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	workflow, ok := root["workflow"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotWorkflow
+	}
+
+	names := map[string]string{}
+	outline := &Outline{}
+	outline.Title, _ = workflow["title"].(string)
+	if properties, ok := workflow["properties"].(map[string]interface{}); ok {
+		outline.Description, _ = properties["description"].(string)
+	}
+	variables, _ := workflow["variables"].([]interface{})
+	for _, raw := range variables {
+		variable, _ := raw.(map[string]interface{})
+		properties, _ := variable["properties"].(map[string]interface{})
+		v := Variable{}
+		v.Name, _ = properties["name"].(string)
+		v.Type, _ = properties["type"].(string)
+		v.Type = strings.TrimPrefix(v.Type, "datatype.")
+		v.Description, _ = properties["description"].(string)
+		v.Required, _ = properties["is_required"].(bool)
+		wizard, _ := properties["display_on_wizard"].(bool)
+		invisible, _ := properties["is_invisible"].(bool)
+		v.Hidden = !wizard || invisible
+		if id, ok := variable["unique_name"].(string); ok {
+			names[id] = v.Name
+		}
+		switch properties["scope"] {
+		case "input":
+			outline.Inputs = append(outline.Inputs, v)
+		case "output":
+			outline.Outputs = append(outline.Outputs, v)
+		}
+	}
+
+	// Titles are collected first so references to later steps resolve too.
+	collectTitles(workflow["actions"], names)
+	p := parser{names: names, requestType: requestType}
+	outline.Steps = p.steps(workflow["actions"])
+	return outline, nil
+}
+
+func collectTitles(value interface{}, names map[string]string) {
+	list, _ := value.([]interface{})
+	for _, raw := range list {
+		action, _ := raw.(map[string]interface{})
+		id, _ := action["unique_name"].(string)
+		title, _ := action["title"].(string)
+		if id != "" {
+			names[id] = title
+		}
+		collectTitles(action["blocks"], names)
+		collectTitles(action["actions"], names)
+	}
+}
+
+type parser struct {
+	names       map[string]string
+	requestType string
+}
+
+func (p parser) steps(value interface{}) []Step {
+	list, _ := value.([]interface{})
+	var steps []Step
+	for _, raw := range list {
+		action, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		step := Step{}
+		step.Title, _ = action["title"].(string)
+		step.Type, _ = action["type"].(string)
+		properties, _ := action["properties"].(map[string]interface{})
+		step.Skipped, _ = properties["skip_execution"].(bool)
+		if condition, ok := properties["condition"]; ok {
+			step.Condition = p.condition(condition)
+		}
+		if step.Type == p.requestType {
+			step.Request = p.request(properties)
+		}
+		step.Steps = append(p.steps(action["blocks"]), p.steps(action["actions"])...)
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// request picks the method, endpoint and body out of the connector's request
+// properties by their key suffixes (api_method/_method, api_url/_endpoint,
+// api_body/_body), so every connector is handled the same way.
+func (p parser) request(properties map[string]interface{}) *Request {
+	request := &Request{}
+	for key, value := range properties {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(key, "method"):
+			request.Method = text
+		case strings.HasSuffix(key, "url"), strings.HasSuffix(key, "endpoint"):
+			request.Endpoint = p.readable(text)
+		case strings.HasSuffix(key, "body"):
+			request.Body = p.readable(text)
+		}
+	}
+	return request
+}
+
+var operatorSymbols = map[string]string{
+	"eq":  "==",
+	"ne":  "!=",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+// condition renders an AO condition (left_operand, operator, right_operand,
+// nested for and/or) as a readable expression.
+func (p parser) condition(value interface{}) string {
+	condition, ok := value.(map[string]interface{})
+	if !ok {
+		return p.operand(value)
+	}
+	operator, _ := condition["operator"].(string)
+	left := p.operand(condition["left_operand"])
+	right := p.operand(condition["right_operand"])
+	if operator == "and" || operator == "or" {
+		return fmt.Sprintf("(%s) %s (%s)", left, operator, right)
+	}
+	if symbol, ok := operatorSymbols[operator]; ok {
+		operator = symbol
+	}
+	return fmt.Sprintf("%s %s %s", left, operator, right)
+}
+
+func (p parser) operand(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return p.condition(v)
+	case string:
+		if referencePattern.MatchString(v) {
+			return p.readable(v)
+		}
+		return fmt.Sprintf("%q", v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// readable replaces AO references with {Variable Name} for workflow variables
+// and {Step Title.field} for activity outputs.
+func (p parser) readable(text string) string {
+	return referencePattern.ReplaceAllStringFunc(text, func(ref string) string {
+		parts := strings.Split(strings.Trim(ref, "$"), ".")
+		if len(parts) < 4 {
+			return ref
+		}
+		name, ok := p.names[parts[3]]
+		if parts[0] == "workflow" && ok {
+			return "{" + strings.Join(append([]string{name}, parts[4:]...), ".") + "}"
+		}
+		if title, ok := p.names[parts[1]]; ok && parts[0] == "activity" {
+			return "{" + title + "." + strings.Join(parts[3:], ".") + "}"
+		}
+		return ref
+	})
+}
+
+// WriteText writes the outline as indented plain text.
+func (o *Outline) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Workflow: %s\n", o.Title)
+	if o.Description != "" {
+		for _, line := range strings.Split(strings.TrimSpace(o.Description), "\n") {
+			fmt.Fprintf(b, "  %s\n", line)
+		}
+	}
+
+	fmt.Fprintf(b, "\nInputs (%d):\n", len(o.Inputs))
+	writeVariables(b, o.Inputs, true)
+
+	fmt.Fprintln(b, "\nPrep steps:")
+	prep := o.Prep()
+	if len(prep) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for i, step := range prep {
+		fmt.Fprintf(b, "  %d. %s [%s]\n", i+1, step.Title, step.Type)
+	}
+
+	requests := o.Requests()
+	if len(requests) > 0 {
+		fmt.Fprintln(b, "\nRequest:")
+		for _, step := range requests {
+			fmt.Fprintf(b, "  %s %s", step.Request.Method, step.Request.Endpoint)
+			if step.Skipped {
+				b.WriteString(" (skipped)")
+			}
+			b.WriteString("\n")
+			if body := strings.TrimSpace(step.Request.Body); body != "" {
+				b.WriteString("  Body:\n")
+				for _, line := range strings.Split(body, "\n") {
+					fmt.Fprintf(b, "    %s\n", strings.TrimRight(line, " \t"))
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(b, "\nFlow:")
+	writeSteps(b, o.Steps, 1)
+
+	fmt.Fprintf(b, "\nOutputs (%d):\n", len(o.Outputs))
+	writeVariables(b, o.Outputs, false)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeVariables(b *strings.Builder, variables []Variable, inputs bool) {
+	if len(variables) == 0 {
+		b.WriteString("  (none)\n")
+		return
+	}
+	nameWidth, typeWidth := 0, 0
+	for _, v := range variables {
+		nameWidth = max(nameWidth, len(v.Name))
+		typeWidth = max(typeWidth, len(v.Type))
+	}
+	for _, v := range variables {
+		line := fmt.Sprintf("  %-*s  %-*s", nameWidth, v.Name, typeWidth, v.Type)
+		if inputs {
+			switch {
+			case v.Required:
+				line += "  required"
+			case v.Hidden:
+				line += "  hidden  "
+			default:
+				line += "  optional"
+			}
+		}
+		if v.Description != "" {
+			line += "  " + firstLine(v.Description)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+}
+
+func writeSteps(b *strings.Builder, steps []Step, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, step := range steps {
+		switch {
+		case step.Type == "logic.condition_block":
+			fmt.Fprintf(b, "%swhen %s: %s\n", indent, step.Title, step.Condition)
+		case step.Condition != "":
+			fmt.Fprintf(b, "%s- %s [%s] while %s\n", indent, step.Title, step.Type, step.Condition)
+		case step.Request != nil:
+			fmt.Fprintf(b, "%s- %s [%s] %s %s\n", indent, step.Title, step.Type, step.Request.Method, step.Request.Endpoint)
+		default:
+			fmt.Fprintf(b, "%s- %s [%s]\n", indent, step.Title, step.Type)
+		}
+		writeSteps(b, step.Steps, depth+1)
+	}
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
@@ -0,0 +1,109 @@
+package explain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MermaidExtension is the file extension of flowcharts written next to workflows.
+const MermaidExtension = ".mmd"
+
+// edge is a pending connection from a node to whatever step runs next.
+type edge struct {
+	from  string
+	label string
+}
+
+type mermaid struct {
+	b     strings.Builder
+	nodes int
+}
+
+// Mermaid renders the outline's flow as a Mermaid flowchart: API requests and
+// other actions as boxes, if/else steps as decisions with one labelled edge per
+// condition branch plus one for no match, loops as hexagons with a back edge,
+// and completions as terminal nodes.
+func (o *Outline) Mermaid() string {
+	m := &mermaid{}
+	m.b.WriteString("flowchart TD\n")
+	fmt.Fprintf(&m.b, "  start([%s])\n", quote(o.Title))
+	m.connect(m.steps(o.Steps, []edge{{from: "start"}}), "finish", "([End])")
+	return m.b.String()
+}
+
+// connect declares node id with its shape and links every pending edge to it.
+func (m *mermaid) connect(pending []edge, id, node string) {
+	if len(pending) == 0 {
+		return
+	}
+	fmt.Fprintf(&m.b, "  %s%s\n", id, node)
+	for _, e := range pending {
+		m.link(e, id)
+	}
+}
+
+func (m *mermaid) link(e edge, to string) {
+	if e.label == "" {
+		fmt.Fprintf(&m.b, "  %s --> %s\n", e.from, to)
+		return
+	}
+	fmt.Fprintf(&m.b, "  %s -->|%s| %s\n", e.from, quote(e.label), to)
+}
+
+// steps lays out steps in sequence starting from pending and returns the edges
+// leaving the last one; steps that end the workflow leave none.
+func (m *mermaid) steps(steps []Step, pending []edge) []edge {
+	for _, step := range steps {
+		if len(pending) == 0 {
+			break
+		}
+		m.nodes++
+		id := fmt.Sprintf("s%d", m.nodes)
+		label := step.Title
+		if step.Request != nil {
+			label += "<br/>" + step.Request.Method + " " + step.Request.Endpoint
+		}
+		if step.Skipped {
+			label += "<br/>(skipped)"
+		}
+
+		switch {
+		case step.Type == "logic.completed":
+			m.connect(pending, id, "(["+quote(label)+"])")
+			pending = nil
+		case step.Type == "logic.if_else":
+			m.connect(pending, id, "{"+quote(label)+"}")
+			// When no branch matches, the workflow carries on after the if/else.
+			pending = []edge{{from: id, label: "no match"}}
+			for _, block := range step.Steps {
+				branch := block.Title
+				if block.Condition != "" {
+					branch += ": " + block.Condition
+				}
+				pending = append(pending, m.steps(block.Steps, []edge{{from: id, label: branch}})...)
+			}
+		case step.Condition != "":
+			m.connect(pending, id, "{{"+quote(label+"<br/>while "+step.Condition)+"}}")
+			for _, e := range m.steps(step.Steps, []edge{{from: id, label: "loop"}}) {
+				m.link(e, id)
+			}
+			pending = []edge{{from: id, label: "done"}}
+		default:
+			m.connect(pending, id, "["+quote(label)+"]")
+			pending = m.steps(step.Steps, []edge{{from: id}})
+		}
+	}
+	return pending
+}
+
+// quote wraps a label in double quotes, escaping characters Mermaid would
+// otherwise parse; <br/> line breaks are kept.
+func quote(label string) string {
+	lines := strings.Split(strings.ReplaceAll(label, "\n", "<br/>"), "<br/>")
+	for i, line := range lines {
+		lines[i] = labelEscaper.Replace(line)
+	}
+	return `"` + strings.Join(lines, "<br/>") + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
@@ -0,0 +1,147 @@
+// Package fsutil wraps the file operations the generator uses so they behave the
+// same on Windows runners: long paths get the \\?\ prefix, generated file names
+// avoid characters and device names Windows rejects, and resource files are
+// found next to the executable instead of only in the working directory.
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadFile reads path, which may be longer than the Windows MAX_PATH.
+func ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(LongPath(path))
+}
+
+// FileMode and DirMode are the permissions of the files and directories the
+// generator creates; the -fileMode and -dirMode flags set them.
+var (
+	FileMode os.FileMode = 0644
+	DirMode  os.FileMode = 0755
+)
+
+// WriteFile writes data to path atomically (see CreateAtomic) with FileMode;
+// path may be longer than the Windows MAX_PATH.
+func WriteFile(path string, data []byte) error {
+	file, err := CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
+}
+
+// MkdirAll creates dir and its parents with DirMode; dir may be longer than the
+// Windows MAX_PATH.
+func MkdirAll(dir string) error {
+	return os.MkdirAll(LongPath(dir), DirMode)
+}
+
+// AtomicFile is written under a temporary name next to its target and renamed
+// over it by Commit, so an interrupted run leaves either the previous file or
+// the complete new one, never a truncated one.
+type AtomicFile struct {
+	*os.File
+	path string
+}
+
+// CreateAtomic starts writing path, which may be longer than the Windows
+// MAX_PATH. The temporary file is a hidden ".<name>.*.tmp" in the same
+// directory, so the rename stays on one file system and a leftover is never
+// taken for a workflow.
+func CreateAtomic(path string) (*AtomicFile, error) {
+	dir, name := filepath.Split(path)
+	file, err := os.CreateTemp(LongPath(filepath.Clean(dir+".")), "."+name+".*.tmp")
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return nil, &os.PathError{Op: "create", Path: path, Err: err}
+	}
+	return &AtomicFile{File: file, path: path}, nil
+}
+
+// Commit closes the file, gives it FileMode and renames it over the target.
+func (f *AtomicFile) Commit() error {
+	err := f.Close()
+	if err == nil {
+		err = os.Chmod(f.Name(), FileMode)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), LongPath(f.path))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Abort closes and removes the temporary file, leaving the target untouched.
+func (f *AtomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// Open opens path for reading, which may be longer than the Windows MAX_PATH.
+func Open(path string) (*os.File, error) {
+	return os.Open(LongPath(path))
+}
+
+// ResourcePath locates a resource file shipped with the tool: next to the
+// executable (following symlinks) first, then relative to the working directory
+// for go run and development checkouts. It returns name unchanged when neither
+// exists so the caller's error names the file.
+func ResourcePath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		candidate := filepath.Join(filepath.Dir(exe), name)
+		if _, err := os.Stat(LongPath(candidate)); err == nil {
+			return candidate
+		}
+	}
+	return name
+}
+
+// reservedNames are device names Windows refuses as file names, with or without
+// an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SafeFileName turns an operationId or recipe name into a file name that is
+// valid on every platform: path separators and the characters Windows rejects
+// become "_", trailing dots and spaces are dropped and device names get a "_"
+// prefix. Names that are already safe are returned unchanged.
+func SafeFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	safe = strings.TrimRight(safe, ". ")
+	if safe == "" {
+		return "_"
+	}
+	base, _, _ := strings.Cut(safe, ".")
+	if reservedNames[strings.ToUpper(base)] {
+		safe = "_" + safe
+	}
+	return safe
+}
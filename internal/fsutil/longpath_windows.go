@@ -0,0 +1,29 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is the length from which Win32 calls need the \\?\ prefix; directory
+// creation fails at 248 characters, files at 260.
+const maxPath = 248
+
+// LongPath returns path in the \\?\ extended-length form when it is too long for
+// the Win32 APIs. Such paths must be absolute and are not normalized by Windows,
+// so the path is made absolute and cleaned first.
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < maxPath {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
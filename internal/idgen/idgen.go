@@ -0,0 +1,140 @@
+// Package idgen generates the IDs that replace the $<token>KSUID placeholders
+// of rendered workflows and name the triggers, categories and composite steps
+// the generator creates. KSUIDs are the default; some platform versions and
+// downstream tools prefer UUIDs or ULIDs. The placeholders keep their KSUID
+// suffix whatever the format.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/ksuid"
+)
+
+// Formats.
+const (
+	KSUID = "ksuid"
+	UUID  = "uuid"
+	ULID  = "ulid"
+)
+
+// Formats lists the supported formats, default first.
+var Formats = []string{KSUID, UUID, ULID}
+
+// Generator returns IDs of one format.
+type Generator interface {
+	// New returns a fresh ID.
+	New() string
+	// Derive returns the ID for a SHA-256 digest; the same digest always
+	// gives the same ID, so objects named after it stay stable across runs.
+	Derive(sum [32]byte) string
+}
+
+// Default generates KSUIDs without a prefix.
+var Default Generator = ksuidGenerator{}
+
+// New returns the generator of format whose IDs start with prefix. The prefix
+// is limited to letters and digits so IDs stay valid in unique names and
+// references.
+func New(format, prefix string) (Generator, error) {
+	var generator Generator
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", KSUID:
+		generator = ksuidGenerator{}
+	case UUID:
+		generator = uuidGenerator{}
+	case ULID:
+		generator = ulidGenerator{}
+	default:
+		return nil, fmt.Errorf("unsupported ID format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+	for _, r := range prefix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return nil, fmt.Errorf("ID prefix %q may only contain letters and digits", prefix)
+		}
+	}
+	if prefix == "" {
+		return generator, nil
+	}
+	return prefixed{Generator: generator, prefix: prefix}, nil
+}
+
+type ksuidGenerator struct{}
+
+func (ksuidGenerator) New() string {
+	return ksuid.New().String()
+}
+
+func (ksuidGenerator) Derive(sum [32]byte) string {
+	// FromBytes only fails on a length other than 20.
+	id, _ := ksuid.FromBytes(sum[:20])
+	return id.String()
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) New() string {
+	return uuid.NewString()
+}
+
+func (uuidGenerator) Derive(sum [32]byte) string {
+	return uuid.NewSHA1(uuid.Nil, sum[:]).String()
+}
+
+// ulidGenerator returns ULIDs: a 48-bit millisecond timestamp and 80 random
+// bits in Crockford base32, so IDs sort by creation time.
+type ulidGenerator struct{}
+
+func (ulidGenerator) New() string {
+	var id [16]byte
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], timestamp[2:])
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(fmt.Sprintf("idgen: reading random bytes: %v", err))
+	}
+	return encodeULID(id)
+}
+
+func (ulidGenerator) Derive(sum [32]byte) string {
+	var id [16]byte
+	copy(id[:], sum[:16])
+	return encodeULID(id)
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID writes the 128 bits of id as 26 base32 digits; the first digit
+// carries two padding bits.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	for i := range out {
+		digit := 0
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			digit <<= 1
+			if bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				digit |= 1
+			}
+		}
+		out[i] = crockford[digit]
+	}
+	return string(out[:])
+}
+
+type prefixed struct {
+	Generator
+	prefix string
+}
+
+func (p prefixed) New() string {
+	return p.prefix + p.Generator.New()
+}
+
+func (p prefixed) Derive(sum [32]byte) string {
+	return p.prefix + p.Generator.Derive(sum)
+}
@@ -0,0 +1,99 @@
+package idgen
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		format string
+		prefix string
+		id     *regexp.Regexp
+	}{
+		{format: "", id: regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+		{format: "KSUID", id: regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+		{format: " uuid ", id: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[45][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{format: "ulid", id: regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+		{format: "ulid", prefix: "ao1", id: regexp.MustCompile(`^ao1[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+tt.prefix, func(t *testing.T) {
+			generator, err := New(tt.format, tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, second := generator.New(), generator.New()
+			if !tt.id.MatchString(first) {
+				t.Errorf("New() = %q, want it to match %s", first, tt.id)
+			}
+			if first == second {
+				t.Errorf("New() returned %q twice", first)
+			}
+			if derived := generator.Derive(sha256.Sum256([]byte("x"))); !tt.id.MatchString(derived) {
+				t.Errorf("Derive() = %q, want it to match %s", derived, tt.id)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidSettings(t *testing.T) {
+	for _, tt := range []struct{ format, prefix, err string }{
+		{format: "snowflake", err: `unsupported ID format "snowflake" (expected ksuid, uuid, ulid)`},
+		{format: "ksuid", prefix: "ao-", err: `ID prefix "ao-" may only contain letters and digits`},
+		{format: "uuid", prefix: "a b", err: `ID prefix "a b" may only contain letters and digits`},
+	} {
+		if _, err := New(tt.format, tt.prefix); err == nil || err.Error() != tt.err {
+			t.Errorf("New(%q, %q) error = %v, want %s", tt.format, tt.prefix, err, tt.err)
+		}
+	}
+}
+
+func TestDeriveIsStable(t *testing.T) {
+	sum := sha256.Sum256([]byte("dcim_sites_list"))
+	other := sha256.Sum256([]byte("dcim_sites_create"))
+	for _, format := range Formats {
+		generator, err := New(format, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if generator.Derive(sum) != generator.Derive(sum) {
+			t.Errorf("%s: Derive returns different IDs for one digest", format)
+		}
+		if generator.Derive(sum) == generator.Derive(other) {
+			t.Errorf("%s: Derive returns one ID for different digests", format)
+		}
+	}
+	derived, err := uuid.Parse(uuidGenerator{}.Derive(sum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived.Version() != 5 {
+		t.Errorf("derived UUID has version %d, want 5", derived.Version())
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var ones [16]byte
+	for i := range ones {
+		ones[i] = 0xFF
+	}
+	tests := []struct {
+		id   [16]byte
+		want string
+	}{
+		{want: strings.Repeat("0", 26)},
+		{id: ones, want: "7" + strings.Repeat("Z", 25)},
+		// The example ULID of the specification.
+		{id: [16]byte{0x01, 0x56, 0x3E, 0x3A, 0xB5, 0xD3, 0xD6, 0x76, 0x4C, 0x61, 0xEF, 0xB9, 0x93, 0x02, 0xBD, 0x5B}, want: "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+	}
+	for _, tt := range tests {
+		if got := encodeULID(tt.id); got != tt.want {
+			t.Errorf("encodeULID(%X) = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package jsonrpc reads and writes the JSON-RPC 2.0 messages of -rpc. A message
+// is either a single JSON line or framed LSP-style behind a Content-Length
+// header; replies use the framing of their request.
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+)
+
+// Request is a call or, without ID, a notification.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response carries either Result or Error.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the error member of a failed call.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReadMessage returns the next message body and whether it was Content-Length
+// framed. Blank lines yield a nil body.
+func ReadMessage(reader *bufio.Reader) ([]byte, bool, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+		return nil, false, err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, false, nil
+	}
+	if !strings.HasPrefix(strings.ToLower(line), "content-length:") {
+		return []byte(line), false, nil
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(line[len("content-length:"):]))
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid Content-Length header %q", line)
+	}
+	// Skip any further headers up to the blank separator line.
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, true, err
+		}
+		if strings.TrimSpace(header) == "" {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, true, err
+	}
+	return body, true, nil
+}
+
+// WriteMessage writes resp to out, Content-Length framed if framed.
+func WriteMessage(out io.Writer, resp Response, framed bool) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if framed {
+		_, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	} else {
+		_, err = fmt.Fprintf(out, "%s\n", data)
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+// Package lockfile records the content the generator last wrote for each
+// workflow file of an output directory. It is the common base of a three-way
+// comparison: the lockfile says what was generated, the file on disk what the
+// user has since made of it, and a new render what the generator would write now.
+package lockfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+)
+
+// FileName is the lockfile written into the output directory.
+const FileName = "generated.lock.json"
+
+// Entry is the last generated content of one workflow file. Spec is the
+// fingerprint of the spec operation an atomic was rendered from, if any.
+type Entry struct {
+	SHA256  string          `json:"sha256"`
+	Spec    string          `json:"spec,omitempty"`
+	Content json.RawMessage `json:"content"`
+}
+
+// Lockfile maps workflow file names to their last generated content.
+type Lockfile struct {
+	Workflows map[string]Entry `json:"workflows"`
+}
+
+// Load reads the lockfile of dir; a missing lockfile is empty.
+func Load(dir string) (*Lockfile, error) {
+	l := &Lockfile{Workflows: make(map[string]Entry)}
+	data, err := fsutil.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("%s: %w", FileName, err)
+	}
+	if l.Workflows == nil {
+		l.Workflows = make(map[string]Entry)
+	}
+	return l, nil
+}
+
+// Base returns the last generated content of file.
+func (l *Lockfile) Base(file string) ([]byte, bool) {
+	entry, ok := l.Workflows[file]
+	return entry.Content, ok
+}
+
+// Set records content as the generated content of file.
+func (l *Lockfile) Set(file string, content []byte) error {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, content); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	sum := sha256.Sum256(content)
+	l.Workflows[file] = Entry{SHA256: hex.EncodeToString(sum[:]), Content: compacted.Bytes()}
+	return nil
+}
+
+// Spec returns the spec fingerprint recorded for file.
+func (l *Lockfile) Spec(file string) string {
+	return l.Workflows[file].Spec
+}
+
+// SetSpec records the spec fingerprint file was rendered from; Set clears it.
+func (l *Lockfile) SetSpec(file, fingerprint string) {
+	entry, ok := l.Workflows[file]
+	if !ok {
+		return
+	}
+	entry.Spec = fingerprint
+	l.Workflows[file] = entry
+}
+
+// Unchanged reports whether content is byte for byte what was generated for file.
+func (l *Lockfile) Unchanged(file string, content []byte) bool {
+	entry, ok := l.Workflows[file]
+	sum := sha256.Sum256(content)
+	return ok && entry.SHA256 == hex.EncodeToString(sum[:])
+}
+
+// Save writes the lockfile into dir, one workflow per line in file name order so
+// it diffs reasonably under version control.
+func (l *Lockfile) Save(dir string) error {
+	names := make([]string, 0, len(l.Workflows))
+	for name := range l.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString("{\n  \"workflows\": {")
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(name)
+		entry, err := json.Marshal(l.Workflows[name])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "\n    %s: %s", key, entry)
+	}
+	buf.WriteString("\n  }\n}\n")
+	return fsutil.WriteFile(filepath.Join(dir, FileName), buf.Bytes())
+}
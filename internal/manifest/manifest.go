@@ -0,0 +1,265 @@
+// Package manifest builds the import-order manifest written next to generated
+// workflows, so importers apply categories before atomics and atomics before the
+// composite workflows that call them.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+)
+
+// Kinds of importable objects, in import order.
+const (
+	KindCategory  = "category"
+	KindAtomic    = "atomic"
+	KindComposite = "composite"
+	KindTrigger   = "trigger"
+)
+
+var kindRank = map[string]int{
+	KindCategory:  0,
+	KindAtomic:    1,
+	KindComposite: 2,
+	KindTrigger:   3,
+}
+
+// FileName is the manifest file written into the output directory.
+const FileName = "import-manifest.json"
+
+// Entry is one object to import.
+type Entry struct {
+	Order      int      `json:"order"`
+	Kind       string   `json:"kind"`
+	UniqueName string   `json:"unique_name"`
+	Title      string   `json:"title"`
+	File       string   `json:"file,omitempty"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+}
+
+// Manifest lists objects in the order they must be imported. External lists
+// the unique names entries depend on that are not part of the manifest and must
+// already exist on the platform; bundles record it so importers can check first.
+type Manifest struct {
+	Entries  []Entry  `json:"entries"`
+	External []string `json:"external,omitempty"`
+}
+
+// Builder collects rendered workflows and produces an ordered Manifest.
+type Builder struct {
+	entries []Entry
+	seen    map[string]bool
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{seen: make(map[string]bool)}
+}
+
+// workflowExport is the subset of a workflow export the manifest needs.
+type workflowExport struct {
+	Workflow struct {
+		UniqueName string `json:"unique_name"`
+		Title      string `json:"title"`
+		Properties struct {
+			Atomic struct {
+				IsAtomic bool `json:"is_atomic"`
+			} `json:"atomic"`
+		} `json:"properties"`
+		Categories []string `json:"categories"`
+	} `json:"workflow"`
+	Categories      map[string]struct{ Title string } `json:"categories"`
+	AtomicWorkflows []string                          `json:"atomic_workflows"`
+}
+
+// AddWorkflow records a rendered workflow written to file. Categories embedded in
+// the export become their own entries; called atomic workflows become dependencies.
+func (b *Builder) AddWorkflow(file string, content []byte) error {
+	var export workflowExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	categoryIDs := make([]string, 0, len(export.Categories))
+	for id := range export.Categories {
+		categoryIDs = append(categoryIDs, id)
+	}
+	sort.Strings(categoryIDs)
+	for _, id := range categoryIDs {
+		b.add(Entry{Kind: KindCategory, UniqueName: id, Title: export.Categories[id].Title})
+	}
+	kind := KindAtomic
+	if !export.Workflow.Properties.Atomic.IsAtomic || len(export.AtomicWorkflows) > 0 {
+		kind = KindComposite
+	}
+	dependsOn := append([]string{}, export.Workflow.Categories...)
+	dependsOn = append(dependsOn, export.AtomicWorkflows...)
+	b.add(Entry{
+		Kind:       kind,
+		UniqueName: export.Workflow.UniqueName,
+		Title:      export.Workflow.Title,
+		File:       file,
+		DependsOn:  dependsOn,
+	})
+	return nil
+}
+
+// AddTrigger records a trigger definition written to file that starts the
+// workflow with unique name workflow.
+func (b *Builder) AddTrigger(file, uniqueName, title, workflow string) {
+	b.add(Entry{Kind: KindTrigger, UniqueName: uniqueName, Title: title, File: file, DependsOn: []string{workflow}})
+}
+
+func (b *Builder) add(entry Entry) {
+	if entry.UniqueName == "" || b.seen[entry.UniqueName] {
+		return
+	}
+	b.seen[entry.UniqueName] = true
+	b.entries = append(b.entries, entry)
+}
+
+// Build orders entries by kind (categories, atomics, composites, triggers) and,
+// within a kind, after the entries they depend on (a composite calling another
+// composite); otherwise entries keep the order in which they were added.
+func (b *Builder) Build() Manifest {
+	entries := append([]Entry{}, b.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return kindRank[entries[i].Kind] < kindRank[entries[j].Kind]
+	})
+	entries = dependencyOrder(entries)
+	for i := range entries {
+		entries[i].Order = i + 1
+	}
+	return Manifest{Entries: entries}
+}
+
+// dependencyOrder moves each entry after the entries of its own kind it depends
+// on and keeps the order of entries otherwise. No order satisfies a dependency
+// cycle; Check reports it.
+func dependencyOrder(entries []Entry) []Entry {
+	index := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		index[entry.UniqueName] = i
+	}
+	ordered := make([]Entry, 0, len(entries))
+	visited := make([]bool, len(entries))
+	var place func(i int)
+	place = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, name := range entries[i].DependsOn {
+			if j, ok := index[name]; ok && entries[j].Kind == entries[i].Kind {
+				place(j)
+			}
+		}
+		ordered = append(ordered, entries[i])
+	}
+	for i := range entries {
+		place(i)
+	}
+	return ordered
+}
+
+// Load reads the manifest written into dir.
+func Load(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := fsutil.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", FileName, err)
+	}
+	sort.SliceStable(m.Entries, func(i, j int) bool { return m.Entries[i].Order < m.Entries[j].Order })
+	return m, nil
+}
+
+// Files returns the workflow files in import order. Categories have no file of
+// their own; they are imported with the first workflow that embeds them.
+func (m Manifest) Files() []string {
+	var files []string
+	for _, entry := range m.Entries {
+		if entry.File != "" {
+			files = append(files, entry.File)
+		}
+	}
+	return files
+}
+
+// WorkflowFiles returns the workflow files in import order, without triggers.
+func (m Manifest) WorkflowFiles() []string {
+	var files []string
+	for _, entry := range m.Entries {
+		if entry.File != "" && entry.Kind != KindTrigger {
+			files = append(files, entry.File)
+		}
+	}
+	return files
+}
+
+// Check verifies the run order against the workflow files, read with read. It
+// fails when a workflow references a category or atomic its entry does not list
+// (a stale manifest) or when a dependency is imported after the entry needing
+// it, and returns the dependencies no entry provides, sorted.
+func (m Manifest) Check(read func(file string) ([]byte, error)) ([]string, error) {
+	provided := make(map[string]int, len(m.Entries))
+	for i, entry := range m.Entries {
+		if _, ok := provided[entry.UniqueName]; !ok {
+			provided[entry.UniqueName] = i
+		}
+	}
+	external := map[string]bool{}
+	for i, entry := range m.Entries {
+		if entry.File != "" && (entry.Kind == KindAtomic || entry.Kind == KindComposite) {
+			content, err := read(entry.File)
+			if err != nil {
+				return nil, err
+			}
+			var export workflowExport
+			if err := json.Unmarshal(content, &export); err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.File, err)
+			}
+			listed := make(map[string]bool, len(entry.DependsOn))
+			for _, name := range entry.DependsOn {
+				listed[name] = true
+			}
+			var unlisted []string
+			for _, name := range append(append([]string{}, export.Workflow.Categories...), export.AtomicWorkflows...) {
+				if !listed[name] {
+					unlisted = append(unlisted, name)
+				}
+			}
+			if len(unlisted) > 0 {
+				return nil, fmt.Errorf("%s references %s missing from its depends_on; regenerate %s", entry.File, strings.Join(unlisted, ", "), FileName)
+			}
+		}
+		for _, name := range entry.DependsOn {
+			at, ok := provided[name]
+			switch {
+			case !ok:
+				external[name] = true
+			case at > i:
+				return nil, fmt.Errorf("%s depends on %s, which is imported after it", entryLabel(entry), name)
+			}
+		}
+	}
+	names := make([]string, 0, len(external))
+	for name := range external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// entryLabel names entry in messages by its file or, for categories, its unique name.
+func entryLabel(entry Entry) string {
+	if entry.File != "" {
+		return entry.File
+	}
+	return entry.UniqueName
+}
@@ -0,0 +1,161 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// export returns a workflow export with the given category and called workflows.
+func export(uniqueName string, atomic bool, categories, calls []string) []byte {
+	quote := func(names []string) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	categoryObjects := make([]string, len(categories))
+	for i, category := range categories {
+		categoryObjects[i] = fmt.Sprintf("%q: {\"title\": %q}", category, category)
+	}
+	return []byte(fmt.Sprintf(`{"workflow": {"unique_name": %q, "title": %q, "properties": {"atomic": {"is_atomic": %t}}, "categories": %s},
+		"categories": {%s}, "atomic_workflows": %s}`, uniqueName, uniqueName, atomic, quote(categories), strings.Join(categoryObjects, ", "), quote(calls)))
+}
+
+func TestBuildOrdersCompositesByDependency(t *testing.T) {
+	type workflow struct {
+		name   string
+		atomic bool
+		calls  []string
+	}
+	tests := []struct {
+		name      string
+		workflows []workflow
+		want      []string
+	}{
+		{
+			name: "kinds in import order",
+			workflows: []workflow{
+				{name: "composite", calls: []string{"atomic"}},
+				{name: "atomic", atomic: true},
+			},
+			want: []string{"category", "atomic", "composite"},
+		},
+		{
+			name: "composite calling a composite added later",
+			workflows: []workflow{
+				{name: "onboard", calls: []string{"claim", "atomic"}},
+				{name: "atomic", atomic: true},
+				{name: "claim", calls: []string{"atomic"}},
+			},
+			want: []string{"category", "atomic", "claim", "onboard"},
+		},
+		{
+			name: "chain added in reverse",
+			workflows: []workflow{
+				{name: "c3", calls: []string{"c2"}},
+				{name: "c2", calls: []string{"c1"}},
+				{name: "c1", calls: []string{"atomic"}},
+				{name: "atomic", atomic: true},
+				{name: "independent", calls: []string{"atomic"}},
+			},
+			want: []string{"category", "atomic", "c1", "c2", "c3", "independent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder()
+			files := make(map[string][]byte)
+			for _, w := range tt.workflows {
+				content := export(w.name, w.atomic, []string{"category"}, w.calls)
+				files[w.name+".json"] = content
+				if err := b.AddWorkflow(w.name+".json", content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m := b.Build()
+			var got []string
+			for i, entry := range m.Entries {
+				if entry.Order != i+1 {
+					t.Errorf("%s has order %d at position %d", entry.UniqueName, entry.Order, i+1)
+				}
+				got = append(got, entry.UniqueName)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+			if _, err := m.Check(func(file string) ([]byte, error) { return files[file], nil }); err != nil {
+				t.Errorf("Check: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	files := map[string][]byte{
+		"atomic.json":    export("atomic", true, []string{"category"}, nil),
+		"composite.json": export("composite", false, []string{"category"}, []string{"atomic", "platform_atomic"}),
+	}
+	category := Entry{Kind: KindCategory, UniqueName: "category"}
+	atomic := Entry{Kind: KindAtomic, UniqueName: "atomic", File: "atomic.json", DependsOn: []string{"category"}}
+	composite := Entry{Kind: KindComposite, UniqueName: "composite", File: "composite.json", DependsOn: []string{"category", "atomic", "platform_atomic"}}
+	tests := []struct {
+		name         string
+		entries      []Entry
+		wantExternal []string
+		wantErr      string
+	}{
+		{
+			name:         "in order",
+			entries:      []Entry{category, atomic, composite},
+			wantExternal: []string{"platform_atomic"},
+		},
+		{
+			name:    "dependency imported later",
+			entries: []Entry{category, composite, atomic},
+			wantErr: "composite.json depends on atomic, which is imported after it",
+		},
+		{
+			name:    "category imported later",
+			entries: []Entry{atomic, category},
+			wantErr: "atomic.json depends on category, which is imported after it",
+		},
+		{
+			name:    "stale depends_on",
+			entries: []Entry{category, atomic, {Kind: KindComposite, UniqueName: "composite", File: "composite.json", DependsOn: []string{"category", "atomic"}}},
+			wantErr: "composite.json references platform_atomic missing from its depends_on; regenerate " + FileName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			external, err := Manifest{Entries: tt.entries}.Check(func(file string) ([]byte, error) {
+				content, ok := files[file]
+				if !ok {
+					return nil, fmt.Errorf("%s not found", file)
+				}
+				return content, nil
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(external, ",") != strings.Join(tt.wantExternal, ",") {
+				t.Errorf("external = %v, want %v", external, tt.wantExternal)
+			}
+		})
+	}
+}
+
+func TestCheckReportsUnreadableFiles(t *testing.T) {
+	m := Manifest{Entries: []Entry{{Kind: KindAtomic, UniqueName: "atomic", File: "atomic.json"}}}
+	_, err := m.Check(func(file string) ([]byte, error) { return nil, fmt.Errorf("%s not found", file) })
+	if err == nil || err.Error() != "atomic.json not found" {
+		t.Errorf("err = %v, want the read error", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package selector implements the line-based picker behind -interactive: fuzzy
+// search over the spec's operations, checkbox toggles and a final selection.
+package selector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrCancelled is returned when the user quits without confirming a selection.
+var ErrCancelled = errors.New("selection cancelled")
+
+// maxShown caps how many matches are listed at once.
+const maxShown = 30
+
+// Item is one selectable entry; ID is returned, Label is searched and shown.
+type Item struct {
+	ID    string
+	Label string
+}
+
+// FuzzyMatch reports whether every whitespace-separated term of query appears in
+// text as a case-insensitive subsequence ("dev lst" matches "dcim_devices_list").
+func FuzzyMatch(query, text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !subsequence(term, text) {
+			return false
+		}
+	}
+	return true
+}
+
+func subsequence(term, text string) bool {
+	i := 0
+	for _, r := range text {
+		if i < len(term) && rune(term[i]) == r {
+			i++
+		}
+	}
+	return i == len(term)
+}
+
+// Prompt reads answers line by line; Select and Confirm share its input buffer.
+type Prompt struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// New returns a prompt reading from in and writing to out.
+func New(in io.Reader, out io.Writer) *Prompt {
+	return &Prompt{scanner: bufio.NewScanner(in), out: out}
+}
+
+func (p *Prompt) readLine() (string, error) {
+	if !p.scanner.Scan() {
+		if err := p.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrCancelled
+	}
+	return strings.TrimSpace(p.scanner.Text()), nil
+}
+
+// Confirm asks a yes/no question; anything but y/yes is a no.
+func (p *Prompt) Confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N] ", question)
+	answer, err := p.readLine()
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// Select lets the user search items and toggle checkboxes until they confirm
+// with "d", and returns the selected IDs in item order.
+func (p *Prompt) Select(items []Item) ([]string, error) {
+	selected := make(map[string]bool)
+	query := ""
+	shown := filter(items, query)
+	out := p.out
+
+	fmt.Fprintln(out, help)
+	render(out, shown, selected, query)
+	for {
+		fmt.Fprint(out, "> ")
+		line, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "/"):
+			query = strings.TrimSpace(strings.TrimPrefix(line, "/"))
+			shown = filter(items, query)
+		case line == "a":
+			for _, item := range shown {
+				selected[item.ID] = true
+			}
+		case line == "c":
+			selected = make(map[string]bool)
+		case line == "s":
+			query = ""
+			shown = nil
+			for _, item := range items {
+				if selected[item.ID] {
+					shown = append(shown, item)
+				}
+			}
+		case line == "h":
+			fmt.Fprintln(out, help)
+			continue
+		case line == "q":
+			return nil, ErrCancelled
+		case line == "d":
+			var ids []string
+			for _, item := range items {
+				if selected[item.ID] {
+					ids = append(ids, item.ID)
+				}
+			}
+			if len(ids) == 0 {
+				fmt.Fprintln(out, "Nothing selected.")
+				continue
+			}
+			return ids, nil
+		default:
+			indexes, err := parseIndexes(line, min(len(shown), maxShown))
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			for _, index := range indexes {
+				id := shown[index-1].ID
+				if selected[id] {
+					delete(selected, id)
+				} else {
+					selected[id] = true
+				}
+			}
+		}
+		render(out, shown, selected, query)
+	}
+}
+
+const help = `Search with /<terms> (fuzzy), toggle with numbers or ranges (1 3 5-7),
+a = select all shown, c = clear, s = show selected, d = done, q = quit, h = help.`
+
+// filter returns the items matching query, those containing every term
+// verbatim first.
+func filter(items []Item, query string) []Item {
+	var exact, fuzzy []Item
+	for _, item := range items {
+		switch {
+		case ContainsTerms(query, item.Label):
+			exact = append(exact, item)
+		case FuzzyMatch(query, item.Label):
+			fuzzy = append(fuzzy, item)
+		}
+	}
+	return append(exact, fuzzy...)
+}
+
+// ContainsTerms reports whether text contains every whitespace-separated term of
+// query verbatim, ignoring case.
+func ContainsTerms(query, text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func render(out io.Writer, shown []Item, selected map[string]bool, query string) {
+	for i, item := range shown {
+		if i == maxShown {
+			fmt.Fprintf(out, "  ... %d more, refine the search\n", len(shown)-maxShown)
+			break
+		}
+		box := "[ ]"
+		if selected[item.ID] {
+			box = "[x]"
+		}
+		fmt.Fprintf(out, "%3d %s %s\n", i+1, box, item.Label)
+	}
+	if len(shown) == 0 {
+		fmt.Fprintf(out, "No operations match %q.\n", query)
+	}
+	fmt.Fprintf(out, "%d selected\n", len(selected))
+}
+
+// parseIndexes parses "1 3 5-7" into 1-based indexes no greater than limit.
+func parseIndexes(line string, limit int) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, field := range strings.Fields(strings.ReplaceAll(line, ",", " ")) {
+		start, end, isRange := strings.Cut(field, "-")
+		from, err := strconv.Atoi(start)
+		if err != nil {
+			return nil, fmt.Errorf("unknown command %q (h for help)", line)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(end); err != nil {
+				return nil, fmt.Errorf("invalid range %q", field)
+			}
+		}
+		if from < 1 || to > limit || from > to {
+			return nil, fmt.Errorf("%s is outside 1-%d", field, limit)
+		}
+		for i := from; i <= to; i++ {
+			seen[i] = true
+		}
+	}
+	indexes := make([]int, 0, len(seen))
+	for i := range seen {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
@@ -0,0 +1,166 @@
+// Package specdiff compares two versions of an OpenAPI spec by what generated
+// workflows are built from. Operations are matched by operationId and compared
+// through flat facts (parameters, request body fields, success response fields,
+// endpoint and description), so an upgrade can regenerate just the atomics
+// whose inputs, outputs or requests change.
+package specdiff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kinds of changes.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Operation is what a generated workflow depends on. Facts maps a subject such
+// as "parameter query limit" or "body name" to its shape, e.g. "integer,
+// required".
+type Operation struct {
+	Method string
+	Path   string
+	Facts  map[string]string
+}
+
+// Change is one added, removed or changed operation (Subject is the
+// operationId) or schema (Subject is the schema name). Details lists the facts
+// that changed.
+type Change struct {
+	Kind    string
+	Subject string
+	Method  string
+	Path    string
+	Details []string
+}
+
+// CompareOperations returns the operation changes from old to new, sorted by
+// operationId.
+func CompareOperations(old, new map[string]Operation) []Change {
+	var changes []Change
+	for _, id := range unionKeys(old, new) {
+		before, inOld := old[id]
+		after, inNew := new[id]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Subject: id, Method: after.Method, Path: after.Path})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Subject: id, Method: before.Method, Path: before.Path})
+		default:
+			if details := compareFacts(before.Facts, after.Facts); len(details) > 0 {
+				changes = append(changes, Change{Kind: Changed, Subject: id, Method: after.Method, Path: after.Path, Details: details})
+			}
+		}
+	}
+	return changes
+}
+
+// CompareSchemas returns the component schema changes from old to new; the
+// schemas are compared by their canonical JSON.
+func CompareSchemas(old, new map[string][]byte) []Change {
+	var changes []Change
+	for _, name := range unionKeys(old, new) {
+		before, inOld := old[name]
+		after, inNew := new[name]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Subject: name})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Subject: name})
+		case !bytes.Equal(before, after):
+			changes = append(changes, Change{Kind: Changed, Subject: name})
+		}
+	}
+	return changes
+}
+
+// Regenerate returns the operationIds to generate again: the added and
+// changed operations, sorted.
+func Regenerate(operations []Change) []string {
+	var ids []string
+	for _, change := range operations {
+		if change.Kind != Removed {
+			ids = append(ids, change.Subject)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Fingerprint is a digest of everything op's generated workflow is built from;
+// it changes exactly when CompareOperations would report op as changed.
+func Fingerprint(op Operation) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", op.Method, op.Path)
+	for _, subject := range unionKeys(op.Facts, nil) {
+		fmt.Fprintf(h, "%q: %q\n", subject, op.Facts[subject])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// WriteText prints the operation and schema changes followed by the
+// regeneration list, one operationId per line.
+func WriteText(w io.Writer, operations, schemas []Change) error {
+	var b strings.Builder
+	counts := make(map[string]int)
+	for _, change := range operations {
+		counts[change.Kind]++
+	}
+	fmt.Fprintf(&b, "Operations: %d added, %d removed, %d changed\n", counts[Added], counts[Removed], counts[Changed])
+	for _, change := range operations {
+		fmt.Fprintf(&b, "  %s %s (%s %s)\n", change.Kind, change.Subject, change.Method, change.Path)
+		for _, detail := range change.Details {
+			fmt.Fprintf(&b, "    %s\n", detail)
+		}
+	}
+	fmt.Fprintf(&b, "Schemas: %d changed\n", len(schemas))
+	for _, change := range schemas {
+		fmt.Fprintf(&b, "  %s %s\n", change.Kind, change.Subject)
+	}
+	regenerate := Regenerate(operations)
+	fmt.Fprintf(&b, "Regenerate: %d\n", len(regenerate))
+	for _, id := range regenerate {
+		fmt.Fprintf(&b, "  %s\n", id)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func compareFacts(before, after map[string]string) []string {
+	var details []string
+	for _, subject := range unionKeys(before, after) {
+		old, inOld := before[subject]
+		new, inNew := after[subject]
+		switch {
+		case !inOld:
+			details = append(details, fmt.Sprintf("%s: added (%s)", subject, new))
+		case !inNew:
+			details = append(details, fmt.Sprintf("%s: removed", subject))
+		case old != new:
+			details = append(details, fmt.Sprintf("%s: %s -> %s", subject, old, new))
+		}
+	}
+	return details
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package specdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+var (
+	oldOperations = map[string]Operation{
+		"dcim_sites_list": {Method: "GET", Path: "/api/dcim/sites/", Facts: map[string]string{
+			"parameter query limit": "integer",
+			"response results":      "array",
+		}},
+		"dcim_sites_create": {Method: "POST", Path: "/api/dcim/sites/", Facts: map[string]string{
+			"body name": "string, required",
+			"body slug": "string, required",
+		}},
+		"dcim_sites_destroy": {Method: "DELETE", Path: "/api/dcim/sites/{id}/", Facts: map[string]string{
+			"parameter path id": "integer, required",
+		}},
+	}
+	newOperations = map[string]Operation{
+		"dcim_sites_list": {Method: "GET", Path: "/api/dcim/sites/", Facts: map[string]string{
+			"parameter query limit": "integer",
+			"response results":      "array",
+		}},
+		"dcim_sites_create": {Method: "POST", Path: "/api/dcim/sites/", Facts: map[string]string{
+			"body name":   "string, required",
+			"body slug":   "string",
+			"body status": "string",
+		}},
+		"dcim_sites_retrieve": {Method: "GET", Path: "/api/dcim/sites/{id}/", Facts: map[string]string{
+			"parameter path id": "integer, required",
+		}},
+	}
+)
+
+func TestCompareOperations(t *testing.T) {
+	changes := CompareOperations(oldOperations, newOperations)
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Kind+" "+change.Subject+" "+change.Method+" "+strings.Join(change.Details, "; "))
+	}
+	want := []string{
+		"changed dcim_sites_create POST body slug: string, required -> string; body status: added (string)",
+		"removed dcim_sites_destroy DELETE ",
+		"added dcim_sites_retrieve GET ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := strings.Join(Regenerate(changes), " "); got != "dcim_sites_create dcim_sites_retrieve" {
+		t.Errorf("Regenerate = %s, want the added and changed operations", got)
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	changes := CompareSchemas(
+		map[string][]byte{"Site": []byte(`{"type":"object"}`), "Rack": []byte(`{}`)},
+		map[string][]byte{"Site": []byte(`{"type":"object","required":["name"]}`), "Region": []byte(`{}`), "Rack": []byte(`{}`)},
+	)
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Kind+" "+change.Subject)
+	}
+	if want := "added Region, changed Site"; strings.Join(got, ", ") != want {
+		t.Errorf("changes = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	list := oldOperations["dcim_sites_list"]
+	if Fingerprint(list) != Fingerprint(newOperations["dcim_sites_list"]) {
+		t.Error("unchanged operation has a different fingerprint")
+	}
+	if Fingerprint(oldOperations["dcim_sites_create"]) == Fingerprint(newOperations["dcim_sites_create"]) {
+		t.Error("changed operation keeps its fingerprint")
+	}
+	moved := Operation{Method: list.Method, Path: "/api/dcim/site-list/", Facts: list.Facts}
+	if Fingerprint(list) == Fingerprint(moved) {
+		t.Error("fingerprint ignores the path")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var b strings.Builder
+	operations := CompareOperations(oldOperations, newOperations)
+	schemas := []Change{{Kind: Changed, Subject: "Site"}}
+	if err := WriteText(&b, operations, schemas); err != nil {
+		t.Fatal(err)
+	}
+	want := `Operations: 1 added, 1 removed, 1 changed
+  changed dcim_sites_create (POST /api/dcim/sites/)
+    body slug: string, required -> string
+    body status: added (string)
+  removed dcim_sites_destroy (DELETE /api/dcim/sites/{id}/)
+  added dcim_sites_retrieve (GET /api/dcim/sites/{id}/)
+Schemas: 1 changed
+  changed Site
+Regenerate: 2
+  dcim_sites_create
+  dcim_sites_retrieve
+`
+	if b.String() != want {
+		t.Errorf("report =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,211 @@
+// Package trigger builds AO trigger definitions that start a generated workflow
+// on a schedule (cron) or on an event, so a config can describe the whole
+// automation (e.g. a nightly NetBox to Meraki reconciliation) and not just the
+// workflows it runs. The definitions are scaffolding: they are written disabled
+// unless the config enables them, to be reviewed before they fire.
+package trigger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+)
+
+// FileSuffix ends the name of every trigger file written next to the workflows.
+const FileSuffix = ".trigger.json"
+
+// Config is one entry of the config's triggers list. Workflow names the bound
+// workflow by its file name without .json: an operation ID, <operationId>_wait
+// or a composite name. Inputs are keyed by input variable name ("Input - Site"
+// or just "Site").
+type Config struct {
+	Name     string                 `json:"name" yaml:"name"`
+	Workflow string                 `json:"workflow" yaml:"workflow"`
+	Schedule string                 `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Timezone string                 `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Event    *Event                 `json:"event,omitempty" yaml:"event,omitempty"`
+	Inputs   map[string]interface{} `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Enabled  bool                   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// Event starts a workflow when the platform receives an event of Type (e.g.
+// webhook or email); Properties are passed through as the event's settings.
+type Event struct {
+	Type       string                 `json:"type" yaml:"type"`
+	Properties map[string]interface{} `json:"properties,omitempty" yaml:"properties,omitempty"`
+}
+
+// Validate checks that the trigger names its workflow and has exactly one of a
+// five-field cron schedule and an event.
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("trigger: name is required")
+	}
+	if strings.TrimSpace(c.Workflow) == "" {
+		return fmt.Errorf("trigger %s: workflow is required", c.Name)
+	}
+	hasSchedule := strings.TrimSpace(c.Schedule) != ""
+	if hasSchedule == (c.Event != nil) {
+		return fmt.Errorf("trigger %s: set either schedule or event", c.Name)
+	}
+	if hasSchedule && len(strings.Fields(c.Schedule)) != 5 {
+		return fmt.Errorf("trigger %s: schedule %q is not a five-field cron expression", c.Name, c.Schedule)
+	}
+	if c.Event != nil && strings.TrimSpace(c.Event.Type) == "" {
+		return fmt.Errorf("trigger %s: event type is required", c.Name)
+	}
+	return nil
+}
+
+// FileName returns the trigger file name for name ("Nightly Sync" becomes
+// nightly_sync.trigger.json).
+func FileName(name string) string {
+	return fsutil.SafeFileName(strings.Join(strings.Fields(strings.ToLower(name)), "_")) + FileSuffix
+}
+
+// Trigger is the exported trigger definition.
+type Trigger struct {
+	UniqueName string     `json:"unique_name"`
+	Name       string     `json:"name"`
+	Title      string     `json:"title"`
+	Type       string     `json:"type"`
+	BaseType   string     `json:"base_type"`
+	RefID      string     `json:"ref_id"`
+	Properties Properties `json:"properties"`
+	ObjectType string     `json:"object_type"`
+}
+
+// Properties are the trigger's settings; exactly one of Schedule and Event is set.
+type Properties struct {
+	Disabled       bool         `json:"disabled"`
+	Schedule       *Schedule    `json:"schedule,omitempty"`
+	Event          *Event       `json:"event,omitempty"`
+	InputVariables []InputValue `json:"input_variables"`
+}
+
+// Schedule is a cron schedule in Timezone.
+type Schedule struct {
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"`
+}
+
+// InputValue sets one input variable of the bound workflow.
+type InputValue struct {
+	UniqueName string      `json:"unique_name"`
+	Name       string      `json:"name"`
+	Value      interface{} `json:"value"`
+}
+
+type export struct {
+	Trigger Trigger `json:"trigger"`
+}
+
+// workflowExport is the subset of a workflow export a trigger binds to.
+type workflowExport struct {
+	Workflow struct {
+		UniqueName string `json:"unique_name"`
+		Variables  []struct {
+			UniqueName string `json:"unique_name"`
+			Properties struct {
+				Name       string `json:"name"`
+				Scope      string `json:"scope"`
+				IsRequired bool   `json:"is_required"`
+			} `json:"properties"`
+		} `json:"variables"`
+	} `json:"workflow"`
+}
+
+// Build binds c to the workflow export. uniqueName is the trigger's unique
+// name; pass the previous one when regenerating so the platform updates the
+// trigger instead of adding another. Required inputs without a value are
+// returned as warnings.
+func Build(c Config, workflow []byte, uniqueName string) ([]byte, []string, error) {
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	var wf workflowExport
+	if err := json.Unmarshal(workflow, &wf); err != nil {
+		return nil, nil, fmt.Errorf("trigger %s: %w", c.Name, err)
+	}
+	if wf.Workflow.UniqueName == "" {
+		return nil, nil, fmt.Errorf("trigger %s: workflow %s has no unique name", c.Name, c.Workflow)
+	}
+
+	t := Trigger{
+		UniqueName: uniqueName,
+		Name:       c.Name,
+		Title:      c.Name,
+		BaseType:   "trigger",
+		RefID:      wf.Workflow.UniqueName,
+		Properties: Properties{Disabled: !c.Enabled, InputVariables: []InputValue{}},
+		ObjectType: "trigger",
+	}
+	if c.Event != nil {
+		t.Type = "trigger.event"
+		t.Properties.Event = c.Event
+	} else {
+		t.Type = "trigger.schedule"
+		timezone := c.Timezone
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		t.Properties.Schedule = &Schedule{Cron: strings.Join(strings.Fields(c.Schedule), " "), Timezone: timezone}
+	}
+
+	remaining := make(map[string]string, len(c.Inputs))
+	for key := range c.Inputs {
+		remaining[inputKey(key)] = key
+	}
+	var warnings []string
+	for _, v := range wf.Workflow.Variables {
+		if v.Properties.Scope != "input" {
+			continue
+		}
+		key, ok := remaining[inputKey(v.Properties.Name)]
+		if !ok {
+			if v.Properties.IsRequired {
+				warnings = append(warnings, fmt.Sprintf("trigger %s: required input %q has no value", c.Name, v.Properties.Name))
+			}
+			continue
+		}
+		delete(remaining, inputKey(v.Properties.Name))
+		t.Properties.InputVariables = append(t.Properties.InputVariables, InputValue{
+			UniqueName: v.UniqueName,
+			Name:       v.Properties.Name,
+			Value:      c.Inputs[key],
+		})
+	}
+	if len(remaining) > 0 {
+		unknown := make([]string, 0, len(remaining))
+		for _, key := range remaining {
+			unknown = append(unknown, key)
+		}
+		sort.Strings(unknown)
+		return nil, nil, fmt.Errorf("trigger %s: workflow %s has no input %s", c.Name, c.Workflow, strings.Join(unknown, ", "))
+	}
+
+	content, err := json.MarshalIndent(export{Trigger: t}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, warnings, nil
+}
+
+// UniqueName reads the unique name of a previously written trigger file.
+func UniqueName(content []byte) string {
+	var e export
+	if err := json.Unmarshal(content, &e); err != nil {
+		return ""
+	}
+	return e.Trigger.UniqueName
+}
+
+// inputKey matches input names with or without the "Input - " prefix and in any case.
+func inputKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.TrimSpace(strings.TrimPrefix(name, "input - "))
+}
@@ -0,0 +1,82 @@
+// Package upload sends generated workflows to an AO tenant's import endpoint
+// and checks which dependencies the tenant already has.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+)
+
+// Missing returns the unique names lookupURL does not find on the tenant:
+// {unique_name} in lookupURL is replaced by each name, 404 means missing and
+// any other non-2xx status fails the check.
+func Missing(ctx context.Context, names []string, lookupURL, token string) ([]string, error) {
+	if !strings.Contains(lookupURL, "{unique_name}") {
+		return nil, errors.New("-lookupUrl needs a {unique_name} placeholder")
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	var missing []string
+	for _, name := range names {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(lookupURL, "{unique_name}", url.PathEscape(name)), nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			missing = append(missing, name)
+		case resp.StatusCode < 200 || resp.StatusCode > 299:
+			return nil, fmt.Errorf("looking up %s: %s", name, resp.Status)
+		}
+	}
+	return missing, nil
+}
+
+// Workflows POSTs each file of dir to importURL in order, noting each upload
+// on w, and stops at the first failure, so a workflow is never imported before
+// the atomics it calls.
+func Workflows(ctx context.Context, w io.Writer, dir string, files []string, importURL, token string) error {
+	client := &http.Client{Timeout: 60 * time.Second}
+	for _, file := range files {
+		content, err := fsutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, importURL, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s: %s: %s", file, resp.Status, strings.TrimSpace(string(body)))
+		}
+		fmt.Fprintf(w, "Uploaded %s (%s)\n", file, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package workflowdiff compares two AO workflow exports the way a reviewer reads
+// them: workflow properties, variables by name and actions by their title path.
+// Generated unique names are replaced by the names they stand for, so two
+// renders of the same operation compare equal even though every KSUID differs.
+package workflowdiff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrNotWorkflow is returned for JSON documents that are not workflow exports.
+var ErrNotWorkflow = errors.New("document has no workflow object")
+
+// Kinds of changes.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one difference between two exports.
+type Change struct {
+	Kind    string
+	Subject string
+	Detail  string
+}
+
+func (c Change) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s", c.Kind, c.Subject)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Subject, c.Detail)
+}
+
+// uniqueNamePattern matches generated unique names such as
+// definition_activity_<KSUID> or variable_workflow_<KSUID>, with a KSUID, ULID
+// or UUID after an optional -idPrefix.
+var uniqueNamePattern = regexp.MustCompile(`\b[a-z_]+_(?:[0-9A-Za-z]{26,}|[0-9A-Za-z]*[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\b`)
+
+// export is the normalized view of a workflow export.
+type export struct {
+	properties map[string]interface{}
+	variables  map[string]interface{}
+	actions    map[string]interface{}
+}
+
+// Compare returns the changes from old to new, sorted by subject.
+func Compare(old, new []byte) ([]Change, error) {
+	before, err := normalize(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := normalize(new)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	changes = append(changes, compareMaps("property", before.properties, after.properties)...)
+	changes = append(changes, compareMaps("variable", before.variables, after.variables)...)
+	changes = append(changes, compareMaps("action", before.actions, after.actions)...)
+	return changes, nil
+}
+
+func compareMaps(kind string, before, after map[string]interface{}) []Change {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, key := range sorted {
+		subject := fmt.Sprintf("%s %q", kind, key)
+		oldValue, inOld := before[key]
+		newValue, inNew := after[key]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Subject: subject})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Subject: subject})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, Change{Kind: Changed, Subject: subject, Detail: describe(oldValue, newValue)})
+		}
+	}
+	return changes
+}
+
+// describe lists the fields that differ between two values as dotted paths, or
+// both values when they are scalars.
+func describe(before, after interface{}) string {
+	if _, ok := before.(map[string]interface{}); !ok {
+		return fmt.Sprintf("%s -> %s", compact(before), compact(after))
+	}
+	return strings.Join(differingFields("", before, after), ", ")
+}
+
+func differingFields(prefix string, before, after interface{}) []string {
+	oldMap, okOld := before.(map[string]interface{})
+	newMap, okNew := after.(map[string]interface{})
+	if !okOld || !okNew {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		return []string{prefix}
+	}
+	keys := make(map[string]bool)
+	for key := range oldMap {
+		keys[key] = true
+	}
+	for key := range newMap {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	var fields []string
+	for _, key := range sorted {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fields = append(fields, differingFields(path, oldMap[key], newMap[key])...)
+	}
+	return fields
+}
+
+func compact(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
+
+func normalize(content []byte) (*export, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	workflow, ok := root["workflow"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotWorkflow
+	}
+
+	// Map every unique name to a readable stand-in before comparing values.
+	names := map[string]string{}
+	if id, ok := workflow["unique_name"].(string); ok {
+		names[id] = "<workflow>"
+	}
+	variables, _ := workflow["variables"].([]interface{})
+	for _, raw := range variables {
+		variable, _ := raw.(map[string]interface{})
+		id, _ := variable["unique_name"].(string)
+		properties, _ := variable["properties"].(map[string]interface{})
+		name, _ := properties["name"].(string)
+		names[id] = "<" + name + ">"
+	}
+	actions := map[string]map[string]interface{}{}
+	collectActions(workflow["actions"], "", actions, names)
+
+	replace := func(value interface{}) interface{} {
+		data, _ := json.Marshal(value)
+		data = uniqueNamePattern.ReplaceAllFunc(data, func(id []byte) []byte {
+			if name, ok := names[string(id)]; ok {
+				return []byte(strings.Trim(fmt.Sprintf("%q", name), `"`))
+			}
+			return []byte("<id>")
+		})
+		var normalized interface{}
+		_ = json.Unmarshal(data, &normalized)
+		return normalized
+	}
+
+	result := &export{
+		properties: map[string]interface{}{},
+		variables:  map[string]interface{}{},
+		actions:    map[string]interface{}{},
+	}
+	for _, key := range []string{"name", "title", "type"} {
+		if value, ok := workflow[key]; ok {
+			result.properties[key] = value
+		}
+	}
+	if properties, ok := workflow["properties"].(map[string]interface{}); ok {
+		for key, value := range properties {
+			result.properties[key] = replace(value)
+		}
+	}
+	for _, raw := range variables {
+		variable, _ := raw.(map[string]interface{})
+		properties, _ := variable["properties"].(map[string]interface{})
+		name, _ := properties["name"].(string)
+		result.variables[name] = replace(properties)
+	}
+	for path, action := range actions {
+		result.actions[path] = replace(action)
+	}
+	return result, nil
+}
+
+// collectActions records every action (including those nested in blocks) under
+// its title path, without its own unique name and nested blocks.
+func collectActions(value interface{}, parent string, actions map[string]map[string]interface{}, names map[string]string) {
+	list, _ := value.([]interface{})
+	for _, raw := range list {
+		action, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		title, _ := action["title"].(string)
+		path := title
+		if parent != "" {
+			path = parent + " / " + title
+		}
+		for _, exists := actions[path]; exists; _, exists = actions[path] {
+			path += " (again)"
+		}
+		if id, ok := action["unique_name"].(string); ok {
+			names[id] = "<" + path + ">"
+		}
+		flat := map[string]interface{}{}
+		for key, field := range action {
+			if key != "unique_name" && key != "blocks" && key != "actions" {
+				flat[key] = field
+			}
+		}
+		actions[path] = flat
+		collectActions(action["blocks"], path, actions, names)
+		collectActions(action["actions"], path, actions, names)
+	}
+}
+
+// ThreeWay separates the changes made to a generated export by hand from those
+// a new render brings.
+type ThreeWay struct {
+	// User lists the changes from the generated base to the file on disk.
+	User []Change
+	// Generator lists the changes from the generated base to the new render.
+	Generator []Change
+	// Conflicts lists subjects changed on both sides to different results.
+	Conflicts []Change
+}
+
+// CompareThreeWay compares the last generated export (base) with the edited
+// file (current) and a new render (next).
+func CompareThreeWay(base, current, next []byte) (*ThreeWay, error) {
+	user, err := Compare(base, current)
+	if err != nil {
+		return nil, err
+	}
+	generator, err := Compare(base, next)
+	if err != nil {
+		return nil, err
+	}
+	divergent, err := Compare(current, next)
+	if err != nil {
+		return nil, err
+	}
+	changedByUser := make(map[string]bool, len(user))
+	for _, change := range user {
+		changedByUser[change.Subject] = true
+	}
+	changedByGenerator := make(map[string]bool, len(generator))
+	for _, change := range generator {
+		changedByGenerator[change.Subject] = true
+	}
+	result := &ThreeWay{User: user, Generator: generator}
+	for _, change := range divergent {
+		if changedByUser[change.Subject] && changedByGenerator[change.Subject] {
+			result.Conflicts = append(result.Conflicts, change)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package workflowdiff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// exportWith returns a workflow export whose unique names end in id, with one
+// output variable and a request followed by an if/else holding a completion.
+func exportWith(id, description, status, message string) []byte {
+	return []byte(`{"workflow": {
+  "unique_name": "definition_workflow_W` + id + `",
+  "name": "Get Site", "title": "Get Site", "type": "generic.workflow",
+  "properties": {"description": "` + description + `"},
+  "variables": [
+    {"unique_name": "variable_workflow_S` + id + `", "properties": {"name": "Output - Status", "scope": "output", "type": "datatype.string"}}
+  ],
+  "actions": [
+    {"unique_name": "definition_activity_R` + id + `", "title": "Get Site", "type": "netbox.invoke_api", "properties": {"_method": "GET"}},
+    {"unique_name": "definition_activity_C` + id + `", "title": "Was the Request Successful?", "type": "logic.if_else", "properties": {}, "blocks": [
+      {"unique_name": "definition_activity_B` + id + `", "title": "200/Success", "type": "logic.condition_block", "properties": {"condition": {"left_operand": "$activity.definition_activity_R` + id + `.output.status_code$", "operator": "eq", "right_operand": 200}}, "actions": [
+        {"unique_name": "definition_activity_D` + id + `", "title": "Completed - Success", "type": "logic.completed", "properties": {"result_message": "` + message + `", "status": "` + status + `"}}
+      ]}
+    ]}
+  ]
+}}`)
+}
+
+// Two sets of generated unique name suffixes, as long as ULIDs.
+const (
+	firstID  = "2aB3cD4eF5gH6iJ7kL8mN9oP0q"
+	secondID = "9zY8xW7vU6tS5rQ4pO3nM2lK1j"
+)
+
+func changeStrings(changes []Change) []string {
+	var list []string
+	for _, change := range changes {
+		list = append(list, change.String())
+	}
+	return list
+}
+
+func TestCompare(t *testing.T) {
+	base := exportWith(firstID, "Gets a site.", "succeeded", "done")
+	tests := []struct {
+		name string
+		new  []byte
+		want []string
+	}{
+		{
+			name: "regenerated IDs",
+			new:  exportWith(secondID, "Gets a site.", "succeeded", "done"),
+		},
+		{
+			name: "property",
+			new:  exportWith(secondID, "Gets one site.", "succeeded", "done"),
+			want: []string{`changed property "description": "Gets a site." -> "Gets one site."`},
+		},
+		{
+			name: "nested action",
+			new:  exportWith(secondID, "Gets a site.", "failed", "done"),
+			want: []string{`changed action "Was the Request Successful? / 200/Success / Completed - Success": properties.status`},
+		},
+		{
+			name: "variable added",
+			new: []byte(strings.Replace(string(exportWith(secondID, "Gets a site.", "succeeded", "done")), `"variables": [`,
+				`"variables": [{"unique_name": "variable_workflow_X`+secondID+`", "properties": {"name": "Output - Name", "scope": "output"}},`, 1)),
+			want: []string{`added variable "Output - Name"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Compare(base, tt.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changeStrings(changes); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareResolvesReferences(t *testing.T) {
+	// The condition references the request by unique name; renaming the
+	// request's title changes what the reference stands for.
+	before := exportWith(firstID, "Gets a site.", "succeeded", "done")
+	after := []byte(strings.Replace(string(exportWith(secondID, "Gets a site.", "succeeded", "done")), `"title": "Get Site", "type": "netbox.invoke_api"`, `"title": "Fetch Site", "type": "netbox.invoke_api"`, 1))
+	changes, err := Compare(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`added action "Fetch Site"`,
+		`removed action "Get Site"`,
+		`changed action "Was the Request Successful? / 200/Success": properties.condition.left_operand`,
+	}
+	got := changeStrings(changes)
+	if len(got) != len(want) {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+	for _, change := range want {
+		if !strings.Contains(strings.Join(got, "\n"), change) {
+			t.Errorf("changes = %q, want %q among them", got, change)
+		}
+	}
+}
+
+func TestCompareThreeWay(t *testing.T) {
+	base := exportWith(firstID, "Gets a site.", "succeeded", "done")
+	tests := []struct {
+		name      string
+		current   []byte
+		next      []byte
+		user      int
+		generator int
+		conflicts []string
+	}{
+		{
+			name:      "separate edits",
+			current:   exportWith(firstID, "Gets a site by ID.", "succeeded", "done"),
+			next:      exportWith(secondID, "Gets a site.", "succeeded", "Site found"),
+			user:      1,
+			generator: 1,
+		},
+		{
+			name:      "same edit",
+			current:   exportWith(firstID, "Gets a site.", "succeeded", "Site found"),
+			next:      exportWith(secondID, "Gets a site.", "succeeded", "Site found"),
+			user:      1,
+			generator: 1,
+		},
+		{
+			name:      "conflicting edits",
+			current:   exportWith(firstID, "Gets a site.", "succeeded", "OK"),
+			next:      exportWith(secondID, "Gets a site.", "succeeded", "Site found"),
+			user:      1,
+			generator: 1,
+			conflicts: []string{`changed action "Was the Request Successful? / 200/Success / Completed - Success": properties.result_message`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CompareThreeWay(base, tt.current, tt.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.User) != tt.user || len(result.Generator) != tt.generator {
+				t.Errorf("user = %q, generator = %q, want %d and %d changes", changeStrings(result.User), changeStrings(result.Generator), tt.user, tt.generator)
+			}
+			if got := changeStrings(result.Conflicts); strings.Join(got, "\n") != strings.Join(tt.conflicts, "\n") {
+				t.Errorf("conflicts = %q, want %q", got, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestCompareRejectsOtherDocuments(t *testing.T) {
+	_, err := Compare([]byte(`{"composite": {}}`), exportWith(firstID, "", "", ""))
+	if !errors.Is(err, ErrNotWorkflow) {
+		t.Errorf("err = %v, want ErrNotWorkflow", err)
+	}
+}
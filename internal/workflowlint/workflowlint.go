@@ -0,0 +1,413 @@
+// Package workflowlint checks AO workflow exports for structural problems that
+// the generator knows how to avoid: dangling references, duplicate unique
+// names, output variables that are never populated and $...KSUID placeholders
+// that were never replaced.
+package workflowlint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gitlab.ikarem.io/cross-domain-automation/ao-atomic-generator/internal/fsutil"
+)
+
+// ErrNotWorkflow is returned for JSON documents that are not workflow exports
+// (e.g. the import manifest); LintDir skips them.
+var ErrNotWorkflow = errors.New("document has no workflow object")
+
+// Severity classifies an issue; errors break imports or runs, warnings are suspicious.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue describes one problem found in a workflow document.
+type Issue struct {
+	File     string
+	Location string
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	location := i.Location
+	if i.File != "" {
+		location = i.File + ": " + location
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, location, i.Message)
+}
+
+// ReferencePattern matches $workflow.<id>.<scope>.<name>$ and $activity.<id>.output...$ references.
+var ReferencePattern = regexp.MustCompile(`\$(workflow|activity)\.([A-Za-z0-9_-]+)\.(input|output|local)\.([^$"]*)\$`)
+
+// PlaceholderPattern matches generator placeholders such as $WorkflowKSUID
+// left in a document after ID replacement, including misspelled ones the
+// replacement does not recognise ($site-idKSUID, $KSUID).
+var PlaceholderPattern = regexp.MustCompile(`\$[^\s"$.]*KSUID`)
+
+// builtinWorkflowOutputs are output variables every AO workflow has implicitly.
+var builtinWorkflowOutputs = map[string]bool{
+	"workflow_results":      true,
+	"workflow_results_code": true,
+}
+
+// connectorFixedOutputs are the standard outputs generated atomics declare for
+// their connector (connector.Config.FixedOutputs). Older and hand-edited
+// atomics declare them even where no step sets them, e.g. Output - Status
+// Message on NetBox, so an unset one is not reported.
+var connectorFixedOutputs = map[string]bool{
+	"Output - Status Message": true,
+	"Output - Status Code":    true,
+	"Output - Error Message":  true,
+	"Output - Response Body":  true,
+	"Output - Request URL":    true,
+	"Output - Duration":       true,
+}
+
+// Reference is a placeholder reference found in a workflow string value.
+type Reference struct {
+	Kind     string
+	Target   string
+	Scope    string
+	Name     string
+	Location string
+}
+
+// document is the subset of a workflow export the checks need.
+type document struct {
+	workflowID string
+	variables  map[string]string
+	names      map[string]string
+	outputs    []string
+	uniqueIDs  map[string][]string
+	activities map[string]bool
+	references []Reference
+	assigned   map[string]bool
+}
+
+// Lint parses a workflow export and returns every issue found.
+func Lint(content []byte) ([]Issue, error) {
+	var parsed interface{}
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		return nil, err
+	}
+	// Output directories also hold JSON reports such as required-fields.json,
+	// which may not even be objects.
+	root, _ := parsed.(map[string]interface{})
+	workflow, ok := root["workflow"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotWorkflow
+	}
+	doc := collect(workflow)
+
+	var issues []Issue
+	issues = append(issues, duplicateIssues(doc)...)
+	issues = append(issues, ReferenceIssues(doc.workflowID, doc.variables, doc.activities, doc.references)...)
+	issues = append(issues, outputIssues(doc)...)
+	issues = append(issues, placeholderIssues(root, "")...)
+	return issues, nil
+}
+
+// CheckReferences verifies that every $activity/$workflow reference in a rendered
+// workflow points at a unique_name that exists in the same document.
+func CheckReferences(content []byte) ([]Issue, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	workflow, ok := root["workflow"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotWorkflow
+	}
+	doc := collect(workflow)
+	return ReferenceIssues(doc.workflowID, doc.variables, doc.activities, doc.references), nil
+}
+
+// CheckPlaceholders reports every $...KSUID placeholder left in a rendered
+// document, wherever it is; the IDs are replaced by pattern, so a placeholder
+// misspelled in a template or built from an unusual name survives silently.
+func CheckPlaceholders(content []byte) ([]Issue, error) {
+	var root interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	return placeholderIssues(root, ""), nil
+}
+
+func placeholderIssues(value interface{}, location string) []Issue {
+	var issues []Issue
+	report := func(text, location string) {
+		for _, placeholder := range PlaceholderPattern.FindAllString(text, -1) {
+			issues = append(issues, Issue{
+				Location: location,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("unreplaced placeholder %s", placeholder),
+			})
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		report(v, location)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyLocation := strings.TrimPrefix(location+"."+key, ".")
+			report(key, keyLocation)
+			issues = append(issues, placeholderIssues(v[key], keyLocation)...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			issues = append(issues, placeholderIssues(item, fmt.Sprintf("%s[%d]", location, i))...)
+		}
+	}
+	return issues
+}
+
+// LintFile lints a single workflow JSON file.
+func LintFile(path string) ([]Issue, error) {
+	content, err := fsutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := Lint(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range issues {
+		issues[i].File = path
+	}
+	return issues, nil
+}
+
+// LintDir lints every *.json file below dir.
+func LintDir(dir string) ([]Issue, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var issues []Issue
+	for _, file := range files {
+		fileIssues, err := LintFile(file)
+		if errors.Is(err, ErrNotWorkflow) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+	return issues, nil
+}
+
+// HasErrors reports whether any issue is an error.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func collect(workflow map[string]interface{}) *document {
+	doc := &document{
+		variables:  make(map[string]string),
+		names:      make(map[string]string),
+		uniqueIDs:  make(map[string][]string),
+		activities: make(map[string]bool),
+		assigned:   make(map[string]bool),
+	}
+	doc.workflowID, _ = workflow["unique_name"].(string)
+	if doc.workflowID != "" {
+		doc.uniqueIDs[doc.workflowID] = append(doc.uniqueIDs[doc.workflowID], "workflow")
+	}
+	variables, _ := workflow["variables"].([]interface{})
+	for i, raw := range variables {
+		variable, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		location := fmt.Sprintf("workflow.variables[%d]", i)
+		name, _ := variable["unique_name"].(string)
+		if name == "" {
+			continue
+		}
+		doc.uniqueIDs[name] = append(doc.uniqueIDs[name], location)
+		props, _ := variable["properties"].(map[string]interface{})
+		scope, _ := props["scope"].(string)
+		doc.variables[name] = scope
+		doc.names[name], _ = props["name"].(string)
+		if scope == "output" {
+			doc.outputs = append(doc.outputs, name)
+		}
+	}
+	actions, _ := workflow["actions"].([]interface{})
+	collectActions(doc, actions, "workflow.actions")
+	return doc
+}
+
+func collectActions(doc *document, actions []interface{}, location string) {
+	for i, raw := range actions {
+		action, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		actionLocation := fmt.Sprintf("%s[%d]", location, i)
+		if name, _ := action["unique_name"].(string); name != "" {
+			doc.uniqueIDs[name] = append(doc.uniqueIDs[name], actionLocation)
+			doc.activities[name] = true
+		}
+		collectStrings(doc, action["properties"], actionLocation+".properties")
+		if blocks, ok := action["blocks"].([]interface{}); ok {
+			collectActions(doc, blocks, actionLocation+".blocks")
+		}
+		if nested, ok := action["actions"].([]interface{}); ok {
+			collectActions(doc, nested, actionLocation+".actions")
+		}
+	}
+}
+
+func collectStrings(doc *document, value interface{}, location string) {
+	switch v := value.(type) {
+	case string:
+		for _, match := range ReferencePattern.FindAllStringSubmatch(v, -1) {
+			doc.references = append(doc.references, Reference{
+				Kind:     match[1],
+				Target:   match[2],
+				Scope:    match[3],
+				Name:     match[4],
+				Location: location,
+			})
+		}
+	case map[string]interface{}:
+		if target, ok := v["variable_to_update"].(string); ok {
+			for _, match := range ReferencePattern.FindAllStringSubmatch(target, -1) {
+				doc.assigned[variableName(match[4])] = true
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectStrings(doc, v[key], location+"."+key)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectStrings(doc, item, fmt.Sprintf("%s[%d]", location, i))
+		}
+	}
+}
+
+// variableName strips any trailing path from a workflow variable reference.
+func variableName(name string) string {
+	if idx := strings.Index(name, "."); idx >= 0 {
+		return name[:idx]
+	}
+	return name
+}
+
+// ReferenceIssues reports references to workflows, variables or activities that
+// are not part of the document.
+func ReferenceIssues(workflowID string, variables map[string]string, activities map[string]bool, references []Reference) []Issue {
+	var issues []Issue
+	for _, ref := range references {
+		switch ref.Kind {
+		case "workflow":
+			if workflowID != "" && ref.Target != workflowID {
+				issues = append(issues, Issue{
+					Location: ref.Location,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("reference to unknown workflow %s", ref.Target),
+				})
+				continue
+			}
+			name := variableName(ref.Name)
+			if builtinWorkflowOutputs[name] {
+				continue
+			}
+			scope, ok := variables[name]
+			if !ok {
+				issues = append(issues, Issue{
+					Location: ref.Location,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("dangling variable reference %s", name),
+				})
+				continue
+			}
+			if scope != "" && scope != ref.Scope {
+				issues = append(issues, Issue{
+					Location: ref.Location,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("variable %s is referenced as %s but declared as %s", name, ref.Scope, scope),
+				})
+			}
+		case "activity":
+			if !activities[ref.Target] {
+				issues = append(issues, Issue{
+					Location: ref.Location,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("dangling activity reference %s", ref.Target),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func duplicateIssues(doc *document) []Issue {
+	names := make([]string, 0, len(doc.uniqueIDs))
+	for name, locations := range doc.uniqueIDs {
+		if len(locations) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	issues := make([]Issue, 0, len(names))
+	for _, name := range names {
+		locations := doc.uniqueIDs[name]
+		issues = append(issues, Issue{
+			Location: locations[0],
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("duplicate unique_name %s (also at %s)", name, strings.Join(locations[1:], ", ")),
+		})
+	}
+	return issues
+}
+
+func outputIssues(doc *document) []Issue {
+	var issues []Issue
+	for _, name := range doc.outputs {
+		if !doc.assigned[name] && !connectorFixedOutputs[doc.names[name]] {
+			issues = append(issues, Issue{
+				Location: "workflow.variables",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("output variable %q (%s) is never set", doc.names[name], name),
+			})
+		}
+	}
+	return issues
+}
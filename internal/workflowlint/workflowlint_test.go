@@ -0,0 +1,120 @@
+package workflowlint
+
+import (
+	"strings"
+	"testing"
+)
+
+// workflow returns a workflow export with the given variables and actions
+// (JSON array contents).
+func workflow(variables, actions string) []byte {
+	return []byte(`{"workflow": {"unique_name": "definition_workflow_W", "variables": [` + variables + `], "actions": [` + actions + `]}}`)
+}
+
+func output(uniqueName, name string) string {
+	return `{"unique_name": "` + uniqueName + `", "properties": {"scope": "output", "name": "` + name + `"}}`
+}
+
+func TestLint(t *testing.T) {
+	setName := `{"unique_name": "definition_activity_A", "properties": {"variables_to_update": [{"variable_to_update": "$workflow.definition_workflow_W.output.variable_workflow_N$", "variable_value_new": "x"}]}}`
+	tests := []struct {
+		name      string
+		variables string
+		actions   string
+		want      []string
+	}{
+		{
+			name:      "set output",
+			variables: output("variable_workflow_N", "Output - Name"),
+			actions:   setName,
+		},
+		{
+			name:      "unset output",
+			variables: output("variable_workflow_N", "Output - Name"),
+			want:      []string{`output variable "Output - Name" (variable_workflow_N) is never set`},
+		},
+		{
+			name:      "duplicate unique_name",
+			variables: output("variable_workflow_N", "Output - Name") + "," + output("variable_workflow_N", "Output - Other"),
+			actions:   setName,
+			want:      []string{"duplicate unique_name variable_workflow_N (also at workflow.variables[1])"},
+		},
+		{
+			name:      "unset connector outputs",
+			variables: output("variable_workflow_S", "Output - Status Message") + "," + output("variable_workflow_C", "Output - Status Code"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := Lint(workflow(tt.variables, tt.actions))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintSkipsOtherDocuments(t *testing.T) {
+	for _, content := range []string{`{"workflows": []}`, `[1, 2]`} {
+		if _, err := Lint([]byte(content)); err != ErrNotWorkflow {
+			t.Errorf("Lint(%s) error = %v, want ErrNotWorkflow", content, err)
+		}
+	}
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "replaced",
+			content: `{"workflow": {"unique_name": "definition_workflow_2aB3cD4eF5gH6iJ7kL8mN9oP0q", "name": "KSUID lookup"}}`,
+		},
+		{
+			name:    "value",
+			content: `{"workflow": {"variables": [{"unique_name": "variable_workflow_$site-idKSUID"}]}}`,
+			want:    []string{"workflow.variables[0].unique_name: unreplaced placeholder $site-idKSUID"},
+		},
+		{
+			name:    "reference and key",
+			content: `{"workflow": {"properties": {"$KSUID": "$workflow.definition_workflow_$WorkflowKSUID.input.x$ $StatusKSUID"}}}`,
+			want: []string{
+				"workflow.properties.$KSUID: unreplaced placeholder $KSUID",
+				"workflow.properties.$KSUID: unreplaced placeholder $WorkflowKSUID",
+				"workflow.properties.$KSUID: unreplaced placeholder $StatusKSUID",
+			},
+		},
+		{
+			name:    "outside the workflow",
+			content: `{"categories": {"category_$CategoryKSUID": {}}}`,
+			want:    []string{"categories.category_$CategoryKSUID: unreplaced placeholder $CategoryKSUID"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := CheckPlaceholders([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				if issue.Severity != SeverityError {
+					t.Errorf("%s has severity %s, want error", issue.Message, issue.Severity)
+				}
+				got = append(got, issue.Location+": "+issue.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("issues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package connector
+
+// aci addresses APIC's class and mo URLs with a .json suffix and reads the
+// managed objects of the imdata envelope.
+var aci = Config{
+	AtomicGroup:         "Cisco ACI",
+	TargetType:          "apic.endpoint",
+	ActionType:          "apic.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "/api",
+	APIRoot:             "/api/",
+	ResponseEnvelope:    "imdata",
+	ManagedObjects:      true,
+	PathSuffix:          ".json",
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "Cisco APIC",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    AdapterProperties,
+}
+
+func init() {
+	Register("aci", aci)
+	Register("apic", aci)
+}
@@ -0,0 +1,23 @@
+package connector
+
+// catalystCenter prefixes spec paths with /dna/intent/api, leaving paths
+// that already start with /dna/ (e.g. /dna/system/api/v1/auth/token) alone.
+var catalystCenter = Config{
+	AtomicGroup:         "Cisco Catalyst Center",
+	TargetType:          "dnac.endpoint",
+	ActionType:          "dnac.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "/dna/intent/api",
+	APIRoot:             "/dna/",
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "Cisco Catalyst Center",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    AdapterProperties,
+}
+
+func init() {
+	Register("catalystcenter", catalystCenter)
+	Register("dnac", catalystCenter)
+}
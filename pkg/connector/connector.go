@@ -0,0 +1,179 @@
+// Package connector is the registry of the platforms the generator targets. A
+// Config describes a platform's AO adapter: its target and API request action
+// types, base path, response fields and how the request action's properties
+// are built. The built-in connectors register themselves from this package's
+// files; third parties vend a connector as a Go package that calls Register
+// from its init function and is blank-imported into the generator binary:
+//
+//	import _ "example.com/ao-connectors/infoblox"
+package connector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Standard outputs a connector can declare by default (Config.FixedOutputs).
+const (
+	OutputStatusMessage = "status_message"
+	OutputStatusCode    = "status_code"
+	OutputErrorMessage  = "error_message"
+	OutputResponseBody  = "response_body"
+	OutputRequestURL    = "request_url"
+	OutputDuration      = "duration"
+)
+
+// Outputs lists the standard outputs in the order atomics declare them.
+var Outputs = []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage, OutputResponseBody, OutputRequestURL, OutputDuration}
+
+// ParseOutputs validates a list of standard output names, ignoring case and
+// empty names.
+func ParseOutputs(names []string) ([]string, error) {
+	known := make(map[string]bool, len(Outputs))
+	for _, output := range Outputs {
+		known[output] = true
+	}
+	outputs := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown fixed output %q (expected one of %s)", name, strings.Join(Outputs, ", "))
+		}
+		outputs = append(outputs, name)
+	}
+	return outputs, nil
+}
+
+// Config describes one connector.
+type Config struct {
+	AtomicGroup         string
+	TargetType          string
+	ActionType          string
+	ResponseBodyField   string
+	StatusMessageField  string
+	ErrorMessageField   string // request action output with the adapter's error text, e.g. error.message; empty if it reports none
+	APIBasePath         string
+	APIRoot             string // prefix of paths that already carry a base path; empty means APIBasePath
+	ResponseEnvelope    string // response property wrapping the payload, e.g. vManage's data
+	ManagedObjects      bool   // envelope records are APIC managed objects, {"<class>": {"attributes": {...}}}
+	PathSuffix          string // format extension added to paths without one, e.g. APIC's .json
+	ContinueOnFailure   bool
+	PlatformDisplayName string
+	FixedOutputs        []string
+	PathDefaults        map[string]string // default values of path parameters, e.g. FMC's Global domainUUID
+	QueryPrep           bool              // GET query parameters are URL-encoded and serialized by a Prepare Query Params step
+	ListQueryParams     []string          // query parameters taken as a comma-separated list sent as one value, e.g. ServiceNow's sysparm_fields
+	QueryParamHints     map[string]string // sentences added to the description of query inputs, e.g. ServiceNow's sysparm_query syntax
+	BuildActionProps    func(req Request) interface{}
+}
+
+// Request is what the properties of an API request action are built from.
+type Request struct {
+	Method      string
+	Endpoint    string // path and query string, with workflow variable references
+	Body        string // JSON body template or prep-step reference
+	HasBody     bool   // whether the operation sends a request body
+	Description string // the operation's description
+	DisplayName string
+	Timeout     int          // action_timeout in seconds
+	Headers     []HTTPHeader // custom headers (-httpHeader), for connectors that send them
+}
+
+// RuntimeUser selects the account the action runs as; the built-in
+// connectors use the target's default.
+type RuntimeUser struct {
+	TargetDefault bool `json:"target_default"`
+}
+
+// HTTPHeader is one custom header of an HTTP request.
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// workflowTarget makes an action run against the workflow's target.
+func workflowTarget() map[string]bool {
+	return map[string]bool{"use_workflow_target": true}
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Config{}
+)
+
+// Register makes a connector available under name (case-insensitive), e.g.
+// for -connector. A later registration of the same name replaces the earlier
+// one, so a plugin or connector definition can override a built-in. It panics
+// on an empty name or a config without action type or request builder.
+func Register(name string, cfg Config) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		panic("connector: Register with an empty name")
+	}
+	if cfg.ActionType == "" || cfg.BuildActionProps == nil {
+		panic(fmt.Sprintf("connector: Register %s without ActionType or BuildActionProps", name))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = cfg
+}
+
+// Lookup returns the connector registered under name.
+func Lookup(name string) (Config, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	cfg, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return cfg, ok
+}
+
+// Names returns the registered names, aliases included, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// APIRequestProperties are the properties of the Cisco adapters'
+// <platform>.api_request actions.
+type APIRequestProperties struct {
+	ActionTimeout     int             `json:"action_timeout"`
+	ApiBody           string          `json:"api_body"`
+	ApiMethod         string          `json:"api_method"`
+	ApiURL            string          `json:"api_url"`
+	ContinueOnFailure bool            `json:"continue_on_failure"`
+	Description       string          `json:"description"`
+	DisplayName       string          `json:"display_name"`
+	RuntimeUser       RuntimeUser     `json:"runtime_user"`
+	SkipExecution     bool            `json:"skip_execution"`
+	Target            map[string]bool `json:"target"`
+}
+
+// AdapterProperties builds the request of the Catalyst Center, vManage, APIC,
+// FMC and ServiceNow adapters; the body is only sent by methods that take one.
+func AdapterProperties(req Request) interface{} {
+	props := APIRequestProperties{
+		ActionTimeout:     req.Timeout,
+		ApiMethod:         req.Method,
+		ApiURL:            req.Endpoint,
+		ContinueOnFailure: false,
+		Description:       req.Description,
+		DisplayName:       req.DisplayName,
+		RuntimeUser:       RuntimeUser{TargetDefault: true},
+		SkipExecution:     false,
+		Target:            workflowTarget(),
+	}
+	if req.HasBody {
+		props.ApiBody = req.Body
+	}
+	return props
+}
@@ -0,0 +1,27 @@
+package connector
+
+import "encoding/json"
+
+// DefinedRequestProperties are the request properties of a connector loaded
+// from a definition (-connectorDef). They marshal as Values, keyed by the
+// property names the definition declares; DescriptionKey is the one holding
+// the description.
+type DefinedRequestProperties struct {
+	Values         map[string]interface{}
+	DescriptionKey string
+}
+
+func (p DefinedRequestProperties) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Values)
+}
+
+// With returns a copy of p with key set to value.
+func (p DefinedRequestProperties) With(key string, value interface{}) DefinedRequestProperties {
+	values := make(map[string]interface{}, len(p.Values)+1)
+	for k, v := range p.Values {
+		values[k] = v
+	}
+	values[key] = value
+	p.Values = values
+	return p
+}
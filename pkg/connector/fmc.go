@@ -0,0 +1,27 @@
+package connector
+
+// FMCGlobalDomainUUID is the UUID of the Global domain every FMC has; FMC
+// config paths start with /api/fmc_config/v1/domain/{domainUUID}.
+const FMCGlobalDomainUUID = "e276abec-e0f2-11e3-8169-6d9ed49b625f"
+
+// fmc defaults the domainUUID path parameter to the Global domain and reads
+// list responses from their items array.
+var fmc = Config{
+	AtomicGroup:         "Cisco Secure Firewall",
+	TargetType:          "fmc.endpoint",
+	ActionType:          "fmc.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "",
+	ResponseEnvelope:    "items",
+	PathDefaults:        map[string]string{"domainUUID": FMCGlobalDomainUUID},
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "Cisco Secure Firewall Management Center",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    AdapterProperties,
+}
+
+func init() {
+	Register("fmc", fmc)
+}
@@ -0,0 +1,71 @@
+package connector
+
+// HTTPRequestActionType is the standard AO HTTP request activity the generic
+// connector uses.
+const HTTPRequestActionType = "web-service.http_request"
+
+// generic converts any REST API through the standard HTTP request activity;
+// the generator puts -httpBasePath in front of its paths.
+var generic = Config{
+	AtomicGroup:         "HTTP",
+	TargetType:          "web-service.endpoint",
+	ActionType:          HTTPRequestActionType,
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "",
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "HTTP",
+	FixedOutputs:        []string{OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    httpRequestActionProperties,
+}
+
+func init() {
+	Register("generic", generic)
+	Register("http", generic)
+}
+
+// HTTPRequestProperties are the properties of the standard
+// web-service.http_request activity the generic connector uses.
+type HTTPRequestProperties struct {
+	ActionTimeout             int             `json:"action_timeout"`
+	AllowAutoRedirect         bool            `json:"allow_auto_redirect"`
+	Body                      string          `json:"body,omitempty"`
+	ContentType               string          `json:"content_type,omitempty"`
+	ContinueOnErrorStatusCode bool            `json:"continue_on_error_status_code"`
+	ContinueOnFailure         bool            `json:"continue_on_failure"`
+	CustomHeaders             []HTTPHeader    `json:"custom_headers,omitempty"`
+	Description               string          `json:"description"`
+	DisplayName               string          `json:"display_name"`
+	Method                    string          `json:"method"`
+	RelativeURL               string          `json:"relative_url"`
+	RuntimeUser               RuntimeUser     `json:"runtime_user"`
+	SkipExecution             bool            `json:"skip_execution"`
+	Target                    map[string]bool `json:"target"`
+}
+
+// httpRequestActionProperties builds a standard web-service.http_request
+// activity: the target supplies the host and credentials, the request its
+// relative URL, the custom headers and a JSON body for methods that take one.
+// Error status codes continue to the condition branches like the adapters' do.
+func httpRequestActionProperties(req Request) interface{} {
+	props := HTTPRequestProperties{
+		ActionTimeout:             req.Timeout,
+		AllowAutoRedirect:         true,
+		ContinueOnErrorStatusCode: true,
+		ContinueOnFailure:         false,
+		CustomHeaders:             req.Headers,
+		Description:               req.Description,
+		DisplayName:               req.DisplayName,
+		Method:                    req.Method,
+		RelativeURL:               req.Endpoint,
+		RuntimeUser:               RuntimeUser{TargetDefault: true},
+		SkipExecution:             false,
+		Target:                    workflowTarget(),
+	}
+	if req.HasBody {
+		props.Body = req.Body
+		props.ContentType = "application/json"
+	}
+	return props
+}
@@ -0,0 +1,37 @@
+package connector
+
+// meraki is the default connector.
+var meraki = Config{
+	AtomicGroup:         "Cisco Meraki",
+	TargetType:          "meraki.endpoint",
+	ActionType:          "meraki.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "/api/v1",
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "Cisco Meraki",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    merakiActionProperties,
+}
+
+func init() {
+	Register("meraki", meraki)
+}
+
+// merakiActionProperties builds a meraki.api_request; the adapter always takes
+// an api_body, empty for methods without one.
+func merakiActionProperties(req Request) interface{} {
+	return APIRequestProperties{
+		ActionTimeout:     req.Timeout,
+		ApiMethod:         req.Method,
+		ApiURL:            req.Endpoint,
+		ApiBody:           req.Body,
+		ContinueOnFailure: false,
+		Description:       req.Description,
+		DisplayName:       req.DisplayName,
+		RuntimeUser:       RuntimeUser{TargetDefault: true},
+		SkipExecution:     false,
+		Target:            workflowTarget(),
+	}
+}
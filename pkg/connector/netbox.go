@@ -0,0 +1,55 @@
+package connector
+
+import "strings"
+
+// netbox sends GET query parameters through the Prepare Query Params step;
+// the generator also builds POST/PATCH/PUT bodies in a prep step for it.
+var netbox = Config{
+	AtomicGroup:         "NetBox",
+	TargetType:          "netbox.endpoint",
+	ActionType:          "netbox.invoke_api",
+	ResponseBodyField:   "raw_body",
+	StatusMessageField:  "",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "",
+	QueryPrep:           true,
+	ContinueOnFailure:   true,
+	PlatformDisplayName: "Netbox",
+	FixedOutputs:        []string{OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    netboxActionProperties,
+}
+
+func init() {
+	Register("netbox", netbox)
+}
+
+// NetboxAPIRequestProperties are the properties of the netbox.invoke_api
+// action.
+type NetboxAPIRequestProperties struct {
+	ActionTimeout     int             `json:"action_timeout"`
+	ContinueOnFailure bool            `json:"continue_on_failure"`
+	DisplayName       string          `json:"display_name"`
+	Method            string          `json:"_method"`
+	Endpoint          string          `json:"_endpoint"`
+	RuntimeUser       RuntimeUser     `json:"runtime_user"`
+	SkipExecution     bool            `json:"skip_execution"`
+	Target            map[string]bool `json:"target"`
+	Body              string          `json:"_body,omitempty"`
+}
+
+func netboxActionProperties(req Request) interface{} {
+	props := NetboxAPIRequestProperties{
+		ActionTimeout:     req.Timeout,
+		ContinueOnFailure: true,
+		DisplayName:       req.DisplayName,
+		Method:            req.Method,
+		Endpoint:          req.Endpoint,
+		RuntimeUser:       RuntimeUser{TargetDefault: true},
+		SkipExecution:     false,
+		Target:            workflowTarget(),
+	}
+	if req.HasBody && strings.TrimSpace(req.Body) != "" {
+		props.Body = req.Body
+	}
+	return props
+}
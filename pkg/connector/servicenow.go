@@ -0,0 +1,34 @@
+package connector
+
+// serviceNowQueryParamHints explain the Table API's sysparm_* query syntax on
+// the inputs.
+var serviceNowQueryParamHints = map[string]string{
+	"sysparm_query":         "Encoded query, e.g. active=true^priority=1^ORDERBYDESCsys_created_on; it is URL-encoded when sent.",
+	"sysparm_display_value": "true (display values), false (actual values) or all (both).",
+}
+
+// serviceNow targets the Table API: records come inside the result envelope
+// and GET query parameters go through the prep step like NetBox's.
+var serviceNow = Config{
+	AtomicGroup:         "ServiceNow",
+	TargetType:          "servicenow.endpoint",
+	ActionType:          "servicenow.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "/api",
+	APIRoot:             "/api/",
+	ResponseEnvelope:    "result",
+	QueryPrep:           true,
+	ListQueryParams:     []string{"sysparm_fields"},
+	QueryParamHints:     serviceNowQueryParamHints,
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "ServiceNow",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    AdapterProperties,
+}
+
+func init() {
+	Register("servicenow", serviceNow)
+	Register("snow", serviceNow)
+}
@@ -0,0 +1,23 @@
+package connector
+
+// vManage reads responses from inside their {"header": ..., "data": ...}
+// envelope; the target handles the session login.
+var vManage = Config{
+	AtomicGroup:         "Cisco SD-WAN",
+	TargetType:          "vmanage.endpoint",
+	ActionType:          "vmanage.api_request",
+	ResponseBodyField:   "response_body",
+	StatusMessageField:  "status_text",
+	ErrorMessageField:   "error.message",
+	APIBasePath:         "/dataservice",
+	ResponseEnvelope:    "data",
+	ContinueOnFailure:   false,
+	PlatformDisplayName: "Cisco SD-WAN Manager",
+	FixedOutputs:        []string{OutputStatusMessage, OutputStatusCode, OutputErrorMessage},
+	BuildActionProps:    AdapterProperties,
+}
+
+func init() {
+	Register("vmanage", vManage)
+	Register("sdwan", vManage)
+}
@@ -0,0 +1,56 @@
+// Package generator is the home of the generator's library API. It defines the
+// error categories generation fails with, so tools embedding the generator can
+// branch with errors.Is and errors.As instead of matching message text.
+package generator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure categories. Errors returned by generation wrap exactly one of them.
+var (
+	// ErrOperationNotFound means the requested operationId is not in the spec.
+	ErrOperationNotFound = errors.New("operation not found")
+	// ErrUnsupportedSchema means the operation uses schema or parameter
+	// constructs the generator cannot turn into inputs (reported by strict mode).
+	ErrUnsupportedSchema = errors.New("unsupported schema")
+	// ErrTemplateRender means the workflow template failed to parse or execute,
+	// or did not produce valid JSON.
+	ErrTemplateRender = errors.New("template render failed")
+)
+
+// Error is a generation failure for one operation. errors.Is matches its Kind
+// and the underlying Err; errors.As gives access to the operation.
+type Error struct {
+	// Kind is one of the Err* categories above.
+	Kind error
+	// OperationID is the operation being generated, if known.
+	OperationID string
+	// Err is the underlying cause; it may be nil when Kind says it all.
+	Err error
+}
+
+func (e *Error) Error() string {
+	message := e.Kind.Error()
+	if e.Err != nil {
+		message = fmt.Sprintf("%s: %v", message, e.Err)
+	}
+	if e.OperationID == "" {
+		return message
+	}
+	return e.OperationID + ": " + message
+}
+
+// Unwrap returns the category and the cause for errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// NewError returns an *Error of the given kind for operationID wrapping err.
+func NewError(kind error, operationID string, err error) error {
+	return &Error{Kind: kind, OperationID: operationID, Err: err}
+}
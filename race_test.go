@@ -0,0 +1,6 @@
+//go:build race
+
+package main
+
+// raceEnabled reports whether the tests run with -race.
+const raceEnabled = true